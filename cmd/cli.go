@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"frontend-backend/internal/backup"
+	"frontend-backend/internal/cdn"
+	"frontend-backend/internal/config"
+	"frontend-backend/internal/fixtures"
+	"frontend-backend/internal/jobs"
+	"frontend-backend/internal/logging"
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/ratelimit"
+	"frontend-backend/internal/server"
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/synthetic"
+)
+
+// noConfig — аннотация команд, которым не нужна конфигурация
+const noConfig = "no-config"
+
+// cli — общее состояние команд: конфигурация загружается один раз перед
+// запуском любой команды, кроме помеченных noConfig
+type cli struct {
+	configPath string
+	cfg        *config.Config
+	logger     *slog.Logger
+}
+
+func newRootCommand() *cobra.Command {
+	c := &cli{}
+	root := &cobra.Command{
+		Use:   "frontend-backend",
+		Short: "Stock predictions API",
+		Long: "Stock predictions API. Without a command runs serve.\n\n" +
+			"Configuration is read from --config (default: optional config.yaml in the\n" +
+			"current directory) and FB_* environment variables.",
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		PersistentPreRunE: c.load,
+		RunE:              c.serve,
+	}
+	root.PersistentFlags().StringVarP(&c.configPath, "config", "c", "", "path to config file")
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Run the HTTP API and background workers",
+			Args:  cobra.NoArgs,
+			RunE:  c.serve,
+		},
+		c.migrateCommand(),
+		c.importCommand(),
+		c.seedCommand(),
+		c.generateCommand(),
+		c.backupCommand(),
+		c.restoreCommand(),
+		c.checkCommand(),
+		c.extractCommand(),
+		c.backfillOutcomesCommand(),
+		&cobra.Command{
+			Use:   "backfill-dedup",
+			Short: "Compute dedup keys for predictions saved before deduplication; duplicates are left for /admin/predictions/duplicates",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, _ []string) {
+				runBackfillDedup(cmd.Context(), c.cfg, c.logger)
+			},
+		},
+		&cobra.Command{
+			Use:   "normalize-recommendations",
+			Short: "Re-apply the extract.recommendations rule table to stored predictions",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, _ []string) {
+				runNormalizeRecommendations(cmd.Context(), c.cfg, c.logger)
+			},
+		},
+		c.demoCommand(),
+	)
+	return root
+}
+
+// demoCommand — демо-режим без конфигурации и PostgreSQL
+func (c *cli) demoCommand() *cobra.Command {
+	var dbPath string
+	cmd := &cobra.Command{
+		Use:         "demo",
+		Short:       "Run the API and frontend on a random local port with built-in sample data in SQLite (no config or database server)",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{noConfig: "true"},
+		Run: func(cmd *cobra.Command, _ []string) {
+			runDemo(cmd.Context(), dbPath)
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", "", "SQLite database file to keep demo data in (default: in memory)")
+	return cmd
+}
+
+// load читает конфигурацию и настраивает логгер
+func (c *cli) load(cmd *cobra.Command, _ []string) error {
+	if cmd.Annotations[noConfig] != "" {
+		return nil
+	}
+	cfg, err := config.LoadConfig(c.configPath)
+	if err != nil {
+		return fmt.Errorf("error loading configuration: %w", err)
+	}
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("error loading configuration: log: %w", err)
+	}
+	logLevel.Set(level)
+	logger, err := logging.New(os.Stderr, &logLevel, cfg.Log.Format)
+	if err != nil {
+		return fmt.Errorf("error loading configuration: log: %w", err)
+	}
+	// Сообщения библиотек через log и slog.Default идут в тот же логгер
+	slog.SetDefault(logger)
+	c.cfg, c.logger = cfg, logger
+	return nil
+}
+
+func (c *cli) serve(cmd *cobra.Command, _ []string) error {
+	serve(cmd.Context(), c.cfg, c.configPath, c.logger)
+	return nil
+}
+
+func (c *cli) migrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema (embedded migrations)",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up [N]",
+			Short: "Apply N pending migrations (default: all)",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps, err := stepsArg(args, 0)
+				if err != nil {
+					return err
+				}
+				return c.withMigrator(cmd, func(m *storage.Migrator) error {
+					done, err := m.Up(cmd.Context(), steps)
+					printMigrations("Applied", done)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N applied migrations (default: 1)",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps, err := stepsArg(args, 1)
+				if err != nil {
+					return err
+				}
+				return c.withMigrator(cmd, func(m *storage.Migrator) error {
+					done, err := m.Down(cmd.Context(), steps)
+					printMigrations("Rolled back", done)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "List migrations and when they were applied",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return c.withMigrator(cmd, func(m *storage.Migrator) error {
+					all, err := m.Status(cmd.Context())
+					if err != nil {
+						return err
+					}
+					for _, mg := range all {
+						applied := "pending"
+						if mg.AppliedAt != nil {
+							applied = mg.AppliedAt.Format(time.RFC3339)
+						}
+						fmt.Printf("%s_%-32s %s\n", mg.Version, mg.Name, applied)
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "baseline VERSION",
+			Short: "Mark migrations up to VERSION as applied without running them (for databases migrated by hand)",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withMigrator(cmd, func(m *storage.Migrator) error {
+					n, err := m.Baseline(cmd.Context(), args[0])
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Marked %d migrations up to %s as applied\n", n, args[0])
+					return nil
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+// withMigrator подключается к БД и вызывает run с Migrator
+func (c *cli) withMigrator(cmd *cobra.Command, run func(*storage.Migrator) error) error {
+	db, err := openDatabase(cmd.Context(), c.cfg.Database, c.logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return run(storage.NewMigrator(db))
+}
+
+func stepsArg(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+	return n, nil
+}
+
+func printMigrations(verb string, ms []storage.Migration) {
+	if len(ms) == 0 {
+		fmt.Println("No migrations to run")
+	}
+	for _, mg := range ms {
+		fmt.Printf("%s %s_%s\n", verb, mg.Version, mg.Name)
+	}
+}
+
+func (c *cli) importCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Load data from files",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "prices DIR",
+		Short: "Merge <TICKER>_D1.csv price history files from DIR into marketdata.data_dir",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			results, err := marketdata.ImportPrices(args[0], c.cfg.MarketData.DataDir)
+			for _, r := range results {
+				fmt.Printf("%-12s %d rows, %d skipped, %d candles in history\n", r.Ticker, r.Rows, r.Skipped, r.Total)
+			}
+			return err
+		},
+	})
+	return cmd
+}
+
+func (c *cli) seedCommand() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load stocks, messages and predictions from a fixture file into the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			f, err := fixtures.Load(file)
+			if err != nil {
+				return err
+			}
+			db, err := openDatabase(cmd.Context(), c.cfg.Database, c.logger)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			res, err := fixtures.Apply(cmd.Context(), storage.NewPostgresStorage(db, c.cfg.MarketData.DataDir, c.logger), f)
+			fmt.Printf("Stocks: %d created, %d updated, %d unchanged\n", res.Stocks, res.StocksUpdated, res.StocksUnchanged)
+			fmt.Printf("Messages: %d inserted, %d already present\n", res.Messages, res.MessagesExisting)
+			fmt.Printf("Predictions: %d inserted, %d already present\n", res.Predictions, res.PredictionsExisting)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "fixtures.yaml", "fixture file (YAML)")
+	return cmd
+}
+
+func (c *cli) generateCommand() *cobra.Command {
+	var opts synthetic.Options
+	var end string
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Fill the database and marketdata.data_dir with synthetic stocks, price history and predictions",
+		Long: "Generate random stocks on the " + synthetic.Exchange + " exchange with a daily price walk and\n" +
+			"predictions, for load testing and frontend development. The same flags and\n" +
+			"--seed produce the same data, and re-running does not duplicate it.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if end != "" {
+				t, err := time.Parse("2006-01-02", end)
+				if err != nil {
+					return fmt.Errorf("invalid --end: %w", err)
+				}
+				opts.End = t
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			db, err := openDatabase(ctx, c.cfg.Database, c.logger)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			pg := storage.NewPostgresStorage(db, c.cfg.MarketData.DataDir, c.logger)
+
+			dataDir := c.cfg.MarketData.DataDir
+			if opts.Exclude, err = realTickers(ctx, pg, dataDir); err != nil {
+				return err
+			}
+			ds, err := synthetic.Generate(opts)
+			if err != nil {
+				return err
+			}
+			for _, st := range ds.Fixtures.Stocks {
+				if _, err := marketdata.WriteCandles(dataDir, st.Ticker, ds.Prices[st.Ticker]); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("Price history: %d tickers written to %s\n", len(ds.Prices), dataDir)
+			res, err := fixtures.Apply(ctx, pg, ds.Fixtures)
+			fmt.Printf("Stocks: %d created, %d updated, %d unchanged\n", res.Stocks, res.StocksUpdated, res.StocksUnchanged)
+			fmt.Printf("Predictions: %d inserted, %d already present\n", res.Predictions, res.PredictionsExisting)
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&opts.Stocks, "stocks", 50, "number of stocks")
+	cmd.Flags().IntVar(&opts.Days, "days", 365, "price history depth in calendar days")
+	cmd.Flags().IntVar(&opts.Predictions, "predictions", 2000, "number of predictions (one message each)")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 1, "random seed")
+	cmd.Flags().StringVar(&end, "end", "", "last day of price history, YYYY-MM-DD (default: today)")
+	return cmd
+}
+
+// realTickers возвращает тикеры, которые generate не должен занимать: акции
+// других бирж и файлы истории цен, не принадлежащие синтетическим акциям
+func realTickers(ctx context.Context, pg *storage.PostgresStorage, dataDir string) (map[string]bool, error) {
+	stocks, err := pg.ListStocks(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dataDir, "*_D1.csv"))
+	if err != nil {
+		return nil, err
+	}
+	exclude := make(map[string]bool, len(stocks)+len(files))
+	generated := make(map[string]bool)
+	for _, st := range stocks {
+		if st.Exchange == synthetic.Exchange {
+			generated[st.Ticker] = true
+		} else {
+			exclude[st.Ticker] = true
+		}
+	}
+	for _, f := range files {
+		if ticker := strings.TrimSuffix(filepath.Base(f), "_D1.csv"); !generated[ticker] {
+			exclude[ticker] = true
+		}
+	}
+	return exclude, nil
+}
+
+func (c *cli) backupCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Export stocks, messages, predictions, price tables and price files into one archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output == "" {
+				output = "fb-backup-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz"
+			}
+			db, err := openDatabase(cmd.Context(), c.cfg.Database, c.logger)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			// Архив пишется во временный файл, чтобы прерванная выгрузка не
+			// оставила файл, похожий на полный
+			tmp, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			m, err := backup.Backup(cmd.Context(), db, c.cfg.MarketData.DataDir, tmp)
+			if err != nil {
+				tmp.Close()
+				return err
+			}
+			if err := tmp.Close(); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp.Name(), output); err != nil {
+				return err
+			}
+			printManifest("Backed up", m)
+			fmt.Printf("Archive: %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "archive path (default: fb-backup-<UTC time>.tar.gz)")
+	return cmd
+}
+
+func (c *cli) restoreCommand() *cobra.Command {
+	var opts backup.RestoreOptions
+	cmd := &cobra.Command{
+		Use:   "restore ARCHIVE",
+		Short: "Load an archive made by backup into the database and marketdata.data_dir",
+		Long: "Load an archive made by backup. The database must be migrated to the archive's\n" +
+			"schema version. Without --replace the tables must be empty and the price files\n" +
+			"must not exist; with --replace they are overwritten.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			db, err := openDatabase(cmd.Context(), c.cfg.Database, c.logger)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			m, err := backup.Restore(cmd.Context(), db, c.cfg.MarketData.DataDir, f, opts)
+			if err != nil {
+				return err
+			}
+			printManifest("Restored", m)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Replace, "replace", false, "clear the tables and overwrite price files")
+	return cmd
+}
+
+func printManifest(verb string, m backup.Manifest) {
+	fmt.Printf("%s snapshot of %s (schema %s)\n", verb, m.CreatedAt.Format(time.RFC3339), m.SchemaVersion)
+	for _, table := range backup.Tables {
+		fmt.Printf("  %-22s %d rows\n", table, m.Rows[table])
+	}
+	fmt.Printf("  %-22s %d files\n", "price history", len(m.PriceFiles))
+}
+
+func (c *cli) checkCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Validate the setup without starting the service",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "config",
+		Short: "Validate configuration values the service checks at startup",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			errs := checkConfig(c.cfg)
+			for _, err := range errs {
+				fmt.Println("error:", err)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("configuration has %d error(s)", len(errs))
+			}
+			fmt.Println("Configuration is valid")
+			return nil
+		},
+	})
+	return cmd
+}
+
+// checkConfig выполняет проверки значений, которые serve делает при старте,
+// без подключения к БД и внешним сервисам
+func checkConfig(cfg *config.Config) []error {
+	var errs []error
+	check := func(key string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	_, err := logging.New(io.Discard, slog.LevelInfo, cfg.Log.Format)
+	check("log.format", err)
+	switch cfg.Storage.Driver {
+	case storage.DriverPostgres:
+		_, err := cfg.Database.DSN()
+		check("database", err)
+	case storage.DriverMock:
+	default:
+		check("storage.driver", fmt.Errorf("unknown driver %q (expected %q or %q)", cfg.Storage.Driver, storage.DriverPostgres, storage.DriverMock))
+	}
+	if cfg.API.Degradation != server.DegradationStrict && cfg.API.Degradation != server.DegradationLenient {
+		check("api.degradation", fmt.Errorf("unknown value %q (expected %q or %q)", cfg.API.Degradation, server.DegradationStrict, server.DegradationLenient))
+	}
+	_, err = server.NewDefaults(cfg.API.Defaults)
+	check("api.defaults", err)
+	_, err = server.NewCachePolicies(cfg.API.CacheControl.Default, cfg.API.CacheControl.Endpoints)
+	check("api.cache_control", err)
+	_, err = newLicenses(cfg.MarketData)
+	check("marketdata.licenses", err)
+	_, err = newRecommendations(cfg.Extract)
+	check("extract.recommendations", err)
+	if cfg.RateLimit.Enabled {
+		limits, err := rateLimitOptions(cfg.RateLimit)
+		if err == nil {
+			_, err = ratelimit.New(limits)
+		}
+		check("rate_limit", err)
+	}
+	if cfg.Auth.Anonymous {
+		_, err := anonymousRole(cfg.Auth)
+		check("auth.anonymous_role", err)
+	}
+	if cfg.CDN.Driver != "" {
+		_, err := cdn.NewPurger(cfg.CDN.Driver, cfg.CDN.Target, cfg.CDN.APIToken, cfg.CDN.Timeout)
+		check("cdn", err)
+	}
+	if cfg.TLS.Enabled {
+		_, _, _, err := serverTLS(cfg.TLS)
+		check("tls", err)
+	}
+	return errs
+}
+
+func (c *cli) extractCommand() *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "extract",
+		Short: "Re-extract predictions from stored messages",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			runExtract(cmd.Context(), c.cfg, c.logger, from, to)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "process messages sent on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "process messages sent before this date (YYYY-MM-DD)")
+	return cmd
+}
+
+func (c *cli) backfillOutcomesCommand() *cobra.Command {
+	var opts jobs.BackfillOptions
+	cmd := &cobra.Command{
+		Use:   "backfill-outcomes",
+		Short: "Score all historical predictions with an expired horizon; resumable via checkpoint",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			runBackfillOutcomes(cmd.Context(), c.cfg, c.logger, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.Workers, "workers", 4, "number of tickers processed in parallel")
+	cmd.Flags().IntVar(&opts.BatchSize, "batch", 500, "outcomes saved per database round trip")
+	cmd.Flags().StringVar(&opts.Checkpoint, "checkpoint", "backfill-outcomes.json", "checkpoint file for resuming (empty disables)")
+	return cmd
+}
@@ -1,49 +1,1137 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
-	"flag"
+	"embed"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/breaker"
+	"frontend-backend/internal/bus"
+	"frontend-backend/internal/cache"
+	"frontend-backend/internal/cdn"
 	"frontend-backend/internal/config"
+	"frontend-backend/internal/deadletter"
+	"frontend-backend/internal/debug"
+	"frontend-backend/internal/events"
+	"frontend-backend/internal/extract"
+	"frontend-backend/internal/grpcapi"
+	"frontend-backend/internal/ingest"
+	"frontend-backend/internal/jobs"
+	"frontend-backend/internal/logging"
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/ratelimit"
 	"frontend-backend/internal/server"
+	"frontend-backend/internal/shapes"
+	"frontend-backend/internal/sqlconsole"
 	"frontend-backend/internal/storage"
+	"frontend-backend/internal/tracing"
+	"frontend-backend/internal/version"
+	"frontend-backend/internal/warmstate"
+	"frontend-backend/internal/webhook"
 )
 
+// logLevel — уровень логов; меняется при перезагрузке конфигурации
+var logLevel slog.LevelVar
+
 func main() {
-	configPath := flag.String("c", "config.yaml", "path to config file")
-	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	cfg, err := config.LoadConfig(*configPath)
-	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
-		fmt.Println("Usage: go run cmd/main.go [-c <config_file_path>]\nExample: go run cmd/main.go -c config.yaml")
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
+}
+
+// serve запускает HTTP API и фоновые подсистемы
+func serve(ctx context.Context, cfg *config.Config, configPath string, logger *slog.Logger) {
+	logger.Info("Запуск frontend-backend", "version", version.Get())
+
+	if tc := cfg.Tracing; tc.Enabled {
+		shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+			Endpoint:    tc.Endpoint,
+			Insecure:    tc.Insecure,
+			Headers:     tc.Headers,
+			SampleRatio: tc.SampleRatio,
+			ServiceName: tc.ServiceName,
+		})
+		if err != nil {
+			fatal(fmt.Errorf("tracing: %w", err))
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				logger.Error("Ошибка при отправке оставшихся трасс", "err", err)
+			}
+		}()
+		logger.Info("Трассировка OpenTelemetry включена", "sample_ratio", tc.SampleRatio)
+	}
+
+	demand := marketdata.NewDemandTracker(cfg.MarketData.DemandHalfLife)
+	if cfg.API.Degradation != server.DegradationStrict && cfg.API.Degradation != server.DegradationLenient {
+		fatal(fmt.Errorf("unknown api.degradation %q (expected %q or %q)", cfg.API.Degradation, server.DegradationStrict, server.DegradationLenient))
+	}
+
+	defaults, err := server.NewDefaults(cfg.API.Defaults)
+	if err != nil {
+		fatal(err)
+	}
+
+	licenses, err := newLicenses(cfg.MarketData)
+	if err != nil {
+		fatal(err)
+	}
+
+	recs, err := newRecommendations(cfg.Extract)
+	if err != nil {
+		fatal(err)
+	}
+
+	cachePolicies, err := server.NewCachePolicies(cfg.API.CacheControl.Default, cfg.API.CacheControl.Endpoints)
+	if err != nil {
+		fatal(err)
+	}
+
+	var store storage.Storage
+	hub := server.NewHub(logger)
+	opts := []server.Option{
+		server.WithLogger(logger),
+		server.WithPriceDataDir(cfg.MarketData.DataDir),
+		server.WithHub(hub),
+		server.WithDefaults(defaults),
+		server.WithCachePolicies(cachePolicies),
+		server.WithDemandTracker(demand),
+		server.WithLicenses(licenses),
+		server.WithDegradation(cfg.API.Degradation),
+		server.WithBenchmark(cfg.API.Benchmark),
+		server.WithCORSOrigins(cfg.CORS.AllowedOrigins),
+	}
+	if cfg.API.Compression.Enabled {
+		opts = append(opts, server.WithCompression(cfg.API.Compression.MinSize))
+	}
+
+	// События об изменении данных получают подписчики /ws и /events и,
+	// если настроен CDN, сброс кеша по суррогатным ключам
+	var pub events.Publisher = hub
+	if cfg.CDN.Driver != "" {
+		purger, err := cdn.NewPurger(cfg.CDN.Driver, cfg.CDN.Target, cfg.CDN.APIToken, cfg.CDN.Timeout)
+		if err != nil {
+			fatal(err)
+		}
+		invalidator := cdn.NewInvalidator(purger, cfg.CDN.PurgeWindow)
+		go invalidator.Run(ctx)
+		pub = events.Multi{hub, invalidator}
+		opts = append(opts, server.WithCDN(purger))
+	}
+	// Ключи, выпущенные через API, справочник акций и прогнозы меняются только в PostgreSQL
+	var keyStore auth.KeyStore
+	var stockWriter storage.StockWriter
+	var predictionWriter storage.PredictionWriter
+	switch cfg.Storage.Driver {
+	case storage.DriverMock:
+		logger.Info("Используется mock-хранилище, база данных не подключается")
+		store = storage.NewMockStorage(cfg.Storage.MockSeed)
+	case storage.DriverPostgres:
+		db, err := openDatabase(ctx, cfg.Database, logger)
+		if err != nil {
+			fatal(err)
+		}
+		defer db.Close()
+		if cfg.Database.KeepaliveInterval > 0 {
+			go storage.KeepAlive(ctx, db, cfg.Database.KeepaliveInterval, databaseRetry(cfg.Database, logger))
+		}
+		// Состояние пула соединений: go_sql_* с меткой db_name
+		_, dbname := cfg.Database.Endpoint()
+		prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbname))
+
+		pg := storage.NewPostgresStorage(db, cfg.MarketData.DataDir, logger)
+		store = pg
+		opts = append(opts, server.WithReadinessChecks(
+			server.ReadinessCheck{Name: "database", Check: db.PingContext},
+			server.ReadinessCheck{Name: "migrations", Check: pg.CheckSchema},
+			server.ReadinessCheck{Name: "price_data", Check: func(context.Context) error { return storage.CheckPriceDataDir(cfg.MarketData.DataDir) }},
+		))
+		keyStore = pg
+		stockWriter = pg
+		predictionWriter = pg
+		opts = append(opts, server.WithAPIKeyUsage(pg))
+		pg.SQLLogger().SetEnabled(cfg.Storage.SQLLogging.Enabled)
+		pg.SQLLogger().SetSlowThreshold(cfg.Storage.SQLLogging.SlowThreshold)
+		if len(cfg.Storage.SQLLogging.Redact) > 0 {
+			pg.SQLLogger().SetRedacted(cfg.Storage.SQLLogging.Redact)
+		}
+
+		deadLetters := deadletter.NewQueue(pg)
+		reprocessor := extract.NewReprocessor(pg, deadLetters)
+		reprocessor.SetRecommendations(recs, pg)
+		dispatcher, err := startWebhooks(ctx, cfg.Webhooks, pg, logger)
+		if err != nil {
+			fatal(err)
+		}
+		pub = events.Multi{pub, dispatcher}
+
+		processor := bus.NewProcessor(pg, deadLetters, logger)
+		processor.SetPublisher(pub)
+		processor.SetRecommendations(recs, pg)
+		deadLetters.Register(deadletter.SourceExtract, reprocessor.RetryMessage)
+		deadLetters.Register(deadletter.SourceBus, processor.Handle)
+
+		opts = append(opts,
+			server.WithReprocessor(reprocessor),
+			server.WithRecommendations(extract.NewNormalizer(pg, recs)),
+			server.WithAdminStore(pg),
+			server.WithDeadLetters(deadLetters),
+			server.WithSQLLogger(pg.SQLLogger()),
+			server.WithWebhooks(dispatcher),
+			server.WithChangeFeed(pg),
+			server.WithDatasets(pg, cfg.Datasets.Retention),
+			server.WithIdempotency(pg, cfg.Idempotency.TTL),
+			server.WithAuditLog(pg),
+			server.WithRevisions(pg),
+		)
+
+		if jwtCfg := cfg.Auth.JWT; jwtCfg.Secret != "" {
+			accounts, err := auth.NewAccounts(pg, jwtCfg.Secret, jwtCfg.AccessTTL, jwtCfg.RefreshTTL)
+			if err != nil {
+				fatal(fmt.Errorf("auth.jwt: %w", err))
+			}
+			opts = append(opts, server.WithAccounts(accounts, cfg.Auth.Registration))
+			if cc := cfg.Auth.Cookie; cc.Enabled {
+				sameSite, err := server.ParseSameSite(cc.SameSite)
+				if err != nil {
+					fatal(fmt.Errorf("auth.cookie.same_site: %w", err))
+				}
+				opts = append(opts, server.WithSessionCookies(server.SessionCookies{Secure: cc.Secure, SameSite: sameSite}))
+				logger.Info("Вход через cookie включен, изменяющие запросы проверяются на CSRF")
+			}
+		}
+
+		if cfg.Shapes.Enabled {
+			recorder := shapes.NewRecorder(pg, shapes.Options{
+				SampleRate:    cfg.Shapes.SampleRate,
+				BatchSize:     cfg.Shapes.BatchSize,
+				FlushInterval: cfg.Shapes.FlushInterval,
+				Logger:        logger,
+			})
+			go recorder.Run(ctx)
+			opts = append(opts, server.WithRequestShapes(recorder, pg))
+		}
+
+		if cfg.SQLConsole.Enabled {
+			opt, closeConsole, err := sqlConsoleOption(ctx, cfg, pg, logger)
+			if err != nil {
+				fatal(err)
+			}
+			defer closeConsole()
+			opts = append(opts, opt)
+		}
+
+		lag, err := startIngestion(ctx, cfg.Ingest, pg)
+		if err != nil {
+			fatal(err)
+		}
+		if lag != nil {
+			opts = append(opts, server.WithIngestLag(lag))
+		}
+		if err := startBusConsumer(ctx, cfg.Bus, processor); err != nil {
+			fatal(err)
+		}
+		if err := startMarketData(ctx, cfg.MarketData, demand, pg, pub); err != nil {
+			fatal(err)
+		}
+		opts = append(opts, server.WithJobs(startJobs(ctx, cfg, pg, pub, logger)))
+	default:
+		fatal(fmt.Errorf("unknown storage driver %q (expected %q or %q)", cfg.Storage.Driver, storage.DriverPostgres, storage.DriverMock))
+	}
+
+	if cb := cfg.Storage.CircuitBreaker; cb.Enabled {
+		guarded := storage.NewBreakerStorage(store, breaker.New("database", breaker.Options{
+			Window:       cb.Window,
+			MinRequests:  cb.MinRequests,
+			FailureRatio: cb.FailureRatio,
+			OpenTimeout:  cb.OpenTimeout,
+		}), cb.CallTimeout)
+		store = guarded
+		if stockWriter != nil {
+			stockWriter = guarded.StockWriter(stockWriter)
+			predictionWriter = guarded.PredictionWriter(predictionWriter)
+		}
+	}
+
+	var cached *storage.CachedStorage
+	if cfg.Cache.Enabled {
+		cached = storage.NewCachedStorage(store, cache.Options{
+			TTL:      cfg.Cache.TTL,
+			StaleTTL: cfg.Cache.StaleTTL,
+			Jitter:   cfg.Cache.Jitter,
+			Logger:   logger,
+		})
+		store = cached
+		if stockWriter != nil {
+			stockWriter = cached.StockWriter(stockWriter)
+			predictionWriter = cached.PredictionWriter(predictionWriter)
+		}
+	}
+	if stockWriter != nil {
+		opts = append(opts, server.WithStockWriter(stockWriter), server.WithPredictionWriter(predictionWriter))
+	}
+	if cfg.Cache.SnapshotPath != "" {
+		restoreWarmState(cfg.Cache, demand, cached)
+	}
+	if cfg.Storage.ResultMetrics {
+		store = storage.NewInstrumentedStorage(store)
+	}
+	if cfg.Tracing.Enabled {
+		store = storage.NewTracedStorage(store)
+	}
+
+	if cfg.AccessLog.Enabled {
+		opt, closeLog, err := accessLogOption(cfg.AccessLog)
+		if err != nil {
+			fatal(err)
+		}
+		defer closeLog()
+		opts = append(opts, opt)
+	}
+	keyring, err := loadAPIKeys(ctx, cfg.Auth, keyStore, logger)
+	if err != nil {
+		fatal(err)
+	}
+	opts = append(opts, server.WithAPIKeys(keyring))
+	if cfg.Auth.Anonymous {
+		role, err := anonymousRole(cfg.Auth)
+		if err != nil {
+			fatal(fmt.Errorf("auth.anonymous_role: %w", err))
+		}
+		if role == auth.RoleAdmin {
+			logger.Warn("Анонимным запросам доступен админский API (auth.anonymous_admin), только для разработки")
+		}
+		logger.Info("Анонимный доступ к API разрешен (auth.anonymous), ключ не обязателен", "role", role)
+		opts = append(opts, server.WithAnonymous(role))
+	}
+	if oc := cfg.Auth.OIDC; oc.Issuer != "" {
+		provider, err := auth.NewOIDC(ctx, auth.OIDCOptions{
+			Issuer:        oc.Issuer,
+			Audience:      oc.Audience,
+			JWKSURL:       oc.JWKSURL,
+			UsernameClaim: oc.UsernameClaim,
+			RolesClaim:    oc.RolesClaim,
+			Roles:         oc.Roles,
+		})
+		if err != nil {
+			fatal(fmt.Errorf("auth.oidc: %w", err))
+		}
+		opts = append(opts, server.WithOIDC(provider))
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limits, err := rateLimitOptions(cfg.RateLimit)
+		if err != nil {
+			fatal(err)
+		}
+		limiter, err = ratelimit.New(limits)
+		if err != nil {
+			fatal(err)
+		}
+		opts = append(opts, server.WithRateLimit(limiter))
+	}
+
+	if cfg.GRPC.Enabled {
+		if err := startGRPC(ctx, cfg.GRPC.Addr, store, logger); err != nil {
+			fatal(err)
+		}
+	}
+	if cfg.Debug.Enabled {
+		if err := startDebug(ctx, cfg.Debug); err != nil {
+			fatal(err)
+		}
+	}
+
+	var tlsConfig *tls.Config
+	var acmeManager *autocert.Manager
+	if cfg.TLS.Enabled {
+		var certs *auth.ClientCerts
+		tlsConfig, acmeManager, certs, err = serverTLS(cfg.TLS)
+		if err != nil {
+			fatal(fmt.Errorf("tls: %w", err))
+		}
+		if certs != nil {
+			opts = append(opts, server.WithClientCerts(certs))
+		}
+	}
+
+	server := server.NewServer(store, opts...)
+	watchConfig(configPath, cfg, reloadTargets{server: server, cached: cached, limiter: limiter})
+	httpServer := &http.Server{
+		Addr:              cfg.Server.Addr(),
+		Handler:           server,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	if tlsConfig != nil && (cfg.TLS.RedirectHTTP || acmeManager != nil) {
+		if err := startHTTPRedirect(ctx, cfg.TLS.HTTPAddr, httpServer.Addr, acmeManager); err != nil {
+			fatal(err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("HTTP API слушает", "addr", httpServer.Addr, "tls", tlsConfig != nil)
+	if tlsConfig != nil {
+		// С ACME файлы пусты, сертификат выдает tlsConfig.GetCertificate
+		err = httpServer.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		fatal(err)
+	}
+	logger.Info("Сервер остановлен")
+
+	if cfg.Cache.SnapshotPath != "" {
+		saveWarmState(cfg.Cache.SnapshotPath, demand, cached)
+	}
+}
+
+// rateLimitOptions переводит rate_limit в параметры ограничителя
+func rateLimitOptions(cfg config.RateLimitConfig) (ratelimit.Options, error) {
+	if cfg.Mode != ratelimit.ModeSoft && cfg.Mode != ratelimit.ModeEnforce {
+		return ratelimit.Options{}, fmt.Errorf("unknown rate_limit.mode %q (expected %q or %q)", cfg.Mode, ratelimit.ModeSoft, ratelimit.ModeEnforce)
+	}
+	groups := make([]ratelimit.Group, 0, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		groups = append(groups, ratelimit.Group{Name: g.Name, Paths: g.Paths, RequestsPerMinute: g.RequestsPerMinute, Burst: g.Burst})
+	}
+	return ratelimit.Options{
+		RequestsPerMinute: cfg.RequestsPerMinute,
+		Burst:             cfg.Burst,
+		Mode:              cfg.Mode,
+		Enforce:           cfg.Enforce,
+		Groups:            groups,
+	}, nil
+}
+
+// reloadTargets — компоненты, настройки которых меняются без перезапуска
+type reloadTargets struct {
+	server  *server.Server
+	cached  *storage.CachedStorage // nil — кеш выключен
+	limiter *ratelimit.Limiter     // nil — ограничение частоты выключено
+}
+
+// watchConfig применяет изменения файла конфигурации на лету. Если
+// изменился хотя бы один ключ вне config.Reloadable (адрес БД, порты и
+// т.п.), перезагрузка отклоняется целиком: работающий сервис не должен
+// расходиться с файлом наполовину.
+func watchConfig(path string, current *config.Config, t reloadTargets) {
+	var mu sync.Mutex
+	err := config.Watch(path, func(cfg *config.Config, err error) {
+		if err != nil {
+			slog.Error("Конфигурация не перезагружена", "err", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		reloadable, restart := config.Diff(current, cfg)
+		if len(restart) > 0 {
+			slog.Error("Конфигурация не перезагружена: изменения требуют перезапуска", "keys", restart)
+			return
+		}
+		if len(reloadable) == 0 {
+			return
+		}
+		if err := applyConfig(cfg, t); err != nil {
+			slog.Error("Конфигурация не перезагружена", "err", err)
+			return
+		}
+		current = cfg
+		slog.Info("Конфигурация перезагружена", "keys", reloadable)
+	})
+	switch {
+	case errors.Is(err, config.ErrNoConfigFile):
+		slog.Info("Файла конфигурации нет, перезагрузка на лету отключена")
+	case err != nil:
+		slog.Error("Не удалось следить за файлом конфигурации", "err", err)
+	}
+}
+
+// applyConfig применяет ключи config.Reloadable. Все, что может не пройти
+// проверку, выполняется до первого изменения, поэтому при ошибке остаются
+// прежние настройки.
+func applyConfig(cfg *config.Config, t reloadTargets) error {
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("log.level: %w", err)
+	}
+	if t.limiter != nil {
+		limits, err := rateLimitOptions(cfg.RateLimit)
+		if err != nil {
+			return err
+		}
+		if err := t.limiter.Update(limits); err != nil {
+			return err
+		}
+	}
+	logLevel.Set(level)
+	t.server.SetCORSOrigins(cfg.CORS.AllowedOrigins)
+	if t.cached != nil {
+		t.cached.SetTTL(cfg.Cache.TTL, cfg.Cache.StaleTTL, cfg.Cache.Jitter)
+	}
+	return nil
+}
+
+// startGRPC запускает gRPC API на отдельном порту; остановка — по отмене ctx
+func startGRPC(ctx context.Context, addr string, store storage.Storage, logger *slog.Logger) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen grpc on %s: %w", addr, err)
+	}
+	gs := grpcapi.NewServer(store, logger)
+	go func() {
+		<-ctx.Done()
+		gs.GracefulStop()
+	}()
+	go func() {
+		logger.Info("gRPC API слушает", "addr", ln.Addr().String())
+		if err := gs.Serve(ln); err != nil {
+			logger.Error("gRPC-сервер остановлен с ошибкой", "err", err)
+		}
+	}()
+	return nil
+}
+
+// startDebug запускает порт отладки с pprof и expvar; остановка — по отмене ctx
+func startDebug(ctx context.Context, cfg config.DebugConfig) error {
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("listen debug on %s: %w", cfg.Addr, err)
+	}
+	if cfg.Token == "" && !isLoopback(ln.Addr()) {
+		slog.Warn("Порт отладки доступен не только с localhost, а debug.token не задан", "addr", ln.Addr().String())
+	}
+	srv := &http.Server{Handler: debug.Handler(cfg.Token)}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		slog.Info("Порт отладки (pprof, expvar) слушает", "addr", ln.Addr().String())
+		if err := srv.Serve(ln); err != http.ErrServerClosed {
+			slog.Error("Порт отладки остановлен с ошибкой", "err", err)
+		}
+	}()
+	return nil
+}
+
+// isLoopback сообщает, слушает ли адрес только локальный интерфейс
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// demoPrices — история цен mock-акций для демо-режима, встроенная в бинарник
+//
+//go:embed demodata/*_D1.csv
+var demoPrices embed.FS
+
+// demoWeb — фронтенд демо-режима
+//
+//go:embed web
+var demoWeb embed.FS
+
+// runDemo запускает API и фронтенд на случайном свободном порту и печатает
+// адрес. Данные mock-хранилища загружаются в SQLite: в память или, если
+// задан dbPath, в файл, который при следующих запусках используется как есть.
+func runDemo(ctx context.Context, dbPath string) {
+	prices, err := fs.Sub(demoPrices, "demodata")
+	if err != nil {
+		fatal(err)
+	}
+	web, err := fs.Sub(demoWeb, "web")
+	if err != nil {
+		fatal(err)
+	}
+	mock := storage.NewMockStorageWithPrices(42, prices)
+	store, err := storage.OpenSQLiteStorage(ctx, storage.SQLiteOptions{Path: dbPath, Prices: prices, AsOf: mock.Epoch()})
+	if err != nil {
+		fatal(err)
+	}
+	defer store.Close()
+	empty, err := store.Empty(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	if empty {
+		if err := store.Seed(ctx, mock); err != nil {
+			fatal(fmt.Errorf("error seeding demo database: %w", err))
+		}
+	}
+
+	handler := server.NewServer(store,
+		server.WithDemandTracker(marketdata.NewDemandTracker(time.Hour)),
+		server.WithDegradation(server.DegradationLenient),
+		server.WithStaticFiles(web),
+	)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fatal(err)
+	}
+	httpServer := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	fmt.Printf("Demo is running at http://%s/ (API: http://%s/stocks, Ctrl+C to stop)\n", ln.Addr(), ln.Addr())
+	if err := httpServer.Serve(ln); err != http.ErrServerClosed {
+		fatal(err)
+	}
+}
+
+// restoreWarmState восстанавливает спрос на тикеры и кеши из снимка
+func restoreWarmState(cfg config.CacheConfig, demand *marketdata.DemandTracker, cached *storage.CachedStorage) {
+	st, ok, err := warmstate.Load(cfg.SnapshotPath, cfg.SnapshotMaxAge)
+	if err != nil {
+		slog.Error("Не удалось загрузить снимок кеша", "err", err)
+		return
+	}
+	if !ok {
+		slog.Info("Снимок кеша отсутствует или устарел, старт с пустым кешем", "path", cfg.SnapshotPath)
+		return
+	}
+
+	demand.Restore(st.Demand, st.SavedAt)
+	keys := 0
+	if cached != nil && st.Cache != nil {
+		cached.Restore(*st.Cache)
+		keys = st.Cache.Keys()
+	}
+	slog.Info("Восстановлен снимок кеша", "saved_at", st.SavedAt.Format(time.RFC3339), "tickers", len(st.Demand), "keys", keys)
+}
+
+// saveWarmState сохраняет спрос на тикеры и кеши перед остановкой
+func saveWarmState(path string, demand *marketdata.DemandTracker, cached *storage.CachedStorage) {
+	st := warmstate.State{SavedAt: time.Now(), Demand: demand.Snapshot()}
+	if cached != nil {
+		snap := cached.Snapshot()
+		st.Cache = &snap
+	}
+	if err := warmstate.Save(path, st); err != nil {
+		slog.Error("Не удалось сохранить снимок кеша", "err", err)
+		return
+	}
+	slog.Info("Снимок кеша сохранен", "path", path)
+}
+
+// accessLogOption открывает поток access-лога и возвращает опцию сервера
+func accessLogOption(cfg config.AccessLogConfig) (server.Option, func(), error) {
+	if cfg.Format != server.AccessLogCommon && cfg.Format != server.AccessLogCombined {
+		return nil, nil, fmt.Errorf("unknown access_log.format %q (expected %q or %q)", cfg.Format, server.AccessLogCommon, server.AccessLogCombined)
+	}
+
+	switch cfg.Output {
+	case "stdout":
+		return server.WithAccessLog(os.Stdout, cfg.Format), func() {}, nil
+	case "stderr":
+		return server.WithAccessLog(os.Stderr, cfg.Format), func() {}, nil
+	}
 
-	dbinfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.DBName, cfg.Database.SSLMode)
+	f, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening access log %s: %w", cfg.Output, err)
+	}
+	return server.WithAccessLog(f, cfg.Format), func() { f.Close() }, nil
+}
+
+// openDatabase подключается к PostgreSQL, повторяя попытки по database.retry
+func openDatabase(ctx context.Context, cfg config.DatabaseConfig, logger *slog.Logger) (*sql.DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+	db, err := storage.Open(ctx, dsn, databaseRetry(cfg, logger))
+	if err != nil {
+		return nil, err
+	}
+
+	host, dbname := cfg.Endpoint()
+	logger.Info("Подключение к базе данных установлено", "host", host, "dbname", dbname)
+	return db, nil
+}
+
+// databaseRetry переводит database.retry в параметры повторов
+func databaseRetry(cfg config.DatabaseConfig, logger *slog.Logger) storage.RetryOptions {
+	return storage.RetryOptions{
+		Attempts:       cfg.Retry.Attempts,
+		InitialBackoff: cfg.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Retry.MaxBackoff,
+		Logger:         logger,
+	}
+}
+
+// sqlConsoleOption подключается к БД от read-only роли и возвращает опцию
+// сервера с SQL-консолью
+func sqlConsoleOption(ctx context.Context, cfg *config.Config, pg *storage.PostgresStorage, logger *slog.Logger) (server.Option, func(), error) {
+	sc := cfg.SQLConsole
+	if sc.User == "" {
+		return nil, nil, fmt.Errorf("sql_console.user is required")
+	}
+	dbCfg := cfg.Database
+	dbCfg.User, dbCfg.Password = sc.User, sc.Password
+	db, err := openDatabase(ctx, dbCfg, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect sql console role: %w", err)
+	}
+	db.SetMaxOpenConns(2)
+	console := sqlconsole.New(db, pg, sc.StatementTimeout, sc.MaxRows, logger)
+	return server.WithSQLConsole(console), func() { db.Close() }, nil
+}
+
+// runExtract повторно извлекает прогнозы из сохраненных сообщений
+func runExtract(ctx context.Context, cfg *config.Config, logger *slog.Logger, fromStr, toStr string) {
+	var from, to time.Time
+	var err error
+	if fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			fatal(fmt.Errorf("invalid --from: %w", err))
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			fatal(fmt.Errorf("invalid --to: %w", err))
+		}
+	}
 
-	db, err := sql.Open("postgres", dbinfo)
+	db, err := openDatabase(ctx, cfg.Database, logger)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	defer db.Close()
 
-	err = db.Ping()
+	recs, err := newRecommendations(cfg.Extract)
+	if err != nil {
+		fatal(err)
+	}
+
+	pg := storage.NewPostgresStorage(db, cfg.MarketData.DataDir, logger)
+	reprocessor := extract.NewReprocessor(pg, deadletter.NewQueue(pg))
+	reprocessor.SetRecommendations(recs, pg)
+	stats, err := reprocessor.Reprocess(ctx, from, to)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+	fmt.Printf("Processed %d messages, extracted %d predictions, inserted %d new, %d messages sent to dead-letter\n",
+		stats.Messages, stats.Extracted, stats.Inserted, stats.Failed)
+}
+
+// runNormalizeRecommendations применяет таблицу рекомендаций из конфигурации
+// к уже сохраненным прогнозам
+func runNormalizeRecommendations(ctx context.Context, cfg *config.Config, logger *slog.Logger) {
+	recs, err := newRecommendations(cfg.Extract)
+	if err != nil {
+		fatal(err)
 	}
 
-	fmt.Println("Successfully connected to database!")
+	db, err := openDatabase(ctx, cfg.Database, logger)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
 
-	store := storage.NewPostgresStorage(db)
-	server := server.NewServer(store)
+	stats, err := extract.NewNormalizer(storage.NewPostgresStorage(db, cfg.MarketData.DataDir, logger), recs).Normalize(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Normalized %d predictions, classified %d from text, resolved %d phrases, %d phrases still unknown\n",
+		stats.Renamed, stats.Classified, stats.Resolved, stats.Unknown)
+}
+
+// runBackfillOutcomes проставляет исходы всем историческим прогнозам. При
+// прерывании (Ctrl+C) повторный запуск с той же контрольной точкой
+// продолжает с необработанных тикеров.
+func runBackfillOutcomes(ctx context.Context, cfg *config.Config, logger *slog.Logger, opts jobs.BackfillOptions) {
+	db, err := openDatabase(ctx, cfg.Database, logger)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+
+	opts.Logger = logger
+	stats, err := jobs.BackfillOutcomes(ctx, storage.NewPostgresStorage(db, cfg.MarketData.DataDir, logger), opts)
+	fmt.Printf("Processed %d tickers (%d already done), scored %d predictions, %d lack price history, %d tickers failed\n",
+		stats.Tickers, stats.Resumed, stats.Evaluated, stats.Skipped, stats.Failed)
+	if err != nil {
+		fatal(err)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runBackfillDedup вычисляет ключи дедупликации для старых прогнозов
+func runBackfillDedup(ctx context.Context, cfg *config.Config, logger *slog.Logger) {
+	db, err := openDatabase(ctx, cfg.Database, logger)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+
+	stats, err := storage.NewPostgresStorage(db, cfg.MarketData.DataDir, logger).BackfillDedupHashes(ctx)
+	fmt.Printf("Hashed %d predictions, %d duplicates left for merging\n", stats.Hashed, stats.Duplicates)
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// startIngestion запускает загрузку сообщений из Telegram, если она включена,
+// и возвращает трекер отставания каналов
+func startIngestion(ctx context.Context, cfg config.IngestConfig, store ingest.MessageStore) (*ingest.LagTracker, error) {
+	tg := cfg.Telegram
+	if !tg.Enabled {
+		return nil, nil
+	}
+
+	var source ingest.Source
+	switch tg.Mode {
+	case "bot":
+		if tg.BotToken == "" {
+			return nil, fmt.Errorf("ingest.telegram.bot_token is required in bot mode")
+		}
+		source = ingest.NewTelegramBotSource(tg.BotToken, tg.Channels, tg.PollTimeout)
+	case "mtproto":
+		return nil, fmt.Errorf("ingest.telegram.mode %q is not supported yet, use \"bot\"", tg.Mode)
+	default:
+		return nil, fmt.Errorf("unknown ingest.telegram.mode %q", tg.Mode)
+	}
+
+	lag := ingest.NewLagTracker(tg.Channels, cfg.StaleAfter)
+	prometheus.MustRegister(lag)
+	if cfg.StaleAfter > 0 {
+		go lag.Watch(ctx, time.Minute)
+	}
+
+	go ingest.NewService(store, lag, source).Run(ctx)
+	return lag, nil
+}
+
+// startBusConsumer запускает консьюмер прогнозов из шины, если он включен
+func startBusConsumer(ctx context.Context, cfg config.BusConfig, processor *bus.Processor) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.DSN == "" {
+		return fmt.Errorf("bus.dsn is required")
+	}
+
+	var consumer bus.Consumer
+	switch cfg.Driver {
+	case bus.DriverKafka:
+		consumer = bus.NewKafkaConsumer(cfg.DSN, cfg.Topic, cfg.Group, processor)
+	case bus.DriverNATS:
+		c, err := bus.NewNATSConsumer(cfg.DSN, cfg.Topic, cfg.Group, processor)
+		if err != nil {
+			return err
+		}
+		consumer = c
+	default:
+		return fmt.Errorf("unknown bus.driver %q (expected %q or %q)", cfg.Driver, bus.DriverKafka, bus.DriverNATS)
+	}
+
+	go func() {
+		defer consumer.Close()
+		slog.Info("Запуск консьюмера прогнозов", "driver", cfg.Driver, "topic", cfg.Topic, "group", cfg.Group)
+		if err := consumer.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Консьюмер прогнозов остановлен с ошибкой", "err", err)
+		}
+	}()
+	return nil
+}
+
+// newLicenses собирает лицензии на котировки из marketdata.license и marketdata.licenses
+func newLicenses(cfg config.MarketDataConfig) (*marketdata.Licenses, error) {
+	license := func(c config.LicenseConfig) marketdata.License {
+		return marketdata.License{Source: c.Source, Name: c.Name, URL: c.URL, Attribution: c.Attribution, Class: c.Class}
+	}
+	licenses, err := marketdata.NewLicenses(license(cfg.License))
+	if err != nil {
+		return nil, fmt.Errorf("marketdata.license: %w", err)
+	}
+	for i, c := range cfg.Licenses {
+		if len(c.Tickers) == 0 {
+			return nil, fmt.Errorf("marketdata.licenses[%d]: tickers are required", i)
+		}
+		if err := licenses.Set(c.Tickers, license(c)); err != nil {
+			return nil, fmt.Errorf("marketdata.licenses[%d]: %w", i, err)
+		}
+	}
+	return licenses, nil
+}
+
+// newRecommendations собирает таблицу рекомендаций из extract.recommendations
+func newRecommendations(cfg config.ExtractConfig) (*extract.Recommendations, error) {
+	rules := make([]extract.RecommendationRule, len(cfg.Recommendations))
+	for i, c := range cfg.Recommendations {
+		rules[i] = extract.RecommendationRule{Phrases: c.Phrases, Recommendation: c.Recommendation}
+	}
+	recs, err := extract.NewRecommendations(rules)
+	if err != nil {
+		return nil, fmt.Errorf("extract.recommendations: %w", err)
+	}
+	return recs, nil
+}
+
+// startMarketData запускает планировщик обновления котировок, если он включен
+func startMarketData(ctx context.Context, cfg config.MarketDataConfig, demand *marketdata.DemandTracker, store storage.Storage, pub events.Publisher) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Quota <= 0 || cfg.QuotaPeriod <= 0 || cfg.Tick <= 0 {
+		return fmt.Errorf("marketdata.quota, quota_period and tick must be positive")
+	}
+
+	var provider marketdata.Provider
+	switch cfg.Provider {
+	case "moex":
+		provider = marketdata.NewMOEXProvider(cfg.DataDir)
+	default:
+		return fmt.Errorf("unknown marketdata.provider %q", cfg.Provider)
+	}
+
+	tickers := func() ([]string, error) {
+		stocks, err := store.GetStocks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(stocks))
+		for i, st := range stocks {
+			out[i] = st.Ticker
+		}
+		return out, nil
+	}
+
+	scheduler := marketdata.NewScheduler(provider, demand, tickers, marketdata.SchedulerOptions{
+		Quota:           cfg.Quota,
+		QuotaPeriod:     cfg.QuotaPeriod,
+		Tick:            cfg.Tick,
+		HotInterval:     cfg.HotInterval,
+		DormantInterval: cfg.DormantInterval,
+	})
+	scheduler.OnRefresh(func(ctx context.Context, ticker string) {
+		history, err := store.GetStockPriceHistory(ctx, ticker)
+		if err != nil || len(history) == 0 {
+			return
+		}
+		last := history[len(history)-1]
+		pub.Publish(events.Event{
+			Type:   events.TypePriceUpdated,
+			Ticker: ticker,
+			Time:   time.Now().UTC(),
+			Data:   events.PriceUpdated{Timestamp: last.Timestamp, Price: last.Price, Volume: last.Volume},
+		})
+	})
+	go scheduler.Run(ctx)
+	return nil
+}
+
+// startWebhooks загружает подписки на новые прогнозы и запускает воркеры доставки
+func startWebhooks(ctx context.Context, cfg config.WebhooksConfig, pg *storage.PostgresStorage, logger *slog.Logger) (*webhook.Dispatcher, error) {
+	static := make([]storage.WebhookSubscription, 0, len(cfg.Predictions))
+	for _, t := range cfg.Predictions {
+		secret := t.Secret
+		if secret == "" {
+			secret = cfg.Secret
+		}
+		static = append(static, storage.WebhookSubscription{URL: t.URL, Secret: secret, Tickers: t.Tickers})
+	}
+	dispatcher, err := webhook.NewDispatcher(pg, static, cfg.Timeout, cfg.Retries, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := dispatcher.Load(ctx); err != nil {
+		return nil, err
+	}
+	go dispatcher.Run(ctx, cfg.Workers)
+	return dispatcher, nil
+}
+
+// serverTLS настраивает HTTPS; с client_ca_file сервер требует клиентский
+// сертификат, подписанный этим CA, и возвращает сопоставление CN ролям
+func serverTLS(cfg config.TLSConfig) (*tls.Config, *autocert.Manager, *auth.ClientCerts, error) {
+	var tc *tls.Config
+	var manager *autocert.Manager
+	switch {
+	case cfg.ACME.Enabled:
+		if cfg.CertFile != "" || cfg.KeyFile != "" {
+			return nil, nil, nil, fmt.Errorf("cert_file/key_file and acme are mutually exclusive")
+		}
+		if len(cfg.ACME.Domains) == 0 {
+			return nil, nil, nil, fmt.Errorf("acme.domains is required")
+		}
+		manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACME.Domains...),
+			Cache:      autocert.DirCache(cfg.ACME.CacheDir),
+			Email:      cfg.ACME.Email,
+		}
+		if cfg.ACME.DirectoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: cfg.ACME.DirectoryURL}
+		}
+		tc = manager.TLSConfig()
+		tc.MinVersion = tls.VersionTLS12
+		slog.Info("Сертификаты выпускаются по ACME", "domains", cfg.ACME.Domains, "cache_dir", cfg.ACME.CacheDir)
+	case cfg.CertFile == "" || cfg.KeyFile == "":
+		return nil, nil, nil, fmt.Errorf("cert_file and key_file are required")
+	default:
+		tc = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.ClientCAFile == "" {
+		return tc, manager, nil, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, nil, nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+	}
+	tc.ClientCAs = pool
+	tc.ClientAuth = tls.RequireAndVerifyClientCert
+	certs, err := auth.NewClientCerts(cfg.ClientRoles, cfg.ClientDefaultRole)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	slog.Info("mTLS включен: клиентские сертификаты проверяются по CA", "ca_file", cfg.ClientCAFile)
+	return tc, manager, certs, nil
+}
+
+// startHTTPRedirect поднимает на addr HTTP-сервер, который перенаправляет
+// запросы на HTTPS-сервер httpsAddr, а с ACME еще и отвечает на проверки
+// HTTP-01; остановка — по отмене ctx
+func startHTTPRedirect(ctx context.Context, addr, httpsAddr string, manager *autocert.Manager) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen http on %s: %w", addr, err)
+	}
+	handler := redirectToHTTPS(httpsAddr)
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		slog.Info("HTTP перенаправляет на HTTPS", "addr", ln.Addr().String())
+		if err := srv.Serve(ln); err != http.ErrServerClosed {
+			slog.Error("HTTP-сервер перенаправления остановлен с ошибкой", "err", err)
+		}
+	}()
+	return nil
+}
+
+// redirectToHTTPS отвечает 308 на тот же хост и путь по HTTPS. Порт
+// httpsAddr добавляется к хосту, если он не 443.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// anonymousRole проверяет роль анонимных запросов: admin разрешается только
+// явным auth.anonymous_admin
+func anonymousRole(cfg config.AuthConfig) (auth.Role, error) {
+	role, err := auth.ParseRole(cfg.AnonymousRole)
+	if err != nil {
+		return "", err
+	}
+	if role == auth.RoleAdmin && !cfg.AnonymousAdmin {
+		return "", errors.New("admin requires auth.anonymous_admin: true (development only)")
+	}
+	return role, nil
+}
+
+// loadAPIKeys собирает ключи из конфигурации и БД
+func loadAPIKeys(ctx context.Context, cfg config.AuthConfig, store auth.KeyStore, logger *slog.Logger) (*auth.Keyring, error) {
+	static := make([]auth.StaticKey, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		static = append(static, auth.StaticKey{Name: k.Name, Key: k.Key, Role: k.Role})
+	}
+	keyring, err := auth.NewKeyring(store, static, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.Load(ctx); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// startJobs запускает периодические задачи с ненулевым интервалом
+func startJobs(ctx context.Context, cfg *config.Config, pg *storage.PostgresStorage, pub events.Publisher, logger *slog.Logger) *jobs.Runner {
+	runner := jobs.NewRunner(logger)
+	if cfg.Jobs.EODSummariesInterval > 0 {
+		runner.Add(jobs.EODSummaries(pg, cfg.Jobs.EODSummariesInterval, logger))
+	}
+	if cfg.Jobs.OutcomesInterval > 0 {
+		var notifier jobs.OutcomeNotifier
+		if wh := cfg.Webhooks; wh.OutcomesURL != "" {
+			n := accuracy.NewWebhookNotifier(webhook.NewSender(wh.OutcomesURL, wh.Secret, wh.Timeout, wh.Retries, logger), logger)
+			go n.Run(ctx, wh.Workers)
+			notifier = n
+		}
+		runner.Add(jobs.Outcomes(pg, notifier, cfg.Jobs.OutcomesInterval, logger))
+	}
+	if cfg.Jobs.StockEventsInterval > 0 {
+		runner.Add(jobs.StockEvents(pg, pub, cfg.Jobs.StockEventsInterval))
+	}
+	go runner.Run(ctx)
+	return runner
+}
 
-	log.Fatal(http.ListenAndServe(":8080", server))
+// fatal пишет ошибку в лог и завершает процесс, как log.Fatal
+func fatal(err error) {
+	slog.Error("Фатальная ошибка", "err", err)
+	os.Exit(1)
 }
@@ -0,0 +1,192 @@
+// Package accuracy сверяет прогнозы с фактическими ценами (бэктестинг):
+// достигнута ли цель в пределах горизонта, ошибка и число дней до цели.
+package accuracy
+
+import (
+	"math"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Исходы прогноза
+const (
+	OutcomeHit     = "hit"
+	OutcomeMiss    = "miss"
+	OutcomePending = "pending" // горизонт еще не истек, цель не достигнута
+	OutcomeNoData  = "no_data" // нет цены на дату прогноза
+)
+
+// Result — результат сверки одного прогноза
+type Result struct {
+	MessageID    int64    `json:"MessageID"`
+	PredictedAt  string   `json:"PredictedAt"`
+	TargetPrice  float64  `json:"TargetPrice"`
+	EntryPrice   *float64 `json:"EntryPrice"` // цена закрытия в день прогноза
+	HorizonDays  int      `json:"HorizonDays"`
+	Outcome      string   `json:"Outcome"`
+	ErrorPercent *float64 `json:"ErrorPercent"` // (цена на конец горизонта - цель) / цель
+	DaysToTarget *int     `json:"DaysToTarget"`
+}
+
+// Summary — сводка по набору прогнозов
+type Summary struct {
+	Total           int      `json:"Total"`
+	Hits            int      `json:"Hits"`
+	Misses          int      `json:"Misses"`
+	Pending         int      `json:"Pending"`
+	NoData          int      `json:"NoData"`
+	HitRate         *float64 `json:"HitRate"` // hits / (hits + misses)
+	MeanAbsError    *float64 `json:"MeanAbsError"`
+	AvgDaysToTarget *float64 `json:"AvgDaysToTarget"`
+}
+
+// Report — результат бэктестинга прогнозов по тикеру
+type Report struct {
+	Ticker      string   `json:"Ticker"`
+	Summary     Summary  `json:"Summary"`
+	Predictions []Result `json:"Predictions"`
+	Warnings    []string `json:"Warnings,omitempty"`
+}
+
+// PredictedTime разбирает PredictedAt (RFC 3339 или Unix timestamp)
+func PredictedTime(p storage.Prediction) (time.Time, bool) {
+	return storage.ParseTimestamp(p.PredictedAt)
+}
+
+// pricePoint — запись истории с разобранным временем
+type pricePoint struct {
+	t     time.Time
+	price float64
+}
+
+func parseHistory(history []storage.StockPriceHistory) []pricePoint {
+	points := make([]pricePoint, 0, len(history))
+	for _, h := range history {
+		t, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err != nil {
+			continue
+		}
+		points = append(points, pricePoint{t: t, price: h.Price})
+	}
+	return points
+}
+
+// Evaluate сверяет прогноз с историей цен (от старых к новым). Цель
+// считается достигнутой, если цена закрытия дошла до нее в направлении от
+// цены входа. ok=false, если у прогноза нет целевой цены.
+func Evaluate(p storage.Prediction, history []storage.StockPriceHistory) (Result, bool) {
+	return evaluate(p, parseHistory(history))
+}
+
+func evaluate(p storage.Prediction, points []pricePoint) (Result, bool) {
+	if p.TargetPrice == nil || *p.TargetPrice <= 0 {
+		return Result{}, false
+	}
+	target := *p.TargetPrice
+	r := Result{
+		MessageID:   p.MessageID,
+		PredictedAt: p.PredictedAt,
+		TargetPrice: target,
+		HorizonDays: storage.HorizonDays(p.Period),
+		Outcome:     OutcomeNoData,
+	}
+
+	start, ok := PredictedTime(p)
+	if !ok || len(points) == 0 || start.Before(points[0].t.AddDate(0, 0, -1)) {
+		return r, true
+	}
+
+	// Вход — первая цена закрытия в день прогноза или позже
+	first := -1
+	for i, pt := range points {
+		if !pt.t.Before(start.Truncate(24 * time.Hour)) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return r, true
+	}
+	entry := points[first].price
+	r.EntryPrice = &entry
+
+	end := start.AddDate(0, 0, r.HorizonDays)
+	up := target >= entry
+	last := points[first]
+	for _, pt := range points[first:] {
+		if pt.t.After(end) {
+			break
+		}
+		last = pt
+		if (up && pt.price >= target) || (!up && pt.price <= target) {
+			days := int(pt.t.Sub(points[first].t).Hours() / 24)
+			r.DaysToTarget = &days
+			r.Outcome = OutcomeHit
+			break
+		}
+	}
+
+	errPct := (last.price - target) / target * 100
+	r.ErrorPercent = &errPct
+	if r.Outcome == OutcomeHit {
+		return r, true
+	}
+	if points[len(points)-1].t.Before(end) {
+		r.Outcome = OutcomePending
+	} else {
+		r.Outcome = OutcomeMiss
+	}
+	return r, true
+}
+
+// Backtest сверяет все прогнозы с целевой ценой и считает сводку
+func Backtest(ticker string, predictions []storage.Prediction, history []storage.StockPriceHistory) Report {
+	points := parseHistory(history)
+	report := Report{Ticker: ticker, Predictions: []Result{}}
+	for _, p := range predictions {
+		if r, ok := evaluate(p, points); ok {
+			report.Predictions = append(report.Predictions, r)
+		}
+	}
+	report.Summary = Summarize(report.Predictions)
+	return report
+}
+
+// Summarize считает сводку по результатам сверки
+func Summarize(results []Result) Summary {
+	var s Summary
+	var absErr, days float64
+	var withErr int
+	for _, r := range results {
+		s.Total++
+		switch r.Outcome {
+		case OutcomeHit:
+			s.Hits++
+			days += float64(*r.DaysToTarget)
+		case OutcomeMiss:
+			s.Misses++
+		case OutcomePending:
+			s.Pending++
+		default:
+			s.NoData++
+		}
+		if r.Outcome != OutcomeNoData && r.Outcome != OutcomePending {
+			absErr += math.Abs(*r.ErrorPercent)
+			withErr++
+		}
+	}
+	if decided := s.Hits + s.Misses; decided > 0 {
+		rate := float64(s.Hits) / float64(decided)
+		s.HitRate = &rate
+	}
+	if withErr > 0 {
+		mae := absErr / float64(withErr)
+		s.MeanAbsError = &mae
+	}
+	if s.Hits > 0 {
+		avg := days / float64(s.Hits)
+		s.AvgDaysToTarget = &avg
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package accuracy
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"frontend-backend/internal/storage"
+)
+
+// Store — данные, по которым строится рейтинг источников
+type Store interface {
+	GetStocks(ctx context.Context) ([]storage.Stock, error)
+	GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]storage.Prediction, error)
+	GetStockPriceHistory(ctx context.Context, ticker string) ([]storage.StockPriceHistory, error)
+}
+
+// SourceScore — точность прогнозов одного источника
+type SourceScore struct {
+	Rank     int     `json:"Rank"`
+	SourceID int64   `json:"SourceID"`
+	Source   string  `json:"Source"`
+	Summary  Summary `json:"Summary"`
+}
+
+// Leaderboard сверяет прогнозы всех акций с историей цен и ранжирует
+// источники по доле сбывшихся прогнозов, затем по числу попаданий и прогнозов.
+// Прогнозы без источника не учитываются.
+func Leaderboard(ctx context.Context, store Store) ([]SourceScore, error) {
+	stocks, err := store.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int64]string{}
+	results := map[int64][]Result{}
+	for _, st := range stocks {
+		predictions, err := store.GetPredictionsByTicker(ctx, st.Ticker, st.Exchange)
+		if err != nil {
+			return nil, err
+		}
+		if len(predictions) == 0 {
+			continue
+		}
+		history, err := store.GetStockPriceHistory(ctx, st.Ticker)
+		if err != nil {
+			// Без истории прогнозы попадут в рейтинг как no_data
+			slog.Warn("Нет истории цен при построении рейтинга", "ticker", st.Ticker, "err", err)
+		}
+		points := parseHistory(history)
+
+		for _, p := range predictions {
+			if p.SourceID == nil {
+				continue
+			}
+			r, ok := evaluate(p, points)
+			if !ok {
+				continue
+			}
+			results[*p.SourceID] = append(results[*p.SourceID], r)
+			if p.Source != nil {
+				names[*p.SourceID] = *p.Source
+			}
+		}
+	}
+
+	board := make([]SourceScore, 0, len(results))
+	for id, rs := range results {
+		board = append(board, SourceScore{SourceID: id, Source: names[id], Summary: Summarize(rs)})
+	}
+	sort.Slice(board, func(i, j int) bool {
+		a, b := board[i].Summary, board[j].Summary
+		if (a.HitRate == nil) != (b.HitRate == nil) {
+			return a.HitRate != nil
+		}
+		if a.HitRate != nil && *a.HitRate != *b.HitRate {
+			return *a.HitRate > *b.HitRate
+		}
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return board[i].SourceID < board[j].SourceID
+	})
+	for i := range board {
+		board[i].Rank = i + 1
+	}
+	return board, nil
+}
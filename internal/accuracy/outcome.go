@@ -0,0 +1,84 @@
+package accuracy
+
+import (
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// OutcomePartialHit — цель не достигнута, но цена прошла не меньше
+// PartialHitShare пути от цены входа до цели
+const OutcomePartialHit = "partially-hit"
+
+// PartialHitShare — доля пути до цели для исхода partially-hit
+const PartialHitShare = 0.5
+
+// Outcome — окончательный исход прогноза после истечения горизонта
+type Outcome struct {
+	Outcome        string
+	RealizedReturn float64 // доходность за горизонт в направлении прогноза, %
+}
+
+// EvaluateOutcome определяет окончательный исход прогноза. ok=false, если
+// у прогноза нет цели или история цен не покрывает весь горизонт, включая
+// день прогноза: иначе ценой входа стала бы первая свеча после начала
+// истории.
+func EvaluateOutcome(p storage.Prediction, history []storage.StockPriceHistory) (Outcome, bool) {
+	if p.TargetPrice == nil || *p.TargetPrice <= 0 {
+		return Outcome{}, false
+	}
+	target := *p.TargetPrice
+	start, ok := PredictedTime(p)
+	points := parseHistory(history)
+	if !ok || len(points) == 0 {
+		return Outcome{}, false
+	}
+	end := start.AddDate(0, 0, storage.HorizonDays(p.Period))
+	if points[len(points)-1].t.Before(end) {
+		return Outcome{}, false
+	}
+
+	first := -1
+	for i, pt := range points {
+		if !pt.t.Before(start.Truncate(24 * time.Hour)) {
+			first = i
+			break
+		}
+	}
+	// Свеча до дня прогноза доказывает, что история его покрывает
+	if first <= 0 || points[first].t.After(end) {
+		return Outcome{}, false
+	}
+	entry := points[first].price
+	if entry <= 0 {
+		return Outcome{}, false
+	}
+
+	up := target >= entry
+	best, last := entry, entry
+	hit := false
+	for _, pt := range points[first:] {
+		if pt.t.After(end) {
+			break
+		}
+		last = pt.price
+		if up && pt.price > best || !up && pt.price < best {
+			best = pt.price
+		}
+		if (up && pt.price >= target) || (!up && pt.price <= target) {
+			hit = true
+		}
+	}
+
+	o := Outcome{Outcome: OutcomeMiss, RealizedReturn: (last/entry - 1) * 100}
+	if !up {
+		o.RealizedReturn = -o.RealizedReturn
+	}
+	switch {
+	case hit:
+		o.Outcome = OutcomeHit
+	case target != entry && (best-entry)/(target-entry) >= PartialHitShare:
+		o.Outcome = OutcomePartialHit
+	}
+	return o, true
+}
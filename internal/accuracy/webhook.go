@@ -0,0 +1,130 @@
+package accuracy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/webhook"
+)
+
+// EventOutcome — тип события об исходе прогноза
+const EventOutcome = "prediction.outcome"
+
+// notifierQueue — сколько событий может ждать отправки
+const notifierQueue = 1024
+
+// ErrNotifierQueueFull возвращается, когда очередь отправки переполнена и
+// событие пропущено
+var ErrNotifierQueueFull = errors.New("outcome webhook queue is full")
+
+// Features — признаки прогноза, которые передаются вместе с исходом
+type Features struct {
+	PredictionType      *string  `json:"prediction_type"`
+	TargetPrice         *float64 `json:"target_price"`
+	TargetChangePercent *float64 `json:"target_change_percent"`
+	Period              *string  `json:"period"`
+	HorizonDays         int      `json:"horizon_days"`
+	Recommendation      *string  `json:"recommendation"`
+	Direction           *string  `json:"direction"`
+	SourceID            *int64   `json:"source_id"`
+	PredictedAt         string   `json:"predicted_at"`
+}
+
+// OutcomeEvent — событие об исходе прогноза для обратной связи ML
+type OutcomeEvent struct {
+	Type           string   `json:"type"`
+	Channel        string   `json:"channel"`
+	MessageID      int64    `json:"message_id"`
+	StockID        int64    `json:"stock_id"`
+	Ticker         string   `json:"ticker"`
+	Outcome        string   `json:"outcome"`
+	RealizedReturn float64  `json:"realized_return"`
+	Features       Features `json:"features"`
+}
+
+// NewOutcomeEvent собирает событие из прогноза и его исхода
+func NewOutcomeEvent(u storage.UnevaluatedPrediction, o Outcome) OutcomeEvent {
+	p := u.Prediction
+	return OutcomeEvent{
+		Type:           EventOutcome,
+		Channel:        p.Channel,
+		MessageID:      p.MessageID,
+		StockID:        p.StockID,
+		Ticker:         u.Ticker,
+		Outcome:        o.Outcome,
+		RealizedReturn: o.RealizedReturn,
+		Features: Features{
+			PredictionType:      p.PredictionType,
+			TargetPrice:         p.TargetPrice,
+			TargetChangePercent: p.TargetChangePercent,
+			Period:              p.Period,
+			HorizonDays:         storage.HorizonDays(p.Period),
+			Recommendation:      p.Recommendation,
+			Direction:           p.Direction,
+			SourceID:            p.SourceID,
+			PredictedAt:         p.PredictedAt,
+		},
+	}
+}
+
+// WebhookNotifier отправляет исходы прогнозов вебхуком. NotifyOutcome только
+// ставит событие в очередь; отправку с повторами выполняют воркеры Run, чтобы
+// медленный получатель не задерживал проставление исходов.
+type WebhookNotifier struct {
+	sender *webhook.Sender
+	queue  chan OutcomeEvent
+	log    *slog.Logger
+}
+
+// NewWebhookNotifier создает новый экземпляр WebhookNotifier; nil logger —
+// slog.Default()
+func NewWebhookNotifier(sender *webhook.Sender, logger *slog.Logger) *WebhookNotifier {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &WebhookNotifier{sender: sender, queue: make(chan OutcomeEvent, notifierQueue), log: logger}
+}
+
+// NotifyOutcome ставит событие prediction.outcome в очередь отправки
+func (n *WebhookNotifier) NotifyOutcome(ctx context.Context, u storage.UnevaluatedPrediction, o Outcome) error {
+	select {
+	case n.queue <- NewOutcomeEvent(u, o):
+		return nil
+	default:
+		metrics.WebhookDeliveries.WithLabelValues(EventOutcome, "dropped").Inc()
+		return ErrNotifierQueueFull
+	}
+}
+
+// Run запускает workers воркеров отправки и ждет отмены ctx
+func (n *WebhookNotifier) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-n.queue:
+					n.deliver(ctx, e)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (n *WebhookNotifier) deliver(ctx context.Context, e OutcomeEvent) {
+	if err := n.sender.Send(ctx, EventOutcome, e); err != nil {
+		metrics.WebhookDeliveries.WithLabelValues(EventOutcome, "failure").Inc()
+		n.log.Warn("Не удалось отправить исход прогноза", "channel", e.Channel, "message_id", e.MessageID, "stock_id", e.StockID, "err", err)
+		return
+	}
+	metrics.WebhookDeliveries.WithLabelValues(EventOutcome, "success").Inc()
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/storage"
+)
+
+// ChartMaxCandles — предел числа свечей при автоматическом выборе интервала
+const ChartMaxCandles = 200
+
+// Candle — свеча, построенная по ценам закрытия за интервал
+type Candle struct {
+	Time   string  `json:"Time"` // начало интервала, YYYY-MM-DD
+	Open   float64 `json:"Open"`
+	High   float64 `json:"High"`
+	Low    float64 `json:"Low"`
+	Close  float64 `json:"Close"`
+	Volume int64   `json:"Volume"`
+}
+
+// Marker — прогноз на оси времени графика
+type Marker struct {
+	Time           string   `json:"Time"` // начало интервала свечи, YYYY-MM-DD
+	PredictedAt    string   `json:"PredictedAt"`
+	MessageID      int64    `json:"MessageID"`
+	TargetPrice    *float64 `json:"TargetPrice"`
+	Direction      *string  `json:"Direction"`
+	Recommendation *string  `json:"Recommendation"`
+}
+
+// Chart — свечи и маркеры прогнозов на общей оси времени
+type Chart struct {
+	Ticker   string   `json:"Ticker"`
+	Bucket   string   `json:"Bucket"`
+	Candles  []Candle `json:"Candles"`
+	Markers  []Marker `json:"Markers"`
+	Warnings []string `json:"Warnings,omitempty"`
+	// Attribution — ссылка на поставщика котировок, если ее требует лицензия
+	Attribution *marketdata.License `json:"Attribution,omitempty"`
+}
+
+// AutoBucket выбирает наименьший интервал, при котором свечей не больше ChartMaxCandles
+func AutoBucket(history []storage.StockPriceHistory) string {
+	if len(history) < 2 {
+		return storage.BucketDay
+	}
+	first, _ := time.Parse(time.RFC3339, history[0].Timestamp)
+	days := LastTime(history).Sub(first).Hours() / 24
+	switch {
+	case len(history) <= ChartMaxCandles:
+		return storage.BucketDay
+	case days/7 <= ChartMaxCandles:
+		return storage.BucketWeek
+	default:
+		return storage.BucketMonth
+	}
+}
+
+// BuildChart сворачивает историю (от старых к новым) в свечи по интервалам и
+// привязывает прогнозы к интервалу, в который попадает их дата
+func BuildChart(ticker string, history []storage.StockPriceHistory, predictions []storage.Prediction, bucket string) Chart {
+	c := Chart{Ticker: ticker, Bucket: bucket, Candles: []Candle{}, Markers: []Marker{}}
+
+	for _, h := range history {
+		t, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err != nil {
+			continue
+		}
+		key := storage.BucketStart(t, bucket).Format("2006-01-02")
+		if n := len(c.Candles); n > 0 && c.Candles[n-1].Time == key {
+			last := &c.Candles[n-1]
+			last.High = math.Max(last.High, h.Price)
+			last.Low = math.Min(last.Low, h.Price)
+			last.Close = h.Price
+			last.Volume += h.Volume
+			continue
+		}
+		c.Candles = append(c.Candles, Candle{
+			Time: key, Open: h.Price, High: h.Price, Low: h.Price, Close: h.Price, Volume: h.Volume,
+		})
+	}
+
+	for _, p := range predictions {
+		at, ok := storage.ParseTimestamp(p.PredictedAt)
+		if !ok {
+			continue
+		}
+		c.Markers = append(c.Markers, Marker{
+			Time:           storage.BucketStart(at, bucket).Format("2006-01-02"),
+			PredictedAt:    p.PredictedAt,
+			MessageID:      p.MessageID,
+			TargetPrice:    p.TargetPrice,
+			Direction:      p.Direction,
+			Recommendation: p.Recommendation,
+		})
+	}
+	return c
+}
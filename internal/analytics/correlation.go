@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Correlation — матрица попарных корреляций дневных доходностей
+type Correlation struct {
+	Tickers  []string     `json:"Tickers"`
+	Window   string       `json:"Window"`
+	From     string       `json:"From,omitempty"` // начало периода, YYYY-MM-DD
+	To       string       `json:"To,omitempty"`
+	Matrix   [][]*float64 `json:"Matrix"`  // nil, если общих дат меньше двух
+	Samples  [][]int      `json:"Samples"` // число общих дат в паре
+	Warnings []string     `json:"Warnings,omitempty"`
+}
+
+// ComputeCorrelation считает корреляцию Пирсона дневных логарифмических
+// доходностей для каждой пары тикеров по общим датам. Период заканчивается
+// на самой поздней дате среди всех рядов.
+func ComputeCorrelation(tickers []string, histories map[string][]storage.StockPriceHistory, window Window) Correlation {
+	c := Correlation{
+		Tickers: tickers,
+		Window:  window.String(),
+		Matrix:  make([][]*float64, len(tickers)),
+		Samples: make([][]int, len(tickers)),
+	}
+
+	var end time.Time
+	for _, h := range histories {
+		if last := LastTime(h); last.After(end) {
+			end = last
+		}
+	}
+	var since time.Time
+	if !end.IsZero() {
+		since = window.Start(end)
+		c.From, c.To = since.Format("2006-01-02"), end.Format("2006-01-02")
+	}
+
+	returns := make([]map[string]float64, len(tickers))
+	for i, t := range tickers {
+		returns[i] = dailyReturns(histories[t], since)
+	}
+
+	for i := range tickers {
+		c.Matrix[i] = make([]*float64, len(tickers))
+		c.Samples[i] = make([]int, len(tickers))
+	}
+	for i := range tickers {
+		for j := i; j < len(tickers); j++ {
+			xs, ys := alignReturns(returns[i], returns[j])
+			c.Samples[i][j], c.Samples[j][i] = len(xs), len(xs)
+			if corr, ok := correlation(xs, ys); ok {
+				c.Matrix[i][j], c.Matrix[j][i] = &corr, &corr
+			}
+		}
+	}
+	return c
+}
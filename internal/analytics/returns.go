@@ -0,0 +1,72 @@
+// Package analytics считает метрики по истории цен: риск, корреляции и т. п.
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// TradingDaysPerYear — число торговых дней для годовой волатильности
+const TradingDaysPerYear = 252
+
+// dailyReturns возвращает логарифмические доходности по датам (YYYY-MM-DD)
+// начиная с since (нулевое since — вся история)
+func dailyReturns(history []storage.StockPriceHistory, since time.Time) map[string]float64 {
+	returns := map[string]float64{}
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1], history[i]
+		if prev.Price <= 0 || cur.Price <= 0 || len(cur.Timestamp) < 10 {
+			continue
+		}
+		if !since.IsZero() {
+			if t, err := time.Parse(time.RFC3339, cur.Timestamp); err != nil || t.Before(since) {
+				continue
+			}
+		}
+		returns[cur.Timestamp[:10]] = math.Log(cur.Price / prev.Price)
+	}
+	return returns
+}
+
+// alignReturns оставляет только даты, общие для обоих рядов
+func alignReturns(a, b map[string]float64) (xs, ys []float64) {
+	for day, x := range a {
+		if y, ok := b[day]; ok {
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	return xs, ys
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// covariance — выборочная ковариация; len(xs) == len(ys) >= 2
+func covariance(xs, ys []float64) float64 {
+	mx, my := mean(xs), mean(ys)
+	var sum float64
+	for i := range xs {
+		sum += (xs[i] - mx) * (ys[i] - my)
+	}
+	return sum / float64(len(xs)-1)
+}
+
+// correlation — коэффициент корреляции Пирсона; ok=false при нулевой дисперсии
+func correlation(xs, ys []float64) (float64, bool) {
+	if len(xs) < 2 {
+		return 0, false
+	}
+	vx, vy := covariance(xs, xs), covariance(ys, ys)
+	if vx == 0 || vy == 0 {
+		return 0, false
+	}
+	return covariance(xs, ys) / math.Sqrt(vx*vy), true
+}
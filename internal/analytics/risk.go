@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Risk — метрики риска акции за период
+type Risk struct {
+	Ticker         string   `json:"Ticker"`
+	From           string   `json:"From,omitempty"` // первая дата периода, YYYY-MM-DD
+	To             string   `json:"To,omitempty"`
+	Volatility     *float64 `json:"Volatility"`  // годовая волатильность, %
+	MaxDrawdown    *float64 `json:"MaxDrawdown"` // максимальная просадка от пика, %
+	Benchmark      string   `json:"Benchmark,omitempty"`
+	Beta           *float64 `json:"Beta"`
+	AvgDailyVolume *float64 `json:"AvgDailyVolume"`
+	Warnings       []string `json:"Warnings,omitempty"`
+}
+
+// ComputeRisk считает волатильность, просадку и средний объем по истории
+// (от старых к новым) начиная с since. Если задан benchmark, считается бета
+// по общим датам обоих рядов.
+func ComputeRisk(ticker string, history []storage.StockPriceHistory, since time.Time, benchmark string, benchmarkHistory []storage.StockPriceHistory) Risk {
+	r := Risk{Ticker: ticker, Benchmark: benchmark}
+	window := HistorySince(history, since)
+	if len(window) == 0 {
+		return r
+	}
+	r.From = window[0].Timestamp[:10]
+	r.To = window[len(window)-1].Timestamp[:10]
+
+	returns := dailyReturns(history, since)
+	if len(returns) >= 2 {
+		xs := make([]float64, 0, len(returns))
+		for _, x := range returns {
+			xs = append(xs, x)
+		}
+		vol := math.Sqrt(covariance(xs, xs)*TradingDaysPerYear) * 100
+		r.Volatility = &vol
+	}
+
+	peak, drawdown := 0.0, 0.0
+	var volume int64
+	for _, h := range window {
+		peak = math.Max(peak, h.Price)
+		if peak > 0 {
+			drawdown = math.Min(drawdown, (h.Price/peak-1)*100)
+		}
+		volume += h.Volume
+	}
+	r.MaxDrawdown = &drawdown
+	avgVolume := float64(volume) / float64(len(window))
+	r.AvgDailyVolume = &avgVolume
+
+	if benchmark != "" && len(benchmarkHistory) > 0 {
+		xs, ys := alignReturns(returns, dailyReturns(benchmarkHistory, since))
+		if len(xs) >= 2 {
+			if v := covariance(ys, ys); v > 0 {
+				beta := covariance(xs, ys) / v
+				r.Beta = &beta
+			}
+		}
+	}
+	return r
+}
+
+// HistorySince возвращает часть истории начиная с since
+func HistorySince(history []storage.StockPriceHistory, since time.Time) []storage.StockPriceHistory {
+	if since.IsZero() {
+		return history
+	}
+	i := sort.Search(len(history), func(i int) bool {
+		t, _ := time.Parse(time.RFC3339, history[i].Timestamp)
+		return !t.Before(since)
+	})
+	return history[i:]
+}
@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Window — период анализа вида 90d, 12w, 6m, 1y
+type Window struct {
+	N    int
+	Unit byte // d, w, m, y
+}
+
+// ParseWindow разбирает период вида <число><d|w|m|y>
+func ParseWindow(s string) (Window, error) {
+	if len(s) < 2 {
+		return Window{}, fmt.Errorf("invalid window %q: expected e.g. 90d, 12w, 6m, 1y", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	unit := s[len(s)-1]
+	if err != nil || n <= 0 || (unit != 'd' && unit != 'w' && unit != 'm' && unit != 'y') {
+		return Window{}, fmt.Errorf("invalid window %q: expected e.g. 90d, 12w, 6m, 1y", s)
+	}
+	return Window{N: n, Unit: unit}, nil
+}
+
+// Start возвращает начало периода, заканчивающегося в end
+func (w Window) Start(end time.Time) time.Time {
+	switch w.Unit {
+	case 'w':
+		return end.AddDate(0, 0, -7*w.N)
+	case 'm':
+		return end.AddDate(0, -w.N, 0)
+	case 'y':
+		return end.AddDate(-w.N, 0, 0)
+	default:
+		return end.AddDate(0, 0, -w.N)
+	}
+}
+
+// String возвращает период в исходной записи
+func (w Window) String() string {
+	return strconv.Itoa(w.N) + string(w.Unit)
+}
+
+// LastTime возвращает время последней записи истории (нулевое для пустой)
+func LastTime(history []storage.StockPriceHistory) time.Time {
+	if len(history) == 0 {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, history[len(history)-1].Timestamp)
+	return t
+}
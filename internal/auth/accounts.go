@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// issuer — значение iss в выпущенных токенах
+const issuer = "frontend-backend"
+
+// minPasswordLength — минимальная длина пароля при регистрации
+const minPasswordLength = 8
+
+// refreshPrefix отличает refresh-токены от API-ключей
+const refreshPrefix = "fbr_"
+
+// ErrInvalidCredentials возвращается при неверном email или пароле; какой из
+// них неверен, не сообщается
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrInvalidToken возвращается для неверного или истекшего access-токена
+var ErrInvalidToken = errors.New("invalid or expired access token")
+
+// ErrInvalidAccount возвращается для некорректного email или слишком короткого пароля
+var ErrInvalidAccount = storage.NewValidationError("invalid account")
+
+// UserStore хранит пользователей и их refresh-токены
+type UserStore interface {
+	CreateUser(ctx context.Context, u *storage.User) error
+	GetUserByEmail(ctx context.Context, email string) (storage.User, error)
+	GetUser(ctx context.Context, id int64) (storage.User, error)
+	ListUsers(ctx context.Context) ([]storage.User, error)
+	SetUserRole(ctx context.Context, id int64, role string) error
+	AddRefreshToken(ctx context.Context, userID int64, hash string, expiresAt time.Time) error
+	ConsumeRefreshToken(ctx context.Context, hash string) (int64, error)
+}
+
+// Tokens — ответ на вход: короткоживущий JWT и refresh-токен для его обновления
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // срок жизни access-токена, секунд
+	RefreshToken string `json:"refresh_token"`
+}
+
+// claims — содержимое access-токена. Роль записывается при выпуске, поэтому
+// ее изменение вступает в силу со следующим токеном.
+type claims struct {
+	Email string `json:"email"`
+	Role  Role   `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Accounts регистрирует пользователей и выпускает им токены. Access-токен —
+// JWT с подписью HS256, проверяется без обращения к БД; refresh-токен
+// хранится в БД хешем и при обновлении заменяется новым.
+type Accounts struct {
+	store      UserStore
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewAccounts создает новый экземпляр Accounts
+func NewAccounts(store UserStore, secret string, accessTTL, refreshTTL time.Duration) (*Accounts, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("jwt secret must be at least 32 characters")
+	}
+	if accessTTL <= 0 || refreshTTL <= 0 {
+		return nil, fmt.Errorf("token lifetimes must be positive")
+	}
+	return &Accounts{store: store, secret: []byte(secret), accessTTL: accessTTL, refreshTTL: refreshTTL}, nil
+}
+
+// normalizeEmail проверяет адрес и приводит его к нижнему регистру
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", fmt.Errorf("%w: email %q is not a valid address", ErrInvalidAccount, email)
+	}
+	return email, nil
+}
+
+// Register создает пользователя с паролем
+func (a *Accounts) Register(ctx context.Context, email, password string) (storage.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return storage.User{}, err
+	}
+	if len(password) < minPasswordLength {
+		return storage.User{}, fmt.Errorf("%w: password must be at least %d characters", ErrInvalidAccount, minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		// bcrypt ограничивает пароль 72 байтами
+		return storage.User{}, fmt.Errorf("%w: %v", ErrInvalidAccount, err)
+	}
+	u := storage.User{Email: email, PasswordHash: string(hash)}
+	if err := a.store.CreateUser(ctx, &u); err != nil {
+		return storage.User{}, err
+	}
+	return u, nil
+}
+
+// Login проверяет пароль и выпускает токены
+func (a *Accounts) Login(ctx context.Context, email, password string) (Tokens, error) {
+	u, err := a.store.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return Tokens{}, ErrInvalidCredentials
+	} else if err != nil {
+		return Tokens{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return Tokens{}, ErrInvalidCredentials
+	}
+	return a.issue(ctx, u)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов; старый
+// refresh-токен перестает действовать
+func (a *Accounts) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	userID, err := a.store.ConsumeRefreshToken(ctx, Hash(refreshToken))
+	if err != nil {
+		return Tokens{}, err
+	}
+	u, err := a.store.GetUser(ctx, userID)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return a.issue(ctx, u)
+}
+
+// Logout отзывает refresh-токен; выпущенный access-токен действует до истечения
+func (a *Accounts) Logout(ctx context.Context, refreshToken string) error {
+	_, err := a.store.ConsumeRefreshToken(ctx, Hash(refreshToken))
+	return err
+}
+
+// User возвращает пользователя по ID
+func (a *Accounts) User(ctx context.Context, id int64) (storage.User, error) {
+	return a.store.GetUser(ctx, id)
+}
+
+// Users возвращает всех пользователей
+func (a *Accounts) Users(ctx context.Context) ([]storage.User, error) {
+	return a.store.ListUsers(ctx)
+}
+
+// SetRole меняет роль пользователя
+func (a *Accounts) SetRole(ctx context.Context, id int64, role string) error {
+	r, err := ParseRole(role)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAccount, err)
+	}
+	return a.store.SetUserRole(ctx, id, string(r))
+}
+
+// issue выпускает access-токен и сохраняет новый refresh-токен
+func (a *Accounts) issue(ctx context.Context, u storage.User) (Tokens, error) {
+	now := time.Now()
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Email: u.Email,
+		Role:  Role(u.Role),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatInt(u.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTTL)),
+		},
+	}).SignedString(a.secret)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("error signing access token: %w", err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Tokens{}, fmt.Errorf("error generating refresh token: %w", err)
+	}
+	refresh := refreshPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	if err := a.store.AddRefreshToken(ctx, u.ID, Hash(refresh), now.Add(a.refreshTTL)); err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.accessTTL.Seconds()),
+		RefreshToken: refresh,
+	}, nil
+}
+
+// RefreshTTL возвращает срок жизни refresh-токена
+func (a *Accounts) RefreshTTL() time.Duration {
+	return a.refreshTTL
+}
+
+// Verify проверяет подпись и срок access-токена и возвращает его владельца
+func (a *Accounts) Verify(token string) (*Principal, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	id, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%w: bad subject %q", ErrInvalidToken, c.Subject)
+	}
+	if _, err := ParseRole(string(c.Role)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &Principal{Name: c.Email, UserID: id, Role: c.Role}, nil
+}
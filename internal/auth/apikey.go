@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"frontend-backend/internal/storage"
+)
+
+// ErrInvalidKey возвращается для ключа без имени или с неизвестной ролью и
+// для слишком короткого ключа из конфигурации
+var ErrInvalidKey = storage.NewValidationError("invalid api key")
+
+// keyPrefix отличает ключи этого сервиса от прочих секретов (например, в
+// сканерах утечек)
+const keyPrefix = "fbk_"
+
+// minKeyLength — минимальная длина ключа из конфигурации
+const minKeyLength = 16
+
+// shownPrefix — сколько первых символов ключа показывается в списке ключей
+const shownPrefix = len(keyPrefix) + 8
+
+// KeyStore хранит ключи, выпущенные через админский API
+type KeyStore interface {
+	ListAPIKeys(ctx context.Context) ([]storage.APIKey, error)
+	AddAPIKey(ctx context.Context, k *storage.APIKey) error
+	RevokeAPIKey(ctx context.Context, id int64) error
+	SetAPIKeyRole(ctx context.Context, id int64, role string) error
+	SetAPIKeyQuota(ctx context.Context, id, daily, monthly int64) error
+}
+
+// StaticKey — ключ из конфигурации; пустая роль означает viewer
+type StaticKey struct {
+	Name string
+	Key  string
+	Role string
+}
+
+// Keyring проверяет API-ключи. Активные ключи держатся в памяти по хешу,
+// поэтому проверка запроса не обращается к БД.
+type Keyring struct {
+	store KeyStore
+	log   *slog.Logger
+
+	mu     sync.RWMutex
+	static []storage.APIKey
+	active map[string]storage.APIKey // hex(SHA-256) ключа → ключ
+}
+
+// NewKeyring создает новый экземпляр Keyring с ключами из конфигурации;
+// store может быть nil, тогда выпуск ключей через API недоступен; nil
+// logger — slog.Default()
+func NewKeyring(store KeyStore, static []StaticKey, logger *slog.Logger) (*Keyring, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	k := &Keyring{store: store, log: logger, active: make(map[string]storage.APIKey)}
+	for i, s := range static {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: key %d: name is required", ErrInvalidKey, i)
+		}
+		if len(s.Key) < minKeyLength {
+			return nil, fmt.Errorf("%w: key %q must be at least %d characters", ErrInvalidKey, name, minKeyLength)
+		}
+		role, err := keyRole(s.Role)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKey, name, err)
+		}
+		key := storage.APIKey{Name: name, Prefix: shown(s.Key), Hash: Hash(s.Key), Role: string(role), Source: "config"}
+		if _, dup := k.active[key.Hash]; dup {
+			return nil, fmt.Errorf("%w: key %q duplicates another key", ErrInvalidKey, name)
+		}
+		k.static = append(k.static, key)
+		k.active[key.Hash] = key
+	}
+	return k, nil
+}
+
+// Load загружает активные ключи из хранилища
+func (k *Keyring) Load(ctx context.Context) error {
+	if k.store == nil {
+		return nil
+	}
+	stored, err := k.store.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, key := range stored {
+		if key.RevokedAt == nil {
+			k.active[key.Hash] = key
+		}
+	}
+	k.log.Info("Активных API-ключей", "count", len(k.active))
+	return nil
+}
+
+// Hash возвращает hex(SHA-256) ключа — под этим значением ключ хранится
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// shown возвращает видимое начало ключа; от ключей из конфигурации, формат
+// которых неизвестен, показывается не больше четверти
+func shown(key string) string {
+	if strings.HasPrefix(key, keyPrefix) && len(key) > 2*shownPrefix {
+		return key[:shownPrefix]
+	}
+	return key[:len(key)/4]
+}
+
+// Authenticate возвращает клиента по ключу из заголовка X-API-Key
+func (k *Keyring) Authenticate(key string) (*Principal, bool) {
+	k.mu.RLock()
+	found, ok := k.active[Hash(key)]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return &Principal{Name: found.Name, KeyID: found.ID, Role: Role(found.Role)}, true
+}
+
+// keyRole разбирает роль ключа; по умолчанию ключ только читает
+func keyRole(s string) (Role, error) {
+	if s == "" {
+		return RoleViewer, nil
+	}
+	return ParseRole(s)
+}
+
+// List возвращает все ключи: сначала из конфигурации, затем выпущенные через API
+func (k *Keyring) List(ctx context.Context) ([]storage.APIKey, error) {
+	k.mu.RLock()
+	keys := append([]storage.APIKey{}, k.static...)
+	k.mu.RUnlock()
+	if k.store == nil {
+		return keys, nil
+	}
+	stored, err := k.store.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, stored...), nil
+}
+
+// Create выпускает новый ключ с квотами daily и monthly (0 — без
+// ограничения). Сам ключ возвращается только здесь — в хранилище остается его хеш.
+func (k *Keyring) Create(ctx context.Context, name, role string, daily, monthly int64) (string, storage.APIKey, error) {
+	if k.store == nil {
+		return "", storage.APIKey{}, fmt.Errorf("api keys store is not configured")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", storage.APIKey{}, fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+	r, err := keyRole(role)
+	if err != nil {
+		return "", storage.APIKey{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if err := checkQuota(daily, monthly); err != nil {
+		return "", storage.APIKey{}, err
+	}
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", storage.APIKey{}, fmt.Errorf("error generating api key: %w", err)
+	}
+	secret := keyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	key := storage.APIKey{Name: name, Prefix: shown(secret), Hash: Hash(secret), Role: string(r), DailyQuota: daily, MonthlyQuota: monthly}
+	if err := k.store.AddAPIKey(ctx, &key); err != nil {
+		return "", key, err
+	}
+	k.mu.Lock()
+	k.active[key.Hash] = key
+	k.mu.Unlock()
+	return secret, key, nil
+}
+
+// Revoke отзывает ключ, выпущенный через API; ключ перестает действовать сразу
+func (k *Keyring) Revoke(ctx context.Context, id int64) error {
+	if k.store == nil {
+		return fmt.Errorf("%w: %d", storage.ErrAPIKeyNotFound, id)
+	}
+	if err := k.store.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for hash, key := range k.active {
+		if key.ID == id && key.Source == "api" {
+			delete(k.active, hash)
+		}
+	}
+	return nil
+}
+
+// SetRole меняет роль ключа, выпущенного через API; новая роль действует сразу
+func (k *Keyring) SetRole(ctx context.Context, id int64, role string) error {
+	r, err := ParseRole(role)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if k.store == nil {
+		return fmt.Errorf("%w: %d", storage.ErrAPIKeyNotFound, id)
+	}
+	if err := k.store.SetAPIKeyRole(ctx, id, string(r)); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for hash, key := range k.active {
+		if key.ID == id && key.Source == "api" {
+			key.Role = string(r)
+			k.active[hash] = key
+		}
+	}
+	return nil
+}
+
+// SetQuota меняет квоты ключа, выпущенного через API; 0 снимает ограничение
+func (k *Keyring) SetQuota(ctx context.Context, id, daily, monthly int64) error {
+	if err := checkQuota(daily, monthly); err != nil {
+		return err
+	}
+	if k.store == nil {
+		return fmt.Errorf("%w: %d", storage.ErrAPIKeyNotFound, id)
+	}
+	if err := k.store.SetAPIKeyQuota(ctx, id, daily, monthly); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for hash, key := range k.active {
+		if key.ID == id && key.Source == "api" {
+			key.DailyQuota, key.MonthlyQuota = daily, monthly
+			k.active[hash] = key
+		}
+	}
+	return nil
+}
+
+// Key возвращает действующий ключ, выпущенный через API, по ID
+func (k *Keyring) Key(id int64) (storage.APIKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.active {
+		if key.ID == id && key.Source == "api" {
+			return key, true
+		}
+	}
+	return storage.APIKey{}, false
+}
+
+func checkQuota(daily, monthly int64) error {
+	if daily < 0 || monthly < 0 {
+		return fmt.Errorf("%w: quota must not be negative", ErrInvalidKey)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// ClientCerts сопоставляет клиентские сертификаты, уже проверенные TLS по
+// доверенному CA, клиентам API. Клиент — CN сертификата; CN сравниваются
+// без учета регистра.
+type ClientCerts struct {
+	roles       map[string]Role // CN в нижнем регистре → роль
+	defaultRole Role
+}
+
+// NewClientCerts создает новый экземпляр ClientCerts. roles — CN → роль;
+// сертификаты с другими CN получают defaultRole, пустая defaultRole их отклоняет.
+func NewClientCerts(roles map[string]string, defaultRole string) (*ClientCerts, error) {
+	c := &ClientCerts{roles: make(map[string]Role, len(roles))}
+	for cn, name := range roles {
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("client %q: %w", cn, err)
+		}
+		c.roles[strings.ToLower(cn)] = role
+	}
+	if defaultRole != "" {
+		role, err := ParseRole(defaultRole)
+		if err != nil {
+			return nil, fmt.Errorf("default role: %w", err)
+		}
+		c.defaultRole = role
+	}
+	return c, nil
+}
+
+// Authenticate возвращает клиента по проверенному сертификату соединения;
+// ok равен false, если сертификата нет или его CN не сопоставлен роли
+func (c *ClientCerts) Authenticate(state *tls.ConnectionState) (*Principal, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	role, ok := c.roles[strings.ToLower(cn)]
+	if !ok {
+		role = c.defaultRole
+	}
+	if cn == "" || role == "" {
+		return nil, false
+	}
+	return &Principal{Name: "cert:" + cn, Role: role}, true
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCOptions описывает доверенного провайдера OIDC (например, Keycloak)
+type OIDCOptions struct {
+	// Issuer — значение iss в токенах провайдера
+	Issuer string
+	// Audience — значение, которое должно быть в aud (client_id в Keycloak)
+	Audience string
+	// JWKSURL — ключи подписи; пустой адрес берется из
+	// <Issuer>/.well-known/openid-configuration
+	JWKSURL string
+	// UsernameClaim — claim с именем пользователя для логов; по умолчанию preferred_username
+	UsernameClaim string
+	// RolesClaim — путь к списку ролей через точку: realm_access.roles или
+	// resource_access.<client>.roles
+	RolesClaim string
+	// Roles — внутренняя роль (viewer, editor, admin) → роли провайдера,
+	// которые ее дают. Клиент без подходящих ролей получает viewer.
+	Roles map[string][]string
+}
+
+// OIDC проверяет токены, выпущенные внешним провайдером: подпись по JWKS,
+// iss, aud и срок действия
+type OIDC struct {
+	verifier      *oidc.IDTokenVerifier
+	usernameClaim string
+	rolesClaim    []string
+	roles         map[string]Role // роль провайдера → внутренняя роль
+}
+
+// NewOIDC создает новый экземпляр OIDC. Без JWKSURL адрес ключей читается из
+// discovery-документа провайдера, поэтому провайдер должен быть доступен.
+// Ключи загружаются с ctx и обновляются, когда в токене встречается новый kid.
+func NewOIDC(ctx context.Context, opts OIDCOptions) (*OIDC, error) {
+	if opts.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	if opts.Audience == "" {
+		return nil, fmt.Errorf("audience is required")
+	}
+	config := &oidc.Config{ClientID: opts.Audience}
+	o := &OIDC{usernameClaim: opts.UsernameClaim, roles: make(map[string]Role)}
+	if o.usernameClaim == "" {
+		o.usernameClaim = "preferred_username"
+	}
+	if opts.RolesClaim != "" {
+		o.rolesClaim = strings.Split(opts.RolesClaim, ".")
+	}
+	for name, external := range opts.Roles {
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range external {
+			o.roles[e] = o.roles[e].Max(role)
+		}
+	}
+	if len(o.roles) > 0 && o.rolesClaim == nil {
+		return nil, fmt.Errorf("roles_claim is required to map roles")
+	}
+
+	if opts.JWKSURL != "" {
+		o.verifier = oidc.NewVerifier(opts.Issuer, oidc.NewRemoteKeySet(ctx, opts.JWKSURL), config)
+		return o, nil
+	}
+	provider, err := oidc.NewProvider(ctx, opts.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error discovering oidc provider %s: %w", opts.Issuer, err)
+	}
+	o.verifier = provider.Verifier(config)
+	return o, nil
+}
+
+// Verify проверяет токен провайдера и возвращает клиента с его внутренними ролями
+func (o *OIDC) Verify(ctx context.Context, token string) (*Principal, error) {
+	t, err := o.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var c map[string]any
+	if err := t.Claims(&c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	p := &Principal{Name: t.Subject, Role: o.Role(c)}
+	if name, ok := c[o.usernameClaim].(string); ok && name != "" {
+		p.Name = name
+	}
+	return p, nil
+}
+
+// Role возвращает внутреннюю роль по claims токена — наибольшую из
+// сопоставленных ролям провайдера
+func (o *OIDC) Role(claims map[string]any) Role {
+	var v any = claims
+	for _, key := range o.rolesClaim {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return RoleViewer
+		}
+		v = m[key]
+	}
+	var external []string
+	switch v := v.(type) {
+	case string:
+		external = strings.Fields(v)
+	case []any:
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				external = append(external, s)
+			}
+		}
+	}
+
+	role := RoleViewer
+	for _, e := range external {
+		role = role.Max(o.roles[e])
+	}
+	return role
+}
+
+// IsLocalToken сообщает, что токен выпущен этим сервисом (по iss без
+// проверки подписи) — чтобы выбрать, кто его проверяет
+func IsLocalToken(token string) bool {
+	var c jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &c); err != nil {
+		return false
+	}
+	return c.Issuer == issuer
+}
@@ -0,0 +1,32 @@
+// Package auth проверяет, кто обращается к API: API-ключи из конфигурации
+// и из БД, JWT пользователей или токены внешнего провайдера OIDC, и кладет
+// найденного клиента в контекст запроса.
+package auth
+
+import "context"
+
+// Principal — аутентифицированный клиент запроса
+type Principal struct {
+	// Name — имя ключа, email пользователя или имя из токена OIDC, под
+	// которым клиент виден в логах
+	Name string
+	// KeyID — ID ключа в БД; 0 для ключей из конфигурации и пользователей
+	KeyID int64
+	// UserID — ID локального пользователя, вошедшего по JWT; 0 для ключей и OIDC
+	UserID int64
+	// Role определяет, какие эндпоинты доступны клиенту
+	Role Role
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с клиентом запроса
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает клиента запроса; nil — анонимный запрос
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
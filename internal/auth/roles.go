@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role — уровень доступа клиента. Каждая следующая роль включает права
+// предыдущей: viewer читает данные, editor управляет прогнозами, admin —
+// акциями, пользователями и ключами.
+type Role string
+
+// Роли клиентов
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// Roles — все роли по возрастанию прав
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+// ErrInvalidRole возвращается для неизвестной роли
+var ErrInvalidRole = fmt.Errorf("unknown role (expected %s, %s or %s)", RoleViewer, RoleEditor, RoleAdmin)
+
+// ParseRole проверяет имя роли
+func ParseRole(s string) (Role, error) {
+	r := Role(strings.ToLower(strings.TrimSpace(s)))
+	if r.level() == 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRole, s)
+	}
+	return r, nil
+}
+
+// level — место роли в иерархии; 0 — неизвестная роль
+func (r Role) level() int {
+	for i, known := range Roles {
+		if r == known {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Allows сообщает, что роль дает права required
+func (r Role) Allows(required Role) bool {
+	return r.level() >= required.level() && r.level() > 0
+}
+
+// Max возвращает роль с большими правами
+func (r Role) Max(other Role) Role {
+	if other.level() > r.level() {
+		return other
+	}
+	return r
+}
@@ -0,0 +1,491 @@
+// Package backup выгружает данные сервиса — акции, сообщения, прогнозы,
+// ценовые таблицы и CSV-файлы истории цен — в один архив tar.gz и загружает
+// их обратно, чтобы небольшие инсталляции делали снимки без инструментов DBA
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"frontend-backend/internal/storage"
+)
+
+// FormatVersion — версия формата архива
+const FormatVersion = 1
+
+// Tables — таблицы в архиве в порядке загрузки: ссылающиеся идут после тех,
+// на которые ссылаются
+var Tables = []string{
+	"sources",
+	"stocks",
+	"stock_names",
+	"messages",
+	"predictions",
+	"prediction_revisions",
+	"corporate_actions",
+	"eod_summaries",
+}
+
+// Пути внутри архива
+const (
+	manifestName = "manifest.json"
+	tablesDir    = "tables/"
+	pricesDir    = "prices/"
+)
+
+// insertBatch — строк в одном INSERT при восстановлении
+const insertBatch = 500
+
+// ErrNotEmpty возвращается, если восстановление без Replace затрагивает
+// непустые таблицы или существующие файлы истории цен
+var ErrNotEmpty = storage.NewConflictError("restore target is not empty")
+
+// ErrSchemaMismatch возвращается, если схема БД не совпадает со схемой
+// архива: столбцы таблиц могли измениться
+var ErrSchemaMismatch = storage.NewPreconditionError("schema version mismatch")
+
+// Manifest — описание архива; первая запись в нем
+type Manifest struct {
+	Format        int            `json:"format"`
+	CreatedAt     time.Time      `json:"created_at"`
+	SchemaVersion string         `json:"schema_version"` // последняя примененная миграция
+	Rows          map[string]int `json:"rows"`           // строк по таблицам
+	PriceFiles    []string       `json:"price_files"`
+}
+
+// Backup пишет архив в w. Таблицы читаются в одной транзакции REPEATABLE
+// READ, поэтому снимок согласован; каждая таблица — файл JSON Lines, по
+// объекту на строку. Файлы <TICKER>_D1.csv копируются из dataDir как есть.
+func Backup(ctx context.Context, db *sql.DB, dataDir string, w io.Writer) (Manifest, error) {
+	version, err := storage.NewMigrator(db).Version(ctx)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m := Manifest{
+		Format:        FormatVersion,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		SchemaVersion: version,
+		Rows:          make(map[string]int, len(Tables)),
+	}
+
+	// Размер записи tar нужен до ее содержимого, поэтому таблицы сначала
+	// выгружаются во временные файлы
+	tmpDir, err := os.MkdirTemp("", "fb-backup-")
+	if err != nil {
+		return m, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := dumpTables(ctx, db, tmpDir, m.Rows); err != nil {
+		return m, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "*_D1.csv"))
+	if err != nil {
+		return m, err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		m.PriceFiles = append(m.PriceFiles, filepath.Base(f))
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	if err := writeEntry(tw, manifestName, m.CreatedAt, int64(len(manifest)), strings.NewReader(string(manifest))); err != nil {
+		return m, err
+	}
+	for _, table := range Tables {
+		if err := copyFile(tw, tablesDir+table+".jsonl", filepath.Join(tmpDir, table+".jsonl")); err != nil {
+			return m, err
+		}
+	}
+	for _, f := range files {
+		if err := copyFile(tw, pricesDir+filepath.Base(f), f); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+// dumpTables выгружает Tables в dir/<table>.jsonl и записывает число строк
+func dumpTables(ctx context.Context, db *sql.DB, dir string, rows map[string]int) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error starting backup transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range Tables {
+		n, err := dumpTable(ctx, tx, table, filepath.Join(dir, table+".jsonl"))
+		if err != nil {
+			return err
+		}
+		rows[table] = n
+	}
+	return nil
+}
+
+func dumpTable(ctx context.Context, tx *sql.Tx, table, file string) (int, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT row_to_json(t)::text FROM %s t`, pq.QuoteIdentifier(table)))
+	if err != nil {
+		return 0, fmt.Errorf("error reading table %s: %w", table, err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return n, fmt.Errorf("error scanning table %s: %w", table, err)
+		}
+		w.WriteString(line)
+		w.WriteByte('\n')
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("error reading table %s: %w", table, err)
+	}
+	if err := w.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+func copyFile(tw *tar.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeEntry(tw, name, info.ModTime(), info.Size(), f)
+}
+
+func writeEntry(tw *tar.Writer, name string, modTime time.Time, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: modTime}); err != nil {
+		return fmt.Errorf("error writing %s to archive: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("error writing %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// RestoreOptions — параметры восстановления
+type RestoreOptions struct {
+	// Replace разрешает восстановление поверх данных: таблицы архива
+	// очищаются, файлы истории цен перезаписываются
+	Replace bool
+}
+
+// Restore загружает архив из r в БД и dataDir. Схема БД должна быть той же
+// версии, что в архиве. Таблицы загружаются в одной транзакции с
+// отключенными пользовательскими триггерами, чтобы версии, updated_at и
+// история прогнозов остались как в архиве; после загрузки счетчики id
+// продолжаются с максимального значения. Файлы истории цен переносятся на
+// место только после фиксации транзакции.
+func Restore(ctx context.Context, db *sql.DB, dataDir string, r io.Reader, opts RestoreOptions) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error opening archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	m, err := readManifest(tr)
+	if err != nil {
+		return m, err
+	}
+	if err := m.validate(dataDir); err != nil {
+		return m, err
+	}
+	version, err := storage.NewMigrator(db).Version(ctx)
+	if err != nil {
+		return m, err
+	}
+	if version != m.SchemaVersion {
+		return m, fmt.Errorf("%w: archive has %q, database has %q; run migrate first", ErrSchemaMismatch, m.SchemaVersion, version)
+	}
+	if !opts.Replace {
+		for _, name := range m.PriceFiles {
+			if _, err := os.Stat(filepath.Join(dataDir, name)); err == nil {
+				return m, fmt.Errorf("%w: price file %s exists", ErrNotEmpty, name)
+			}
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return m, fmt.Errorf("error starting restore transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := prepareTables(ctx, tx, opts.Replace); err != nil {
+		return m, err
+	}
+
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return m, fmt.Errorf("error creating %s: %w", dataDir, err)
+	}
+	var staged []string
+	defer func() {
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}()
+	prices := map[string]string{}
+	loaded := map[string]int{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return m, fmt.Errorf("error reading archive: %w", err)
+		}
+		switch dir, name := path.Split(hdr.Name); dir {
+		case tablesDir:
+			table := strings.TrimSuffix(name, ".jsonl")
+			if _, ok := m.Rows[table]; !ok {
+				return m, fmt.Errorf("archive entry %s is not in the manifest", hdr.Name)
+			}
+			if _, ok := loaded[table]; ok {
+				return m, fmt.Errorf("archive entry %s is duplicated", hdr.Name)
+			}
+			if loaded[table], err = loadTable(ctx, tx, table, tr); err != nil {
+				return m, err
+			}
+		case pricesDir:
+			if !slices.Contains(m.PriceFiles, name) {
+				return m, fmt.Errorf("archive entry %s is not in the manifest", hdr.Name)
+			}
+			tmp, err := stageFile(dataDir, name, tr)
+			if tmp != "" {
+				staged = append(staged, tmp)
+			}
+			if err != nil {
+				return m, err
+			}
+			prices[name] = tmp
+		default:
+			return m, fmt.Errorf("unexpected archive entry %s", hdr.Name)
+		}
+	}
+
+	for table, want := range m.Rows {
+		if loaded[table] != want {
+			return m, fmt.Errorf("table %s: archive has %d rows, manifest lists %d", table, loaded[table], want)
+		}
+	}
+	if len(prices) != len(m.PriceFiles) {
+		return m, fmt.Errorf("archive has %d price files, manifest lists %d", len(prices), len(m.PriceFiles))
+	}
+	if err := finishTables(ctx, tx); err != nil {
+		return m, err
+	}
+	if err := tx.Commit(); err != nil {
+		return m, fmt.Errorf("error committing restore: %w", err)
+	}
+	for name, tmp := range prices {
+		if err := os.Rename(tmp, filepath.Join(dataDir, name)); err != nil {
+			return m, fmt.Errorf("error restoring price file %s: %w", name, err)
+		}
+	}
+	return m, nil
+}
+
+// validate отклоняет архив, который затрагивает что-то кроме Tables и файлов
+// истории цен: иначе подложенный архив мог бы записать, например, users
+func (m Manifest) validate(dataDir string) error {
+	if m.Format != FormatVersion {
+		return fmt.Errorf("unsupported archive format %d", m.Format)
+	}
+	for table := range m.Rows {
+		if !slices.Contains(Tables, table) {
+			return fmt.Errorf("archive contains table %q, which restore does not load", table)
+		}
+	}
+	for _, name := range m.PriceFiles {
+		if err := validPriceFile(dataDir, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validPriceFile проверяет, что name — файл истории цен <TICKER>_D1.csv
+func validPriceFile(dataDir, name string) error {
+	ticker, ok := strings.CutSuffix(name, "_D1.csv")
+	if !ok {
+		return fmt.Errorf("invalid price file %q in archive", name)
+	}
+	if _, err := storage.PriceHistoryPath(dataDir, ticker); err != nil {
+		return fmt.Errorf("invalid price file %q in archive: %w", name, err)
+	}
+	return nil
+}
+
+func readManifest(tr *tar.Reader) (Manifest, error) {
+	var m Manifest
+	hdr, err := tr.Next()
+	if err != nil {
+		return m, fmt.Errorf("error reading archive: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return m, fmt.Errorf("archive does not start with %s", manifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return m, fmt.Errorf("error parsing %s: %w", manifestName, err)
+	}
+	return m, nil
+}
+
+// prepareTables проверяет, что таблицы пусты, или очищает их при replace,
+// и отключает пользовательские триггеры до конца транзакции
+func prepareTables(ctx context.Context, tx *sql.Tx, replace bool) error {
+	quoted := make([]string, len(Tables))
+	for i, table := range Tables {
+		quoted[i] = pq.QuoteIdentifier(table)
+	}
+	if replace {
+		if _, err := tx.ExecContext(ctx, `TRUNCATE `+strings.Join(quoted, ", ")); err != nil {
+			return fmt.Errorf("error clearing tables: %w", err)
+		}
+	} else {
+		for i, table := range Tables {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s)`, quoted[i])).Scan(&exists); err != nil {
+				return fmt.Errorf("error checking table %s: %w", table, err)
+			}
+			if exists {
+				return fmt.Errorf("%w: table %s has rows", ErrNotEmpty, table)
+			}
+		}
+	}
+	for i, table := range Tables {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DISABLE TRIGGER USER`, quoted[i])); err != nil {
+			return fmt.Errorf("error disabling triggers on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// loadTable вставляет строки JSON Lines пачками по insertBatch и возвращает
+// их число
+func loadTable(ctx context.Context, tx *sql.Tx, table string, r io.Reader) (int, error) {
+	query := fmt.Sprintf(`INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s, $1::json)`, pq.QuoteIdentifier(table))
+	var batch []string
+	n := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, query, "["+strings.Join(batch, ",")+"]"); err != nil {
+			return fmt.Errorf("error restoring table %s: %w", table, err)
+		}
+		n += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		batch = append(batch, sc.Text())
+		if len(batch) == insertBatch {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("error reading table %s from archive: %w", table, err)
+	}
+	return n, flush()
+}
+
+// finishTables продолжает счетчики id с максимального значения и включает
+// триггеры обратно
+func finishTables(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range Tables {
+		quoted := pq.QuoteIdentifier(table)
+		rows, err := tx.QueryContext(ctx, `
+			SELECT column_name, pg_get_serial_sequence($1, column_name)
+			FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1
+			  AND pg_get_serial_sequence($1, column_name) IS NOT NULL`, table)
+		if err != nil {
+			return fmt.Errorf("error querying sequences of %s: %w", table, err)
+		}
+		seqs := map[string]string{}
+		for rows.Next() {
+			var column, seq string
+			if err := rows.Scan(&column, &seq); err != nil {
+				rows.Close()
+				return fmt.Errorf("error scanning sequences of %s: %w", table, err)
+			}
+			seqs[column] = seq
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error querying sequences of %s: %w", table, err)
+		}
+		for column, seq := range seqs {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(
+				`SELECT setval($1, COALESCE((SELECT max(%s) FROM %s), 0) + 1, false)`,
+				pq.QuoteIdentifier(column), quoted), seq)
+			if err != nil {
+				return fmt.Errorf("error resetting sequence %s: %w", seq, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ENABLE TRIGGER USER`, quoted)); err != nil {
+			return fmt.Errorf("error enabling triggers on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// stageFile записывает файл истории цен во временный файл рядом с целевым
+func stageFile(dataDir, name string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(dataDir, name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error staging price file %s: %w", name, err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return tmp.Name(), fmt.Errorf("error staging price file %s: %w", name, err)
+	}
+	return tmp.Name(), tmp.Close()
+}
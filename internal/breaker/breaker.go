@@ -0,0 +1,174 @@
+// Package breaker реализует автоматический выключатель (circuit breaker):
+// при всплеске ошибок зависимости вызовы к ней на время отклоняются сразу,
+// а не ждут таймаута.
+package breaker
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/metrics"
+)
+
+// State — состояние выключателя
+type State int
+
+const (
+	// Closed — вызовы проходят, ошибки считаются
+	Closed State = iota
+	// HalfOpen — пропускается один пробный вызов: успех замыкает
+	// выключатель, ошибка снова размыкает
+	HalfOpen
+	// Open — вызовы отклоняются до истечения OpenTimeout
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// Options задает пороги выключателя
+type Options struct {
+	// Window — окно, в котором считается доля ошибок
+	Window time.Duration
+	// MinRequests — меньше вызовов в окне недостаточно для размыкания
+	MinRequests int
+	// FailureRatio — доля ошибок в окне (0..1), при которой выключатель
+	// размыкается
+	FailureRatio float64
+	// OpenTimeout — сколько выключатель остается разомкнутым до пробы
+	OpenTimeout time.Duration
+}
+
+// OpenError возвращается, пока выключатель разомкнут
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration // когда будет пробный вызов
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open", e.Name)
+}
+
+// Breaker — выключатель одной зависимости
+type Breaker struct {
+	name string
+	opts Options
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64 // растет при каждой смене состояния
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool // пробный вызов в полуоткрытом состоянии уже идет
+
+	now func() time.Time // подменяется в тестах
+}
+
+// New создает замкнутый выключатель; name — метка в метриках и логах
+func New(name string, opts Options) *Breaker {
+	b := &Breaker{name: name, opts: opts, now: time.Now}
+	b.windowStart = b.now()
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+// State возвращает текущее состояние
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(b.now())
+	return b.state
+}
+
+// Allow решает, можно ли выполнить вызов. Если можно, после вызова нужно
+// вызвать done с признаком неудачи; иначе возвращается *OpenError.
+func (b *Breaker) Allow() (done func(failed bool), err error) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(now)
+	switch {
+	case b.state == Open, b.state == HalfOpen && b.probing:
+		metrics.CircuitBreakerRejected.WithLabelValues(b.name).Inc()
+		return nil, &OpenError{Name: b.name, RetryAfter: max(b.openedAt.Add(b.opts.OpenTimeout).Sub(now), 0)}
+	case b.state == HalfOpen:
+		b.probing = true
+	}
+	gen := b.generation
+	return func(failed bool) { b.done(gen, failed) }, nil
+}
+
+// done учитывает результат вызова, начатого в поколении gen. Результаты
+// вызовов из прежнего состояния не влияют на новое.
+func (b *Breaker) done(gen uint64, failed bool) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.generation {
+		return
+	}
+	switch b.state {
+	case HalfOpen:
+		b.probing = false
+		if failed {
+			b.setLocked(Open, now)
+		} else {
+			b.setLocked(Closed, now)
+		}
+	case Closed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.opts.MinRequests &&
+			float64(b.failures) >= b.opts.FailureRatio*float64(b.requests) {
+			slog.Error("Выключатель разомкнут: слишком много ошибок", "breaker", b.name, "failures", b.failures, "requests", b.requests)
+			b.setLocked(Open, now)
+		}
+	}
+}
+
+// advanceLocked начинает новое окно подсчета и переводит разомкнутый
+// выключатель в полуоткрытый по истечении OpenTimeout. Вызывается под b.mu.
+func (b *Breaker) advanceLocked(now time.Time) {
+	switch b.state {
+	case Closed:
+		if now.Sub(b.windowStart) >= b.opts.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+	case Open:
+		if now.Sub(b.openedAt) >= b.opts.OpenTimeout {
+			b.setLocked(HalfOpen, now)
+		}
+	}
+}
+
+// setLocked меняет состояние. Вызывается под b.mu.
+func (b *Breaker) setLocked(s State, now time.Time) {
+	if s != Open || b.state != Closed {
+		// Размыкание из замкнутого логируется в done вместе с причиной
+		slog.Info("Состояние выключателя изменено", "breaker", b.name, "from", b.state.String(), "to", s.String())
+	}
+	b.state = s
+	b.generation++
+	b.probing = false
+	switch s {
+	case Open:
+		b.openedAt = now
+	case Closed:
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(s))
+}
@@ -0,0 +1,143 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// clock — управляемое время для выключателя
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(t *testing.T) (*Breaker, *clock) {
+	t.Helper()
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New(t.Name(), Options{
+		Window:       time.Minute,
+		MinRequests:  4,
+		FailureRatio: 0.5,
+		OpenTimeout:  10 * time.Second,
+	})
+	b.now = c.now
+	b.windowStart = c.now()
+	return b, c
+}
+
+// call выполняет один вызов через выключатель
+func call(t *testing.T, b *Breaker, failed bool) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() = %v, want nil", err)
+	}
+	done(failed)
+}
+
+func TestOpensOnFailureRatio(t *testing.T) {
+	b, _ := newTestBreaker(t)
+	call(t, b, false)
+	call(t, b, true)
+	call(t, b, false)
+	if got := b.State(); got != Closed {
+		t.Fatalf("state after 3 calls = %v, want closed: MinRequests not reached", got)
+	}
+	call(t, b, true)
+	if got := b.State(); got != Open {
+		t.Fatalf("state after 2 failures of 4 = %v, want open", got)
+	}
+
+	_, err := b.Allow()
+	var open *OpenError
+	if !errors.As(err, &open) {
+		t.Fatalf("Allow() on open breaker = %v, want *OpenError", err)
+	}
+	if open.RetryAfter != 10*time.Second {
+		t.Errorf("RetryAfter = %v, want 10s", open.RetryAfter)
+	}
+}
+
+func TestStaysClosedBelowRatio(t *testing.T) {
+	b, _ := newTestBreaker(t)
+	for _, failed := range []bool{false, false, false, true, false, false} {
+		call(t, b, failed)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("state = %v, want closed", got)
+	}
+}
+
+func TestWindowResetsCounters(t *testing.T) {
+	b, c := newTestBreaker(t)
+	call(t, b, true)
+	call(t, b, true)
+	call(t, b, true)
+	c.advance(time.Minute)
+	call(t, b, true)
+	if got := b.State(); got != Closed {
+		t.Fatalf("state = %v, want closed: failures from the previous window must not count", got)
+	}
+}
+
+func TestHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+		want   State
+	}{
+		{"success closes", false, Closed},
+		{"failure reopens", true, Open},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, c := newTestBreaker(t)
+			for range 4 {
+				call(t, b, true)
+			}
+			c.advance(10 * time.Second)
+			if got := b.State(); got != HalfOpen {
+				t.Fatalf("state after OpenTimeout = %v, want half-open", got)
+			}
+
+			done, err := b.Allow()
+			if err != nil {
+				t.Fatalf("probe Allow() = %v, want nil", err)
+			}
+			if _, err := b.Allow(); err == nil {
+				t.Fatal("second Allow() during probe = nil, want *OpenError")
+			}
+			done(tt.failed)
+			if got := b.State(); got != tt.want {
+				t.Errorf("state after probe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaleResultIgnored(t *testing.T) {
+	b, c := newTestBreaker(t)
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		call(t, b, true)
+	}
+	c.advance(10 * time.Second)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Вызов, начатый до размыкания, не решает судьбу пробы
+	stale(false)
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("state after stale success = %v, want half-open", got)
+	}
+	probe(false)
+	if got := b.State(); got != Closed {
+		t.Fatalf("state after probe = %v, want closed", got)
+	}
+}
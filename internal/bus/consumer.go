@@ -0,0 +1,160 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/deadletter"
+	"frontend-backend/internal/events"
+	"frontend-backend/internal/extract"
+	"frontend-backend/internal/storage"
+)
+
+// Поддерживаемые драйверы шины (config: bus.driver)
+const (
+	DriverKafka = "kafka"
+	DriverNATS  = "nats"
+)
+
+// PredictionStore — хранилище, в которое консьюмер пишет прогнозы
+type PredictionStore interface {
+	InsertPrediction(ctx context.Context, p storage.NewPrediction) (bool, error)
+}
+
+// Consumer читает события из шины до отмены ctx
+type Consumer interface {
+	Run(ctx context.Context) error
+	Close() error
+}
+
+// DeadLetters принимает события, которые нельзя обработать
+type DeadLetters interface {
+	Add(ctx context.Context, source string, payload []byte, reason error) error
+}
+
+// Processor обрабатывает одно событие. Возвращенная ошибка означает, что
+// событие нужно доставить повторно (at-least-once); некорректные события
+// отправляются в dead-letter и подтверждаются.
+type Processor struct {
+	store       PredictionStore
+	deadLetters DeadLetters
+	events      events.Publisher
+	recs        *extract.Recommendations
+	unknown     extract.UnknownPhrases
+	log         *slog.Logger
+}
+
+// NewProcessor создает новый экземпляр Processor; deadLetters может быть nil,
+// nil logger — slog.Default()
+func NewProcessor(store PredictionStore, deadLetters DeadLetters, logger *slog.Logger) *Processor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Processor{store: store, deadLetters: deadLetters, recs: extract.DefaultRecommendations, log: logger}
+}
+
+// SetPublisher включает публикацию событий о новых прогнозах
+func (p *Processor) SetPublisher(pub events.Publisher) {
+	p.events = pub
+}
+
+// SetRecommendations задает таблицу, по которой рекомендации из событий
+// приводятся к каноническим; нераспознанные сохраняются как есть и
+// отправляются в unknown, если он не nil
+func (p *Processor) SetRecommendations(recs *extract.Recommendations, unknown extract.UnknownPhrases) {
+	p.recs = recs
+	p.unknown = unknown
+}
+
+// Process обрабатывает событие из шины. Если некорректное событие не
+// удалось сохранить в dead-letter, возвращается ошибка: событие не
+// подтверждается и будет доставлено повторно.
+func (p *Processor) Process(ctx context.Context, data []byte) error {
+	err := p.Handle(ctx, data)
+	if !IsPermanent(err) {
+		return err
+	}
+
+	if p.deadLetters != nil {
+		if dlErr := p.deadLetters.Add(ctx, deadletter.SourceBus, data, err); dlErr != nil {
+			return dlErr
+		}
+	} else {
+		p.log.Warn("Пропускаем событие прогноза", "err", err)
+	}
+	return nil
+}
+
+// Handle валидирует событие и вставляет прогноз в хранилище. Ошибки
+// валидации возвращаются как есть, их можно проверить через IsPermanent.
+func (p *Processor) Handle(ctx context.Context, data []byte) error {
+	prediction, err := DecodePredictionEvent(data)
+	if err != nil {
+		return err
+	}
+	p.normalizeRecommendation(ctx, &prediction)
+
+	inserted, err := p.store.InsertPrediction(ctx, prediction)
+	if err != nil {
+		return err
+	}
+
+	if inserted {
+		p.log.Info("Добавлен прогноз", "ticker", prediction.Ticker, "channel", prediction.Channel, "message_id", prediction.MessageID)
+		p.publish(prediction)
+	} else {
+		p.log.Info("Прогноз уже существует, пропускаем", "ticker", prediction.Ticker, "channel", prediction.Channel, "message_id", prediction.MessageID)
+	}
+	return nil
+}
+
+// normalizeRecommendation заменяет рекомендацию из события канонической
+func (p *Processor) normalizeRecommendation(ctx context.Context, np *storage.NewPrediction) {
+	if np.Recommendation == nil || strings.TrimSpace(*np.Recommendation) == "" {
+		np.Recommendation = nil
+		return
+	}
+	if canonical, ok := p.recs.Normalize(*np.Recommendation); ok {
+		np.Recommendation = &canonical
+		return
+	}
+	p.log.Warn("Нераспознанная рекомендация в прогнозе", "recommendation", *np.Recommendation, "message_id", np.MessageID)
+	if p.unknown != nil {
+		if err := p.unknown.RecordUnknownRecommendation(ctx, *np.Recommendation, deadletter.SourceBus, np.MessageID); err != nil {
+			p.log.Error("Не удалось сохранить нераспознанную рекомендацию", "err", err)
+		}
+	}
+}
+
+// publish сообщает подписчикам о вставленном прогнозе
+func (p *Processor) publish(np storage.NewPrediction) {
+	if p.events == nil {
+		return
+	}
+	p.events.Publish(events.Event{
+		Type:   events.TypePredictionCreated,
+		Ticker: np.Ticker,
+		Time:   time.Now().UTC(),
+		Data: events.PredictionCreated{
+			Channel:             np.Channel,
+			MessageID:           np.MessageID,
+			PredictionType:      np.PredictionType,
+			TargetPrice:         np.TargetPrice,
+			TargetChangePercent: np.TargetChangePercent,
+			Period:              np.Period,
+			Recommendation:      np.Recommendation,
+			Direction:           np.Direction,
+			PredictedAt:         np.PredictedAt,
+		},
+	})
+}
+
+// IsPermanent сообщает, что повторная доставка события не поможет:
+// событие некорректно или его данные отвергло хранилище
+// (storage.IsPermanent)
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrInvalidEvent) || storage.IsPermanent(err)
+}
@@ -0,0 +1,67 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// ErrInvalidEvent означает, что событие некорректно и повторная доставка
+// не поможет: такое событие подтверждается и пропускается
+var ErrInvalidEvent = errors.New("invalid prediction event")
+
+// PredictionEvent — событие о новом прогнозе, публикуемое NLP-сервисом
+type PredictionEvent struct {
+	Channel             string   `json:"channel"` // канал сообщения; message_id уникален только в нем
+	MessageID           int64    `json:"message_id"`
+	Ticker              string   `json:"ticker"`
+	PredictionType      *string  `json:"prediction_type"`
+	TargetPrice         *float64 `json:"target_price"`
+	TargetChangePercent *float64 `json:"target_change_percent"`
+	Period              *string  `json:"period"`
+	Recommendation      *string  `json:"recommendation"`
+	Direction           *string  `json:"direction"`
+	JustificationText   *string  `json:"justification_text"`
+	PredictedAt         string   `json:"predicted_at"` // RFC3339
+}
+
+// DecodePredictionEvent разбирает и валидирует событие
+func DecodePredictionEvent(data []byte) (storage.NewPrediction, error) {
+	var e PredictionEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return storage.NewPrediction{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	if e.MessageID <= 0 {
+		return storage.NewPrediction{}, fmt.Errorf("%w: message_id is required", ErrInvalidEvent)
+	}
+	ticker := strings.ToUpper(strings.TrimSpace(e.Ticker))
+	if ticker == "" {
+		return storage.NewPrediction{}, fmt.Errorf("%w: ticker is required", ErrInvalidEvent)
+	}
+	if e.TargetPrice != nil && *e.TargetPrice <= 0 {
+		return storage.NewPrediction{}, fmt.Errorf("%w: target_price must be positive", ErrInvalidEvent)
+	}
+	predictedAt, err := time.Parse(time.RFC3339, e.PredictedAt)
+	if err != nil {
+		return storage.NewPrediction{}, fmt.Errorf("%w: predicted_at: %v", ErrInvalidEvent, err)
+	}
+
+	return storage.NewPrediction{
+		Channel:             strings.TrimSpace(e.Channel),
+		MessageID:           e.MessageID,
+		Ticker:              ticker,
+		PredictionType:      e.PredictionType,
+		TargetPrice:         e.TargetPrice,
+		TargetChangePercent: e.TargetChangePercent,
+		Period:              e.Period,
+		Recommendation:      e.Recommendation,
+		Direction:           e.Direction,
+		JustificationText:   e.JustificationText,
+		PredictedAt:         predictedAt,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConsumer читает события из топика Kafka в составе consumer group.
+// Offset коммитится только после успешной обработки сообщения.
+type KafkaConsumer struct {
+	reader     *kafka.Reader
+	processor  *Processor
+	retryDelay time.Duration
+}
+
+// NewKafkaConsumer создает консьюмер; dsn — список брокеров через запятую
+func NewKafkaConsumer(dsn, topic, group string, processor *Processor) *KafkaConsumer {
+	return &KafkaConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: strings.Split(dsn, ","),
+			Topic:   topic,
+			GroupID: group,
+		}),
+		processor:  processor,
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run читает сообщения до отмены ctx
+func (c *KafkaConsumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("error fetching kafka message: %w", err)
+		}
+
+		// Повторяем обработку, пока она не завершится успешно: следующее
+		// сообщение партиции не читается, пока не закоммичено текущее
+		for {
+			err := c.processor.Process(ctx, msg.Value)
+			if err == nil {
+				break
+			}
+			c.processor.log.Error("Ошибка обработки сообщения kafka, повтор", "partition", msg.Partition, "offset", msg.Offset, "err", err, "retry_delay", c.retryDelay)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.retryDelay):
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			return fmt.Errorf("error committing kafka offset: %w", err)
+		}
+	}
+}
+
+// Close закрывает соединение с Kafka
+func (c *KafkaConsumer) Close() error {
+	return c.reader.Close()
+}
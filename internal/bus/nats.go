@@ -0,0 +1,64 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSConsumer читает события из JetStream через durable queue-подписку.
+// Сообщение подтверждается после успешной обработки, иначе доставляется повторно.
+type NATSConsumer struct {
+	conn       *nats.Conn
+	subject    string
+	group      string
+	processor  *Processor
+	retryDelay time.Duration
+}
+
+// NewNATSConsumer подключается к NATS по dsn (nats://host:4222)
+func NewNATSConsumer(dsn, subject, group string, processor *Processor) (*NATSConsumer, error) {
+	conn, err := nats.Connect(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to nats: %w", err)
+	}
+	return &NATSConsumer{
+		conn:       conn,
+		subject:    subject,
+		group:      group,
+		processor:  processor,
+		retryDelay: 5 * time.Second,
+	}, nil
+}
+
+// Run подписывается на subject и обрабатывает сообщения до отмены ctx
+func (c *NATSConsumer) Run(ctx context.Context) error {
+	js, err := c.conn.JetStream()
+	if err != nil {
+		return fmt.Errorf("error getting jetstream context: %w", err)
+	}
+
+	sub, err := js.QueueSubscribe(c.subject, c.group, func(msg *nats.Msg) {
+		if err := c.processor.Process(ctx, msg.Data); err != nil {
+			c.processor.log.Error("Ошибка обработки сообщения nats, повтор", "err", err, "retry_delay", c.retryDelay)
+			msg.NakWithDelay(c.retryDelay)
+			return
+		}
+		msg.Ack()
+	}, nats.Durable(c.group), nats.ManualAck(), nats.DeliverAll())
+	if err != nil {
+		return fmt.Errorf("error subscribing to %s: %w", c.subject, err)
+	}
+	defer sub.Drain()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Close закрывает соединение с NATS
+func (c *NATSConsumer) Close() error {
+	c.conn.Close()
+	return nil
+}
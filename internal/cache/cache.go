@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/metrics"
+)
+
+// LoadFunc загружает значение по ключу из первичного источника
+type LoadFunc[V any] func() (V, error)
+
+// Options задает параметры кеша
+type Options struct {
+	// TTL — время, в течение которого значение считается свежим
+	TTL time.Duration
+	// StaleTTL — сколько после истечения TTL можно отдавать устаревшее
+	// значение, обновляя его в фоне (stale-while-revalidate)
+	StaleTTL time.Duration
+	// Jitter — доля TTL (0..1), на которую случайно сдвигается срок жизни,
+	// чтобы горячие ключи не истекали одновременно
+	Jitter float64
+	// Logger получает ошибки фонового обновления; nil — slog.Default()
+	Logger *slog.Logger
+}
+
+type entry[V any] struct {
+	value      V
+	written    time.Time
+	freshUntil time.Time
+	staleUntil time.Time
+}
+
+// Item — значение из снимка кеша и время его загрузки
+type Item[V any] struct {
+	Value     V         `json:"value"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache — in-memory кеш с защитой от cache stampede:
+//   - срок жизни каждого значения сдвигается на случайный jitter;
+//   - после истечения TTL устаревшее значение отдается сразу, а обновление
+//     выполняется в фоне;
+//   - одновременные загрузки одного ключа объединяются в одну.
+type Cache[V any] struct {
+	name string
+	opts Options
+
+	mu       sync.Mutex
+	entries  map[string]*entry[V]
+	inflight map[string]*call[V]
+	rnd      *rand.Rand
+}
+
+// New создает новый кеш; name — метка кеша в метриках
+func New[V any](name string, opts Options) *Cache[V] {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Cache[V]{
+		name:     name,
+		opts:     opts,
+		entries:  make(map[string]*entry[V]),
+		inflight: make(map[string]*call[V]),
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Get возвращает значение из кеша или загружает его через load
+func (c *Cache[V]) Get(key string, load LoadFunc[V]) (V, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	switch {
+	case ok && now.Before(e.freshUntil):
+		c.mu.Unlock()
+		metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+		return e.value, nil
+	case ok && now.Before(e.staleUntil):
+		// Отдаем устаревшее значение без ожидания БД, обновляем в фоне
+		value := e.value
+		cl, started := c.startLocked(key)
+		c.mu.Unlock()
+		if started {
+			go c.load(key, cl, load)
+		}
+		metrics.CacheRequests.WithLabelValues(c.name, "stale").Inc()
+		return value, nil
+	}
+	cl, started := c.startLocked(key)
+	c.mu.Unlock()
+	metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+
+	if started {
+		c.load(key, cl, load)
+	}
+	<-cl.done
+	return cl.value, cl.err
+}
+
+// Invalidate удаляет ключ из кеша
+func (c *Cache[V]) Invalidate(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// InvalidateAll очищает кеш; загрузки, которые уже идут, не прерываются
+func (c *Cache[V]) InvalidateAll() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// SetTTL меняет TTL, StaleTTL и Jitter. Новые сроки действуют для значений,
+// загруженных после вызова; уже закешированные живут по прежним.
+func (c *Cache[V]) SetTTL(ttl, staleTTL time.Duration, jitter float64) {
+	c.mu.Lock()
+	c.opts.TTL, c.opts.StaleTTL, c.opts.Jitter = ttl, staleTTL, jitter
+	c.mu.Unlock()
+}
+
+// Snapshot возвращает значения, которые еще можно отдавать (свежие и
+// устаревшие в пределах StaleTTL) со временем загрузки, для сохранения
+// между перезапусками
+func (c *Cache[V]) Snapshot() map[string]Item[V] {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]Item[V], len(c.entries))
+	for key, e := range c.entries {
+		if now.Before(e.staleUntil) {
+			out[key] = Item[V]{Value: e.value, WrittenAt: e.written}
+		}
+	}
+	return out
+}
+
+// Restore загружает значения из снимка. Срок жизни отсчитывается от времени
+// загрузки значения, а не от старта: время простоя вычитается, и значения,
+// которые уже нельзя отдавать даже устаревшими, отбрасываются. Срок
+// сдвигается на jitter, поэтому после старта ключи обновляются вразнобой.
+func (c *Cache[V]) Restore(items map[string]Item[V]) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, it := range items {
+		if _, ok := c.entries[key]; ok {
+			continue
+		}
+		ttl := c.jitteredLocked(c.opts.TTL)
+		e := &entry[V]{
+			value:      it.Value,
+			written:    it.WrittenAt,
+			freshUntil: it.WrittenAt.Add(ttl),
+			staleUntil: it.WrittenAt.Add(ttl + c.opts.StaleTTL),
+		}
+		if !now.Before(e.staleUntil) {
+			continue
+		}
+		c.entries[key] = e
+	}
+}
+
+// startLocked регистрирует загрузку ключа либо возвращает уже идущую.
+// Вызывается под c.mu.
+func (c *Cache[V]) startLocked(key string) (*call[V], bool) {
+	if cl, ok := c.inflight[key]; ok {
+		return cl, false
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = cl
+	return cl, true
+}
+
+// load выполняет загрузку и сохраняет результат. Ошибки не кешируются:
+// при неудачном фоновом обновлении остается прежнее устаревшее значение.
+func (c *Cache[V]) load(key string, cl *call[V], load LoadFunc[V]) {
+	cl.value, cl.err = load()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inflight, key)
+	close(cl.done)
+
+	if cl.err != nil {
+		c.opts.Logger.Error("Ошибка обновления кеша", "key", key, "err", cl.err)
+		return
+	}
+	ttl := c.jitteredLocked(c.opts.TTL)
+	now := time.Now()
+	c.entries[key] = &entry[V]{
+		value:      cl.value,
+		written:    now,
+		freshUntil: now.Add(ttl),
+		staleUntil: now.Add(ttl + c.opts.StaleTTL),
+	}
+}
+
+// jitteredLocked случайно сдвигает d в пределах ±Jitter. Вызывается под c.mu.
+func (c *Cache[V]) jitteredLocked(d time.Duration) time.Duration {
+	if c.opts.Jitter <= 0 {
+		return d
+	}
+	delta := (c.rnd.Float64()*2 - 1) * c.opts.Jitter * float64(d)
+	return d + time.Duration(delta)
+}
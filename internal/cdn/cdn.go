@@ -0,0 +1,68 @@
+// Package cdn сбрасывает кеш CDN перед API по суррогатным ключам: каждый
+// кешируемый ответ помечается ключами тикера и эндпоинта, а изменение данных
+// превращается в purge этих ключей у провайдера (Fastly, Cloudflare).
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/requestid"
+	"frontend-backend/internal/tracing"
+)
+
+// Драйверы провайдеров
+const (
+	DriverFastly     = "fastly"
+	DriverCloudflare = "cloudflare"
+)
+
+// Purger сбрасывает кеш CDN по суррогатным ключам
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// TickerKey — ключ всех ответов по тикеру
+func TickerKey(ticker string) string {
+	return "ticker:" + strings.ToUpper(ticker)
+}
+
+// EndpointKey — ключ всех ответов эндпоинта, например stocks-history
+func EndpointKey(endpoint string) string {
+	return "endpoint:" + endpoint
+}
+
+// NewPurger создает клиента провайдера по имени драйвера. target —
+// service_id для Fastly или zone_id для Cloudflare.
+func NewPurger(driver, target, token string, timeout time.Duration) (Purger, error) {
+	if target == "" || token == "" {
+		return nil, fmt.Errorf("cdn.target and cdn.api_token are required for driver %q", driver)
+	}
+	client := &http.Client{Timeout: timeout, Transport: tracing.Transport(requestid.Transport(nil))}
+	switch driver {
+	case DriverFastly:
+		return &Fastly{serviceID: target, token: token, client: client, baseURL: fastlyAPI}, nil
+	case DriverCloudflare:
+		return &Cloudflare{zoneID: target, token: token, client: client, baseURL: cloudflareAPI}, nil
+	default:
+		return nil, fmt.Errorf("unknown cdn.driver %q (expected %q or %q)", driver, DriverFastly, DriverCloudflare)
+	}
+}
+
+// do выполняет запрос к API провайдера и проверяет статус ответа
+func do(client *http.Client, req *http.Request, provider string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s purge API: %w", provider, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s purge API responded with status %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// Cloudflare сбрасывает кеш зоны Cloudflare по тегам (заголовок Cache-Tag)
+type Cloudflare struct {
+	zoneID  string
+	token   string
+	client  *http.Client
+	baseURL string
+}
+
+// cloudflareBatch — сколько тегов Cloudflare принимает в одном запросе
+const cloudflareBatch = 30
+
+// Purge реализует Purger
+func (c *Cloudflare) Purge(ctx context.Context, keys []string) error {
+	for len(keys) > 0 {
+		n := min(len(keys), cloudflareBatch)
+		body, _ := json.Marshal(map[string][]string{"tags": keys[:n]})
+		url := fmt.Sprintf("%s/zones/%s/purge_cache", c.baseURL, c.zoneID)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("error creating cloudflare purge request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Content-Type", "application/json")
+		if err := do(c.client, req, "cloudflare"); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const fastlyAPI = "https://api.fastly.com"
+
+// Fastly сбрасывает кеш сервиса Fastly по суррогатным ключам
+// (POST /service/{id}/purge с заголовком Surrogate-Key)
+type Fastly struct {
+	serviceID string
+	token     string
+	client    *http.Client
+	baseURL   string
+}
+
+// fastlyBatch — сколько ключей Fastly принимает в одном запросе
+const fastlyBatch = 256
+
+// Purge реализует Purger
+func (f *Fastly) Purge(ctx context.Context, keys []string) error {
+	for len(keys) > 0 {
+		n := min(len(keys), fastlyBatch)
+		url := fmt.Sprintf("%s/service/%s/purge", f.baseURL, f.serviceID)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+		if err != nil {
+			return fmt.Errorf("error creating fastly purge request: %w", err)
+		}
+		req.Header.Set("Fastly-Key", f.token)
+		req.Header.Set("Surrogate-Key", strings.Join(keys[:n], " "))
+		if err := do(f.client, req, "fastly"); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
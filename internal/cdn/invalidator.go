@@ -0,0 +1,92 @@
+package cdn
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"frontend-backend/internal/events"
+	"frontend-backend/internal/metrics"
+)
+
+// invalidatorQueue — сколько событий ждет отправки; при переполнении
+// событие теряется, и ответы остаются в CDN до истечения их TTL
+const invalidatorQueue = 1024
+
+// Invalidator превращает события об изменении данных в purge суррогатных
+// ключей. Ключи копятся в течение window и сбрасываются одним вызовом, чтобы
+// пачка прогнозов по тикеру давала один запрос к CDN.
+type Invalidator struct {
+	purger Purger
+	window time.Duration
+	queue  chan []string
+}
+
+// NewInvalidator создает новый экземпляр Invalidator
+func NewInvalidator(purger Purger, window time.Duration) *Invalidator {
+	return &Invalidator{purger: purger, window: window, queue: make(chan []string, invalidatorQueue)}
+}
+
+// KeysFor возвращает ключи, ответы с которыми устаревают после события
+func KeysFor(e events.Event) []string {
+	switch e.Type {
+	case events.TypeStockCreated:
+		return []string{TickerKey(e.Ticker), EndpointKey("stocks"), EndpointKey("quick-search")}
+	case events.TypePredictionCreated, events.TypePriceUpdated:
+		return []string{TickerKey(e.Ticker)}
+	default:
+		return nil
+	}
+}
+
+// Publish реализует events.Publisher и не блокируется
+func (inv *Invalidator) Publish(e events.Event) {
+	keys := KeysFor(e)
+	if len(keys) == 0 {
+		return
+	}
+	select {
+	case inv.queue <- keys:
+	default:
+		slog.Warn("Очередь сброса кеша CDN переполнена, событие пропущено", "type", e.Type, "ticker", e.Ticker)
+	}
+}
+
+// Run отправляет накопленные ключи до отмены ctx
+func (inv *Invalidator) Run(ctx context.Context) {
+	pending := map[string]bool{}
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case keys := <-inv.queue:
+			for _, k := range keys {
+				pending[k] = true
+			}
+			if flush == nil {
+				flush = time.After(inv.window)
+			}
+		case <-flush:
+			flush = nil
+			keys := make([]string, 0, len(pending))
+			for k := range pending {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			clear(pending)
+			inv.purge(ctx, keys)
+		}
+	}
+}
+
+func (inv *Invalidator) purge(ctx context.Context, keys []string) {
+	if err := inv.purger.Purge(ctx, keys); err != nil {
+		metrics.CDNPurges.WithLabelValues("failure").Inc()
+		slog.Error("Ошибка сброса кеша CDN по ключам", "keys", keys, "err", err)
+		return
+	}
+	metrics.CDNPurges.WithLabelValues("success").Inc()
+	slog.Info("Сброшен кеш CDN по ключам", "keys", keys)
+}
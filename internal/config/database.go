@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readPasswordFile подставляет в Password содержимое PasswordFile без
+// завершающего перевода строки
+func (c *DatabaseConfig) readPasswordFile() error {
+	if c.PasswordFile == "" {
+		return nil
+	}
+	if c.Password != "" {
+		return fmt.Errorf("database.password and database.password_file are mutually exclusive")
+	}
+	data, err := os.ReadFile(c.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("database.password_file: %w", err)
+	}
+	c.Password = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+// DSN возвращает строку подключения для lib/pq
+func (c DatabaseConfig) DSN() (string, error) {
+	if c.URL == "" {
+		var port string
+		if c.Port != 0 {
+			port = strconv.Itoa(c.Port)
+		}
+		params := []struct{ key, value string }{
+			{"host", c.Host},
+			{"port", port},
+			{"user", c.User},
+			{"password", c.Password},
+			{"dbname", c.DBName},
+			{"sslmode", c.SSLMode},
+		}
+		var parts []string
+		for _, p := range params {
+			if p.value != "" {
+				parts = append(parts, p.key+"="+quoteDSN(p.value))
+			}
+		}
+		return strings.Join(parts, " "), nil
+	}
+	u, err := c.parseURL()
+	if err != nil {
+		return "", err
+	}
+	// Заданный user заменяет учетные данные целиком: пароль из URL к нему
+	// не относится
+	switch {
+	case c.User != "" && c.Password != "":
+		u.User = url.UserPassword(c.User, c.Password)
+	case c.User != "":
+		u.User = url.User(c.User)
+	case c.Password != "":
+		u.User = url.UserPassword(u.User.Username(), c.Password)
+	}
+	return u.String(), nil
+}
+
+// Endpoint возвращает хост и имя базы для логов и метрик (без учетных данных)
+func (c DatabaseConfig) Endpoint() (host, dbname string) {
+	if c.URL == "" {
+		return c.Host, c.DBName
+	}
+	u, err := c.parseURL()
+	if err != nil {
+		return "", ""
+	}
+	return u.Hostname(), strings.TrimPrefix(u.Path, "/")
+}
+
+func (c DatabaseConfig) parseURL() (*url.URL, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		// Ошибка url.Parse содержит URL целиком вместе с паролем
+		return nil, fmt.Errorf("database.url is not a valid URL")
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("database.url: unsupported scheme %q (expected postgres)", u.Scheme)
+	}
+	return u, nil
+}
+
+// quoteDSN экранирует значение для формата key=value: пароли из файлов
+// могут содержать пробелы и кавычки
+func quoteDSN(s string) string {
+	if !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
@@ -0,0 +1,98 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/storage"
+)
+
+// Источники элементов
+const (
+	SourceBus     = "bus"
+	SourceExtract = "extract"
+)
+
+// Store — хранилище dead-letter элементов
+type Store interface {
+	AddDeadLetter(ctx context.Context, source string, payload []byte, reason string) error
+	ListDeadLetters(ctx context.Context, source string, limit int) ([]storage.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id int64) (storage.DeadLetter, error)
+	RecordDeadLetterAttempt(ctx context.Context, id int64, reason string) error
+	DeleteDeadLetter(ctx context.Context, id int64) error
+}
+
+// ErrRetryFailed возвращается, если обработчик снова не справился с элементом
+var ErrRetryFailed = errors.New("dead letter retry failed")
+
+// Handler повторно обрабатывает payload элемента своего источника
+type Handler func(ctx context.Context, payload []byte) error
+
+// Queue сохраняет необработанные элементы конвейера и позволяет повторить
+// или отбросить их
+type Queue struct {
+	store    Store
+	handlers map[string]Handler
+}
+
+// NewQueue создает новый экземпляр Queue
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store, handlers: make(map[string]Handler)}
+}
+
+// Register задает обработчик повторных попыток для источника
+func (q *Queue) Register(source string, h Handler) {
+	q.handlers[source] = h
+}
+
+// Add сохраняет элемент. Если записать его не удалось, вызывающий код не
+// должен подтверждать исходное сообщение, иначе оно будет потеряно.
+func (q *Queue) Add(ctx context.Context, source string, payload []byte, reason error) error {
+	if err := q.store.AddDeadLetter(ctx, source, payload, reason.Error()); err != nil {
+		return fmt.Errorf("error saving %s dead letter: %w", source, err)
+	}
+	metrics.DeadLetters.WithLabelValues(source).Inc()
+	slog.Info("Элемент отправлен в dead-letter", "source", source, "reason", reason)
+	return nil
+}
+
+// List возвращает элементы источника (пустой source — все)
+func (q *Queue) List(ctx context.Context, source string, limit int) ([]storage.DeadLetter, error) {
+	return q.store.ListDeadLetters(ctx, source, limit)
+}
+
+// Retry повторно обрабатывает элемент. При успехе элемент удаляется,
+// иначе увеличивается счетчик попыток и сохраняется новая ошибка.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	d, err := q.store.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	h, ok := q.handlers[d.Source]
+	if !ok {
+		return fmt.Errorf("no retry handler for dead letter source %q", d.Source)
+	}
+
+	if err := h(ctx, []byte(d.Payload)); err != nil {
+		metrics.DeadLetterRetries.WithLabelValues(d.Source, "failure").Inc()
+		if recErr := q.store.RecordDeadLetterAttempt(ctx, id, err.Error()); recErr != nil {
+			return recErr
+		}
+		return fmt.Errorf("%w: id %d: %v", ErrRetryFailed, id, err)
+	}
+
+	metrics.DeadLetterRetries.WithLabelValues(d.Source, "success").Inc()
+	return q.store.DeleteDeadLetter(ctx, id)
+}
+
+// Discard удаляет элемент без обработки
+func (q *Queue) Discard(ctx context.Context, id int64) error {
+	if err := q.store.DeleteDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	metrics.DeadLetterDiscards.Inc()
+	return nil
+}
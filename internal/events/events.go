@@ -0,0 +1,54 @@
+package events
+
+import "time"
+
+// Типы событий для подписчиков (/ws, /events)
+const (
+	TypeStockCreated      = "stock.created"
+	TypePredictionCreated = "prediction.created"
+	TypePriceUpdated      = "price.updated"
+)
+
+// Event — событие об изменении данных по тикеру
+type Event struct {
+	ID     string    `json:"id"` // присваивается хабом при публикации
+	Type   string    `json:"type"`
+	Ticker string    `json:"ticker"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data"`
+}
+
+// Publisher доставляет события подписчикам. Publish не должен блокироваться.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// PredictionCreated — данные события prediction.created
+type PredictionCreated struct {
+	Channel             string    `json:"channel"`
+	MessageID           int64     `json:"message_id"`
+	PredictionType      *string   `json:"prediction_type"`
+	TargetPrice         *float64  `json:"target_price"`
+	TargetChangePercent *float64  `json:"target_change_percent"`
+	Period              *string   `json:"period"`
+	Recommendation      *string   `json:"recommendation"`
+	Direction           *string   `json:"direction"`
+	PredictedAt         time.Time `json:"predicted_at"`
+}
+
+// PriceUpdated — данные события price.updated: последняя точка истории
+type PriceUpdated struct {
+	Timestamp string  `json:"timestamp"`
+	Price     float64 `json:"price"`
+	Volume    int64   `json:"volume,omitempty"`
+}
+
+// Multi рассылает каждое событие всем получателям по очереди
+type Multi []Publisher
+
+// Publish реализует Publisher
+func (m Multi) Publish(e Event) {
+	for _, p := range m {
+		p.Publish(e)
+	}
+}
@@ -0,0 +1,208 @@
+package extract
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Канонические значения полей прогноза (совпадают с данными в predictions)
+const (
+	RecommendationBuy  = "Покупать"
+	RecommendationHold = "Держать"
+	RecommendationSell = "Продавать"
+
+	DirectionLong      = "Лонг"
+	DirectionShort     = "Шорт"
+	DirectionUndefined = "Неопределенный"
+
+	PeriodShort  = "Краткосрочный"
+	PeriodMedium = "Среднесрочный"
+	PeriodLong   = "Долгосрочный"
+
+	TypeTrend    = "Продолжение тренда"
+	TypeReversal = "Разворот"
+	TypeBreakout = "Пробой уровня"
+	TypeRebound  = "Отскок"
+)
+
+// Result — прогноз, извлеченный из текста сообщения
+type Result struct {
+	Ticker              string
+	PredictionType      *string
+	TargetPrice         *float64
+	TargetChangePercent *float64
+	Period              *string
+	Recommendation      *string
+	Direction           *string
+	JustificationText   *string
+}
+
+var (
+	tickerRe  = regexp.MustCompile(`[$#]?\b([A-Z]{4,5})\b`)
+	targetRe  = regexp.MustCompile(`(?i)(?:цель|цели|таргет|target|тп|tp)\s*[:\-–—]?\s*(?:до\s+)?(\d+(?:[.,]\d+)?)\s*(?:₽|руб|р\.)?`)
+	percentRe = regexp.MustCompile(`([+\-−]?\d+(?:[.,]\d+)?)\s*%(?:\s*за\s+([а-яё0-9\- ]{3,20}))?`)
+
+	recommendationRules = []rule{
+		{wordRe(`продавать|продаем|продаём|продажа|sell|фиксир\p{L}*`), RecommendationSell},
+		{wordRe(`держать|держим|удерживать|hold`), RecommendationHold},
+		{wordRe(`покупать|покупаем|покупка|набираем|buy`), RecommendationBuy},
+	}
+	directionRules = []rule{
+		{wordRe(`шорт\p{L}*|short|падени\p{L}*|снижени\p{L}*`), DirectionShort},
+		{wordRe(`лонг\p{L}*|long|рост\p{L}*`), DirectionLong},
+	}
+	typeRules = []rule{
+		{wordRe(`пробо\p{L}*`), TypeBreakout},
+		{wordRe(`разворот\p{L}*`), TypeReversal},
+		{wordRe(`отскок\p{L}*`), TypeRebound},
+		{wordRe(`продолжени\p{L}*\s+(?:тренда|роста|падения)`), TypeTrend},
+	}
+	periodRules = []rule{
+		{wordRe(`долгосрок\p{L}*|год\p{L}*|лет`), PeriodLong},
+		{wordRe(`среднесрок\p{L}*|квартал\p{L}*|полгода|[2-9]\s*месяц\p{L}*`), PeriodMedium},
+		{wordRe(`краткосрок\p{L}*|месяц\p{L}*|недел\p{L}*|дн\p{L}*|день`), PeriodShort},
+	}
+)
+
+// Extractor извлекает прогнозы по правилам на основе регулярных выражений.
+// Тикер ищется среди известных: по символу (SBER, $SBER, #SBER) или названию.
+type Extractor struct {
+	tickers map[string]bool
+	names   map[string]string // название в нижнем регистре -> тикер
+	recs    *Recommendations
+}
+
+// NewExtractor создает экстрактор; names сопоставляет тикер с названием компании
+func NewExtractor(names map[string]string) *Extractor {
+	e := &Extractor{tickers: map[string]bool{}, names: map[string]string{}, recs: DefaultRecommendations}
+	for ticker, name := range names {
+		e.tickers[ticker] = true
+		if name != "" {
+			e.names[strings.ToLower(name)] = ticker
+		}
+	}
+	return e
+}
+
+// SetRecommendations заменяет встроенную таблицу рекомендаций
+func (e *Extractor) SetRecommendations(r *Recommendations) {
+	e.recs = r
+}
+
+// Extract разбирает текст и возвращает по одному прогнозу на каждый
+// упомянутый тикер. Если в тексте нет ни цели, ни процента, ни рекомендации,
+// результат пустой.
+func (e *Extractor) Extract(text string) []Result {
+	tickers := e.findTickers(text)
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	var r Result
+	if m := targetRe.FindStringSubmatch(text); m != nil {
+		r.TargetPrice = parseNumber(m[1])
+	}
+	if m := percentRe.FindStringSubmatch(text); m != nil {
+		r.TargetChangePercent = parseNumber(m[1])
+		if m[2] != "" {
+			r.Period = matchRule(periodRules, m[2])
+		}
+	}
+	if r.TargetPrice == nil && r.TargetChangePercent == nil && e.recs.Match(text) == nil {
+		return nil
+	}
+
+	if r.Period == nil {
+		r.Period = matchRule(periodRules, text)
+	}
+	r.Recommendation = e.recs.Match(text)
+	r.Direction = matchRule(directionRules, text)
+	r.PredictionType = matchRule(typeRules, text)
+	r.Direction = inferDirection(r)
+
+	justification := strings.TrimSpace(text)
+	if len([]rune(justification)) > 500 {
+		justification = string([]rune(justification)[:500])
+	}
+	r.JustificationText = &justification
+
+	results := make([]Result, 0, len(tickers))
+	for _, t := range tickers {
+		res := r
+		res.Ticker = t
+		results = append(results, res)
+	}
+	return results
+}
+
+// findTickers возвращает упомянутые тикеры в порядке появления без повторов
+func (e *Extractor) findTickers(text string) []string {
+	seen := map[string]bool{}
+	var tickers []string
+	for _, m := range tickerRe.FindAllStringSubmatch(text, -1) {
+		if t := m[1]; e.tickers[t] && !seen[t] {
+			seen[t] = true
+			tickers = append(tickers, t)
+		}
+	}
+	lower := strings.ToLower(text)
+	for name, t := range e.names {
+		if !seen[t] && strings.Contains(lower, name) {
+			seen[t] = true
+			tickers = append(tickers, t)
+		}
+	}
+	return tickers
+}
+
+// inferDirection выводит направление из рекомендации или знака процента
+func inferDirection(r Result) *string {
+	if r.Direction != nil {
+		return r.Direction
+	}
+	dir := DirectionUndefined
+	switch {
+	case r.Recommendation != nil && *r.Recommendation == RecommendationBuy:
+		dir = DirectionLong
+	case r.Recommendation != nil && *r.Recommendation == RecommendationSell:
+		dir = DirectionShort
+	case r.TargetChangePercent != nil && *r.TargetChangePercent > 0:
+		dir = DirectionLong
+	case r.TargetChangePercent != nil && *r.TargetChangePercent < 0:
+		dir = DirectionShort
+	}
+	return &dir
+}
+
+// rule сопоставляет фразу в тексте каноническому значению
+type rule struct {
+	re    *regexp.Regexp
+	value string
+}
+
+// wordRe компилирует альтернативы, которые должны начинаться с начала слова.
+// \b в Go работает только для ASCII, поэтому граница задается явно.
+func wordRe(alternatives string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(?:` + alternatives + `)`)
+}
+
+func matchRule(rules []rule, text string) *string {
+	for _, rule := range rules {
+		if rule.re.MatchString(text) {
+			v := rule.value
+			return &v
+		}
+	}
+	return nil
+}
+
+func parseNumber(s string) *float64 {
+	s = strings.ReplaceAll(s, ",", ".")
+	s = strings.ReplaceAll(s, "−", "-")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
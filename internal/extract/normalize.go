@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"context"
+
+	"frontend-backend/internal/storage"
+)
+
+// SourcePredictions — источник нераспознанных значений, найденных в сохраненных прогнозах
+const SourcePredictions = "predictions"
+
+// NormalizeStore — хранилище прогнозов и фраз на проверку
+type NormalizeStore interface {
+	UnknownPhrases
+	ListUnknownRecommendations(ctx context.Context) ([]storage.UnknownRecommendation, error)
+	DeleteUnknownRecommendations(ctx context.Context, phrases []string) error
+	ListRecommendationValues(ctx context.Context) ([]storage.RecommendationValue, error)
+	RenameRecommendation(ctx context.Context, from, to string) (int64, error)
+	ListPredictionsWithoutRecommendation(ctx context.Context) ([]storage.PredictionText, error)
+	SetPredictionRecommendation(ctx context.Context, channel string, messageID, stockID int64, recommendation string) (bool, error)
+}
+
+// NormalizeStats — итог повторного применения таблицы рекомендаций
+type NormalizeStats struct {
+	Renamed    int64 `json:"renamed"`    // прогнозы, чья рекомендация приведена к канонической
+	Classified int64 `json:"classified"` // прогнозы без рекомендации, получившие ее по тексту
+	Resolved   int   `json:"resolved"`   // фразы, снятые с проверки
+	Unknown    int   `json:"unknown"`    // фразы, оставшиеся на проверке
+}
+
+// Normalizer применяет текущую таблицу рекомендаций к уже сохраненным
+// прогнозам. Повторный запуск ничего не меняет, пока не изменилась таблица.
+type Normalizer struct {
+	store NormalizeStore
+	recs  *Recommendations
+}
+
+// NewNormalizer создает новый экземпляр Normalizer
+func NewNormalizer(store NormalizeStore, recs *Recommendations) *Normalizer {
+	return &Normalizer{store: store, recs: recs}
+}
+
+// Unknown возвращает фразы, ожидающие правила в таблице
+func (n *Normalizer) Unknown(ctx context.Context) ([]storage.UnknownRecommendation, error) {
+	return n.store.ListUnknownRecommendations(ctx)
+}
+
+// Normalize приводит рекомендации прогнозов к каноническим значениям,
+// проставляет их прогнозам без рекомендации и снимает с проверки фразы,
+// которые теперь распознаются
+func (n *Normalizer) Normalize(ctx context.Context) (NormalizeStats, error) {
+	var stats NormalizeStats
+	pending, err := n.store.ListUnknownRecommendations(ctx)
+	if err != nil {
+		return stats, err
+	}
+	listed := make(map[string]bool, len(pending))
+	var resolved []string
+	for _, u := range pending {
+		listed[u.Phrase] = true
+		if _, ok := n.recs.Normalize(u.Phrase); ok {
+			resolved = append(resolved, u.Phrase)
+		}
+	}
+
+	values, err := n.store.ListRecommendationValues(ctx)
+	if err != nil {
+		return stats, err
+	}
+	for _, v := range values {
+		if IsCanonicalRecommendation(v.Value) {
+			continue
+		}
+		canonical, ok := n.recs.Normalize(v.Value)
+		if !ok {
+			// Значение уже на проверке: повторный запуск не должен раздувать счетчик
+			if !listed[v.Value] {
+				if err := n.store.RecordUnknownRecommendation(ctx, v.Value, SourcePredictions, 0); err != nil {
+					return stats, err
+				}
+				listed[v.Value] = true
+			}
+			continue
+		}
+		renamed, err := n.store.RenameRecommendation(ctx, v.Value, canonical)
+		if err != nil {
+			return stats, err
+		}
+		stats.Renamed += renamed
+	}
+
+	preds, err := n.store.ListPredictionsWithoutRecommendation(ctx)
+	if err != nil {
+		return stats, err
+	}
+	for _, p := range preds {
+		rec := n.recs.Match(p.Text)
+		if rec == nil {
+			continue
+		}
+		ok, err := n.store.SetPredictionRecommendation(ctx, p.Channel, p.MessageID, p.StockID, *rec)
+		if err != nil {
+			return stats, err
+		}
+		if ok {
+			stats.Classified++
+		}
+	}
+
+	if err := n.store.DeleteUnknownRecommendations(ctx, resolved); err != nil {
+		return stats, err
+	}
+	stats.Resolved = len(resolved)
+	stats.Unknown = len(listed) - len(resolved)
+	return stats, nil
+}
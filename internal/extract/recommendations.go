@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RecommendationRule — правило таблицы из конфигурации: фразы, которые
+// означают каноническую рекомендацию
+type RecommendationRule struct {
+	Phrases        []string
+	Recommendation string
+}
+
+// recommendationCueRe находит явно названную рекомендацию («Рекомендация:
+// выше рынка»), чтобы показать на проверку фразы, которых нет в таблице
+var recommendationCueRe = regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(?:рекомендаци\p{L}*|рейтинг|recommendation|rating)\s*[:\-–—]\s*(\p{L}+(?:[ \-]\p{L}+){0,2})`)
+
+// Recommendations приводит фразы к каноническим рекомендациям: сначала по
+// правилам из конфигурации, затем по встроенным
+type Recommendations struct {
+	rules []rule
+}
+
+// DefaultRecommendations — только встроенные правила
+var DefaultRecommendations = &Recommendations{rules: recommendationRules}
+
+// NewRecommendations проверяет правила из конфигурации и ставит их перед встроенными
+func NewRecommendations(custom []RecommendationRule) (*Recommendations, error) {
+	rules := make([]rule, 0, len(custom)+len(recommendationRules))
+	for i, c := range custom {
+		if !IsCanonicalRecommendation(c.Recommendation) {
+			return nil, fmt.Errorf("rule %d: unknown recommendation %q (expected %s, %s or %s)",
+				i, c.Recommendation, RecommendationBuy, RecommendationHold, RecommendationSell)
+		}
+		alternatives := make([]string, 0, len(c.Phrases))
+		for _, phrase := range c.Phrases {
+			if words := strings.Fields(phrase); len(words) > 0 {
+				for j, w := range words {
+					words[j] = regexp.QuoteMeta(w)
+				}
+				alternatives = append(alternatives, strings.Join(words, `\s+`))
+			}
+		}
+		if len(alternatives) == 0 {
+			return nil, fmt.Errorf("rule %d: phrases are required", i)
+		}
+		// Фраза должна заканчиваться на границе слова, иначе «hold» совпал бы с «holding»
+		re := wordRe(`(?:` + strings.Join(alternatives, "|") + `)(?:$|[^\p{L}\p{N}])`)
+		rules = append(rules, rule{re, c.Recommendation})
+	}
+	return &Recommendations{rules: append(rules, recommendationRules...)}, nil
+}
+
+// IsCanonicalRecommendation сообщает, что значение уже каноническое
+func IsCanonicalRecommendation(value string) bool {
+	switch value {
+	case RecommendationBuy, RecommendationHold, RecommendationSell:
+		return true
+	}
+	return false
+}
+
+// Match ищет рекомендацию в тексте сообщения
+func (r *Recommendations) Match(text string) *string {
+	return matchRule(r.rules, text)
+}
+
+// Normalize приводит готовое значение рекомендации (из шины или из базы) к
+// каноническому; ok равен false, если ни одно правило не подошло
+func (r *Recommendations) Normalize(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, canonical := range []string{RecommendationBuy, RecommendationHold, RecommendationSell} {
+		if strings.EqualFold(raw, canonical) {
+			return canonical, true
+		}
+	}
+	if v := r.Match(raw); v != nil {
+		return *v, true
+	}
+	return "", false
+}
+
+// Unknown возвращает явно названную в тексте рекомендацию, которую не
+// распознало ни одно правило
+func (r *Recommendations) Unknown(text string) (string, bool) {
+	m := recommendationCueRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	phrase := strings.ToLower(strings.Join(strings.Fields(m[1]), " "))
+	if _, ok := r.Normalize(phrase); ok {
+		return "", false
+	}
+	return phrase, true
+}
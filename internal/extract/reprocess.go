@@ -0,0 +1,157 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"frontend-backend/internal/deadletter"
+	"frontend-backend/internal/storage"
+)
+
+// Store — хранилище, из которого читаются сообщения и в которое пишутся прогнозы
+type Store interface {
+	GetStocks(ctx context.Context) ([]storage.Stock, error)
+	ListMessages(ctx context.Context, from, to time.Time) ([]storage.Message, error)
+	InsertPrediction(ctx context.Context, p storage.NewPrediction) (bool, error)
+}
+
+// DeadLetters принимает сообщения, прогнозы из которых не удалось сохранить
+type DeadLetters interface {
+	Add(ctx context.Context, source string, payload []byte, reason error) error
+}
+
+// UnknownPhrases принимает фразы рекомендаций, которых нет в таблице правил
+type UnknownPhrases interface {
+	RecordUnknownRecommendation(ctx context.Context, phrase, source string, messageID int64) error
+}
+
+// Stats — итог повторной обработки сообщений
+type Stats struct {
+	Messages  int `json:"messages"`
+	Extracted int `json:"extracted"`
+	Inserted  int `json:"inserted"`
+	Failed    int `json:"failed"`
+}
+
+// Reprocessor прогоняет сохраненные сообщения через Extractor и записывает
+// найденные прогнозы. Вставка идемпотентна, поэтому запуск можно повторять.
+type Reprocessor struct {
+	store       Store
+	deadLetters DeadLetters
+	recs        *Recommendations
+	unknown     UnknownPhrases
+}
+
+// NewReprocessor создает новый экземпляр Reprocessor; deadLetters может быть nil
+func NewReprocessor(store Store, deadLetters DeadLetters) *Reprocessor {
+	return &Reprocessor{store: store, deadLetters: deadLetters, recs: DefaultRecommendations}
+}
+
+// SetRecommendations задает таблицу рекомендаций; нераспознанные фразы
+// отправляются в unknown, если он не nil
+func (r *Reprocessor) SetRecommendations(recs *Recommendations, unknown UnknownPhrases) {
+	r.recs = recs
+	r.unknown = unknown
+}
+
+// Reprocess обрабатывает сообщения из интервала [from, to)
+func (r *Reprocessor) Reprocess(ctx context.Context, from, to time.Time) (Stats, error) {
+	extractor, err := r.extractor(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	messages, err := r.store.ListMessages(ctx, from, to)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Messages: len(messages)}
+	for _, m := range messages {
+		extracted, inserted, err := r.processMessage(ctx, extractor, m)
+		stats.Extracted += extracted
+		stats.Inserted += inserted
+		if storage.IsPermanent(err) {
+			// Ошибка в данных, а не сбой БД: сообщение уходит в dead-letter,
+			// обработка остальных продолжается
+			stats.Failed++
+			if r.deadLetters != nil {
+				payload, _ := json.Marshal(m)
+				// Без записи в dead-letter сообщение потерялось бы из виду:
+				// обработка останавливается, как при сбое БД
+				if dlErr := r.deadLetters.Add(ctx, deadletter.SourceExtract, payload, err); dlErr != nil {
+					return stats, dlErr
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// RetryMessage повторно обрабатывает сообщение из dead-letter (payload — JSON storage.Message)
+func (r *Reprocessor) RetryMessage(ctx context.Context, payload []byte) error {
+	var m storage.Message
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return fmt.Errorf("error decoding message payload: %w", err)
+	}
+	extractor, err := r.extractor(ctx)
+	if err != nil {
+		return err
+	}
+	_, _, err = r.processMessage(ctx, extractor, m)
+	return err
+}
+
+// extractor создает Extractor по текущему списку акций
+func (r *Reprocessor) extractor(ctx context.Context) (*Extractor, error) {
+	stocks, err := r.store.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(stocks))
+	for _, st := range stocks {
+		names[st.Ticker] = st.Name
+	}
+	extractor := NewExtractor(names)
+	extractor.SetRecommendations(r.recs)
+	return extractor, nil
+}
+
+// processMessage извлекает и сохраняет прогнозы одного сообщения
+func (r *Reprocessor) processMessage(ctx context.Context, extractor *Extractor, m storage.Message) (extracted, inserted int, err error) {
+	if phrase, ok := r.recs.Unknown(m.Text); ok && r.unknown != nil {
+		if err := r.unknown.RecordUnknownRecommendation(ctx, phrase, deadletter.SourceExtract, m.TelegramID); err != nil {
+			slog.Error("Не удалось сохранить нераспознанную рекомендацию", "err", err)
+		}
+	}
+	for _, res := range extractor.Extract(m.Text) {
+		extracted++
+		ok, err := r.store.InsertPrediction(ctx, storage.NewPrediction{
+			Channel:             m.Channel,
+			MessageID:           m.TelegramID,
+			Ticker:              res.Ticker,
+			PredictionType:      res.PredictionType,
+			TargetPrice:         res.TargetPrice,
+			TargetChangePercent: res.TargetChangePercent,
+			Period:              res.Period,
+			Recommendation:      res.Recommendation,
+			Direction:           res.Direction,
+			JustificationText:   res.JustificationText,
+			PredictedAt:         m.SentAt,
+		})
+		if err != nil {
+			return extracted, inserted, fmt.Errorf("error saving prediction from message %d: %w", m.TelegramID, err)
+		}
+		if ok {
+			inserted++
+		}
+	}
+	return extracted, inserted, nil
+}
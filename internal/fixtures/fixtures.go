@@ -0,0 +1,195 @@
+// Package fixtures загружает в БД акции, сообщения и прогнозы из YAML-файла,
+// чтобы новые окружения и демо-стенды наполнялись одинаково
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"frontend-backend/internal/storage"
+)
+
+// File — содержимое файла фикстур
+type File struct {
+	Stocks      []Stock      `yaml:"stocks"`
+	Messages    []Message    `yaml:"messages"`
+	Predictions []Prediction `yaml:"predictions"`
+}
+
+// Stock — акция справочника
+type Stock struct {
+	Ticker   string            `yaml:"ticker"`
+	Name     string            `yaml:"name"`
+	Exchange string            `yaml:"exchange"`
+	Names    map[string]string `yaml:"names"`
+}
+
+// Message — сообщение канала
+type Message struct {
+	TelegramID int64     `yaml:"telegram_id"`
+	Channel    string    `yaml:"channel"`
+	Text       string    `yaml:"text"`
+	SentAt     time.Time `yaml:"sent_at"`
+}
+
+// Prediction — прогноз из сообщения; без predicted_at берется время
+// сообщения из того же файла
+type Prediction struct {
+	Channel             string    `yaml:"channel"`    // канал сообщения
+	MessageID           int64     `yaml:"message_id"` // telegram_id сообщения в канале
+	Ticker              string    `yaml:"ticker"`
+	PredictionType      *string   `yaml:"prediction_type"`
+	TargetPrice         *float64  `yaml:"target_price"`
+	TargetChangePercent *float64  `yaml:"target_change_percent"`
+	Period              *string   `yaml:"period"`
+	Recommendation      *string   `yaml:"recommendation"`
+	Direction           *string   `yaml:"direction"`
+	JustificationText   *string   `yaml:"justification_text"`
+	PredictedAt         time.Time `yaml:"predicted_at"`
+}
+
+// Store — хранилище, в которое загружаются фикстуры
+type Store interface {
+	UpsertStock(ctx context.Context, st *storage.Stock) (string, error)
+	SaveMessage(ctx context.Context, m storage.Message) (bool, error)
+	InsertPrediction(ctx context.Context, p storage.NewPrediction) (bool, error)
+}
+
+// Result — итог загрузки: сколько записей каждого вида добавлено, изменено
+// или уже было в БД
+type Result struct {
+	Stocks              int
+	StocksUpdated       int
+	StocksUnchanged     int
+	Messages            int
+	MessagesExisting    int
+	Predictions         int
+	PredictionsExisting int
+}
+
+// Load читает и проверяет файл фикстур. Неизвестные поля считаются ошибкой,
+// чтобы опечатка не превращалась в молча пропущенное значение.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *File) validate() error {
+	// telegram_id уникален только внутри канала
+	type messageKey struct {
+		channel string
+		id      int64
+	}
+	sentAt := make(map[messageKey]time.Time, len(f.Messages))
+	for i, m := range f.Messages {
+		if m.TelegramID == 0 {
+			return fmt.Errorf("messages[%d]: telegram_id is required", i)
+		}
+		key := messageKey{m.Channel, m.TelegramID}
+		if _, ok := sentAt[key]; ok {
+			return fmt.Errorf("messages[%d]: duplicate telegram_id %d in channel %q", i, m.TelegramID, m.Channel)
+		}
+		if m.SentAt.IsZero() {
+			return fmt.Errorf("messages[%d]: sent_at is required", i)
+		}
+		sentAt[key] = m.SentAt
+	}
+	for i := range f.Predictions {
+		p := &f.Predictions[i]
+		if p.MessageID == 0 {
+			return fmt.Errorf("predictions[%d]: message_id is required", i)
+		}
+		if p.Ticker == "" {
+			return fmt.Errorf("predictions[%d]: ticker is required", i)
+		}
+		if p.PredictedAt.IsZero() {
+			t, ok := sentAt[messageKey{p.Channel, p.MessageID}]
+			if !ok {
+				return fmt.Errorf("predictions[%d]: predicted_at is required when message %d is not in the file", i, p.MessageID)
+			}
+			p.PredictedAt = t
+		}
+	}
+	return nil
+}
+
+// Apply загружает фикстуры по порядку: акции, сообщения, прогнозы. Повторная
+// загрузка того же файла ничего не дублирует: акции обновляются по тикеру и
+// бирже, сообщения и прогнозы с существующими ключами пропускаются.
+func Apply(ctx context.Context, store Store, f *File) (Result, error) {
+	var res Result
+	for _, s := range f.Stocks {
+		st := storage.Stock{Ticker: s.Ticker, Name: s.Name, Exchange: s.Exchange, Names: s.Names}
+		outcome, err := store.UpsertStock(ctx, &st)
+		if err != nil {
+			return res, fmt.Errorf("stock %s: %w", s.Ticker, err)
+		}
+		switch outcome {
+		case storage.StockCreated:
+			res.Stocks++
+		case storage.StockUpdated:
+			res.StocksUpdated++
+		default:
+			res.StocksUnchanged++
+		}
+	}
+	for _, m := range f.Messages {
+		inserted, err := store.SaveMessage(ctx, storage.Message{
+			TelegramID: m.TelegramID,
+			Channel:    m.Channel,
+			Text:       m.Text,
+			SentAt:     m.SentAt,
+		})
+		if err != nil {
+			return res, err
+		}
+		if inserted {
+			res.Messages++
+		} else {
+			res.MessagesExisting++
+		}
+	}
+	for _, p := range f.Predictions {
+		inserted, err := store.InsertPrediction(ctx, storage.NewPrediction{
+			Channel:             p.Channel,
+			MessageID:           p.MessageID,
+			Ticker:              p.Ticker,
+			PredictionType:      p.PredictionType,
+			TargetPrice:         p.TargetPrice,
+			TargetChangePercent: p.TargetChangePercent,
+			Period:              p.Period,
+			Recommendation:      p.Recommendation,
+			Direction:           p.Direction,
+			JustificationText:   p.JustificationText,
+			PredictedAt:         p.PredictedAt,
+		})
+		if err != nil {
+			return res, fmt.Errorf("prediction %s from message %d: %w", p.Ticker, p.MessageID, err)
+		}
+		if inserted {
+			res.Predictions++
+		} else {
+			res.PredictionsExisting++
+		}
+	}
+	return res, nil
+}
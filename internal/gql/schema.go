@@ -0,0 +1,209 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// schema описывает граф: акция → прогнозы, акция → история цен
+const schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	stocks: [Stock!]!
+	stock(ticker: String!): Stock
+}
+
+type Stock {
+	id: ID!
+	ticker: String!
+	name: String!
+	# Прогнозы в порядке хранилища (от новых к старым); limit — не больше N
+	predictions(limit: Int): [Prediction!]!
+	# История цен; from и to — даты YYYY-MM-DD включительно,
+	# limit — последние N точек диапазона
+	history(from: String, to: String, limit: Int): [PricePoint!]!
+}
+
+type Prediction {
+	id: ID!
+	messageId: ID!
+	predictionType: String
+	targetPrice: Float
+	targetChangePercent: Float
+	period: String
+	recommendation: String
+	direction: String
+	justificationText: String
+	message: String
+	predictedAt: String!
+	source: String
+	outcome: String
+	realizedReturn: Float
+}
+
+type PricePoint {
+	timestamp: String!
+	price: Float!
+	volume: Float
+	filled: Boolean!
+}
+`
+
+// maxDepth ограничивает вложенность запросов
+const maxDepth = 8
+
+// NewSchema создает GraphQL-схему поверх хранилища
+func NewSchema(store storage.Storage) *graphql.Schema {
+	return graphql.MustParseSchema(schema, &Resolver{store: store}, graphql.MaxDepth(maxDepth))
+}
+
+// Resolver — корневой резолвер запросов
+type Resolver struct {
+	store storage.Storage
+}
+
+// Stocks возвращает все акции
+func (r *Resolver) Stocks(ctx context.Context) ([]*StockResolver, error) {
+	stocks, err := r.store.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*StockResolver, len(stocks))
+	for i, st := range stocks {
+		out[i] = &StockResolver{store: r.store, stock: st}
+	}
+	return out, nil
+}
+
+// Stock возвращает акцию по тикеру или null, если ее нет
+func (r *Resolver) Stock(ctx context.Context, args struct{ Ticker string }) (*StockResolver, error) {
+	stocks, err := r.store.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stocks {
+		if st.Ticker == args.Ticker {
+			return &StockResolver{store: r.store, stock: st}, nil
+		}
+	}
+	return nil, nil
+}
+
+// StockResolver разрешает поля акции
+type StockResolver struct {
+	store storage.Storage
+	stock storage.Stock
+}
+
+func (s *StockResolver) ID() graphql.ID { return graphql.ID(strconv.FormatInt(s.stock.ID, 10)) }
+func (s *StockResolver) Ticker() string { return s.stock.Ticker }
+func (s *StockResolver) Name() string   { return s.stock.Name }
+
+// Predictions возвращает прогнозы по акции
+func (s *StockResolver) Predictions(ctx context.Context, args struct{ Limit *int32 }) ([]*PredictionResolver, error) {
+	predictions, err := s.store.GetPredictionsByTicker(ctx, s.stock.Ticker, s.stock.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	if args.Limit != nil && int(*args.Limit) >= 0 && int(*args.Limit) < len(predictions) {
+		predictions = predictions[:*args.Limit]
+	}
+	out := make([]*PredictionResolver, len(predictions))
+	for i := range predictions {
+		out[i] = &PredictionResolver{p: predictions[i]}
+	}
+	return out, nil
+}
+
+// History возвращает историю цен акции в диапазоне дат
+func (s *StockResolver) History(ctx context.Context, args struct {
+	From  *string
+	To    *string
+	Limit *int32
+}) ([]*PricePointResolver, error) {
+	from, err := parseDate("from", args.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseDate("to", args.To)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.store.GetStockPriceHistory(ctx, s.stock.Ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*PricePointResolver, 0, len(history))
+	for _, h := range history {
+		t, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err == nil && (!from.IsZero() && t.Before(from) || !to.IsZero() && !t.Before(to.AddDate(0, 0, 1))) {
+			continue
+		}
+		points = append(points, &PricePointResolver{h: h})
+	}
+	if args.Limit != nil && int(*args.Limit) >= 0 && int(*args.Limit) < len(points) {
+		points = points[len(points)-int(*args.Limit):]
+	}
+	return points, nil
+}
+
+func parseDate(name string, value *string) (time.Time, error) {
+	if value == nil || *value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected YYYY-MM-DD", name)
+	}
+	return t, nil
+}
+
+// PredictionResolver разрешает поля прогноза
+type PredictionResolver struct {
+	p storage.Prediction
+}
+
+func (p *PredictionResolver) ID() graphql.ID { return graphql.ID(strconv.FormatInt(p.p.ID, 10)) }
+func (p *PredictionResolver) MessageID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(p.p.MessageID, 10))
+}
+func (p *PredictionResolver) PredictionType() *string       { return p.p.PredictionType }
+func (p *PredictionResolver) TargetPrice() *float64         { return p.p.TargetPrice }
+func (p *PredictionResolver) TargetChangePercent() *float64 { return p.p.TargetChangePercent }
+func (p *PredictionResolver) Period() *string               { return p.p.Period }
+func (p *PredictionResolver) Recommendation() *string       { return p.p.Recommendation }
+func (p *PredictionResolver) Direction() *string            { return p.p.Direction }
+func (p *PredictionResolver) JustificationText() *string    { return p.p.JustificationText }
+func (p *PredictionResolver) Message() *string              { return p.p.Message }
+func (p *PredictionResolver) PredictedAt() string           { return p.p.PredictedAt }
+func (p *PredictionResolver) Source() *string               { return p.p.Source }
+func (p *PredictionResolver) Outcome() *string              { return p.p.Outcome }
+func (p *PredictionResolver) RealizedReturn() *float64      { return p.p.RealizedReturn }
+
+// PricePointResolver разрешает поля точки истории цен
+type PricePointResolver struct {
+	h storage.StockPriceHistory
+}
+
+func (p *PricePointResolver) Timestamp() string { return p.h.Timestamp }
+func (p *PricePointResolver) Price() float64    { return p.h.Price }
+func (p *PricePointResolver) Filled() bool      { return p.h.Filled }
+
+// Volume — float, так как Int в GraphQL 32-битный
+func (p *PricePointResolver) Volume() *float64 {
+	if p.h.Volume == 0 {
+		return nil
+	}
+	v := float64(p.h.Volume)
+	return &v
+}
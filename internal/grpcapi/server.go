@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/grpcapi/stocksv1"
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server реализует stocksv1.StockService поверх хранилища
+type Server struct {
+	stocksv1.UnimplementedStockServiceServer
+	store storage.Storage
+	log   *slog.Logger
+}
+
+// NewServer создает gRPC-сервер с сервисом акций и reflection; nil logger —
+// slog.Default()
+func NewServer(store storage.Storage, logger *slog.Logger) *grpc.Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	gs := grpc.NewServer()
+	stocksv1.RegisterStockServiceServer(gs, &Server{store: store, log: logger})
+	reflection.Register(gs)
+	return gs
+}
+
+// ListStocks возвращает все акции
+func (s *Server) ListStocks(ctx context.Context, req *stocksv1.ListStocksRequest) (*stocksv1.ListStocksResponse, error) {
+	s.log.InfoContext(ctx, "gRPC ListStocks - получение списка акций")
+	stocks, err := s.store.GetStocks(ctx)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	resp := &stocksv1.ListStocksResponse{Stocks: make([]*stocksv1.Stock, len(stocks))}
+	for i, st := range stocks {
+		resp.Stocks[i] = &stocksv1.Stock{Id: st.ID, Ticker: st.Ticker, Name: st.Name}
+	}
+	return resp, nil
+}
+
+// GetPredictions возвращает прогнозы по тикеру
+func (s *Server) GetPredictions(ctx context.Context, req *stocksv1.GetPredictionsRequest) (*stocksv1.GetPredictionsResponse, error) {
+	s.log.InfoContext(ctx, "gRPC GetPredictions - получение прогнозов", "ticker", req.GetTicker())
+	if req.GetTicker() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ticker is required")
+	}
+	predictions, err := s.store.GetPredictionsByTicker(ctx, req.GetTicker(), "")
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	if limit := int(req.GetLimit()); limit > 0 && limit < len(predictions) {
+		predictions = predictions[:limit]
+	}
+	metrics.PredictionsServed.WithLabelValues(strings.ToUpper(req.GetTicker()), "grpc").Add(float64(len(predictions)))
+	resp := &stocksv1.GetPredictionsResponse{Predictions: make([]*stocksv1.Prediction, len(predictions))}
+	for i, p := range predictions {
+		resp.Predictions[i] = &stocksv1.Prediction{
+			Id:                  p.ID,
+			MessageId:           p.MessageID,
+			StockId:             p.StockID,
+			PredictionType:      p.PredictionType,
+			TargetPrice:         p.TargetPrice,
+			TargetChangePercent: p.TargetChangePercent,
+			Period:              p.Period,
+			Recommendation:      p.Recommendation,
+			Direction:           p.Direction,
+			JustificationText:   p.JustificationText,
+			Message:             p.Message,
+			PredictedAt:         p.PredictedAt,
+			SourceId:            p.SourceID,
+			Source:              p.Source,
+			Outcome:             p.Outcome,
+			RealizedReturn:      p.RealizedReturn,
+		}
+	}
+	return resp, nil
+}
+
+// GetPriceHistory возвращает историю цен по тикеру в диапазоне
+func (s *Server) GetPriceHistory(ctx context.Context, req *stocksv1.GetPriceHistoryRequest) (*stocksv1.GetPriceHistoryResponse, error) {
+	s.log.InfoContext(ctx, "gRPC GetPriceHistory - получение истории цен", "ticker", req.GetTicker())
+	if req.GetTicker() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ticker is required")
+	}
+	history, err := s.store.GetStockPriceHistory(ctx, req.GetTicker())
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	var from, to time.Time
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+	bars := make([]*stocksv1.PriceBar, 0, len(history))
+	for _, h := range history {
+		t, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err != nil {
+			s.log.WarnContext(ctx, "Пропускаем точку истории с некорректным временем", "ticker", req.GetTicker(), "timestamp", h.Timestamp)
+			continue
+		}
+		if !from.IsZero() && t.Before(from) || !to.IsZero() && t.After(to) {
+			continue
+		}
+		bars = append(bars, &stocksv1.PriceBar{
+			Time:   timestamppb.New(t),
+			Price:  h.Price,
+			Volume: h.Volume,
+			Filled: h.Filled,
+		})
+	}
+	if limit := int(req.GetLimit()); limit > 0 && limit < len(bars) {
+		bars = bars[len(bars)-limit:]
+	}
+	metrics.PriceHistoryRowsServed.WithLabelValues(strings.ToUpper(req.GetTicker()), "grpc").Add(float64(len(bars)))
+	return &stocksv1.GetPriceHistoryResponse{Bars: bars}, nil
+}
+
+// toStatus переводит ошибки хранилища в коды gRPC
+func (s *Server) toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		s.log.ErrorContext(ctx, "Ошибка хранилища в gRPC-запросе", "err", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+}
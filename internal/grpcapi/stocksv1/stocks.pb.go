@@ -0,0 +1,753 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: stocksv1/stocks.proto
+
+// Внутренний gRPC API поверх того же хранилища, что и HTTP API.
+// Код генерируется protoc-gen-go и protoc-gen-go-grpc (см. README).
+
+package stocksv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Stock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ticker        string                 `protobuf:"bytes,2,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stock) Reset() {
+	*x = Stock{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stock) ProtoMessage() {}
+
+func (x *Stock) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stock.ProtoReflect.Descriptor instead.
+func (*Stock) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Stock) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Stock) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *Stock) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Prediction struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	MessageId           int64                  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	StockId             int64                  `protobuf:"varint,3,opt,name=stock_id,json=stockId,proto3" json:"stock_id,omitempty"`
+	PredictionType      *string                `protobuf:"bytes,4,opt,name=prediction_type,json=predictionType,proto3,oneof" json:"prediction_type,omitempty"`
+	TargetPrice         *float64               `protobuf:"fixed64,5,opt,name=target_price,json=targetPrice,proto3,oneof" json:"target_price,omitempty"`
+	TargetChangePercent *float64               `protobuf:"fixed64,6,opt,name=target_change_percent,json=targetChangePercent,proto3,oneof" json:"target_change_percent,omitempty"`
+	Period              *string                `protobuf:"bytes,7,opt,name=period,proto3,oneof" json:"period,omitempty"`
+	Recommendation      *string                `protobuf:"bytes,8,opt,name=recommendation,proto3,oneof" json:"recommendation,omitempty"`
+	Direction           *string                `protobuf:"bytes,9,opt,name=direction,proto3,oneof" json:"direction,omitempty"`
+	JustificationText   *string                `protobuf:"bytes,10,opt,name=justification_text,json=justificationText,proto3,oneof" json:"justification_text,omitempty"`
+	Message             *string                `protobuf:"bytes,11,opt,name=message,proto3,oneof" json:"message,omitempty"`
+	// Как в HTTP API: RFC 3339 в UTC
+	PredictedAt    string   `protobuf:"bytes,12,opt,name=predicted_at,json=predictedAt,proto3" json:"predicted_at,omitempty"`
+	SourceId       *int64   `protobuf:"varint,13,opt,name=source_id,json=sourceId,proto3,oneof" json:"source_id,omitempty"`
+	Source         *string  `protobuf:"bytes,14,opt,name=source,proto3,oneof" json:"source,omitempty"`
+	Outcome        *string  `protobuf:"bytes,15,opt,name=outcome,proto3,oneof" json:"outcome,omitempty"`
+	RealizedReturn *float64 `protobuf:"fixed64,16,opt,name=realized_return,json=realizedReturn,proto3,oneof" json:"realized_return,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Prediction) Reset() {
+	*x = Prediction{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prediction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prediction) ProtoMessage() {}
+
+func (x *Prediction) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prediction.ProtoReflect.Descriptor instead.
+func (*Prediction) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Prediction) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Prediction) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *Prediction) GetStockId() int64 {
+	if x != nil {
+		return x.StockId
+	}
+	return 0
+}
+
+func (x *Prediction) GetPredictionType() string {
+	if x != nil && x.PredictionType != nil {
+		return *x.PredictionType
+	}
+	return ""
+}
+
+func (x *Prediction) GetTargetPrice() float64 {
+	if x != nil && x.TargetPrice != nil {
+		return *x.TargetPrice
+	}
+	return 0
+}
+
+func (x *Prediction) GetTargetChangePercent() float64 {
+	if x != nil && x.TargetChangePercent != nil {
+		return *x.TargetChangePercent
+	}
+	return 0
+}
+
+func (x *Prediction) GetPeriod() string {
+	if x != nil && x.Period != nil {
+		return *x.Period
+	}
+	return ""
+}
+
+func (x *Prediction) GetRecommendation() string {
+	if x != nil && x.Recommendation != nil {
+		return *x.Recommendation
+	}
+	return ""
+}
+
+func (x *Prediction) GetDirection() string {
+	if x != nil && x.Direction != nil {
+		return *x.Direction
+	}
+	return ""
+}
+
+func (x *Prediction) GetJustificationText() string {
+	if x != nil && x.JustificationText != nil {
+		return *x.JustificationText
+	}
+	return ""
+}
+
+func (x *Prediction) GetMessage() string {
+	if x != nil && x.Message != nil {
+		return *x.Message
+	}
+	return ""
+}
+
+func (x *Prediction) GetPredictedAt() string {
+	if x != nil {
+		return x.PredictedAt
+	}
+	return ""
+}
+
+func (x *Prediction) GetSourceId() int64 {
+	if x != nil && x.SourceId != nil {
+		return *x.SourceId
+	}
+	return 0
+}
+
+func (x *Prediction) GetSource() string {
+	if x != nil && x.Source != nil {
+		return *x.Source
+	}
+	return ""
+}
+
+func (x *Prediction) GetOutcome() string {
+	if x != nil && x.Outcome != nil {
+		return *x.Outcome
+	}
+	return ""
+}
+
+func (x *Prediction) GetRealizedReturn() float64 {
+	if x != nil && x.RealizedReturn != nil {
+		return *x.RealizedReturn
+	}
+	return 0
+}
+
+type PriceBar struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Time   *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Price  float64                `protobuf:"fixed64,2,opt,name=price,proto3" json:"price,omitempty"`
+	Volume int64                  `protobuf:"varint,3,opt,name=volume,proto3" json:"volume,omitempty"`
+	// Точка восстановлена заполнением пропуска
+	Filled        bool `protobuf:"varint,4,opt,name=filled,proto3" json:"filled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceBar) Reset() {
+	*x = PriceBar{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceBar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceBar) ProtoMessage() {}
+
+func (x *PriceBar) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceBar.ProtoReflect.Descriptor instead.
+func (*PriceBar) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PriceBar) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *PriceBar) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *PriceBar) GetVolume() int64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *PriceBar) GetFilled() bool {
+	if x != nil {
+		return x.Filled
+	}
+	return false
+}
+
+type ListStocksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStocksRequest) Reset() {
+	*x = ListStocksRequest{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStocksRequest) ProtoMessage() {}
+
+func (x *ListStocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStocksRequest.ProtoReflect.Descriptor instead.
+func (*ListStocksRequest) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{3}
+}
+
+type ListStocksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stocks        []*Stock               `protobuf:"bytes,1,rep,name=stocks,proto3" json:"stocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStocksResponse) Reset() {
+	*x = ListStocksResponse{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStocksResponse) ProtoMessage() {}
+
+func (x *ListStocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStocksResponse.ProtoReflect.Descriptor instead.
+func (*ListStocksResponse) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListStocksResponse) GetStocks() []*Stock {
+	if x != nil {
+		return x.Stocks
+	}
+	return nil
+}
+
+type GetPredictionsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ticker string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	// 0 — без ограничения
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPredictionsRequest) Reset() {
+	*x = GetPredictionsRequest{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPredictionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPredictionsRequest) ProtoMessage() {}
+
+func (x *GetPredictionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPredictionsRequest.ProtoReflect.Descriptor instead.
+func (*GetPredictionsRequest) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetPredictionsRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *GetPredictionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetPredictionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Predictions   []*Prediction          `protobuf:"bytes,1,rep,name=predictions,proto3" json:"predictions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPredictionsResponse) Reset() {
+	*x = GetPredictionsResponse{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPredictionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPredictionsResponse) ProtoMessage() {}
+
+func (x *GetPredictionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPredictionsResponse.ProtoReflect.Descriptor instead.
+func (*GetPredictionsResponse) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetPredictionsResponse) GetPredictions() []*Prediction {
+	if x != nil {
+		return x.Predictions
+	}
+	return nil
+}
+
+type GetPriceHistoryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ticker string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	// Границы диапазона включительно; не заданы — вся история
+	From *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// Последние N точек диапазона; 0 — без ограничения
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPriceHistoryRequest) Reset() {
+	*x = GetPriceHistoryRequest{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPriceHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPriceHistoryRequest) ProtoMessage() {}
+
+func (x *GetPriceHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPriceHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetPriceHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetPriceHistoryRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *GetPriceHistoryRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetPriceHistoryRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetPriceHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetPriceHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bars          []*PriceBar            `protobuf:"bytes,1,rep,name=bars,proto3" json:"bars,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPriceHistoryResponse) Reset() {
+	*x = GetPriceHistoryResponse{}
+	mi := &file_stocksv1_stocks_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPriceHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPriceHistoryResponse) ProtoMessage() {}
+
+func (x *GetPriceHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stocksv1_stocks_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPriceHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetPriceHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_stocksv1_stocks_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetPriceHistoryResponse) GetBars() []*PriceBar {
+	if x != nil {
+		return x.Bars
+	}
+	return nil
+}
+
+var File_stocksv1_stocks_proto protoreflect.FileDescriptor
+
+const file_stocksv1_stocks_proto_rawDesc = "" +
+	"\n" +
+	"\x15stocksv1/stocks.proto\x12\tstocks.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"C\n" +
+	"\x05Stock\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06ticker\x18\x02 \x01(\tR\x06ticker\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"\x9b\x06\n" +
+	"\n" +
+	"Prediction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\x03R\tmessageId\x12\x19\n" +
+	"\bstock_id\x18\x03 \x01(\x03R\astockId\x12,\n" +
+	"\x0fprediction_type\x18\x04 \x01(\tH\x00R\x0epredictionType\x88\x01\x01\x12&\n" +
+	"\ftarget_price\x18\x05 \x01(\x01H\x01R\vtargetPrice\x88\x01\x01\x127\n" +
+	"\x15target_change_percent\x18\x06 \x01(\x01H\x02R\x13targetChangePercent\x88\x01\x01\x12\x1b\n" +
+	"\x06period\x18\a \x01(\tH\x03R\x06period\x88\x01\x01\x12+\n" +
+	"\x0erecommendation\x18\b \x01(\tH\x04R\x0erecommendation\x88\x01\x01\x12!\n" +
+	"\tdirection\x18\t \x01(\tH\x05R\tdirection\x88\x01\x01\x122\n" +
+	"\x12justification_text\x18\n" +
+	" \x01(\tH\x06R\x11justificationText\x88\x01\x01\x12\x1d\n" +
+	"\amessage\x18\v \x01(\tH\aR\amessage\x88\x01\x01\x12!\n" +
+	"\fpredicted_at\x18\f \x01(\tR\vpredictedAt\x12 \n" +
+	"\tsource_id\x18\r \x01(\x03H\bR\bsourceId\x88\x01\x01\x12\x1b\n" +
+	"\x06source\x18\x0e \x01(\tH\tR\x06source\x88\x01\x01\x12\x1d\n" +
+	"\aoutcome\x18\x0f \x01(\tH\n" +
+	"R\aoutcome\x88\x01\x01\x12,\n" +
+	"\x0frealized_return\x18\x10 \x01(\x01H\vR\x0erealizedReturn\x88\x01\x01B\x12\n" +
+	"\x10_prediction_typeB\x0f\n" +
+	"\r_target_priceB\x18\n" +
+	"\x16_target_change_percentB\t\n" +
+	"\a_periodB\x11\n" +
+	"\x0f_recommendationB\f\n" +
+	"\n" +
+	"_directionB\x15\n" +
+	"\x13_justification_textB\n" +
+	"\n" +
+	"\b_messageB\f\n" +
+	"\n" +
+	"_source_idB\t\n" +
+	"\a_sourceB\n" +
+	"\n" +
+	"\b_outcomeB\x12\n" +
+	"\x10_realized_return\"\x80\x01\n" +
+	"\bPriceBar\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x01R\x05price\x12\x16\n" +
+	"\x06volume\x18\x03 \x01(\x03R\x06volume\x12\x16\n" +
+	"\x06filled\x18\x04 \x01(\bR\x06filled\"\x13\n" +
+	"\x11ListStocksRequest\">\n" +
+	"\x12ListStocksResponse\x12(\n" +
+	"\x06stocks\x18\x01 \x03(\v2\x10.stocks.v1.StockR\x06stocks\"E\n" +
+	"\x15GetPredictionsRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"Q\n" +
+	"\x16GetPredictionsResponse\x127\n" +
+	"\vpredictions\x18\x01 \x03(\v2\x15.stocks.v1.PredictionR\vpredictions\"\xa2\x01\n" +
+	"\x16GetPriceHistoryRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"B\n" +
+	"\x17GetPriceHistoryResponse\x12'\n" +
+	"\x04bars\x18\x01 \x03(\v2\x13.stocks.v1.PriceBarR\x04bars2\x8a\x02\n" +
+	"\fStockService\x12I\n" +
+	"\n" +
+	"ListStocks\x12\x1c.stocks.v1.ListStocksRequest\x1a\x1d.stocks.v1.ListStocksResponse\x12U\n" +
+	"\x0eGetPredictions\x12 .stocks.v1.GetPredictionsRequest\x1a!.stocks.v1.GetPredictionsResponse\x12X\n" +
+	"\x0fGetPriceHistory\x12!.stocks.v1.GetPriceHistoryRequest\x1a\".stocks.v1.GetPriceHistoryResponseB5Z3frontend-backend/internal/grpcapi/stocksv1;stocksv1b\x06proto3"
+
+var (
+	file_stocksv1_stocks_proto_rawDescOnce sync.Once
+	file_stocksv1_stocks_proto_rawDescData []byte
+)
+
+func file_stocksv1_stocks_proto_rawDescGZIP() []byte {
+	file_stocksv1_stocks_proto_rawDescOnce.Do(func() {
+		file_stocksv1_stocks_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stocksv1_stocks_proto_rawDesc), len(file_stocksv1_stocks_proto_rawDesc)))
+	})
+	return file_stocksv1_stocks_proto_rawDescData
+}
+
+var file_stocksv1_stocks_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_stocksv1_stocks_proto_goTypes = []any{
+	(*Stock)(nil),                   // 0: stocks.v1.Stock
+	(*Prediction)(nil),              // 1: stocks.v1.Prediction
+	(*PriceBar)(nil),                // 2: stocks.v1.PriceBar
+	(*ListStocksRequest)(nil),       // 3: stocks.v1.ListStocksRequest
+	(*ListStocksResponse)(nil),      // 4: stocks.v1.ListStocksResponse
+	(*GetPredictionsRequest)(nil),   // 5: stocks.v1.GetPredictionsRequest
+	(*GetPredictionsResponse)(nil),  // 6: stocks.v1.GetPredictionsResponse
+	(*GetPriceHistoryRequest)(nil),  // 7: stocks.v1.GetPriceHistoryRequest
+	(*GetPriceHistoryResponse)(nil), // 8: stocks.v1.GetPriceHistoryResponse
+	(*timestamppb.Timestamp)(nil),   // 9: google.protobuf.Timestamp
+}
+var file_stocksv1_stocks_proto_depIdxs = []int32{
+	9, // 0: stocks.v1.PriceBar.time:type_name -> google.protobuf.Timestamp
+	0, // 1: stocks.v1.ListStocksResponse.stocks:type_name -> stocks.v1.Stock
+	1, // 2: stocks.v1.GetPredictionsResponse.predictions:type_name -> stocks.v1.Prediction
+	9, // 3: stocks.v1.GetPriceHistoryRequest.from:type_name -> google.protobuf.Timestamp
+	9, // 4: stocks.v1.GetPriceHistoryRequest.to:type_name -> google.protobuf.Timestamp
+	2, // 5: stocks.v1.GetPriceHistoryResponse.bars:type_name -> stocks.v1.PriceBar
+	3, // 6: stocks.v1.StockService.ListStocks:input_type -> stocks.v1.ListStocksRequest
+	5, // 7: stocks.v1.StockService.GetPredictions:input_type -> stocks.v1.GetPredictionsRequest
+	7, // 8: stocks.v1.StockService.GetPriceHistory:input_type -> stocks.v1.GetPriceHistoryRequest
+	4, // 9: stocks.v1.StockService.ListStocks:output_type -> stocks.v1.ListStocksResponse
+	6, // 10: stocks.v1.StockService.GetPredictions:output_type -> stocks.v1.GetPredictionsResponse
+	8, // 11: stocks.v1.StockService.GetPriceHistory:output_type -> stocks.v1.GetPriceHistoryResponse
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_stocksv1_stocks_proto_init() }
+func file_stocksv1_stocks_proto_init() {
+	if File_stocksv1_stocks_proto != nil {
+		return
+	}
+	file_stocksv1_stocks_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stocksv1_stocks_proto_rawDesc), len(file_stocksv1_stocks_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stocksv1_stocks_proto_goTypes,
+		DependencyIndexes: file_stocksv1_stocks_proto_depIdxs,
+		MessageInfos:      file_stocksv1_stocks_proto_msgTypes,
+	}.Build()
+	File_stocksv1_stocks_proto = out.File
+	file_stocksv1_stocks_proto_goTypes = nil
+	file_stocksv1_stocks_proto_depIdxs = nil
+}
@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"frontend-backend/internal/storage"
+)
+
+// MessageStore — хранилище, в которое подсистема загрузки пишет сообщения
+type MessageStore interface {
+	SaveMessage(ctx context.Context, m storage.Message) (bool, error)
+}
+
+// Source — источник сообщений (Telegram Bot API, MTProto и т.п.).
+// Run блокируется до отмены ctx и передает каждое сообщение в handle.
+type Source interface {
+	Name() string
+	Run(ctx context.Context, handle func(context.Context, storage.Message) error) error
+}
+
+// Service запускает источники и сохраняет полученные сообщения
+type Service struct {
+	store   MessageStore
+	lag     *LagTracker
+	sources []Source
+}
+
+// NewService создает новый экземпляр Service; lag может быть nil
+func NewService(store MessageStore, lag *LagTracker, sources ...Source) *Service {
+	return &Service{store: store, lag: lag, sources: sources}
+}
+
+// Run запускает все источники и ждет их завершения
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, src := range s.sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			slog.Info("Запуск источника сообщений", "source", src.Name())
+			if err := src.Run(ctx, s.handle); err != nil && ctx.Err() == nil {
+				slog.Error("Источник сообщений остановлен с ошибкой", "source", src.Name(), "err", err)
+			}
+		}(src)
+	}
+	wg.Wait()
+}
+
+// handle сохраняет сообщение сразу, без буферизации, чтобы оно было
+// доступно в JOIN прогнозов как только появится соответствующий прогноз
+func (s *Service) handle(ctx context.Context, m storage.Message) error {
+	inserted, err := s.store.SaveMessage(ctx, m)
+	if err != nil {
+		return err
+	}
+	if inserted {
+		slog.Info("Сохранено сообщение", "telegram_id", m.TelegramID, "channel", m.Channel)
+	}
+	if s.lag != nil {
+		s.lag.Record(m.Channel, m.SentAt)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ChannelLag — состояние загрузки одного канала
+type ChannelLag struct {
+	Channel         string     `json:"channel"`
+	LastMessageAt   *time.Time `json:"last_message_at"`   // время отправки последнего сообщения
+	LastProcessedAt *time.Time `json:"last_processed_at"` // когда оно было сохранено
+	LagSeconds      *float64   `json:"lag_seconds"`       // now - last_message_at
+	Stale           bool       `json:"stale"`
+}
+
+type channelState struct {
+	lastMessageAt   time.Time
+	lastProcessedAt time.Time
+	alerted         bool
+}
+
+// LagTracker запоминает по каждому каналу время последнего успешно
+// сохраненного сообщения. Канал считается «молчащим» (stale), если от него
+// нет сообщений дольше staleAfter; ожидаемые каналы из конфигурации,
+// которые еще ничего не прислали, тоже считаются молчащими.
+type LagTracker struct {
+	staleAfter time.Duration
+	started    time.Time
+
+	mu       sync.Mutex
+	channels map[string]*channelState
+}
+
+// NewLagTracker создает трекер для ожидаемых каналов (@username или id)
+func NewLagTracker(channels []string, staleAfter time.Duration) *LagTracker {
+	t := &LagTracker{staleAfter: staleAfter, started: time.Now(), channels: map[string]*channelState{}}
+	for _, ch := range channels {
+		t.channels[NormalizeChannel(ch)] = &channelState{}
+	}
+	return t
+}
+
+// NormalizeChannel приводит имя канала к виду, в котором оно хранится в
+// messages.channel: @username или числовой id
+func NormalizeChannel(ch string) string {
+	ch = strings.TrimPrefix(ch, "@")
+	if _, err := strconv.ParseInt(ch, 10, 64); err == nil {
+		return ch
+	}
+	return "@" + ch
+}
+
+// Record отмечает успешно обработанное сообщение канала
+func (t *LagTracker) Record(channel string, sentAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.channels[channel]
+	if !ok {
+		st = &channelState{}
+		t.channels[channel] = st
+	}
+	if sentAt.After(st.lastMessageAt) {
+		st.lastMessageAt = sentAt
+	}
+	st.lastProcessedAt = time.Now()
+	metrics.IngestDelay.WithLabelValues(channel).Observe(st.lastProcessedAt.Sub(sentAt).Seconds())
+	if st.alerted {
+		slog.Info("Канал снова присылает сообщения", "channel", channel)
+		st.alerted = false
+	}
+}
+
+// Status возвращает состояние всех каналов, отсортированное по имени
+func (t *LagTracker) Status() []ChannelLag {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]ChannelLag, 0, len(t.channels))
+	for name, st := range t.channels {
+		out = append(out, t.lagLocked(name, st, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Channel < out[j].Channel })
+	return out
+}
+
+// lagLocked считает состояние канала. Вызывается под t.mu.
+func (t *LagTracker) lagLocked(name string, st *channelState, now time.Time) ChannelLag {
+	l := ChannelLag{Channel: name}
+	if st.lastMessageAt.IsZero() {
+		// Не было сообщений с момента запуска
+		l.Stale = t.staleAfter > 0 && now.Sub(t.started) > t.staleAfter
+		return l
+	}
+	msg, processed := st.lastMessageAt, st.lastProcessedAt
+	lag := now.Sub(msg).Seconds()
+	l.LastMessageAt, l.LastProcessedAt, l.LagSeconds = &msg, &processed, &lag
+	l.Stale = t.staleAfter > 0 && now.Sub(msg) > t.staleAfter
+	return l
+}
+
+// Watch раз в interval проверяет каналы и пишет в лог предупреждение, когда
+// канал становится молчащим. Блокируется до отмены ctx.
+func (t *LagTracker) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		t.mu.Lock()
+		for name, st := range t.channels {
+			if l := t.lagLocked(name, st, now); l.Stale && !st.alerted {
+				st.alerted = true
+				slog.Warn("От канала давно нет сообщений", "name", name, "stale_after", t.staleAfter)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+var (
+	lastMessageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "ingest", "last_message_timestamp_seconds"),
+		"Send time of the last successfully processed message per channel.",
+		[]string{"channel"}, nil)
+	lagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "ingest", "lag_seconds"),
+		"Time since the last successfully processed message per channel.",
+		[]string{"channel"}, nil)
+	staleDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "ingest", "channel_stale"),
+		"1 if the channel has not produced messages for longer than the stale window.",
+		[]string{"channel"}, nil)
+)
+
+// Describe реализует prometheus.Collector
+func (t *LagTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lastMessageDesc
+	ch <- lagDesc
+	ch <- staleDesc
+}
+
+// Collect реализует prometheus.Collector: значения считаются в момент опроса
+func (t *LagTracker) Collect(ch chan<- prometheus.Metric) {
+	for _, l := range t.Status() {
+		stale := 0.0
+		if l.Stale {
+			stale = 1
+		}
+		ch <- prometheus.MustNewConstMetric(staleDesc, prometheus.GaugeValue, stale, l.Channel)
+		if l.LastMessageAt != nil {
+			ch <- prometheus.MustNewConstMetric(lastMessageDesc, prometheus.GaugeValue, float64(l.LastMessageAt.Unix()), l.Channel)
+			ch <- prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, *l.LagSeconds, l.Channel)
+		}
+	}
+}
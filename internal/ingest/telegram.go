@@ -0,0 +1,174 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/requestid"
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/tracing"
+)
+
+const telegramAPIURL = "https://api.telegram.org"
+
+// TelegramBotSource получает сообщения каналов через Telegram Bot API
+// (long polling getUpdates). Бот должен быть добавлен в каналы.
+type TelegramBotSource struct {
+	token       string
+	channels    map[string]bool
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+	client      *http.Client
+	offset      int64
+}
+
+// NewTelegramBotSource создает источник для указанных каналов.
+// Канал задается как @username или числовой id чата (-100...).
+func NewTelegramBotSource(token string, channels []string, pollTimeout time.Duration) *TelegramBotSource {
+	allowed := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		allowed[strings.ToLower(strings.TrimPrefix(ch, "@"))] = true
+	}
+	return &TelegramBotSource{
+		token:       token,
+		channels:    allowed,
+		pollTimeout: pollTimeout,
+		retryDelay:  5 * time.Second,
+		client:      &http.Client{Timeout: pollTimeout + 10*time.Second, Transport: tracing.Transport(requestid.Transport(nil))},
+	}
+}
+
+// Name возвращает имя источника для логов
+func (t *TelegramBotSource) Name() string {
+	return "telegram-bot"
+}
+
+type telegramChat struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type telegramMessage struct {
+	MessageID int64        `json:"message_id"`
+	Date      int64        `json:"date"`
+	Text      string       `json:"text"`
+	Caption   string       `json:"caption"`
+	Chat      telegramChat `json:"chat"`
+}
+
+type telegramUpdate struct {
+	UpdateID    int64            `json:"update_id"`
+	ChannelPost *telegramMessage `json:"channel_post"`
+}
+
+type telegramResponse struct {
+	OK          bool             `json:"ok"`
+	Description string           `json:"description"`
+	Result      []telegramUpdate `json:"result"`
+}
+
+// Run опрашивает getUpdates до отмены ctx. Offset сдвигается только после
+// успешного сохранения, поэтому при ошибке БД сообщение будет получено снова.
+// Каждый опрос получает свой идентификатор запроса: он уходит в X-Request-ID
+// и в записи лога об обработке полученных сообщений.
+func (t *TelegramBotSource) Run(ctx context.Context, handle func(context.Context, storage.Message) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pollCtx := requestid.NewContext(ctx, requestid.New())
+		updates, err := t.getUpdates(pollCtx)
+		if err == nil {
+			err = t.process(pollCtx, updates, handle)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("Ошибка получения сообщений из Telegram, повтор", "err", err, "retry_delay", t.retryDelay)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(t.retryDelay):
+			}
+		}
+	}
+}
+
+// process передает сообщения отслеживаемых каналов в handle
+func (t *TelegramBotSource) process(ctx context.Context, updates []telegramUpdate, handle func(context.Context, storage.Message) error) error {
+	for _, u := range updates {
+		if post := u.ChannelPost; post != nil && t.watched(post.Chat) {
+			text := post.Text
+			if text == "" {
+				text = post.Caption
+			}
+			msg := storage.Message{
+				TelegramID: post.MessageID,
+				Channel:    channelName(post.Chat),
+				Text:       text,
+				SentAt:     time.Unix(post.Date, 0).UTC(),
+			}
+			if err := handle(ctx, msg); err != nil {
+				return fmt.Errorf("error handling update %d: %w", u.UpdateID, err)
+			}
+		}
+		t.offset = u.UpdateID + 1
+	}
+	return nil
+}
+
+// watched проверяет, что канал указан в конфигурации
+func (t *TelegramBotSource) watched(chat telegramChat) bool {
+	return t.channels[strings.ToLower(chat.Username)] || t.channels[strconv.FormatInt(chat.ID, 10)]
+}
+
+func (t *TelegramBotSource) getUpdates(ctx context.Context) ([]telegramUpdate, error) {
+	params := url.Values{}
+	params.Set("offset", strconv.FormatInt(t.offset, 10))
+	params.Set("timeout", strconv.Itoa(int(t.pollTimeout.Seconds())))
+	params.Set("allowed_updates", `["channel_post"]`)
+
+	endpoint := fmt.Sprintf("%s/bot%s/getUpdates?%s", telegramAPIURL, t.token, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating getUpdates request: %w", err)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Не логируем URL: он содержит токен бота
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("error calling getUpdates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding getUpdates response: %w", err)
+	}
+	if !body.OK {
+		return nil, fmt.Errorf("getUpdates failed: %s", body.Description)
+	}
+	return body.Result, nil
+}
+
+// channelName возвращает @username канала или его числовой id
+func channelName(chat telegramChat) string {
+	if chat.Username != "" {
+		return "@" + chat.Username
+	}
+	return strconv.FormatInt(chat.ID, 10)
+}
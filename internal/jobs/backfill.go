@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/storage"
+)
+
+// BackfillStore — хранилище для массового проставления исходов
+type BackfillStore interface {
+	GetStockPriceHistory(ctx context.Context, ticker string) ([]storage.StockPriceHistory, error)
+	ListUnevaluatedPredictions(ctx context.Context, now time.Time) ([]storage.UnevaluatedPrediction, error)
+	SetPredictionOutcomes(ctx context.Context, updates []storage.OutcomeUpdate) error
+}
+
+// BackfillOptions задает параллельность, размер пачки, файл контрольной точки
+// и логгер
+type BackfillOptions struct {
+	Workers    int
+	BatchSize  int
+	Checkpoint string       // пустой — без контрольной точки
+	Logger     *slog.Logger // nil — slog.Default()
+}
+
+// TickerProgress — итог обработки тикера в контрольной точке
+type TickerProgress struct {
+	Evaluated int    `json:"evaluated"`
+	Skipped   int    `json:"skipped"` // не хватило истории цен
+	Error     string `json:"error,omitempty"`
+}
+
+// BackfillCheckpoint — состояние прогона; тикеры из Done, все прогнозы которых
+// оценены, при повторном запуске пропускаются
+type BackfillCheckpoint struct {
+	StartedAt time.Time                 `json:"started_at"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+	Done      map[string]TickerProgress `json:"done"`
+}
+
+// BackfillStats — итог прогона
+type BackfillStats struct {
+	Tickers   int
+	Resumed   int // тикеры, пропущенные по контрольной точке
+	Evaluated int
+	Skipped   int
+	Failed    int // тикеры с ошибкой, будут обработаны при повторном запуске
+}
+
+// BackfillOutcomes проставляет исходы всем прогнозам с истекшим горизонтом:
+// тикеры обрабатываются параллельно, исходы пишутся пачками. После каждого
+// тикера контрольная точка сохраняется, поэтому прерванный прогон
+// продолжается с того же места. В точку попадают только тикеры, все прогнозы
+// которых оценены: тикер с ошибкой или с прогнозами, которым не хватило
+// истории цен, обрабатывается снова при следующем запуске.
+func BackfillOutcomes(ctx context.Context, store BackfillStore, opts BackfillOptions) (BackfillStats, error) {
+	var stats BackfillStats
+	log := opts.Logger
+	if log == nil {
+		log = slog.Default()
+	}
+	cp, err := loadCheckpoint(opts.Checkpoint, log)
+	if err != nil {
+		return stats, err
+	}
+
+	pending, err := store.ListUnevaluatedPredictions(ctx, time.Now())
+	if err != nil {
+		return stats, err
+	}
+	byTicker := map[string][]storage.UnevaluatedPrediction{}
+	var tickers []string
+	for _, u := range pending {
+		if _, ok := byTicker[u.Ticker]; !ok {
+			tickers = append(tickers, u.Ticker)
+		}
+		byTicker[u.Ticker] = append(byTicker[u.Ticker], u)
+	}
+	log.Info("Найдены прогнозы без исхода с истекшим горизонтом", "pending", len(pending), "tickers", len(tickers))
+
+	work := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ticker := range work {
+				p := backfillTicker(ctx, store, byTicker[ticker], max(opts.BatchSize, 1))
+				mu.Lock()
+				stats.Evaluated += p.Evaluated
+				stats.Skipped += p.Skipped
+				switch {
+				case p.Error != "":
+					stats.Failed++
+					log.Error("Ошибка при проставлении исходов", "ticker", ticker, "err", p.Error)
+				case p.Skipped > 0:
+					log.Warn("Не всем прогнозам хватило истории цен, тикер будет обработан повторно", "ticker", ticker, "skipped", p.Skipped)
+				default:
+					cp.Done[ticker] = p
+					if err := saveCheckpoint(opts.Checkpoint, cp); err != nil {
+						log.Error("Не удалось сохранить контрольную точку", "err", err)
+					}
+				}
+				mu.Unlock()
+				log.Info("Исходы прогнозов проставлены", "ticker", ticker, "evaluated", p.Evaluated, "skipped", p.Skipped)
+			}
+		}()
+	}
+
+feed:
+	for _, ticker := range tickers {
+		if _, done := cp.Done[ticker]; done {
+			stats.Resumed++
+			continue
+		}
+		stats.Tickers++
+		select {
+		case work <- ticker:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return stats, ctx.Err()
+}
+
+// backfillTicker оценивает прогнозы одного тикера по истории цен
+func backfillTicker(ctx context.Context, store BackfillStore, preds []storage.UnevaluatedPrediction, batchSize int) TickerProgress {
+	var p TickerProgress
+	history, err := store.GetStockPriceHistory(ctx, preds[0].Ticker)
+	if errors.Is(err, storage.ErrNoPriceHistory) {
+		p.Skipped = len(preds)
+		return p
+	} else if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+
+	batch := make([]storage.OutcomeUpdate, 0, batchSize)
+	flush := func() error {
+		if err := store.SetPredictionOutcomes(ctx, batch); err != nil {
+			return err
+		}
+		p.Evaluated += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for _, u := range preds {
+		o, ok := accuracy.EvaluateOutcome(u.Prediction, history)
+		if !ok {
+			p.Skipped++
+			continue
+		}
+		batch = append(batch, storage.OutcomeUpdate{
+			Channel:        u.Prediction.Channel,
+			MessageID:      u.Prediction.MessageID,
+			StockID:        u.Prediction.StockID,
+			Outcome:        o.Outcome,
+			RealizedReturn: o.RealizedReturn,
+		})
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				p.Error = err.Error()
+				return p
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		p.Error = err.Error()
+	}
+	return p
+}
+
+func loadCheckpoint(path string, log *slog.Logger) (*BackfillCheckpoint, error) {
+	cp := &BackfillCheckpoint{StartedAt: time.Now().UTC(), Done: map[string]TickerProgress{}}
+	if path == "" {
+		return cp, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("error decoding checkpoint %s: %w", path, err)
+	}
+	if cp.Done == nil {
+		cp.Done = map[string]TickerProgress{}
+	}
+	log.Info("Продолжаем прогон с контрольной точки", "started_at", cp.StartedAt.Format(time.RFC3339), "done", len(cp.Done))
+	return cp, nil
+}
+
+// saveCheckpoint атомарно записывает контрольную точку (временный файл и rename)
+func saveCheckpoint(path string, cp *BackfillCheckpoint) error {
+	if path == "" {
+		return nil
+	}
+	cp.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving checkpoint to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// EODStore — хранилище для расчета итогов дня
+type EODStore interface {
+	GetStocks(ctx context.Context) ([]storage.Stock, error)
+	GetStockPriceHistory(ctx context.Context, ticker string) ([]storage.StockPriceHistory, error)
+	CountPredictionsOn(ctx context.Context, date time.Time) (map[int64]int, error)
+	UpsertEODSummary(ctx context.Context, e storage.EODSummary) error
+}
+
+// EODSummaries возвращает задачу, пересчитывающую итоги последнего
+// торгового дня по каждой акции; nil logger — slog.Default()
+func EODSummaries(store EODStore, interval time.Duration, logger *slog.Logger) Job {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return Job{
+		Name:     "eod-summaries",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			return computeEODSummaries(ctx, store, logger)
+		},
+	}
+}
+
+func computeEODSummaries(ctx context.Context, store EODStore, logger *slog.Logger) error {
+	stocks, err := store.GetStocks(ctx)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]map[int64]int{}
+	var saved int
+	for _, st := range stocks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		history, err := store.GetStockPriceHistory(ctx, st.Ticker)
+		if err != nil {
+			// У части акций нет файла истории: это не ошибка задачи
+			logger.Warn("Пропускаем итоги дня", "ticker", st.Ticker, "err", err)
+			continue
+		}
+		e, ok := storage.ComputeEODSummary(history, time.Time{})
+		if !ok {
+			continue
+		}
+		e.StockID = st.ID
+
+		if _, ok := counts[e.Date]; !ok {
+			day, _ := time.Parse("2006-01-02", e.Date)
+			if counts[e.Date], err = store.CountPredictionsOn(ctx, day); err != nil {
+				return err
+			}
+		}
+		e.NewPredictions = counts[e.Date][st.ID]
+
+		if err := store.UpsertEODSummary(ctx, e); err != nil {
+			return err
+		}
+		saved++
+	}
+
+	if saved == 0 && len(stocks) > 0 {
+		return errors.New("no EOD summaries computed")
+	}
+	logger.Info("Сохранены итоги дня", "saved", saved, "stocks", len(stocks))
+	return nil
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/metrics"
+)
+
+// Job — периодическая фоновая задача
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Status — состояние задачи для /admin/jobs
+type Status struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	Running             bool       `json:"running"`
+	LastRun             *time.Time `json:"last_run"`
+	LastSuccess         *time.Time `json:"last_success"`
+	LastDuration        string     `json:"last_duration,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	NextRun             *time.Time `json:"next_run"`
+}
+
+// Runner запускает задачи с их интервалами; первый запуск — сразу после старта
+type Runner struct {
+	jobs []Job
+	log  *slog.Logger
+
+	mu     sync.Mutex
+	status map[string]*Status
+}
+
+// NewRunner создает новый экземпляр Runner; nil logger — slog.Default()
+func NewRunner(logger *slog.Logger) *Runner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Runner{log: logger, status: make(map[string]*Status)}
+}
+
+// Add добавляет задачу
+func (r *Runner) Add(j Job) {
+	r.jobs = append(r.jobs, j)
+	r.mu.Lock()
+	r.status[j.Name] = &Status{Name: j.Name, Interval: j.Interval.String()}
+	r.mu.Unlock()
+	metrics.JobConsecutiveFailures.WithLabelValues(j.Name).Set(0)
+}
+
+// Run выполняет задачи до отмены ctx
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range r.jobs {
+		wg.Add(1)
+		go func(j Job) {
+			defer wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+}
+
+// Status возвращает состояние всех задач, отсортированное по имени
+func (r *Runner) Status() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Status, 0, len(r.status))
+	for _, st := range r.status {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (r *Runner) loop(ctx context.Context, j Job) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	r.log.Info("Запуск задачи с интервалом", "name", j.Name, "interval", j.Interval)
+	for {
+		r.started(j.Name)
+		start := time.Now()
+		err := j.Run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		r.finished(j, start, err)
+		if err != nil {
+			r.log.Error("Задача завершилась с ошибкой", "name", j.Name, "err", err)
+		} else {
+			r.log.Info("Задача выполнена", "name", j.Name, "duration", time.Since(start).Round(time.Millisecond))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) started(name string) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.status[name]
+	st.Running = true
+	st.LastRun = &now
+}
+
+// finished записывает результат запуска в состояние и метрики
+func (r *Runner) finished(j Job, start time.Time, err error) {
+	now := time.Now()
+	duration := now.Sub(start)
+	next := start.Add(j.Interval)
+
+	r.mu.Lock()
+	st := r.status[j.Name]
+	st.Running = false
+	st.LastDuration = duration.Round(time.Millisecond).String()
+	st.NextRun = &next
+	if err != nil {
+		st.LastError = err.Error()
+		st.ConsecutiveFailures++
+	} else {
+		st.LastError = ""
+		st.ConsecutiveFailures = 0
+		st.LastSuccess = &now
+	}
+	failures := st.ConsecutiveFailures
+	r.mu.Unlock()
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	} else {
+		metrics.JobLastSuccess.WithLabelValues(j.Name).Set(float64(now.Unix()))
+	}
+	metrics.JobDuration.WithLabelValues(j.Name, result).Observe(duration.Seconds())
+	metrics.JobConsecutiveFailures.WithLabelValues(j.Name).Set(float64(failures))
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/storage"
+)
+
+// OutcomeStore — хранилище для проставления исходов прогнозов
+type OutcomeStore interface {
+	GetStockPriceHistory(ctx context.Context, ticker string) ([]storage.StockPriceHistory, error)
+	ListUnevaluatedPredictions(ctx context.Context, now time.Time) ([]storage.UnevaluatedPrediction, error)
+	SetPredictionOutcome(ctx context.Context, channel string, messageID, stockID int64, outcome string, realizedReturn float64) error
+}
+
+// OutcomeNotifier получает каждый проставленный исход (например, вебхук для
+// ML). NotifyOutcome вызывается внутри цикла по прогнозам и не должен ждать
+// доставки.
+type OutcomeNotifier interface {
+	NotifyOutcome(ctx context.Context, u storage.UnevaluatedPrediction, o accuracy.Outcome) error
+}
+
+// Outcomes возвращает задачу, проставляющую исход прогнозам с истекшим
+// горизонтом. notifier может быть nil.
+func Outcomes(store OutcomeStore, notifier OutcomeNotifier, interval time.Duration, logger *slog.Logger) Job {
+	return Job{
+		Name:     "prediction-outcomes",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := EvaluateOutcomes(ctx, store, notifier, time.Now(), logger)
+			return err
+		},
+	}
+}
+
+// EvaluateOutcomes проставляет исходы прогнозам, горизонт которых истек к now,
+// и возвращает число оцененных прогнозов. Прогнозы, для которых не хватает
+// истории цен, остаются без исхода до следующего запуска. Ошибка уведомления
+// не прерывает задачу: исход уже сохранен в БД. nil logger — slog.Default().
+func EvaluateOutcomes(ctx context.Context, store OutcomeStore, notifier OutcomeNotifier, now time.Time, logger *slog.Logger) (int, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	pending, err := store.ListUnevaluatedPredictions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var evaluated int
+	var history []storage.StockPriceHistory
+	ticker := ""
+	for _, u := range pending {
+		if ctx.Err() != nil {
+			return evaluated, ctx.Err()
+		}
+		if u.Ticker != ticker {
+			ticker = u.Ticker
+			if history, err = store.GetStockPriceHistory(ctx, ticker); err != nil {
+				logger.Warn("Пропускаем исходы прогнозов", "ticker", ticker, "err", err)
+				history = nil
+			}
+		}
+
+		o, ok := accuracy.EvaluateOutcome(u.Prediction, history)
+		if !ok {
+			continue
+		}
+		if err := store.SetPredictionOutcome(ctx, u.Prediction.Channel, u.Prediction.MessageID, u.Prediction.StockID, o.Outcome, o.RealizedReturn); err != nil {
+			return evaluated, err
+		}
+		evaluated++
+
+		if notifier != nil {
+			if err := notifier.NotifyOutcome(ctx, u, o); err != nil {
+				logger.Warn("Исход прогноза не поставлен в очередь уведомлений", "channel", u.Prediction.Channel, "message_id", u.Prediction.MessageID, "stock_id", u.Prediction.StockID, "err", err)
+			}
+		}
+	}
+
+	logger.Info("Проставлены исходы прогнозов с истекшим горизонтом", "evaluated", evaluated, "pending", len(pending))
+	return evaluated, nil
+}
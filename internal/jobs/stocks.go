@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"frontend-backend/internal/events"
+	"frontend-backend/internal/storage"
+)
+
+// StockLister — источник списка акций
+type StockLister interface {
+	GetStocks(ctx context.Context) ([]storage.Stock, error)
+}
+
+// StockEvents возвращает задачу, которая публикует stock.created для акций,
+// появившихся со времени прошлого запуска. Первый запуск только запоминает
+// текущий список.
+func StockEvents(store StockLister, pub events.Publisher, interval time.Duration) Job {
+	var known map[int64]bool
+	return Job{
+		Name:     "stock-events",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			stocks, err := store.GetStocks(ctx)
+			if err != nil {
+				return err
+			}
+			seen := make(map[int64]bool, len(stocks))
+			for _, st := range stocks {
+				seen[st.ID] = true
+				if known != nil && !known[st.ID] {
+					pub.Publish(events.Event{
+						Type:   events.TypeStockCreated,
+						Ticker: st.Ticker,
+						Time:   time.Now().UTC(),
+						Data:   st,
+					})
+				}
+			}
+			known = seen
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+// Package logging создает структурированный логгер сервиса (log/slog) по
+// уровню и формату из конфигурации.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"frontend-backend/internal/requestid"
+)
+
+const (
+	// FormatText — строки key=value, удобные для чтения в терминале
+	FormatText = "text"
+	// FormatJSON — JSON-объект на строку для сборщиков логов
+	FormatJSON = "json"
+)
+
+// ParseLevel разбирает уровень: debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// New создает логгер, пишущий в w записи не ниже level в формате format
+// (text или json). Уровень можно менять на лету, передав *slog.LevelVar.
+// К записям с контекстом запроса добавляется request_id.
+func New(w io.Writer, level slog.Leveler, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		return slog.New(contextHandler{slog.NewTextHandler(w, opts)}), nil
+	case FormatJSON:
+		return slog.New(contextHandler{slog.NewJSONHandler(w, opts)}), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected %q or %q)", format, FormatText, FormatJSON)
+	}
+}
+
+// contextHandler добавляет к записям request_id из контекста, если запись
+// сделана с контекстом запроса (InfoContext, LogAttrs и т.п.)
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,112 @@
+package marketdata
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Пределы DemandTracker: тикеры, спрос на которые затух ниже demandMinScore,
+// забываются, а больше demandMaxTickers тикеров не хранится
+const (
+	demandMinScore   = 0.01
+	demandMaxTickers = 10_000
+)
+
+// DemandTracker считает спрос пользователей на тикеры. Счетчик затухает
+// экспоненциально с периодом полураспада halfLife, поэтому недавние запросы
+// весят больше старых.
+type DemandTracker struct {
+	halfLife time.Duration
+
+	mu     sync.Mutex
+	scores map[string]demandScore
+}
+
+type demandScore struct {
+	value   float64
+	updated time.Time
+}
+
+// NewDemandTracker создает новый экземпляр DemandTracker
+func NewDemandTracker(halfLife time.Duration) *DemandTracker {
+	return &DemandTracker{halfLife: halfLife, scores: make(map[string]demandScore)}
+}
+
+// Record учитывает один запрос данных по тикеру. Вызывается только для
+// существующих тикеров, иначе перебор выдуманных тикеров раздувает счетчики.
+func (d *DemandTracker) Record(ticker string) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sc, ok := d.scores[ticker]
+	if !ok && len(d.scores) >= demandMaxTickers {
+		d.evict(now)
+	}
+	d.scores[ticker] = demandScore{value: d.decay(sc, now) + 1, updated: now}
+}
+
+// evict забывает тикеры с затухшим спросом, а если их нет — тикер с
+// наименьшим спросом, чтобы освободить место под новый
+func (d *DemandTracker) evict(now time.Time) {
+	weakest, weakestScore := "", math.Inf(1)
+	for ticker, sc := range d.scores {
+		v := d.decay(sc, now)
+		if v < demandMinScore {
+			delete(d.scores, ticker)
+			continue
+		}
+		if v < weakestScore {
+			weakest, weakestScore = ticker, v
+		}
+	}
+	if len(d.scores) >= demandMaxTickers {
+		delete(d.scores, weakest)
+	}
+}
+
+// Score возвращает текущий спрос на тикер
+func (d *DemandTracker) Score(ticker string) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.decay(d.scores[ticker], time.Now())
+}
+
+// Snapshot возвращает текущий спрос по всем тикерам
+func (d *DemandTracker) Snapshot() map[string]float64 {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make(map[string]float64, len(d.scores))
+	for ticker, sc := range d.scores {
+		out[ticker] = d.decay(sc, now)
+	}
+	return out
+}
+
+func (d *DemandTracker) decay(sc demandScore, now time.Time) float64 {
+	if sc.updated.IsZero() || d.halfLife <= 0 {
+		return sc.value
+	}
+	elapsed := now.Sub(sc.updated)
+	return sc.value * math.Pow(0.5, float64(elapsed)/float64(d.halfLife))
+}
+
+// Restore восстанавливает спрос из снимка, сделанного в момент at.
+// Дальше значения затухают так, как если бы процесс не перезапускался;
+// затухший спрос не восстанавливается.
+func (d *DemandTracker) Restore(scores map[string]float64, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for ticker, value := range scores {
+		sc := demandScore{value: value, updated: at}
+		if d.decay(sc, now) < demandMinScore {
+			continue
+		}
+		if _, ok := d.scores[ticker]; !ok && len(d.scores) >= demandMaxTickers {
+			d.evict(now)
+		}
+		d.scores[ticker] = sc
+	}
+}
@@ -0,0 +1,129 @@
+package marketdata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// ImportResult — итог импорта одного CSV-файла истории цен
+type ImportResult struct {
+	Ticker  string
+	File    string
+	Rows    int // строк со свечами в файле
+	Skipped int // строк с неверной датой или ценой
+	Total   int // свечей в истории после объединения
+}
+
+// ImportPrices объединяет CSV-файлы истории цен из srcDir с историей в
+// dataDir. Файлы называются <TICKER>_D1.csv или <TICKER>.csv и имеют тот же
+// формат, что пишет MOEXProvider; свечи из srcDir заменяют свечи с тем же
+// временем. Строки с неверной датой или ценой пропускаются.
+func ImportPrices(srcDir, dataDir string) ([]ImportResult, error) {
+	files, err := filepath.Glob(filepath.Join(srcDir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no CSV files in %s", srcDir)
+	}
+	sort.Strings(files)
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", dataDir, err)
+	}
+
+	var results []ImportResult
+	for _, file := range files {
+		res, err := importPriceFile(file, dataDir)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func importPriceFile(file, dataDir string) (ImportResult, error) {
+	name := strings.TrimSuffix(filepath.Base(file), ".csv")
+	ticker := strings.TrimSuffix(name, "_D1")
+	res := ImportResult{Ticker: ticker, File: file}
+	dst, err := storage.PriceHistoryPath(dataDir, ticker)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", file, err)
+	}
+	res.Ticker = strings.TrimSuffix(filepath.Base(dst), "_D1.csv")
+
+	incoming, err := readCandles(file)
+	if err != nil {
+		return res, err
+	}
+	existing, err := readCandles(dst)
+	if err != nil {
+		return res, err
+	}
+	for key, record := range incoming {
+		res.Rows++
+		if !validCandle(record) {
+			res.Skipped++
+			continue
+		}
+		existing[key] = record
+	}
+	res.Total = len(existing)
+	if res.Rows == res.Skipped {
+		return res, nil
+	}
+	return res, writeCandles(dst, existing)
+}
+
+// validCandle проверяет поля, которые читает GetStockPriceHistory
+func validCandle(record []string) bool {
+	if _, err := time.Parse(csvTimeLayout, record[0]); err != nil {
+		return false
+	}
+	_, err := strconv.ParseFloat(record[4], 64)
+	return err == nil
+}
+
+// Candle — дневная свеча истории цен
+type Candle struct {
+	Time                   time.Time
+	Open, High, Low, Close float64
+	Volume                 int64
+}
+
+// WriteCandles объединяет свечи с историей тикера в dataDir так же, как
+// ImportPrices, и возвращает число свечей в истории
+func WriteCandles(dataDir, ticker string, candles []Candle) (int, error) {
+	dst, err := storage.PriceHistoryPath(dataDir, ticker)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return 0, fmt.Errorf("error creating %s: %w", dataDir, err)
+	}
+	existing, err := readCandles(dst)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range candles {
+		day := c.Time.UTC().Format(csvTimeLayout)
+		existing[day] = []string{
+			day,
+			strconv.FormatFloat(c.Open, 'f', -1, 64),
+			strconv.FormatFloat(c.High, 'f', -1, 64),
+			strconv.FormatFloat(c.Low, 'f', -1, 64),
+			strconv.FormatFloat(c.Close, 'f', -1, 64),
+			"0",
+			"0",
+			strconv.FormatInt(c.Volume, 10),
+		}
+	}
+	return len(existing), writeCandles(dst, existing)
+}
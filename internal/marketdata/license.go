@@ -0,0 +1,91 @@
+package marketdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Классы лицензий на данные о ценах
+const (
+	LicenseOpen        = "open"        // без ограничений
+	LicenseAttribution = "attribution" // ссылка на поставщика в ответах и выгрузках
+	LicenseNoExport    = "no_export"   // ссылка обязательна, выгрузка в файлы запрещена
+)
+
+// License — условия, на которых поставщик отдает котировки тикера
+type License struct {
+	Source      string `json:"Source"`
+	Name        string `json:"License,omitempty"`
+	URL         string `json:"URL,omitempty"`
+	Attribution string `json:"Attribution,omitempty"`
+	Class       string `json:"Class"`
+}
+
+// RequiresAttribution сообщает, что ответы с ценами должны ссылаться на поставщика
+func (l License) RequiresAttribution() bool {
+	return l.Class == LicenseAttribution || l.Class == LicenseNoExport
+}
+
+// AllowsExport сообщает, что цены можно отдавать файлом (csv, xlsx)
+func (l License) AllowsExport() bool {
+	return l.Class != LicenseNoExport
+}
+
+// Text — строка ссылки на поставщика: Attribution или «Source (License)»
+func (l License) Text() string {
+	if l.Attribution != "" {
+		return l.Attribution
+	}
+	if l.Name != "" {
+		return fmt.Sprintf("%s (%s)", l.Source, l.Name)
+	}
+	return l.Source
+}
+
+// Licenses хранит лицензию по умолчанию и переопределения по тикерам
+type Licenses struct {
+	def      License
+	byTicker map[string]License
+}
+
+// NewLicenses создает реестр с лицензией def для всех тикеров.
+// Пустой класс означает LicenseOpen.
+func NewLicenses(def License) (*Licenses, error) {
+	if err := normalizeLicense(&def); err != nil {
+		return nil, err
+	}
+	return &Licenses{def: def, byTicker: make(map[string]License)}, nil
+}
+
+// Set задает лицензию для перечисленных тикеров
+func (l *Licenses) Set(tickers []string, lic License) error {
+	if err := normalizeLicense(&lic); err != nil {
+		return err
+	}
+	for _, t := range tickers {
+		l.byTicker[strings.ToUpper(strings.TrimSpace(t))] = lic
+	}
+	return nil
+}
+
+// For возвращает лицензию на котировки тикера
+func (l *Licenses) For(ticker string) License {
+	if lic, ok := l.byTicker[strings.ToUpper(ticker)]; ok {
+		return lic
+	}
+	return l.def
+}
+
+func normalizeLicense(lic *License) error {
+	switch lic.Class {
+	case "":
+		lic.Class = LicenseOpen
+	case LicenseOpen, LicenseAttribution, LicenseNoExport:
+	default:
+		return fmt.Errorf("unknown license class %q (expected %s, %s or %s)", lic.Class, LicenseOpen, LicenseAttribution, LicenseNoExport)
+	}
+	if lic.RequiresAttribution() && lic.Source == "" && lic.Attribution == "" {
+		return fmt.Errorf("license class %s requires source or attribution", lic.Class)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/requestid"
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/tracing"
+)
+
+const moexISSURL = "https://iss.moex.com/iss/engines/stock/markets/shares/boards/TQBR/securities"
+
+// csvTimeLayout — формат времени в CSV-файлах истории цен
+const csvTimeLayout = "2006.01.02 15:04:05"
+
+var csvHeader = []string{"Time", "Open", "High", "Low", "Close", "TickVolume", "Spread", "RealVolume"}
+
+// MOEXProvider загружает дневные свечи из MOEX ISS и дописывает их
+// в CSV-файлы истории цен (<dataDir>/<TICKER>_D1.csv)
+type MOEXProvider struct {
+	dataDir string
+	client  *http.Client
+}
+
+// NewMOEXProvider создает новый экземпляр MOEXProvider
+func NewMOEXProvider(dataDir string) *MOEXProvider {
+	return &MOEXProvider{dataDir: dataDir, client: &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(requestid.Transport(nil))}}
+}
+
+// Name возвращает имя поставщика для логов
+func (p *MOEXProvider) Name() string {
+	return "moex"
+}
+
+type moexCandlesResponse struct {
+	Candles struct {
+		Columns []string `json:"columns"`
+		Data    [][]any  `json:"data"`
+	} `json:"candles"`
+}
+
+// Refresh загружает последние свечи тикера и объединяет их с файлом истории
+func (p *MOEXProvider) Refresh(ctx context.Context, ticker string) error {
+	path, err := storage.PriceHistoryPath(p.dataDir, ticker)
+	if err != nil {
+		return err
+	}
+	existing, err := readCandles(path)
+	if err != nil {
+		return err
+	}
+
+	// Для нового файла загружаем год истории, для существующего — последние
+	// две недели, чтобы поправить незакрытые свечи
+	from := time.Now().AddDate(-1, 0, 0)
+	if len(existing) > 0 {
+		from = time.Now().AddDate(0, 0, -14)
+	}
+
+	fetched, err := p.fetch(ctx, ticker, from)
+	if err != nil {
+		return err
+	}
+	for k, v := range fetched {
+		existing[k] = v
+	}
+	return writeCandles(path, existing)
+}
+
+func (p *MOEXProvider) fetch(ctx context.Context, ticker string, from time.Time) (map[string][]string, error) {
+	url := fmt.Sprintf("%s/%s/candles.json?interval=24&iss.meta=off&from=%s", moexISSURL, ticker, from.Format("2006-01-02"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating moex request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling moex iss: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moex iss returned status %d", resp.StatusCode)
+	}
+
+	var body moexCandlesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding moex response: %w", err)
+	}
+
+	col := make(map[string]int, len(body.Candles.Columns))
+	for i, c := range body.Candles.Columns {
+		col[c] = i
+	}
+	for _, name := range []string{"open", "close", "high", "low", "volume", "begin"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("moex response has no %q column", name)
+		}
+	}
+
+	out := make(map[string][]string, len(body.Candles.Data))
+	for _, row := range body.Candles.Data {
+		begin, _ := row[col["begin"]].(string)
+		t, err := time.Parse("2006-01-02 15:04:05", begin)
+		if err != nil {
+			continue
+		}
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Format(csvTimeLayout)
+		out[day] = []string{
+			day,
+			formatNumber(row[col["open"]]),
+			formatNumber(row[col["high"]]),
+			formatNumber(row[col["low"]]),
+			formatNumber(row[col["close"]]),
+			"0",
+			"0",
+			formatNumber(row[col["volume"]]),
+		}
+	}
+	return out, nil
+}
+
+// readCandles читает CSV-файл истории в map по времени свечи
+func readCandles(path string) (map[string][]string, error) {
+	out := map[string][]string{}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return out, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error opening price history file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		metrics.CSVParseFailures.WithLabelValues("moex_candles", "read").Inc()
+		return nil, fmt.Errorf("error reading CSV file %s: %w", path, err)
+	}
+	for i, record := range records {
+		if i == 0 && record[0] == csvHeader[0] {
+			continue
+		}
+		if len(record) < len(csvHeader) {
+			metrics.CSVParseFailures.WithLabelValues("moex_candles", "short_row").Inc()
+			continue
+		}
+		out[record[0]] = record
+	}
+	return out, nil
+}
+
+// writeCandles атомарно перезаписывает файл истории, от новых свечей к старым
+func writeCandles(path string, candles map[string][]string) error {
+	keys := make([]string, 0, len(candles))
+	for k := range candles {
+		keys = append(keys, k)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temp file for %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := csv.NewWriter(tmp)
+	w.Write(csvHeader)
+	for _, k := range keys {
+		w.Write(candles[k])
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func formatNumber(v any) string {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case string:
+		return n
+	default:
+		return "0"
+	}
+}
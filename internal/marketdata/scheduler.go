@@ -0,0 +1,155 @@
+package marketdata
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Provider загружает свежие котировки тикера у внешнего поставщика.
+// Каждый вызов расходует один запрос квоты.
+type Provider interface {
+	Name() string
+	Refresh(ctx context.Context, ticker string) error
+}
+
+// TickerSource возвращает список тикеров, которые нужно обновлять
+type TickerSource func() ([]string, error)
+
+// SchedulerOptions задает квоту и интервалы обновления
+type SchedulerOptions struct {
+	// Quota запросов к поставщику за QuotaPeriod
+	Quota       int
+	QuotaPeriod time.Duration
+	// Tick — как часто планировщик выбирает тикеры для обновления
+	Tick time.Duration
+	// HotInterval — интервал обновления самых востребованных тикеров,
+	// DormantInterval — тикеров, которые никто не запрашивает
+	HotInterval     time.Duration
+	DormantInterval time.Duration
+}
+
+// Scheduler обновляет котировки в пределах квоты поставщика. Квота
+// расходуется равномерно (token bucket), а тикеры обновляются тем чаще,
+// чем выше на них спрос.
+type Scheduler struct {
+	provider Provider
+	demand   *DemandTracker
+	tickers  TickerSource
+	opts     SchedulerOptions
+
+	tokens      float64
+	lastFetched map[string]time.Time
+	onRefresh   func(ctx context.Context, ticker string)
+}
+
+// NewScheduler создает новый экземпляр Scheduler
+func NewScheduler(provider Provider, demand *DemandTracker, tickers TickerSource, opts SchedulerOptions) *Scheduler {
+	return &Scheduler{
+		provider:    provider,
+		demand:      demand,
+		tickers:     tickers,
+		opts:        opts,
+		lastFetched: make(map[string]time.Time),
+	}
+}
+
+// OnRefresh задает функцию, вызываемую после успешного обновления тикера
+func (s *Scheduler) OnRefresh(fn func(ctx context.Context, ticker string)) {
+	s.onRefresh = fn
+}
+
+// Run выполняет обновления до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Tick)
+	defer ticker.Stop()
+
+	slog.Info("Запуск планировщика котировок", "provider", s.provider.Name(), "quota", s.opts.Quota, "quota_period", s.opts.QuotaPeriod)
+	for {
+		s.runOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce пополняет бюджет запросов и обновляет самые приоритетные тикеры
+func (s *Scheduler) runOnce(ctx context.Context) {
+	perTick := float64(s.opts.Quota) * float64(s.opts.Tick) / float64(s.opts.QuotaPeriod)
+	// Неизрасходованный бюджет копится, но не больше, чем на один интервал
+	// горячих тикеров, чтобы после простоя не выбрать квоту залпом
+	maxTokens := perTick * float64(s.opts.HotInterval) / float64(s.opts.Tick)
+	s.tokens = min(s.tokens+perTick, max(maxTokens, 1))
+
+	tickers, err := s.tickers()
+	if err != nil {
+		slog.Error("Ошибка получения списка тикеров для обновления котировок", "err", err)
+		return
+	}
+
+	for _, t := range s.due(tickers, time.Now()) {
+		if s.tokens < 1 || ctx.Err() != nil {
+			return
+		}
+		s.tokens--
+		if err := s.provider.Refresh(ctx, t); err != nil {
+			slog.Error("Ошибка обновления котировок", "ticker", t, "provider", s.provider.Name(), "err", err)
+			continue
+		}
+		s.lastFetched[t] = time.Now()
+		if s.onRefresh != nil {
+			s.onRefresh(ctx, t)
+		}
+	}
+}
+
+// due возвращает тикеры, которые пора обновить, в порядке убывания приоритета.
+// Приоритет — отношение возраста данных к желаемому интервалу обновления.
+func (s *Scheduler) due(tickers []string, now time.Time) []string {
+	demand := s.demand.Snapshot()
+	var maxDemand float64
+	for _, v := range demand {
+		maxDemand = max(maxDemand, v)
+	}
+
+	type candidate struct {
+		ticker   string
+		priority float64
+	}
+	var candidates []candidate
+	for _, t := range tickers {
+		interval := s.interval(demand[t], maxDemand)
+		last, ok := s.lastFetched[t]
+		priority := float64(now.Sub(last)) / float64(interval)
+		if !ok {
+			// Никогда не обновлявшиеся тикеры идут первыми, среди них — по спросу
+			priority = 1e9 + demand[t]
+		}
+		if priority >= 1 {
+			candidates = append(candidates, candidate{t, priority})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].priority > candidates[j].priority
+	})
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.ticker
+	}
+	return out
+}
+
+// interval интерполирует желаемый интервал обновления между DormantInterval
+// (спроса нет) и HotInterval (самый востребованный тикер)
+func (s *Scheduler) interval(score, maxScore float64) time.Duration {
+	if maxScore <= 0 {
+		return s.opts.DormantInterval
+	}
+	share := score / maxScore
+	hot, dormant := float64(s.opts.HotInterval), float64(s.opts.DormantInterval)
+	return time.Duration(dormant - (dormant-hot)*share)
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Namespace — префикс всех метрик сервиса
+const Namespace = "frontend_backend"
+
+var (
+	// DeadLetters считает элементы, отправленные в dead-letter, по источнику
+	DeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "dead_letters_total",
+		Help:      "Pipeline items moved to the dead-letter table.",
+	}, []string{"source"})
+
+	// DeadLetterRetries считает повторные попытки по источнику и результату
+	DeadLetterRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "dead_letter_retries_total",
+		Help:      "Dead-letter retry attempts by result (success, failure).",
+	}, []string{"source", "result"})
+
+	// DeadLetterDiscards считает удаленные без обработки элементы
+	DeadLetterDiscards = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "dead_letter_discards_total",
+		Help:      "Dead-letter items discarded by an operator.",
+	})
+)
+
+var (
+	// StorageResultRows — число строк (элементов), которые вернул метод хранилища
+	StorageResultRows = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "storage_result_rows",
+		Help:      "Number of rows returned by storage methods.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 9), // 1 .. 65536
+	}, []string{"method"})
+
+	// StorageResultBytes — размер результата метода хранилища в JSON
+	StorageResultBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "storage_result_bytes",
+		Help:      "JSON-encoded size of results returned by storage methods.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 9), // 256 B .. 16 MiB
+	}, []string{"method"})
+)
+
+// RateLimitViolations считает запросы сверх лимита по режиму (soft, enforced)
+var RateLimitViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "rate_limit_violations_total",
+	Help:      "Requests over the rate limit by mode (soft, enforced).",
+}, []string{"mode"})
+
+var (
+	// JobLastSuccess — время последнего успешного выполнения фоновой задачи
+	JobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run of a scheduled job.",
+	}, []string{"job"})
+
+	// JobDuration — длительность выполнения фоновой задачи по результату
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of scheduled job runs by result (success, failure).",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 8), // 100 ms .. ~27 min
+	}, []string{"job", "result"})
+
+	// JobConsecutiveFailures — число неудачных запусков задачи подряд
+	JobConsecutiveFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "job_consecutive_failures",
+		Help:      "Number of consecutive failed runs of a scheduled job.",
+	}, []string{"job"})
+)
+
+// CDNPurges считает вызовы purge у CDN по результату (success, failure)
+var CDNPurges = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "cdn_purges_total",
+	Help:      "CDN purge calls by result (success, failure).",
+}, []string{"result"})
+
+// WebhookDeliveries считает доставки вебхуков по типу события и результату
+// (success, failure, dropped)
+var WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "webhook_deliveries_total",
+	Help:      "Webhook deliveries by event type and result (success, failure, dropped).",
+}, []string{"event", "result"})
+
+var (
+	// HTTPRequests считает ответы по шаблону маршрута, методу и коду
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP responses by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	// HTTPRequestDuration — время обработки запроса по шаблону маршрута
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 9), // 1 ms .. ~65 s
+	}, []string{"route", "method"})
+
+	// HTTPInFlight — число запросов, которые обрабатываются сейчас
+	HTTPInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+)
+
+// CacheRequests считает обращения к кешу хранилища по результату: hit,
+// stale (отдано устаревшее значение) и miss. Доля попаданий —
+// (hit + stale) / все обращения.
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "cache_requests_total",
+	Help:      "Storage cache lookups by cache and result (hit, stale, miss).",
+}, []string{"cache", "result"})
+
+// DBUp — 1, пока последняя проверка соединения с БД прошла успешно
+var DBUp = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: Namespace,
+	Name:      "db_up",
+	Help:      "Whether the last database connectivity check succeeded (1) or failed (0).",
+})
+
+var (
+	// CircuitBreakerState — состояние выключателя: 0 — замкнут, 1 —
+	// полуоткрыт, 2 — разомкнут
+	CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "circuit_breaker_state",
+		Help:      "Circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	}, []string{"breaker"})
+
+	// CircuitBreakerRejected считает вызовы, отклоненные разомкнутым выключателем
+	CircuitBreakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "circuit_breaker_rejected_total",
+		Help:      "Calls rejected without execution because the circuit breaker was open.",
+	}, []string{"breaker"})
+)
+
+// DBQueryDuration — время выполнения SQL-запроса по имени: методу
+// хранилища, который его выполнил
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "SQL query latency by query name (storage method that issued it).",
+	Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 9), // 0.5 ms .. ~33 s
+}, []string{"query"})
+
+var (
+	// PredictionsServed считает прогнозы, отданные клиентам, по тикеру и API
+	// (http, grpc)
+	PredictionsServed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "predictions_served_total",
+		Help:      "Predictions returned to clients by ticker and API (http, grpc).",
+	}, []string{"ticker", "api"})
+
+	// PriceHistoryRowsServed считает точки истории цен, отданные клиентам
+	PriceHistoryRowsServed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "price_history_rows_served_total",
+		Help:      "Price history rows returned to clients by ticker and API (http, grpc).",
+	}, []string{"ticker", "api"})
+
+	// CSVParseFailures считает ошибки разбора CSV по файлу (price_history —
+	// чтение истории цен хранилищем, moex_candles — слияние свечей MOEX) и
+	// причине: read (файл не читается целиком), short_row, bad_time,
+	// bad_price, bad_volume
+	CSVParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "csv_parse_failures_total",
+		Help:      "CSV rows or files that failed to parse by file kind and reason.",
+	}, []string{"file", "reason"})
+
+	// IngestDelay — сколько прошло от отправки сообщения в канал до его
+	// сохранения
+	IngestDelay = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "ingest_message_delay_seconds",
+		Help:      "Delay between a channel message being sent and being stored.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 9), // 1 s .. ~18 h
+	}, []string{"channel"})
+)
@@ -0,0 +1,327 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Режимы ограничения (config: rate_limit.mode)
+const (
+	// ModeSoft — запросы сверх лимита пропускаются, но помечаются
+	// заголовком и учитываются как нарушения
+	ModeSoft = "soft"
+	// ModeEnforce — запросы сверх лимита отклоняются для всех клиентов
+	ModeEnforce = "enforce"
+)
+
+// idleTTL — как часто удаляются ведра клиентов, которые успели наполниться
+const idleTTL = 10 * time.Minute
+
+// DefaultGroup — группа запросов, не попавших ни в одну из Options.Groups
+const DefaultGroup = "default"
+
+// Options задает параметры ограничителя
+type Options struct {
+	// RequestsPerMinute — средняя допустимая частота запросов
+	RequestsPerMinute int
+	// Burst — сколько запросов можно сделать подряд; 0 — равно RequestsPerMinute
+	Burst int
+	// Mode — soft или enforce
+	Mode string
+	// Enforce — клиенты (в формате Identity), для которых лимит
+	// применяется жестко даже в режиме soft
+	Enforce []string
+	// Groups — группы маршрутов со своими лимитами; у каждого клиента в
+	// каждой группе отдельное ведро
+	Groups []Group
+}
+
+// Group — группа маршрутов со своим лимитом. Paths — префиксы путей по
+// сегментам; «*» заменяет один сегмент: /stocks/*/history.
+type Group struct {
+	Name              string
+	Paths             []string
+	RequestsPerMinute int
+	// Burst — 0 означает RequestsPerMinute группы
+	Burst int
+}
+
+// limit — параметры ведра группы
+type limit struct {
+	name     string
+	patterns [][]string // Paths по сегментам
+	rate     float64    // токенов в секунду
+	burst    float64
+}
+
+// Decision — результат проверки запроса
+type Decision struct {
+	Group      string
+	Limit      int
+	Remaining  int
+	Exceeded   bool          // запрос сверх лимита
+	Enforced   bool          // запрос нужно отклонить
+	RetryAfter time.Duration // когда появится следующий токен
+	Reset      time.Duration // когда ведро наполнится полностью
+}
+
+// Violation — статистика превышений лимита одним клиентом в группе
+type Violation struct {
+	Client   string    `json:"client"`
+	Group    string    `json:"group"`
+	Count    int64     `json:"count"`
+	Enforced bool      `json:"enforced"`
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+}
+
+type bucket struct {
+	limit   *limit
+	tokens  float64
+	updated time.Time
+}
+
+// bucketKey — ведро клиента в группе
+type bucketKey struct {
+	group  string
+	client string
+}
+
+// Limiter — ограничитель частоты запросов по алгоритму token bucket
+// с отдельным ведром на каждого клиента в каждой группе маршрутов
+type Limiter struct {
+	mu         sync.Mutex
+	rules      *rules
+	buckets    map[bucketKey]*bucket
+	violations map[bucketKey]*Violation
+	swept      time.Time
+}
+
+// rules — разобранные Options; при Update заменяются целиком
+type rules struct {
+	mode    string
+	def     *limit
+	groups  []*limit
+	enforce map[string]bool
+}
+
+// New создает ограничитель
+func New(opts Options) (*Limiter, error) {
+	r, err := compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		rules:      r,
+		buckets:    make(map[bucketKey]*bucket),
+		violations: make(map[bucketKey]*Violation),
+	}, nil
+}
+
+// Update заменяет лимиты без сброса ведер: ведро клиента переходит на
+// новый лимит своей группы, а ведра удаленных групп забываются. При ошибке
+// в opts действуют прежние лимиты.
+func (l *Limiter) Update(opts Options) error {
+	r, err := compile(opts)
+	if err != nil {
+		return err
+	}
+	byName := map[string]*limit{DefaultGroup: r.def}
+	for _, g := range r.groups {
+		byName[g.name] = g
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = r
+	for key, b := range l.buckets {
+		g, ok := byName[key.group]
+		if !ok {
+			delete(l.buckets, key)
+			continue
+		}
+		b.limit = g
+		b.tokens = math.Min(b.tokens, g.burst)
+	}
+	return nil
+}
+
+func compile(opts Options) (*rules, error) {
+	r := &rules{
+		mode:    opts.Mode,
+		def:     newLimit(DefaultGroup, nil, opts.RequestsPerMinute, opts.Burst),
+		enforce: make(map[string]bool, len(opts.Enforce)),
+	}
+	for _, client := range opts.Enforce {
+		r.enforce[client] = true
+	}
+	seen := map[string]bool{DefaultGroup: true}
+	for _, g := range opts.Groups {
+		if g.Name == "" || seen[g.Name] {
+			return nil, fmt.Errorf("rate limit group name %q is empty or duplicated", g.Name)
+		}
+		seen[g.Name] = true
+		if g.RequestsPerMinute <= 0 {
+			return nil, fmt.Errorf("rate limit group %s: requests_per_minute must be positive", g.Name)
+		}
+		if len(g.Paths) == 0 {
+			return nil, fmt.Errorf("rate limit group %s: paths are required", g.Name)
+		}
+		var patterns [][]string
+		for _, p := range g.Paths {
+			if !strings.HasPrefix(p, "/") {
+				return nil, fmt.Errorf("rate limit group %s: path %q must start with /", g.Name, p)
+			}
+			patterns = append(patterns, segments(p))
+		}
+		r.groups = append(r.groups, newLimit(g.Name, patterns, g.RequestsPerMinute, g.Burst))
+	}
+	return r, nil
+}
+
+func newLimit(name string, patterns [][]string, perMinute, burst int) *limit {
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &limit{name: name, patterns: patterns, rate: float64(perMinute) / 60, burst: float64(burst)}
+}
+
+func segments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// group выбирает группу по пути: побеждает самый длинный подходящий префикс
+func (r *rules) group(path string) *limit {
+	segs := segments(path)
+	best, bestLen := r.def, 0
+	for _, g := range r.groups {
+		for _, pattern := range g.patterns {
+			if len(pattern) > bestLen && matchPrefix(pattern, segs) {
+				best, bestLen = g, len(pattern)
+			}
+		}
+	}
+	return best
+}
+
+func matchPrefix(pattern, segs []string) bool {
+	if len(pattern) > len(segs) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Identity возвращает идентификатор клиента: по API-ключу, если он передан
+// (ключ не хранится — только префикс его SHA-256), иначе по IP-адресу
+func Identity(apiKey, ip string) string {
+	if apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:6])
+	}
+	return "ip:" + ip
+}
+
+// Allow учитывает запрос клиента к пути path и решает, укладывается ли он
+// в лимит группы этого пути
+func (l *Limiter) Allow(client, path string) Decision {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	g := l.rules.group(path)
+	key := bucketKey{group: g.name, client: client}
+	l.sweepLocked(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: g, tokens: g.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	d := Decision{Group: g.name, Limit: int(g.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Remaining = int(b.tokens)
+		d.Reset = g.wait(g.burst - b.tokens)
+		return d
+	}
+
+	d.Exceeded = true
+	d.Enforced = l.rules.mode == ModeEnforce || l.rules.enforce[client]
+	d.RetryAfter = g.wait(1 - b.tokens)
+	d.Reset = g.wait(g.burst - b.tokens)
+	v, ok := l.violations[key]
+	if !ok {
+		v = &Violation{Client: client, Group: g.name, First: now}
+		l.violations[key] = v
+	}
+	v.Count++
+	v.Last = now
+	v.Enforced = d.Enforced
+	return d
+}
+
+// Violations возвращает нарушителей, начиная с самых частых
+func (l *Limiter) Violations() []Violation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Violation, 0, len(l.violations))
+	for _, v := range l.violations {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].Client != out[j].Client {
+			return out[i].Client < out[j].Client
+		}
+		return out[i].Group < out[j].Group
+	})
+	return out
+}
+
+// ResetViolations очищает статистику нарушений
+func (l *Limiter) ResetViolations() {
+	l.mu.Lock()
+	l.violations = make(map[bucketKey]*Violation)
+	l.mu.Unlock()
+}
+
+// sweepLocked удаляет ведра, которые успели наполниться, чтобы память не
+// росла от разовых IP: новое ведро будет таким же. Вызывается под l.mu.
+func (l *Limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.swept) < idleTTL {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.rate >= b.limit.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// refill добавляет токены, накопившиеся с прошлого запроса
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.limit.burst, b.tokens+now.Sub(b.updated).Seconds()*b.limit.rate)
+	b.updated = now
+}
+
+// wait — за сколько накопится tokens токенов
+func (g *limit) wait(tokens float64) time.Duration {
+	if g.rate <= 0 || tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / g.rate * float64(time.Second))
+}
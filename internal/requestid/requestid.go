@@ -0,0 +1,78 @@
+// Package requestid хранит идентификатор запроса (X-Request-ID) в контексте
+// и передает его в исходящих HTTP-запросах, чтобы по обращению клиента
+// найти все записи логов о его запросе.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header — заголовок с идентификатором запроса
+const Header = "X-Request-ID"
+
+// maxLen — предельная длина принимаемого от клиента идентификатора
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New генерирует случайный идентификатор из 32 шестнадцатеричных символов
+func New() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid сообщает, можно ли принять идентификатор клиента: непустой, не
+// длиннее 128 символов, только латиница, цифры и -_.:
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext возвращает контекст с идентификатором запроса
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Transport оборачивает base (nil — http.DefaultTransport) и добавляет
+// X-Request-ID из контекста к исходящим запросам
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTrip не должен менять исходный запрос
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"strconv"
+
+	"frontend-backend/internal/storage"
+)
+
+// Типы результатов
+const (
+	TypeStock      = "stock"
+	TypeSource     = "source"
+	TypeTag        = "tag"
+	TypePrediction = "prediction"
+)
+
+// predictionScore — релевантность найденного по тексту прогноза: ниже
+// совпадений по названиям, чтобы акции и источники шли первыми
+const predictionScore = 0.3
+
+// Stocks ищет акции по тикеру и названиям на всех языках; в подзаголовке —
+// название на языке Lang
+type Stocks struct {
+	Store storage.Storage
+	Lang  string
+}
+
+func (p Stocks) Type() string { return TypeStock }
+
+func (p Stocks) Search(ctx context.Context, q string, limit int) ([]Result, error) {
+	stocks, err := p.Store.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := []Result{}
+	for _, st := range stocks {
+		if score := Match(q, append([]string{st.Ticker}, st.AllNames()...)...); score > 0 {
+			results = append(results, Result{
+				Type: TypeStock, ID: st.Ticker, Title: st.Ticker, Subtitle: st.LocalizedName(p.Lang), Score: score,
+			})
+		}
+	}
+	return byScore(results), nil
+}
+
+// Sources ищет источники по каналу и имени
+type Sources struct{ Store storage.Storage }
+
+func (p Sources) Type() string { return TypeSource }
+
+func (p Sources) Search(ctx context.Context, q string, limit int) ([]Result, error) {
+	sources, err := p.Store.GetSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := []Result{}
+	for _, src := range sources {
+		name := src.Channel
+		if src.Name != nil {
+			name = *src.Name
+		}
+		if score := Match(q, src.Channel, name); score > 0 {
+			results = append(results, Result{
+				Type: TypeSource, ID: strconv.FormatInt(src.ID, 10), Title: name, Subtitle: src.Channel, Score: score,
+			})
+		}
+	}
+	return byScore(results), nil
+}
+
+// Tags ищет по типам прогнозов («Пробой уровня», «Разворот», ...)
+type Tags struct{ Store storage.Storage }
+
+func (p Tags) Type() string { return TypeTag }
+
+func (p Tags) Search(ctx context.Context, q string, limit int) ([]Result, error) {
+	types, err := p.Store.GetPredictionTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := []Result{}
+	for _, t := range types {
+		if score := Match(q, t); score > 0 {
+			results = append(results, Result{Type: TypeTag, ID: t, Title: t, Score: score})
+		}
+	}
+	return byScore(results), nil
+}
+
+// Predictions ищет последние прогнозы по тексту сообщения
+type Predictions struct{ Store storage.Storage }
+
+func (p Predictions) Type() string { return TypePrediction }
+
+func (p Predictions) Search(ctx context.Context, q string, limit int) ([]Result, error) {
+	matches, err := p.Store.SearchPredictions(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(matches))
+	for _, m := range matches {
+		subtitle := ""
+		if m.Prediction.Recommendation != nil {
+			subtitle = *m.Prediction.Recommendation
+		}
+		results = append(results, Result{
+			Type:     TypePrediction,
+			ID:       strconv.FormatInt(m.Prediction.MessageID, 10) + ":" + m.Ticker,
+			Title:    m.Ticker,
+			Subtitle: subtitle,
+			Score:    predictionScore,
+			Data:     m.Prediction,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,99 @@
+// Package search объединяет поиск по разным сущностям (акции, источники,
+// теги, прогнозы) в одну ранжированную выдачу для командной палитры.
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Result — элемент выдачи
+type Result struct {
+	Type     string  `json:"Type"`
+	ID       string  `json:"ID"`
+	Title    string  `json:"Title"`
+	Subtitle string  `json:"Subtitle,omitempty"`
+	Score    float64 `json:"Score"`
+	Data     any     `json:"Data,omitempty"`
+}
+
+// Provider ищет сущности одного типа
+type Provider interface {
+	Type() string
+	Search(ctx context.Context, q string, limit int) ([]Result, error)
+}
+
+// Engine опрашивает провайдеров с лимитом на тип и объединяет выдачу
+type Engine struct {
+	providers []Provider
+	limits    map[string]int
+}
+
+// DefaultTypeLimit — лимит для типа, которого нет в limits
+const DefaultTypeLimit = 5
+
+// NewEngine создает движок; limits задает число результатов на тип
+func NewEngine(limits map[string]int, providers ...Provider) *Engine {
+	return &Engine{providers: providers, limits: limits}
+}
+
+// Search возвращает не более limit результатов, от более релевантных к менее.
+// При равной релевантности сохраняется порядок провайдеров.
+func (e *Engine) Search(ctx context.Context, q string, limit int) ([]Result, error) {
+	q = strings.TrimSpace(q)
+	results := []Result{}
+	if q == "" {
+		return results, nil
+	}
+
+	for _, p := range e.providers {
+		typeLimit, ok := e.limits[p.Type()]
+		if !ok {
+			typeLimit = DefaultTypeLimit
+		}
+		found, err := p.Search(ctx, q, typeLimit)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > typeLimit {
+			found = found[:typeLimit]
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Match оценивает совпадение q с лучшим из значений: точное — 1,
+// префикс — 0.8, начало слова — 0.6, подстрока — 0.4, нет совпадения — 0
+func Match(q string, values ...string) float64 {
+	q = strings.ToLower(q)
+	best := 0.0
+	for _, v := range values {
+		v = strings.ToLower(v)
+		score := 0.0
+		switch {
+		case v == q:
+			score = 1
+		case strings.HasPrefix(v, q):
+			score = 0.8
+		case strings.Contains(v, " "+q):
+			score = 0.6
+		case strings.Contains(v, q):
+			score = 0.4
+		}
+		best = max(best, score)
+	}
+	return best
+}
+
+// byScore сортирует результаты провайдера по убыванию релевантности
+func byScore(results []Result) []Result {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Форматы access-лога (config: access_log.format)
+const (
+	AccessLogCommon   = "common"
+	AccessLogCombined = "combined"
+)
+
+// clfTimeFormat — формат времени Common Log Format
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// WithAccessLog включает access-лог в формате common или combined
+// (для GoAccess/awstats) в дополнение к обычному логу
+func WithAccessLog(out io.Writer, format string) Option {
+	return func(s *Server) {
+		s.accessLog = &accessLogger{out: out, combined: format == AccessLogCombined}
+	}
+}
+
+// accessLogger пишет по строке на запрос; запись сериализуется мьютексом
+type accessLogger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	combined bool
+}
+
+// statusRecorder запоминает код ответа и число записанных байт
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Hijack передает соединение обработчику /ws (переход на WebSocket)
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Flush нужен потоковым ответам (/events)
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// middleware оборачивает обработчик записью строки access-лога
+func (l *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		l.write(r, rec, start)
+	})
+}
+
+// write форматирует строку:
+// host - user [time] "method uri proto" status bytes ["referer" "user-agent"]
+func (l *accessLogger) write(r *http.Request, rec *statusRecorder, start time.Time) {
+	host := clientIP(r)
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	}
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	size := "-"
+	if rec.bytes > 0 {
+		size = strconv.Itoa(rec.bytes)
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] %q %d %s", host, user, start.Format(clfTimeFormat),
+		r.Method+" "+r.RequestURI+" "+r.Proto, status, size)
+	if l.combined {
+		line += fmt.Sprintf(" %q %q", orDash(r.Referer()), orDash(r.UserAgent()))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, line+"\n")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
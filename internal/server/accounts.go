@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithAccounts включает учетные записи пользователей: вход с выдачей JWT,
+// обновление и отзыв токенов. registration разрешает самостоятельную регистрацию.
+func WithAccounts(a *auth.Accounts, registration bool) Option {
+	return func(s *Server) {
+		s.accounts = a
+		s.registration = registration
+	}
+}
+
+// credentialsRequest — тело POST /auth/register и POST /auth/login
+type credentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// refreshRequest — тело POST /auth/refresh и POST /auth/logout
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// postRegisterHandler создает пользователя
+func (s *Server) postRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req credentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	u, err := s.accounts.Register(r.Context(), req.Email, req.Password)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при регистрации пользователя", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Зарегистрирован пользователь", "id", u.ID)
+	s.respondStatus(w, r, http.StatusCreated, u)
+}
+
+// postLoginHandler проверяет пароль и выдает токены
+func (s *Server) postLoginHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req credentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	tokens, err := s.accounts.Login(r.Context(), req.Email, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		s.log.WarnContext(r.Context(), "Неудачный вход", "client_ip", clientIP(r))
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при входе пользователя", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.writeTokens(w, tokens)
+}
+
+// postRefreshHandler обменивает refresh-токен на новую пару токенов
+func (s *Server) postRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, ok := s.refreshToken(w, r)
+	if !ok {
+		return
+	}
+	tokens, err := s.accounts.Refresh(r.Context(), token)
+	if errors.Is(err, storage.ErrRefreshTokenInvalid) {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при обновлении токенов", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.writeTokens(w, tokens)
+}
+
+// postLogoutHandler отзывает refresh-токен
+func (s *Server) postLogoutHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := s.refreshToken(w, r)
+	if !ok {
+		return
+	}
+	// Выход с уже отозванным токеном не ошибка
+	if err := s.accounts.Logout(r.Context(), token); err != nil && !errors.Is(err, storage.ErrRefreshTokenInvalid) {
+		s.log.ErrorContext(r.Context(), "Ошибка при отзыве refresh-токена", "err", err)
+		writeError(w, err)
+		return
+	}
+	if s.session != nil {
+		s.clearSessionCookies(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getMeHandler возвращает пользователя, которому выдан access-токен запроса
+func (s *Server) getMeHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	p := auth.FromContext(r.Context())
+	if p == nil || p.UserID == 0 {
+		writeProblem(w, http.StatusUnauthorized, "user access token required")
+		return
+	}
+	u, err := s.accounts.User(r.Context(), p.UserID)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении пользователя", "user_id", p.UserID, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, u)
+}
+
+// getUsersHandler возвращает пользователей с их ролями
+func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	users, err := s.accounts.Users(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении пользователей", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, users)
+}
+
+// putUserRoleHandler меняет роль пользователя; она попадет в следующий
+// access-токен, то есть вступит в силу не позже чем через auth.jwt.access_ttl
+func (s *Server) putUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.accounts.SetRole(r.Context(), id, req.Role); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при смене роли пользователя", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.log.InfoContext(r.Context(), "Пользователю назначена роль", "id", id, "role", req.Role)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshToken читает refresh-токен из тела запроса, а в режиме cookie —
+// из cookie, если тело пустое; при ошибке ответ уже отправлен
+func (s *Server) refreshToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !(errors.Is(err, io.EOF) && s.session != nil) {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return "", false
+	}
+	token, err := s.refreshFromCookie(r, req.RefreshToken)
+	if err != nil {
+		s.log.WarnContext(r.Context(), "Отклонен запрос", "method", r.Method, "path", r.URL.Path, "client_ip", clientIP(r), "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return "", false
+	}
+	return token, true
+}
+
+// writeTokens отдает токены; ответ не должен попасть ни в один кеш. В режиме
+// cookie токены кладутся в cookie, а в теле остается только токен CSRF.
+func (s *Server) writeTokens(w http.ResponseWriter, tokens auth.Tokens) {
+	if s.session != nil {
+		if err := s.setSessionCookies(w, tokens); err != nil {
+			s.log.Error("Ошибка при установке cookie сессии", "err", err)
+			writeError(w, err)
+		}
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(tokens)
+}
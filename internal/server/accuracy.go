@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/accuracy"
+
+	"github.com/gorilla/mux"
+)
+
+// getPredictionAccuracyHandler сверяет прогнозы по тикеру с фактическими ценами
+func (s *Server) getPredictionAccuracyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	predictions, err := s.store.GetPredictionsByTicker(r.Context(), ticker, r.URL.Query().Get("exchange"))
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	report := accuracy.Backtest(ticker, predictions, history)
+	report.Warnings = warnings
+
+	s.log.DebugContext(r.Context(), "Прогнозы сверены с историей цен", "total", report.Summary.Total, "ticker", ticker, "hits", report.Summary.Hits, "misses", report.Summary.Misses)
+	s.respond(w, r, report)
+}
+
+// getSourcesLeaderboardHandler ранжирует источники по точности прогнозов
+func (s *Server) getSourcesLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	board, err := accuracy.Leaderboard(r.Context(), s.store)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при построении рейтинга источников", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.DebugContext(r.Context(), "Возвращаем рейтинг источников", "sources", len(board))
+	s.respond(w, r, board)
+}
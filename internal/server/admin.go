@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// reprocessMessagesHandler повторно извлекает прогнозы из сохраненных сообщений.
+// Интервал задается параметрами from/to (YYYY-MM-DD), оба необязательны.
+func (s *Server) reprocessMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, err := parseDateParam(r, "from")
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseDateParam(r, "to")
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := s.reprocessor.Reprocess(r.Context(), from, to)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при повторной обработке сообщений", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Повторная обработка сообщений завершена", "messages", stats.Messages, "extracted", stats.Extracted, "inserted", stats.Inserted)
+	s.respond(w, r, stats)
+}
+
+// getUnknownRecommendationsHandler возвращает фразы рекомендаций, которых нет
+// в таблице правил, самые частые первыми
+func (s *Server) getUnknownRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	phrases, err := s.normalizer.Unknown(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении нераспознанных рекомендаций", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, phrases)
+}
+
+// normalizeRecommendationsHandler применяет текущую таблицу рекомендаций к
+// сохраненным прогнозам
+func (s *Server) normalizeRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stats, err := s.normalizer.Normalize(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при нормализации рекомендаций", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Нормализация рекомендаций завершена", "renamed", stats.Renamed, "classified", stats.Classified, "resolved", stats.Resolved)
+	s.respond(w, r, stats)
+}
+
+// parseDateParam разбирает необязательный параметр запроса в формате YYYY-MM-DD
+func parseDateParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected YYYY-MM-DD", name)
+	}
+	return t, nil
+}
+
+// getDuplicatePredictionsHandler возвращает группы дубликатов прогнозов
+func (s *Server) getDuplicatePredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groups, err := s.admin.FindDuplicatePredictions(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при поиске дубликатов прогнозов", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Найдены дубликаты прогнозов", "groups", len(groups))
+	s.respond(w, r, groups)
+}
+
+// mergeDuplicatePredictionsHandler удаляет дубликаты, оставляя самый ранний прогноз
+func (s *Server) mergeDuplicatePredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	removed, err := s.admin.MergeDuplicatePredictions(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при слиянии дубликатов прогнозов", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Удалены дубликаты прогнозов", "removed", removed)
+	s.respond(w, r, map[string]int64{"removed": removed})
+}
+
+// sqlLoggingState — состояние логирования SQL в админском API
+type sqlLoggingState struct {
+	Enabled       bool     `json:"enabled"`
+	Redact        []string `json:"redact"`
+	SlowThreshold string   `json:"slow_threshold"`
+}
+
+func (s *Server) sqlLoggingState() sqlLoggingState {
+	return sqlLoggingState{
+		Enabled:       s.sqlLog.Enabled(),
+		Redact:        s.sqlLog.Redacted(),
+		SlowThreshold: s.sqlLog.SlowThreshold().String(),
+	}
+}
+
+// getSQLLoggingHandler возвращает текущее состояние логирования SQL
+func (s *Server) getSQLLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.sqlLoggingState())
+}
+
+// putSQLLoggingHandler включает/выключает логирование SQL и, если переданы
+// redact или slow_threshold, заменяет список редактируемых столбцов или
+// порог медленного запроса
+func (s *Server) putSQLLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		Enabled       *bool    `json:"enabled"`
+		Redact        []string `json:"redact"`
+		SlowThreshold *string  `json:"slow_threshold"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	var slow time.Duration
+	if req.SlowThreshold != nil {
+		d, err := time.ParseDuration(*req.SlowThreshold)
+		if err != nil || d < 0 {
+			writeProblem(w, http.StatusBadRequest, "slow_threshold must be a non-negative duration such as 200ms")
+			return
+		}
+		slow = d
+	}
+	before := s.sqlLoggingState()
+	after := before
+	if req.Enabled != nil {
+		after.Enabled = *req.Enabled
+	}
+	if req.Redact != nil {
+		after.Redact = req.Redact
+	}
+	if req.SlowThreshold != nil {
+		after.SlowThreshold = slow.String()
+	}
+	// Настройка меняется, только если изменение попало в журнал
+	if err := s.auditEvent(r, storage.AuditUpdate, "sql_logging", before, after); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка записи изменения логирования SQL в журнал", "err", err)
+		writeError(w, err)
+		return
+	}
+	if req.Enabled != nil {
+		s.sqlLog.SetEnabled(*req.Enabled)
+	}
+	if req.Redact != nil {
+		s.sqlLog.SetRedacted(req.Redact)
+	}
+	if req.SlowThreshold != nil {
+		s.sqlLog.SetSlowThreshold(slow)
+	}
+
+	s.respond(w, r, s.sqlLoggingState())
+}
+
+// getIngestLagHandler возвращает отставание загрузки сообщений по каналам
+func (s *Server) getIngestLagHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.ingestLag.Status())
+}
+
+// getRateLimitViolationsHandler возвращает клиентов, превышавших лимит запросов
+func (s *Server) getRateLimitViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.rateLimit.Violations())
+}
+
+// resetRateLimitViolationsHandler очищает статистику нарушений
+func (s *Server) resetRateLimitViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	s.rateLimit.ResetViolations()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getJobsHandler возвращает состояние фоновых задач и время следующего запуска
+func (s *Server) getJobsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.jobs.Status())
+}
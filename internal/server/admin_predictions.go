@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithPredictionWriter включает эндпоинты изменения прогнозов /admin/predictions
+func WithPredictionWriter(w storage.PredictionWriter) Option {
+	return func(s *Server) {
+		s.predictionWriter = w
+	}
+}
+
+// getPredictionHandler возвращает прогноз по id
+func (s *Server) getPredictionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	p, err := s.predictionWriter.GetPrediction(r.Context(), id)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, p.Version)
+	s.respond(w, r, p)
+}
+
+// listPredictionsHandler возвращает прогнозы по акции ?ticker= (и ?exchange=)
+// с их id; с ?include_deleted=true — и удаленные прогнозы
+func (s *Server) listPredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ticker := r.URL.Query().Get("ticker")
+	if ticker == "" {
+		writeProblem(w, http.StatusBadRequest, "ticker is required")
+		return
+	}
+	deleted, err := includeDeleted(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	predictions, err := s.predictionWriter.ListPredictions(r.Context(), ticker, r.URL.Query().Get("exchange"), deleted)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, predictions)
+}
+
+// postPredictionHandler добавляет прогноз, например введенный вручную
+func (s *Server) postPredictionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var in storage.PredictionInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	p, err := s.predictionWriter.CreatePrediction(r.Context(), in)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при добавлении прогноза", "ticker", in.Ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Добавлен прогноз", "id", p.ID, "ticker", in.Ticker, "message_id", p.MessageID)
+	setVersionETag(w, p.Version)
+	s.respondStatus(w, r, http.StatusCreated, p)
+}
+
+// putPredictionHandler исправляет прогноз версии из If-Match
+func (s *Server) putPredictionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var in storage.PredictionInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	p, err := s.predictionWriter.UpdatePrediction(r.Context(), id, in, version)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при изменении прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, p.Version)
+	s.respond(w, r, p)
+}
+
+// mergePatchType — тип тела PATCH по RFC 7396; application/json тоже принимается
+const mergePatchType = "application/merge-patch+json"
+
+// predictionPatchFields разбирают значения полей PATCH /admin/predictions/{id}
+// по их типу; null дает nil-указатель, то есть очистку столбца
+var predictionPatchFields = map[string]func(json.RawMessage) (any, error){
+	"prediction_type":       decodePatchValue[string],
+	"target_price":          decodePatchValue[float64],
+	"target_change_percent": decodePatchValue[float64],
+	"period":                decodePatchValue[string],
+	"recommendation":        decodePatchValue[string],
+	"direction":             decodePatchValue[string],
+	"justification_text":    decodePatchValue[string],
+	"predicted_at":          decodePatchValue[time.Time],
+}
+
+func decodePatchValue[T any](raw json.RawMessage) (any, error) {
+	var v *T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// patchPredictionHandler меняет только переданные поля прогноза (JSON merge
+// patch): поле со значением null очищается, отсутствующее не меняется
+func (s *Server) patchPredictionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "" && ct != mergePatchType && ct != "application/json" {
+		writeProblem(w, http.StatusUnsupportedMediaType, "expected Content-Type "+mergePatchType)
+		return
+	}
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	patch := storage.PredictionPatch{Fields: make(map[string]any, len(body))}
+	for field, raw := range body {
+		var err error
+		switch field {
+		case "ticker":
+			err = json.Unmarshal(raw, &patch.Ticker)
+			if err == nil && patch.Ticker == "" {
+				err = errors.New("cannot be empty")
+			}
+		case "exchange":
+			err = json.Unmarshal(raw, &patch.Exchange)
+		default:
+			decode, ok := predictionPatchFields[field]
+			if !ok {
+				writeProblem(w, http.StatusBadRequest, fmt.Sprintf("field %s cannot be changed", field))
+				return
+			}
+			patch.Fields[field], err = decode(raw)
+		}
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", field, err))
+			return
+		}
+	}
+	if patch.Exchange != "" && patch.Ticker == "" {
+		writeProblem(w, http.StatusBadRequest, "exchange can be changed only together with ticker")
+		return
+	}
+
+	p, err := s.predictionWriter.PatchPrediction(r.Context(), id, patch, version)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при изменении прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, p.Version)
+	s.respond(w, r, p)
+}
+
+// deletePredictionHandler мягко удаляет прогноз; его можно восстановить через
+// POST /admin/predictions/{id}/restore
+func (s *Server) deletePredictionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.predictionWriter.DeletePrediction(r.Context(), id); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при удалении прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restorePredictionHandler восстанавливает удаленный прогноз
+func (s *Server) restorePredictionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	p, err := s.predictionWriter.RestorePrediction(r.Context(), id)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при восстановлении прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, p.Version)
+	s.respond(w, r, p)
+}
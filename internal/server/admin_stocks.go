@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithStockWriter включает эндпоинты изменения справочника акций /admin/stocks
+func WithStockWriter(w storage.StockWriter) Option {
+	return func(s *Server) {
+		s.stockWriter = w
+	}
+}
+
+// stockRequest — тело POST /admin/stocks и PUT /admin/stocks/{id}. Пустая
+// биржа означает MOEX; names — локализованные названия по языку, при PUT без
+// names они не меняются.
+type stockRequest struct {
+	Ticker   string            `json:"ticker"`
+	Name     string            `json:"name"`
+	Exchange string            `json:"exchange"`
+	Names    map[string]string `json:"names"`
+}
+
+func (req stockRequest) stock() storage.Stock {
+	return storage.Stock{Ticker: req.Ticker, Name: req.Name, Exchange: req.Exchange, Names: req.Names}
+}
+
+// includeDeleted разбирает параметр ?include_deleted=true админских списков
+func includeDeleted(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include_deleted")
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid include_deleted: %q", v)
+	}
+	return b, nil
+}
+
+// listStocksHandler возвращает справочник акций вместе с id; с
+// ?include_deleted=true — и удаленные акции
+func (s *Server) listStocksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	deleted, err := includeDeleted(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	stocks, err := s.stockWriter.ListStocks(r.Context(), deleted)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении справочника акций", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, stocks)
+}
+
+// postStockHandler добавляет акцию в справочник
+func (s *Server) postStockHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req stockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	st := req.stock()
+	if err := s.stockWriter.CreateStock(r.Context(), &st); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при добавлении акции", "ticker", req.Ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Добавлена акция", "id", st.ID, "ticker", st.Ticker, "exchange", st.Exchange)
+	setVersionETag(w, st.Version)
+	s.respondStatus(w, r, http.StatusCreated, st)
+}
+
+// getStockHandler возвращает акцию по id с версией в ETag
+func (s *Server) getStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	st, err := s.stockWriter.GetStock(r.Context(), id)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении акции", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, st.Version)
+	s.respond(w, r, st)
+}
+
+// maxUpsertStocks — наибольшее число акций в одном POST /admin/stocks/upsert
+const maxUpsertStocks = 1000
+
+// upsertedStock — акция из ответа POST /admin/stocks/upsert с результатом
+type upsertedStock struct {
+	storage.Stock
+	Result string `json:"result"` // created, updated или unchanged
+}
+
+// upsertStocksHandler добавляет или обновляет список акций: загрузчики
+// справочника могут присылать его целиком и повторять без последствий.
+// Акции сохраняются по очереди; при ошибке уже сохраненные остаются, а ответ
+// указывает номер акции с ошибкой.
+func (s *Server) upsertStocksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var reqs []stockRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: expected an array of stocks: "+err.Error())
+		return
+	}
+	if len(reqs) > maxUpsertStocks {
+		writeProblem(w, http.StatusBadRequest, fmt.Sprintf("too many stocks: at most %d per request", maxUpsertStocks))
+		return
+	}
+
+	out := make([]upsertedStock, 0, len(reqs))
+	counts := map[string]int{}
+	for i, req := range reqs {
+		st := req.stock()
+		result, err := s.stockWriter.UpsertStock(r.Context(), &st)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при загрузке акции", "ticker", req.Ticker, "err", err)
+			writeError(w, fmt.Errorf("stocks[%d]: %w", i, err))
+			return
+		}
+		counts[result]++
+		out = append(out, upsertedStock{Stock: st, Result: result})
+	}
+
+	s.log.InfoContext(r.Context(), "Справочник акций загружен", "created", counts[storage.StockCreated], "updated", counts[storage.StockUpdated], "unchanged", counts[storage.StockUnchanged])
+	s.respond(w, r, out)
+}
+
+// putStockHandler меняет тикер, название и биржу акции версии из If-Match
+func (s *Server) putStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var req stockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	st := req.stock()
+	st.ID = id
+	if err := s.stockWriter.UpdateStock(r.Context(), &st, version); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при изменении акции", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, st.Version)
+	s.respond(w, r, st)
+}
+
+// deleteStockHandler мягко удаляет акцию; ее можно восстановить через
+// POST /admin/stocks/{id}/restore
+func (s *Server) deleteStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.stockWriter.DeleteStock(r.Context(), id); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при удалении акции", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreStockHandler восстанавливает удаленную акцию
+func (s *Server) restoreStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+
+	st, err := s.stockWriter.RestoreStock(r.Context(), id)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при восстановлении акции", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	setVersionETag(w, st.Version)
+	s.respond(w, r, st)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"frontend-backend/internal/analytics"
+	"frontend-backend/internal/storage"
+)
+
+// correlationMaxTickers — верхняя граница числа тикеров в матрице корреляций
+const correlationMaxTickers = 20
+
+// getCorrelationHandler возвращает матрицу корреляций доходностей для
+// ?tickers=SBER,GAZP за ?window= (по умолчанию api.defaults.correlation.window)
+func (s *Server) getCorrelationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	var tickers []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(query.Get("tickers"), ",") {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		t, err := storage.NormalizeTicker(t)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if !seen[t] {
+			seen[t] = true
+			tickers = append(tickers, t)
+		}
+	}
+	if len(tickers) < 2 || len(tickers) > correlationMaxTickers {
+		writeProblem(w, http.StatusBadRequest, "tickers must list from 2 to 20 tickers")
+		return
+	}
+
+	window, err := analytics.ParseWindow(s.param(r, "correlation", "window"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fill := s.param(r, "correlation", "fill")
+	if fill == storage.FillNull {
+		writeProblem(w, http.StatusBadRequest, "fill=null is not supported for correlation: expected skip, ffill or linear")
+		return
+	} else if err := defaultValidators["fill"](fill); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	histories := make(map[string][]storage.StockPriceHistory, len(tickers))
+	var warnings []string
+	for _, t := range tickers {
+		history, warns, err := s.priceHistory(r.Context(), t)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", t, "err", err)
+			writeError(w, err)
+			return
+		}
+		s.recordDemand(t)
+		if fill != storage.FillSkip {
+			history = storage.FillPriceGaps(history, fill)
+		}
+		histories[t] = history
+		warnings = append(warnings, warns...)
+	}
+
+	corr := analytics.ComputeCorrelation(tickers, histories, window)
+	corr.Warnings = warnings
+	s.respond(w, r, corr)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithAPIKeys включает проверку ключа в заголовке X-API-Key и админские
+// эндпоинты управления ключами
+func WithAPIKeys(k *auth.Keyring) Option {
+	return func(s *Server) {
+		s.keys = k
+	}
+}
+
+// apiKeyRequest — тело POST /admin/api-keys; пустая роль означает viewer,
+// нулевые квоты — без ограничения
+type apiKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	quotaRequest
+}
+
+// quotaRequest — тело PUT /admin/api-keys/{id}/quota
+type quotaRequest struct {
+	DailyQuota   int64 `json:"daily_quota"`
+	MonthlyQuota int64 `json:"monthly_quota"`
+}
+
+// roleRequest — тело PUT /admin/api-keys/{id}/role и PUT /admin/users/{id}/role
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+// createdAPIKey — выпущенный ключ; Key показывается только в этом ответе
+type createdAPIKey struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+// getAPIKeysHandler возвращает ключи из конфигурации и выпущенные через API
+func (s *Server) getAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keys, err := s.keys.List(r.Context())
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении API-ключей", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, keys)
+}
+
+// postAPIKeyHandler выпускает ключ и единственный раз возвращает его целиком
+func (s *Server) postAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	secret, key, err := s.keys.Create(r.Context(), req.Name, req.Role, req.DailyQuota, req.MonthlyQuota)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при выпуске API-ключа", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Выпущен API-ключ", "id", key.ID, "name", key.Name, "prefix", key.Prefix, "role", key.Role)
+	w.Header().Set("Cache-Control", "no-store")
+	s.respondStatus(w, r, http.StatusCreated, createdAPIKey{APIKey: key, Key: secret})
+}
+
+// deleteAPIKeyHandler отзывает ключ, выпущенный через API
+func (s *Server) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.keys.Revoke(r.Context(), id); errors.Is(err, storage.ErrAPIKeyNotFound) {
+		writeProblem(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при отзыве API-ключа", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// putAPIKeyRoleHandler меняет роль ключа, выпущенного через API
+func (s *Server) putAPIKeyRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.keys.SetRole(r.Context(), id, req.Role); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при смене роли API-ключа", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.log.InfoContext(r.Context(), "API-ключу назначена роль", "id", id, "role", req.Role)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// putAPIKeyQuotaHandler меняет квоты ключа, выпущенного через API
+func (s *Server) putAPIKeyQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req quotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := s.keys.SetQuota(r.Context(), id, req.DailyQuota, req.MonthlyQuota); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при смене квот API-ключа", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.log.InfoContext(r.Context(), "API-ключу назначены квоты", "id", id, "daily_quota", req.DailyQuota, "monthly_quota", req.MonthlyQuota)
+	w.WriteHeader(http.StatusNoContent)
+}
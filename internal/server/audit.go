@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/storage"
+)
+
+// maxAuditLimit — наибольший ?limit= журнала изменений
+const maxAuditLimit = 1000
+
+// AuditStore ведет журнал изменений; доступен только с PostgreSQL
+type AuditStore interface {
+	ListAuditLog(ctx context.Context, f storage.AuditFilter) ([]storage.AuditEntry, error)
+	RecordAuditEvent(ctx context.Context, action, entity string, before, after any) error
+}
+
+// WithAuditLog включает админский эндпоинт журнала изменений /admin/audit
+func WithAuditLog(a AuditStore) Option {
+	return func(s *Server) {
+		s.audit = a
+	}
+}
+
+// auditEvent записывает в журнал изменение, которое не хранится в таблицах
+// (настройки сервера, внешние сервисы). Без журнала ничего не делает.
+func (s *Server) auditEvent(r *http.Request, action, entity string, before, after any) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.RecordAuditEvent(r.Context(), action, entity, before, after)
+}
+
+// actorMiddleware передает хранилищу автора изменений для журнала: имя
+// клиента (или anonymous) и его адрес
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := storage.Actor{Name: "anonymous", IP: clientIP(r)}
+		if p := auth.FromContext(r.Context()); p != nil {
+			actor.Name = p.Name
+		}
+		next.ServeHTTP(w, r.WithContext(storage.WithActor(r.Context(), actor)))
+	})
+}
+
+// getAuditLogHandler возвращает записи журнала изменений от новых к старым.
+// Фильтры: actor, action, entity, entity_id, since и until (RFC 3339);
+// следующая страница — ?cursor= из next_cursor.
+func (s *Server) getAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	f := storage.AuditFilter{Actor: q.Get("actor"), Action: q.Get("action"), Entity: q.Get("entity")}
+	var err error
+	if f.Limit, err = strconv.Atoi(s.param(r, "audit", "limit")); err != nil || f.Limit <= 0 || f.Limit > maxAuditLimit {
+		writeProblem(w, http.StatusBadRequest, "invalid limit: expected 1.."+strconv.Itoa(maxAuditLimit))
+		return
+	}
+	for name, dst := range map[string]*int64{"entity_id": &f.EntityID, "cursor": &f.BeforeID} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.ParseInt(v, 10, 64); err != nil || *dst <= 0 {
+				writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: expected a positive integer", name))
+				return
+			}
+		}
+	}
+	for name, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: expected RFC 3339", name))
+				return
+			}
+		}
+	}
+
+	entries, err := s.audit.ListAuditLog(r.Context(), f)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении журнала изменений", "err", err)
+		writeError(w, err)
+		return
+	}
+	page := Page{Items: entries, Limit: f.Limit, Cursor: q.Get("cursor")}
+	if len(entries) == f.Limit {
+		page.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+		setPageLinks(w, r, pageLink{"next", map[string]string{"cursor": page.NextCursor}})
+	}
+	s.respond(w, r, page)
+}
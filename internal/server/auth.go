@@ -0,0 +1,170 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"frontend-backend/internal/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// authExempt — пути, доступные без ключа и токена: метрики собирает
+// Prometheus, версию и пробы проверяют при деплое и балансировщик,
+// остальные выдают токены
+var authExempt = []string{"/metrics", "/version", "/healthz", "/readyz", "/auth/register", "/auth/login", "/auth/refresh", "/auth/logout"}
+
+// errInvalidAPIKey — ответ на неизвестный или отозванный ключ
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// routeRoles — роли маршрутов, которым не подходит правило по умолчанию
+// (requiredRole); ключ — «МЕТОД шаблон маршрута»
+var routeRoles = map[string]auth.Role{
+	// Запросы GraphQL приходят POST, но только читают данные
+	"POST /graphql": auth.RoleViewer,
+	// Прогнозы ведут редакторы: создание, исправление, удаление и
+	// восстановление
+	"GET /admin/predictions":                      auth.RoleEditor,
+	"POST /admin/predictions":                     auth.RoleEditor,
+	"GET /admin/predictions/{id:[0-9]+}":          auth.RoleEditor,
+	"PUT /admin/predictions/{id:[0-9]+}":          auth.RoleEditor,
+	"PATCH /admin/predictions/{id:[0-9]+}":        auth.RoleEditor,
+	"DELETE /admin/predictions/{id:[0-9]+}":       auth.RoleEditor,
+	"POST /admin/predictions/{id:[0-9]+}/restore": auth.RoleEditor,
+}
+
+// WithAnonymous разрешает запросы без ключа и токена с ролью role — режим
+// разработки. Пустая роль запрещает анонимный доступ.
+func WithAnonymous(role auth.Role) Option {
+	return func(s *Server) {
+		s.anonymous = role
+	}
+}
+
+// WithOIDC включает проверку токенов внешнего провайдера OIDC в
+// Authorization: Bearer наравне с токенами локальных пользователей
+func WithOIDC(o *auth.OIDC) Option {
+	return func(s *Server) {
+		s.oidc = o
+	}
+}
+
+// WithClientCerts включает клиентов по сертификату mTLS: запрос без ключа и
+// токена получает клиента по CN проверенного сертификата соединения
+func WithClientCerts(c *auth.ClientCerts) Option {
+	return func(s *Server) {
+		s.certs = c
+	}
+}
+
+// authMiddleware определяет клиента по X-API-Key, JWT в Authorization или
+// cookie сессии либо по клиентскому сертификату,
+// проверяет, что его роли достаточно для маршрута, и кладет клиента в
+// контекст. Неверные учетные данные отклоняются всегда, отсутствующие — если
+// анонимной роли не хватает для маршрута.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || pathIn(authExempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := s.authenticate(r)
+		if err != nil {
+			s.log.WarnContext(r.Context(), "Отклонен запрос", "method", r.Method, "path", r.URL.Path, "client_ip", clientIP(r), "err", err)
+			if errors.Is(err, errCSRF) {
+				writeProblem(w, http.StatusForbidden, err.Error())
+				return
+			}
+			if errors.Is(err, auth.ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				err = auth.ErrInvalidToken
+			}
+			writeProblem(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		required := requiredRole(r)
+		if p == nil {
+			if !s.anonymous.Allows(required) {
+				writeProblem(w, http.StatusUnauthorized, "authentication required: pass an API key in X-API-Key or an access token in Authorization: Bearer")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !p.Role.Allows(required) {
+			s.log.WarnContext(r.Context(), "Клиенту отказано в доступе", "name", p.Name, "role", p.Role, "method", r.Method, "path", r.URL.Path)
+			writeProblem(w, http.StatusForbidden, fmt.Sprintf("role %s required", required))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// authenticate возвращает клиента запроса; nil без ошибки — запрос без
+// учетных данных. Если передан ключ, токен не проверяется; cookie сессии и
+// сертификат используются, только если нет ни ключа, ни токена.
+func (s *Server) authenticate(r *http.Request) (*auth.Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		if s.keys == nil {
+			return nil, errInvalidAPIKey
+		}
+		p, ok := s.keys.Authenticate(key)
+		if !ok {
+			return nil, errInvalidAPIKey
+		}
+		return p, nil
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		if s.session != nil {
+			if p, err := s.sessionPrincipal(r); p != nil || err != nil {
+				return p, err
+			}
+		}
+		if s.certs != nil {
+			if p, ok := s.certs.Authenticate(r.TLS); ok {
+				return p, nil
+			}
+		}
+		return nil, nil
+	}
+	// Токен проверяет тот, кто его выпустил: этот сервис или провайдер OIDC
+	switch {
+	case s.accounts != nil && (s.oidc == nil || auth.IsLocalToken(token)):
+		return s.accounts.Verify(token)
+	case s.oidc != nil:
+		return s.oidc.Verify(r.Context(), token)
+	}
+	return nil, nil
+}
+
+func pathIn(paths []string, path string) bool {
+	for _, p := range paths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredRole возвращает роль, нужную для запроса: /admin/* — admin, чтение
+// (GET, HEAD) — viewer, изменение данных — editor; исключения — в routeRoles
+func requiredRole(r *http.Request) auth.Role {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			if role, ok := routeRoles[r.Method+" "+tpl]; ok {
+				return role
+			}
+		}
+	}
+	switch {
+	case r.URL.Path == "/admin" || strings.HasPrefix(r.URL.Path, "/admin/"):
+		return auth.RoleAdmin
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return auth.RoleViewer
+	default:
+		return auth.RoleEditor
+	}
+}
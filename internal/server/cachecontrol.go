@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// builtinCachePolicies — время кеширования эндпоинтов по умолчанию;
+// api.cache_control.endpoints переопределяет и дополняет их
+var builtinCachePolicies = map[string]string{
+	"stocks":         "5m",
+	"stocks-history": "1h",
+	"predictions":    "30s",
+}
+
+// cacheDirectiveRe — одна директива Cache-Control: max-age=60, no-store, private="x"
+var cacheDirectiveRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z\-]*(=("[^"]*"|[^",\s]+))?$`)
+
+// CachePolicies — значения Cache-Control для ответов публичных GET-эндпоинтов.
+// Эндпоинт называется как в ключах CDN: /stocks/{ticker}/history → stocks-history.
+type CachePolicies struct {
+	def       string
+	endpoints map[string]string
+}
+
+// NewCachePolicies накладывает политики из конфигурации на встроенные.
+// Значение — длительность (5m → public, max-age=300; 0 → no-store) или
+// готовая строка Cache-Control; пустое значение отключает заголовок.
+func NewCachePolicies(def string, overrides map[string]string) (*CachePolicies, error) {
+	c := &CachePolicies{endpoints: make(map[string]string, len(builtinCachePolicies)+len(overrides))}
+	var err error
+	if c.def, err = parseCachePolicy(def); err != nil {
+		return nil, fmt.Errorf("api.cache_control.default: %w", err)
+	}
+	for endpoint, value := range builtinCachePolicies {
+		c.endpoints[endpoint], _ = parseCachePolicy(value)
+	}
+	for endpoint, value := range overrides {
+		if c.endpoints[endpoint], err = parseCachePolicy(value); err != nil {
+			return nil, fmt.Errorf("api.cache_control.endpoints.%s: %w", endpoint, err)
+		}
+	}
+	return c, nil
+}
+
+func parseCachePolicy(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		switch {
+		case d < 0:
+			return "", fmt.Errorf("negative duration %s", value)
+		case d == 0:
+			return "no-store", nil
+		}
+		return fmt.Sprintf("public, max-age=%d", int64(d/time.Second)), nil
+	}
+	for _, directive := range strings.Split(value, ",") {
+		if !cacheDirectiveRe.MatchString(strings.TrimSpace(directive)) {
+			return "", fmt.Errorf("invalid Cache-Control %q: expected a duration or directives", value)
+		}
+	}
+	return value, nil
+}
+
+// WithCachePolicies задает политики Cache-Control
+func WithCachePolicies(c *CachePolicies) Option {
+	return func(s *Server) {
+		s.cache = c
+	}
+}
+
+// For возвращает Cache-Control эндпоинта или значение по умолчанию
+func (c *CachePolicies) For(endpoint string) string {
+	if v, ok := c.endpoints[endpoint]; ok {
+		return v
+	}
+	return c.def
+}
+
+// checkCachePolicies предупреждает о политиках для эндпоинтов, которых нет
+// среди маршрутов: скорее всего, это опечатка в конфигурации
+func (s *Server) checkCachePolicies() {
+	known := map[string]bool{}
+	s.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		if endpoint, ok := templateEndpoint(tpl); ok {
+			known[endpoint] = true
+		}
+		return nil
+	})
+	var unknown []string
+	for endpoint := range s.cache.endpoints {
+		if !known[endpoint] {
+			unknown = append(unknown, endpoint)
+		}
+	}
+	sort.Strings(unknown)
+	for _, endpoint := range unknown {
+		s.log.Warn("Политика Cache-Control задана для неизвестного эндпоинта", "endpoint", endpoint)
+	}
+}
+
+// cacheControlMiddleware проставляет Cache-Control эндпоинта. Обработчик
+// может задать свой заголовок; ответы с ошибкой не кешируются.
+func (s *Server) cacheControlMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint, ok := routeEndpoint(r)
+		policy := ""
+		if ok {
+			policy = s.cache.For(endpoint)
+		}
+		if policy == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", policy)
+		next.ServeHTTP(&cacheControlWriter{ResponseWriter: w}, r)
+	})
+}
+
+// cacheControlWriter заменяет политику на no-store для ответов 4xx и 5xx
+type cacheControlWriter struct {
+	http.ResponseWriter
+}
+
+func (cw *cacheControlWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest {
+		cw.Header().Set("Cache-Control", "no-store")
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheControlWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
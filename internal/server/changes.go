@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// maxChangesLimit — верхняя граница ?limit= для /changes
+const maxChangesLimit = 5000
+
+// ChangeFeed — лента изменений акций и прогнозов, доступна только с PostgreSQL
+type ChangeFeed interface {
+	GetChanges(ctx context.Context, after storage.ChangeCursor, limit int) (storage.Changes, error)
+}
+
+// WithChangeFeed включает эндпоинт инкрементальной выгрузки /changes
+func WithChangeFeed(f ChangeFeed) Option {
+	return func(s *Server) {
+		s.changes = f
+	}
+}
+
+// getChangesHandler возвращает акции и прогнозы, созданные или измененные
+// после ?since= — времени в RFC 3339 или курсора из прошлого ответа. Без
+// since выгружается все с начала.
+func (s *Server) getChangesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	since := r.URL.Query().Get("since")
+
+	limit, err := strconv.Atoi(s.param(r, "changes", "limit"))
+	if err != nil || limit <= 0 || limit > maxChangesLimit {
+		writeProblem(w, http.StatusBadRequest, "invalid limit: expected 1.."+strconv.Itoa(maxChangesLimit))
+		return
+	}
+
+	var after storage.ChangeCursor
+	if since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			after = storage.CursorSince(t)
+		} else if after, err = storage.DecodeCursor(since); err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid since: expected RFC 3339 time or cursor")
+			return
+		}
+	}
+
+	changes, err := s.changes.GetChanges(r.Context(), after, limit)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении изменений", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	if changes.HasMore {
+		setPageLinks(w, r, pageLink{"next", map[string]string{"since": changes.Cursor, "limit": strconv.Itoa(limit)}})
+	}
+	s.log.DebugContext(r.Context(), "Возвращаем изменения", "stocks", len(changes.Stocks), "predictions", len(changes.Predictions))
+	s.respond(w, r, changes)
+}
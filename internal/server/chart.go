@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/analytics"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// getChartHandler возвращает свечи и маркеры прогнозов одним ответом.
+// ?bucket=day|week|month|auto (auto — по длине истории), ?range=all|6m|...
+// и ?adjusted=true; умолчания — api.defaults.chart.
+func (s *Server) getChartHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	bucket := s.param(r, "chart", "bucket")
+	if bucket != bucketAuto && !storage.ValidBucket(bucket) {
+		writeProblem(w, http.StatusBadRequest, "bucket must be day, week, month or auto")
+		return
+	}
+	rangeParam := s.param(r, "chart", "range")
+	if err := defaultValidators["range"](rangeParam); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fill := s.param(r, "chart", "fill")
+	if fill == storage.FillNull {
+		writeProblem(w, http.StatusBadRequest, "fill=null is not supported for chart: expected skip, ffill or linear")
+		return
+	} else if err := defaultValidators["fill"](fill); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	predictions, err := s.store.GetPredictionsByTicker(r.Context(), ticker, r.URL.Query().Get("exchange"))
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	if history, err = historyRange(history, rangeParam); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// ?adjusted=true — корректировка цен на сплиты и дивиденды, как в /history
+	if r.URL.Query().Get("adjusted") == "true" {
+		actions, err := s.store.GetCorporateActions(r.Context(), ticker)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при получении корпоративных действий", "ticker", ticker, "err", err)
+			writeError(w, err)
+			return
+		}
+		history = storage.AdjustPriceHistory(history, actions)
+	}
+	if fill != storage.FillSkip {
+		history = storage.FillPriceGaps(history, fill)
+	}
+	if bucket == bucketAuto {
+		bucket = analytics.AutoBucket(history)
+	}
+	chart := analytics.BuildChart(ticker, history, predictions, bucket)
+	chart.Warnings = warnings
+	chart.Attribution = s.attribution(w, ticker)
+
+	s.log.DebugContext(r.Context(), "Возвращаем данные графика", "candles", len(chart.Candles), "markers", len(chart.Markers), "ticker", ticker)
+	s.respond(w, r, chart)
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Поддерживаемые кодировки ответа
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+)
+
+// brotliLevel — компромисс между степенью и скоростью сжатия на лету
+const brotliLevel = 4
+
+// compressSkipped — пути со своим сжатием или потоковыми ответами
+var compressSkipped = []string{"/metrics", "/ws", "/events"}
+
+// incompressibleTypes — уже сжатые форматы ответов
+var incompressibleTypes = []string{
+	"application/vnd.openxmlformats", // xlsx — zip-архив
+	"application/zip",
+	"application/gzip",
+	"image/",
+	"text/event-stream",
+}
+
+var (
+	gzipPool   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotliLevel) }}
+)
+
+// WithCompression включает сжатие ответов gzip и br по Accept-Encoding.
+// Ответы короче minSize байт отдаются без сжатия.
+func WithCompression(minSize int) Option {
+	return func(s *Server) {
+		s.compressMin = minSize
+		s.compress = true
+	}
+}
+
+// compressMiddleware сжимает ответ кодировкой, выбранной по Accept-Encoding
+func (s *Server) compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || compressExcluded(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: s.compressMin}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func compressExcluded(path string) bool {
+	for _, prefix := range compressSkipped {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding выбирает br или gzip с наибольшим q; при равенстве — br
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encodingGzip && name != encodingBrotli {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && name == encodingBrotli) {
+			best, bestQ = name, q
+		}
+	}
+	if bestQ <= 0 {
+		return ""
+	}
+	return best
+}
+
+// compressWriter копит начало ответа, пока не наберется minSize байт:
+// короткие ответы уходят как есть, длинные — через кодировщик
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	status   int
+	buf      []byte
+	started  bool
+	enc      io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.started || cw.status != 0 {
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.started {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// start отправляет заголовки и накопленное начало ответа
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	h := cw.Header()
+	compressible := compressibleType(h.Get("Content-Type")) && h.Get("Content-Encoding") == ""
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if compress && compressible && status != http.StatusNoContent && status != http.StatusNotModified {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		cw.enc = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush отправляет накопленное, сжимая его, только если набралось minSize
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		cw.start(len(cw.buf) >= cw.minSize)
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close завершает ответ: короткий отдается без сжатия, кодировщик
+// дописывает хвост и возвращается в пул
+func (cw *compressWriter) Close() error {
+	if !cw.started {
+		if err := cw.start(false); err != nil {
+			return err
+		}
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	switch enc := cw.enc.(type) {
+	case *gzip.Writer:
+		gzipPool.Put(enc)
+	case *brotli.Writer:
+		brotliPool.Put(enc)
+	}
+	cw.enc = nil
+	return err
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == encodingBrotli {
+		enc := brotliPool.Get().(*brotli.Writer)
+		enc.Reset(w)
+		return enc
+	}
+	enc := gzipPool.Get().(*gzip.Writer)
+	enc.Reset(w)
+	return enc
+}
+
+func compressibleType(contentType string) bool {
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
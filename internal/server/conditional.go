@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// respondConditional отвечает как respond, но с валидаторами: ETag — хеш
+// закодированного тела, Last-Modified — modified, если оно задано. Если
+// копия клиента актуальна (If-None-Match, а без него If-Modified-Since),
+// ответ — 304 без тела.
+func (s *Server) respondConditional(w http.ResponseWriter, r *http.Request, v any, modified time.Time) {
+	w.Header().Add("Vary", "Accept")
+	var body bytes.Buffer
+	if wantsMsgpack(r) {
+		w.Header().Set("Content-Type", contentTypeMsgpack)
+		if err := encodeResponse(&body, r, v, true); err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка кодирования ответа в MessagePack", "err", err)
+			writeError(w, err)
+			return
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		encodeResponse(&body, r, v, false)
+	}
+
+	// Слабый ETag: тело одно и то же, но может отдаваться сжатым
+	sum := sha256.Sum256(body.Bytes())
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modified) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(body.Bytes())
+}
+
+// notModified проверяет условные заголовки запроса (RFC 9110, 13.1)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified передается с точностью до секунды
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// setVersionETag отдает версию записи админского API в заголовке ETag;
+// клиент возвращает его в If-Match при изменении
+func setVersionETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// ifMatchVersion разбирает If-Match изменяющего запроса: версию из ETag или
+// 0 для "*" (любая версия). Без заголовка отвечает 428, на другой ETag — 412.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" {
+		writeProblem(w, http.StatusPreconditionRequired, "If-Match with the ETag of the current version is required")
+		return 0, false
+	}
+	if tag == "*" {
+		return 0, true
+	}
+	// Слабые ETag не подходят: If-Match сравнивает строго
+	version, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(tag, `"`) {
+		writeProblem(w, http.StatusPreconditionFailed, "If-Match does not match the current version")
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// getConsensusHandler возвращает консенсус активных прогнозов по тикеру
+func (s *Server) getConsensusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	consensus, err := s.store.GetConsensus(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при расчете консенсуса", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	consensus.SetLastPrice(history)
+	consensus.Warnings = warnings
+
+	s.log.DebugContext(r.Context(), "Рассчитан консенсус", "ticker", ticker, "active_predictions", consensus.ActivePredictions)
+	s.respond(w, r, consensus)
+}
+
+// getTargetBandsHandler возвращает p10/p50/p90 целевых цен активных прогнозов
+// по интервалам ?bucket=day|week|month (по умолчанию api.defaults.target_bands.bucket)
+func (s *Server) getTargetBandsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	bucket := s.param(r, "target_bands", "bucket")
+	if !storage.ValidBucket(bucket) {
+		writeProblem(w, http.StatusBadRequest, "bucket must be day, week or month")
+		return
+	}
+
+	bands, err := s.store.GetTargetBands(r.Context(), ticker, bucket)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при расчете полос целевых цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	s.log.DebugContext(r.Context(), "Возвращаем полосы целевых цен", "bands", len(bands), "ticker", ticker)
+	s.respond(w, r, bands)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+)
+
+// DefaultCORSOrigin — адрес фронтенда по умолчанию (Vite dev server)
+const DefaultCORSOrigin = "http://localhost:5173"
+
+// corsPolicy — источники, которым разрешены запросы из браузера
+type corsPolicy struct {
+	any     bool // в списке есть «*»
+	origins map[string]bool
+}
+
+// WithCORSOrigins задает источники, которым разрешены запросы из браузера;
+// «*» разрешает любой, но без credentials. По умолчанию — DefaultCORSOrigin.
+func WithCORSOrigins(origins []string) Option {
+	return func(s *Server) {
+		s.SetCORSOrigins(origins)
+	}
+}
+
+// SetCORSOrigins заменяет список разрешенных источников на лету
+func (s *Server) SetCORSOrigins(origins []string) {
+	p := &corsPolicy{origins: make(map[string]bool, len(origins))}
+	for _, o := range origins {
+		if o == "*" {
+			p.any = true
+		}
+		p.origins[o] = true
+	}
+	s.cors.Store(p)
+}
+
+// allowed сообщает, разрешены ли запросы с источника origin
+func (p *corsPolicy) allowed(origin string) bool {
+	return origin != "" && (p.any || p.origins[origin])
+}
+
+// listed сообщает, указан ли источник origin в списке явно. Только таким
+// источникам разрешены запросы с cookie.
+func (p *corsPolicy) listed(origin string) bool {
+	return origin != "" && p.origins[origin]
+}
+
+// corsMiddleware добавляет CORS заголовки для разрешенных источников.
+// Явно указанным источникам Allow-Origin повторяет Origin запроса и
+// разрешает credentials; остальным при «*» отдается «*» без credentials,
+// чтобы чужой сайт не читал ответы от имени пользователя.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if p := s.cors.Load(); p.allowed(origin) {
+			if p.listed(origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-CSRF-Token, Idempotency-Key, If-Match, If-None-Match, If-Modified-Since, X-Request-ID")
+			w.Header().Set("Access-Control-Expose-Headers", "X-App-Version, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-RateLimit-Warning, Retry-After, Content-Disposition, X-Data-Attribution, ETag, Link, X-Total-Count, Idempotent-Replayed, X-Request-ID")
+		}
+
+		// Обрабатываем preflight запросы
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// maxDatasetLimit — верхняя граница ?limit= для выгрузки наборов данных
+const maxDatasetLimit = 10000
+
+// DatasetStore — снимки наборов данных, доступны только с PostgreSQL
+type DatasetStore interface {
+	CreatePredictionSnapshot(ctx context.Context, retention time.Duration) (storage.DatasetSnapshot, error)
+	GetPredictionSnapshot(ctx context.Context, id, after int64, limit int, retention time.Duration) (storage.DatasetSnapshot, []storage.DatasetPrediction, error)
+}
+
+// WithDatasets включает выгрузку наборов данных; снимки хранятся retention
+func WithDatasets(d DatasetStore, retention time.Duration) Option {
+	return func(s *Server) {
+		s.datasets = d
+		s.datasetTTL = retention
+	}
+}
+
+// predictionDatasetPage — страница выгрузки набора прогнозов
+type predictionDatasetPage struct {
+	Snapshot    storage.DatasetSnapshot     `json:"Snapshot"`
+	Predictions []storage.DatasetPrediction `json:"Predictions"`
+	Next        int64                       `json:"Next"`    // передается в следующий ?after=
+	HasMore     bool                        `json:"HasMore"` // есть строки после Next
+}
+
+// getPredictionDatasetHandler выгружает все прогнозы с исходом. Без
+// ?snapshot= создается новый снимок и отдается его первая страница; затем
+// страницы запрашиваются по ?snapshot=<ID>&after=<Next>. Повтор того же
+// запроса возвращает ту же страницу, пока снимок не истек.
+func (s *Server) getPredictionDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	limit, err := strconv.Atoi(s.param(r, "datasets", "limit"))
+	if err != nil || limit <= 0 || limit > maxDatasetLimit {
+		writeProblem(w, http.StatusBadRequest, "invalid limit: expected 1.."+strconv.Itoa(maxDatasetLimit))
+		return
+	}
+	var after int64
+	if v := query.Get("after"); v != "" {
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil || after < 0 {
+			writeProblem(w, http.StatusBadRequest, "invalid after: expected a non-negative integer")
+			return
+		}
+	}
+
+	var snapshotID int64
+	if v := query.Get("snapshot"); v != "" {
+		if snapshotID, err = strconv.ParseInt(v, 10, 64); err != nil || snapshotID <= 0 {
+			writeProblem(w, http.StatusBadRequest, "invalid snapshot: expected a positive integer")
+			return
+		}
+	} else {
+		if after != 0 {
+			writeProblem(w, http.StatusBadRequest, "after requires snapshot")
+			return
+		}
+		snap, err := s.datasets.CreatePredictionSnapshot(r.Context(), s.datasetTTL)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при создании снимка прогнозов", "err", err)
+			writeError(w, err)
+			return
+		}
+		s.log.InfoContext(r.Context(), "Создан снимок прогнозов", "id", snap.ID, "rows", snap.Rows)
+		snapshotID = snap.ID
+	}
+
+	snap, preds, err := s.datasets.GetPredictionSnapshot(r.Context(), snapshotID, after, limit, s.datasetTTL)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при выгрузке снимка прогнозов", "snapshot_id", snapshotID, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	page := predictionDatasetPage{Snapshot: snap, Predictions: preds, Next: after}
+	if len(preds) > 0 {
+		page.Next = preds[len(preds)-1].Seq
+	}
+	page.HasMore = page.Next < snap.Rows
+	w.Header().Set("X-Total-Count", strconv.FormatInt(snap.Rows, 10))
+	if page.HasMore {
+		setPageLinks(w, r, pageLink{"next", map[string]string{
+			"snapshot": strconv.FormatInt(snap.ID, 10),
+			"after":    strconv.FormatInt(page.Next, 10),
+			"limit":    strconv.Itoa(limit),
+		}})
+	}
+	s.log.DebugContext(r.Context(), "Возвращаем строки снимка", "rows", len(preds), "snapshot_id", snapshotID)
+	s.respond(w, r, page)
+}
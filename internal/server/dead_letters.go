@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/deadletter"
+
+	"github.com/gorilla/mux"
+)
+
+// getDeadLettersHandler возвращает элементы dead-letter очереди.
+// Параметры: source (bus, extract) и limit.
+func (s *Server) getDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	source := r.URL.Query().Get("source")
+
+	limit, err := strconv.Atoi(s.param(r, "dead_letters", "limit"))
+	if err != nil || limit <= 0 {
+		writeProblem(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	letters, err := s.deadLetters.List(r.Context(), source, limit)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении dead-letter элементов", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.DebugContext(r.Context(), "Возвращаем dead-letter элементы", "count", len(letters))
+	s.respond(w, r, letters)
+}
+
+// retryDeadLetterHandler повторно обрабатывает элемент
+func (s *Server) retryDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.deadLetters.Retry(r.Context(), id); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при повторной обработке dead-letter элемента", "id", id, "err", err)
+		writeProblem(w, deadLetterErrorStatus(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// discardDeadLetterHandler удаляет элемент без обработки
+func (s *Server) discardDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.deadLetters.Discard(r.Context(), id); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при удалении dead-letter элемента", "id", id, "err", err)
+		writeProblem(w, deadLetterErrorStatus(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deadLetterErrorStatus: неудачная повторная обработка — 422, остальное — по errorStatus
+// (неизвестный id — 404)
+func deadLetterErrorStatus(err error) int {
+	if errors.Is(err, deadletter.ErrRetryFailed) {
+		return http.StatusUnprocessableEntity
+	}
+	return errorStatus(err)
+}
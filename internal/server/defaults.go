@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/analytics"
+	"frontend-backend/internal/storage"
+)
+
+// Особые значения параметров
+const (
+	rangeAll   = "all"  // ?range= — вся история
+	bucketAuto = "auto" // ?bucket= графика — выбрать интервал по длине истории
+)
+
+// builtinDefaults — значения параметров эндпоинтов, если они не заданы ни в
+// запросе, ни в config (api.defaults.<endpoint>.<param>)
+var builtinDefaults = map[string]map[string]string{
+	"history":      {"range": rangeAll, "fill": storage.FillSkip},
+	"chart":        {"range": rangeAll, "bucket": bucketAuto, "fill": storage.FillSkip},
+	"predictions":  {"sort": "desc", "limit": "0"},
+	"summary":      {"limit": "5"},
+	"risk":         {"window": "1y"},
+	"correlation":  {"window": "90d", "fill": storage.FillSkip},
+	"rollup":       {"bucket": storage.BucketWeek},
+	"target_bands": {"bucket": storage.BucketWeek},
+	"quick_search": {"limit": "10"},
+	"dead_letters": {"limit": "100"},
+	"audit":        {"limit": "100"},
+	"changes":      {"limit": "500"},
+	"datasets":     {"limit": "1000"},
+}
+
+// defaultValidators проверяют значения по имени параметра
+var defaultValidators = map[string]func(string) error{
+	"range": func(v string) error {
+		if v == rangeAll {
+			return nil
+		}
+		_, err := analytics.ParseWindow(v)
+		return err
+	},
+	"window": func(v string) error {
+		_, err := analytics.ParseWindow(v)
+		return err
+	},
+	"bucket": func(v string) error {
+		if v == bucketAuto || storage.ValidBucket(v) {
+			return nil
+		}
+		return fmt.Errorf("invalid bucket %q: expected day, week or month", v)
+	},
+	"limit": func(v string) error {
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			return fmt.Errorf("invalid limit %q: expected a non-negative integer", v)
+		}
+		return nil
+	},
+	"fill": func(v string) error {
+		if !storage.ValidFill(v) {
+			return fmt.Errorf("invalid fill %q: expected skip, ffill, linear or null", v)
+		}
+		return nil
+	},
+	"sort": func(v string) error {
+		if v != "asc" && v != "desc" {
+			return fmt.Errorf("invalid sort %q: expected asc or desc", v)
+		}
+		return nil
+	},
+}
+
+// Defaults — реестр значений по умолчанию для параметров эндпоинтов.
+// Позволяет менять умолчания (например, диапазон истории 6m вместо всей)
+// через конфигурацию, без изменения кода и релиза фронтенда.
+type Defaults struct {
+	values map[string]map[string]string
+}
+
+// NewDefaults накладывает overrides из конфигурации на встроенные значения.
+// Неизвестные эндпоинты, параметры и некорректные значения — ошибка.
+func NewDefaults(overrides map[string]map[string]string) (*Defaults, error) {
+	d := &Defaults{values: make(map[string]map[string]string, len(builtinDefaults))}
+	for endpoint, params := range builtinDefaults {
+		d.values[endpoint] = make(map[string]string, len(params))
+		for name, value := range params {
+			d.values[endpoint][name] = value
+		}
+	}
+	for endpoint, params := range overrides {
+		if _, ok := d.values[endpoint]; !ok {
+			return nil, fmt.Errorf("api.defaults: unknown endpoint %q", endpoint)
+		}
+		for name, value := range params {
+			if _, ok := d.values[endpoint][name]; !ok {
+				return nil, fmt.Errorf("api.defaults.%s: unknown parameter %q", endpoint, name)
+			}
+			if err := defaultValidators[name](value); err != nil {
+				return nil, fmt.Errorf("api.defaults.%s.%s: %w", endpoint, name, err)
+			}
+			d.values[endpoint][name] = value
+		}
+	}
+	return d, nil
+}
+
+// WithDefaults задает реестр значений по умолчанию
+func WithDefaults(d *Defaults) Option {
+	return func(s *Server) {
+		s.defaults = d
+	}
+}
+
+// Get возвращает значение по умолчанию параметра эндпоинта
+func (d *Defaults) Get(endpoint, name string) string {
+	return d.values[endpoint][name]
+}
+
+// All возвращает копию реестра
+func (d *Defaults) All() map[string]map[string]string {
+	out := make(map[string]map[string]string, len(d.values))
+	for endpoint, params := range d.values {
+		out[endpoint] = make(map[string]string, len(params))
+		for name, value := range params {
+			out[endpoint][name] = value
+		}
+	}
+	return out
+}
+
+// param возвращает параметр запроса, а если его нет — значение из реестра
+func (s *Server) param(r *http.Request, endpoint, name string) string {
+	if v := r.URL.Query().Get(name); v != "" {
+		return v
+	}
+	return s.defaults.Get(endpoint, name)
+}
+
+// historyRange обрезает историю по параметру range (all или период вида 6m),
+// отсчитывая от последней записи
+func historyRange(history []storage.StockPriceHistory, value string) ([]storage.StockPriceHistory, error) {
+	if value == rangeAll {
+		return history, nil
+	}
+	window, err := analytics.ParseWindow(value)
+	if err != nil {
+		return nil, err
+	}
+	last := analytics.LastTime(history)
+	if last.IsZero() {
+		return history, nil
+	}
+	return analytics.HistorySince(history, window.Start(last)), nil
+}
+
+// getDefaultsHandler возвращает действующие значения по умолчанию
+func (s *Server) getDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.defaults.All())
+}
+
+// sortPredictions упорядочивает прогнозы по времени прогноза
+func sortPredictions(predictions []storage.Prediction, order string) {
+	sort.SliceStable(predictions, func(i, j int) bool {
+		ti, _ := accuracy.PredictedTime(predictions[i])
+		tj, _ := accuracy.PredictedTime(predictions[j])
+		if order == "asc" {
+			return ti.Before(tj)
+		}
+		return ti.After(tj)
+	})
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/storage"
+)
+
+// Политики деградации при отсутствии истории цен (config: api.degradation)
+const (
+	DegradationStrict  = "strict"  // ошибка 500, как для любой ошибки хранилища
+	DegradationLenient = "lenient" // частичный ответ с предупреждениями
+)
+
+// WithDegradation задает политику деградации. По умолчанию в конфигурации —
+// lenient (api.degradation); сервер без этой опции ведет себя как strict.
+func WithDegradation(policy string) Option {
+	return func(s *Server) {
+		s.lenient = policy == DegradationLenient
+	}
+}
+
+// priceHistory загружает историю цен. В режиме lenient отсутствие истории
+// не считается ошибкой: возвращается пустая история и предупреждение.
+func (s *Server) priceHistory(ctx context.Context, ticker string) ([]storage.StockPriceHistory, []string, error) {
+	history, err := s.store.GetStockPriceHistory(ctx, ticker)
+	if err != nil && s.lenient && errors.Is(err, storage.ErrNoPriceHistory) {
+		s.log.WarnContext(ctx, "Нет истории цен, отдаем частичный ответ", "ticker", ticker)
+		return []storage.StockPriceHistory{}, []string{fmt.Sprintf("price history is not available for ticker %s", ticker)}, nil
+	}
+	return history, nil, err
+}
+
+// setWarningHeaders дублирует предупреждения в заголовках Warning (код 199)
+// для эндпоинтов, ответ которых — массив без места под предупреждения
+func setWarningHeaders(w http.ResponseWriter, warnings []string) {
+	for _, warning := range warnings {
+		w.Header().Add("Warning", "199 - "+strconv.Quote(warning))
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/storage"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Форматы выгрузки (?format=)
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatXLSX = "xlsx"
+)
+
+// exportFormat возвращает запрошенный формат ответа; по умолчанию JSON
+func exportFormat(r *http.Request) (string, error) {
+	switch f := r.URL.Query().Get("format"); f {
+	case "", formatJSON:
+		return formatJSON, nil
+	case formatCSV, formatXLSX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid format %q: expected json, csv or xlsx", f)
+	}
+}
+
+// table — данные для выгрузки в таблицу. Ячейки: nil (пусто), string,
+// float64, int64, bool или time.Time.
+type table struct {
+	name   string // имя файла без расширения и имя листа
+	header []string
+	rows   [][]any
+	// attribution — ссылка на поставщика данных, которую требует лицензия:
+	// в CSV дописывается после пустой строки, в XLSX — отдельным листом
+	attribution *marketdata.License
+}
+
+// attributionRows — строки блока ссылки на поставщика данных
+func (t table) attributionRows() [][]any {
+	a := t.attribution
+	rows := [][]any{{"Source", a.Source}}
+	if a.Name != "" {
+		rows = append(rows, []any{"License", a.Name})
+	}
+	if a.URL != "" {
+		rows = append(rows, []any{"URL", a.URL})
+	}
+	if a.Attribution != "" {
+		rows = append(rows, []any{"Attribution", a.Attribution})
+	}
+	return rows
+}
+
+// writeTable отдает таблицу файлом для скачивания в формате csv или xlsx.
+// Колонки отбираются по ?fields=, время в ячейках переводится в формат из
+// ?ts= и ?tz=.
+func writeTable(w http.ResponseWriter, r *http.Request, format string, t table) error {
+	t = requestFields(r).columns(t)
+	if f := requestTimeFormat(r); !f.isDefault() {
+		for _, row := range t.rows {
+			for i, v := range row {
+				row[i] = f.cell(v)
+			}
+		}
+	}
+	filename := t.name + "." + format
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == formatCSV {
+		return writeCSV(w, t)
+	}
+	return writeXLSX(w, t)
+}
+
+// writeCSV пишет CSV с BOM, чтобы Excel распознал UTF-8 (кириллицу)
+func writeCSV(w http.ResponseWriter, t table) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(t.header)
+	record := make([]string, len(t.header))
+	for _, row := range t.rows {
+		for i, v := range row {
+			record[i] = csvCell(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if t.attribution != nil {
+		cw.Write(nil)
+		for _, row := range t.attributionRows() {
+			cw.Write([]string{csvCell(row[0]), csvCell(row[1])})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeXLSX пишет книгу с одним листом потоково, не собирая ее в памяти целиком
+func writeXLSX(w http.ResponseWriter, t table) error {
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	if err := f.SetSheetName(sheet, t.name); err == nil {
+		sheet = t.name
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("error creating xlsx stream: %w", err)
+	}
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 22}) // m/d/yy h:mm
+	if err != nil {
+		return fmt.Errorf("error creating xlsx style: %w", err)
+	}
+
+	header := make([]any, len(t.header))
+	for i, h := range t.header {
+		header[i] = h
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return fmt.Errorf("error writing xlsx header: %w", err)
+	}
+	for n, row := range t.rows {
+		cells := make([]any, len(row))
+		for i, v := range row {
+			if tm, ok := v.(time.Time); ok {
+				cells[i] = excelize.Cell{StyleID: dateStyle, Value: tm}
+			} else {
+				cells[i] = v
+			}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, n+2)
+		if err := sw.SetRow(cell, cells); err != nil {
+			return fmt.Errorf("error writing xlsx row %d: %w", n+2, err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("error writing xlsx: %w", err)
+	}
+	if t.attribution != nil {
+		if _, err := f.NewSheet("Attribution"); err != nil {
+			return fmt.Errorf("error creating xlsx attribution sheet: %w", err)
+		}
+		for n, row := range t.attributionRows() {
+			cell, _ := excelize.CoordinatesToCellName(1, n+1)
+			if err := f.SetSheetRow("Attribution", cell, &row); err != nil {
+				return fmt.Errorf("error writing xlsx attribution: %w", err)
+			}
+		}
+	}
+	return f.Write(w)
+}
+
+// predictionsTable — прогнозы по тикеру для выгрузки
+func predictionsTable(ticker string, preds []storage.Prediction) table {
+	t := table{
+		name: ticker + "-predictions",
+		header: []string{"PredictedAt", "Source", "PredictionType", "TargetPrice", "TargetChangePercent",
+			"Period", "Recommendation", "Direction", "Outcome", "RealizedReturn", "JustificationText", "Message"},
+	}
+	for _, p := range preds {
+		var predictedAt any
+		if at, ok := accuracy.PredictedTime(p); ok {
+			predictedAt = at
+		}
+		t.rows = append(t.rows, []any{
+			predictedAt, deref(p.Source), deref(p.PredictionType), deref(p.TargetPrice), deref(p.TargetChangePercent),
+			deref(p.Period), deref(p.Recommendation), deref(p.Direction), deref(p.Outcome), deref(p.RealizedReturn),
+			deref(p.JustificationText), deref(p.Message),
+		})
+	}
+	return t
+}
+
+// historyTable — история цен для выгрузки
+func historyTable(ticker string, history []storage.PriceSlot) table {
+	t := table{name: ticker + "-history", header: []string{"Timestamp", "Price", "Volume", "Filled"}}
+	for _, h := range history {
+		var ts any = h.Timestamp
+		if parsed, err := time.Parse(time.RFC3339, h.Timestamp); err == nil {
+			ts = parsed
+		}
+		t.rows = append(t.rows, []any{ts, deref(h.Price), h.Volume, h.Filled})
+	}
+	return t
+}
+
+// deref возвращает значение указателя или nil для пустой ячейки
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// fieldSet — поля, которые клиент запросил в ?fields=, по имени в нижнем
+// регистре. Значение nil — поле целиком, иначе — только вложенные поля.
+type fieldSet map[string]fieldSet
+
+type fieldSetKey struct{}
+
+// parseFields разбирает ?fields=TargetPrice,Markers.Time. Без параметра
+// возвращает nil — ответ отдается целиком.
+func parseFields(r *http.Request) (fieldSet, error) {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil, nil
+	}
+	fields := fieldSet{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		parts := strings.Split(strings.ToLower(path), ".")
+		for _, p := range parts {
+			if p == "" {
+				return nil, fmt.Errorf("invalid fields: empty name in %q", path)
+			}
+		}
+		fields.add(parts)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// add добавляет путь; поле целиком важнее отдельных вложенных полей
+func (fs fieldSet) add(path []string) {
+	name := path[0]
+	sub, exists := fs[name]
+	if len(path) == 1 {
+		fs[name] = nil
+		return
+	}
+	if exists && sub == nil {
+		return
+	}
+	if sub == nil {
+		sub = fieldSet{}
+		fs[name] = sub
+	}
+	sub.add(path[1:])
+}
+
+// lookup сообщает, запрошено ли поле объекта, и возвращает отбор для его значения
+func (fs fieldSet) lookup(key string) (fieldSet, bool) {
+	if fs == nil {
+		return nil, true
+	}
+	sub, ok := fs[strings.ToLower(key)]
+	return sub, ok
+}
+
+// fieldsMiddleware проверяет ?fields= до обработчика и передает отбор в
+// respond через контекст запроса
+func fieldsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields, err := parseFields(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if fields != nil {
+			r = r.WithContext(context.WithValue(r.Context(), fieldSetKey{}, fields))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestFields возвращает отбор полей запроса или nil
+func requestFields(r *http.Request) fieldSet {
+	fields, _ := r.Context().Value(fieldSetKey{}).(fieldSet)
+	return fields
+}
+
+// project оставляет в значении, разобранном из JSON, только запрошенные поля.
+// Отбор применяется к каждому элементу массива.
+func (fs fieldSet) project(v any) any {
+	if fs == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			sub, ok := fs.lookup(k)
+			if !ok {
+				delete(v, k)
+				continue
+			}
+			v[k] = sub.project(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = fs.project(item)
+		}
+	}
+	return v
+}
+
+// columns оставляет в таблице выгрузки только запрошенные колонки
+func (fs fieldSet) columns(t table) table {
+	if fs == nil {
+		return t
+	}
+	var keep []int
+	var header []string
+	for i, h := range t.header {
+		if _, ok := fs.lookup(h); ok {
+			keep = append(keep, i)
+			header = append(header, h)
+		}
+	}
+	rows := make([][]any, len(t.rows))
+	for n, row := range t.rows {
+		rows[n] = make([]any, len(keep))
+		for j, i := range keep {
+			rows[n][j] = row[i]
+		}
+	}
+	t.header, t.rows = header, rows
+	return t
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// priceGapsResponse — ответ /stocks/{ticker}/history/gaps
+type priceGapsResponse struct {
+	Ticker      string                      `json:"Ticker"`
+	MissingDays int                         `json:"MissingDays"`
+	Gaps        []storage.PriceGap          `json:"Gaps"`
+	History     []storage.StockPriceHistory `json:"History,omitempty"`
+	Warnings    []string                    `json:"Warnings,omitempty"`
+	Attribution *marketdata.License         `json:"Attribution,omitempty"`
+}
+
+// getPriceGapsHandler находит пропущенные торговые дни в истории цен.
+// С ?fill=true в ответ добавляется история с заполненными пропусками
+// (?method=ffill по умолчанию или linear); ?fill=ffill|linear — краткая форма.
+func (s *Server) getPriceGapsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	query := r.URL.Query()
+	method := query.Get("method")
+	fill := query.Get("fill")
+	// ?fill=ffill|linear — то же, что ?fill=true&method=...
+	if fill == storage.FillForward || fill == storage.FillLinear {
+		method, fill = fill, "true"
+	}
+	if method == "" {
+		method = storage.FillForward
+	}
+	if method != storage.FillForward && method != storage.FillLinear {
+		writeProblem(w, http.StatusBadRequest, "method must be ffill or linear")
+		return
+	}
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	resp := priceGapsResponse{Ticker: ticker, Gaps: storage.DetectPriceGaps(history), Warnings: warnings, Attribution: s.attribution(w, ticker)}
+	for _, g := range resp.Gaps {
+		resp.MissingDays += g.MissingDays
+	}
+	if fill == "true" {
+		resp.History = storage.FillPriceGaps(history, method)
+	}
+
+	s.log.DebugContext(r.Context(), "Найдены пропуски в истории цен", "gaps", len(resp.Gaps), "missing_days", resp.MissingDays, "ticker", ticker)
+	s.respond(w, r, resp)
+}
@@ -0,0 +1,17 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/gql"
+
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// graphqlHandler возвращает обработчик /graphql поверх хранилища сервера
+func (s *Server) graphqlHandler() http.HandlerFunc {
+	h := &relay.Handler{Schema: gql.NewSchema(s.store)}
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+	}
+}
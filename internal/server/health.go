@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessTimeout ограничивает время одной проверки готовности
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck — проверка зависимости для /readyz: nil — зависимость
+// доступна
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// WithReadinessChecks задает проверки /readyz; без них сервис готов сразу
+// после запуска
+func WithReadinessChecks(checks ...ReadinessCheck) Option {
+	return func(s *Server) {
+		s.readiness = append(s.readiness, checks...)
+	}
+}
+
+// checkResult — результат проверки в теле /readyz
+type checkResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok или fail
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// getHealthzHandler отвечает, что процесс жив и обслуживает запросы
+func (s *Server) getHealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// getReadyzHandler параллельно выполняет проверки зависимостей: 200, если
+// все прошли, иначе 503. В теле — результат и длительность каждой.
+func (s *Server) getReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	results := make([]checkResult, len(s.readiness))
+	var wg sync.WaitGroup
+	for i, c := range s.readiness {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+			defer cancel()
+			start := time.Now()
+			err := c.Check(ctx)
+			res := checkResult{Name: c.Name, Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	status, code := "ok", http.StatusOK
+	for _, res := range results {
+		if res.Status != "ok" {
+			s.log.WarnContext(r.Context(), "Проверка готовности не пройдена", "check", res.Name, "err", res.Error)
+			status, code = "fail", http.StatusServiceUnavailable
+		}
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Status string        `json:"status"`
+		Checks []checkResult `json:"checks"`
+	}{status, results})
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/events"
+)
+
+const (
+	// subscriberBuffer — сколько сообщений может ждать отправки одному
+	// подписчику; подписчик, который не успевает их читать, отключается
+	subscriberBuffer = 64
+	// hubHistory — сколько последних событий хранится для докачки по
+	// Last-Event-ID после переподключения
+	hubHistory = 1000
+	// allTickers — подписка на события по всем тикерам
+	allTickers = "*"
+)
+
+// Hub рассылает события подписчикам /ws и /events по тикерам. Каждое
+// событие получает ID вида <запуск>-<номер>; последние события хранятся,
+// чтобы переподключившийся клиент получил пропущенное.
+type Hub struct {
+	boot string // идентификатор запуска: номера событий не переживают рестарт
+	log  *slog.Logger
+
+	mu      sync.Mutex
+	seq     uint64
+	history []events.Event
+	clients map[*subscriber]struct{}
+}
+
+// NewHub создает новый экземпляр Hub; nil logger — slog.Default()
+func NewHub(logger *slog.Logger) *Hub {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Hub{
+		boot:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		log:     logger,
+		clients: make(map[*subscriber]struct{}),
+	}
+}
+
+// WithHub задает хаб событий, в который публикуют загрузка прогнозов и
+// котировок; без него /ws и /events работают, но событий не получают
+func WithHub(h *Hub) Option {
+	return func(s *Server) {
+		s.hub = h
+	}
+}
+
+// frame — сообщение в очереди подписчика
+type frame struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// subscriber — соединение /ws или /events и его подписки
+type subscriber struct {
+	send chan frame
+
+	mu      sync.Mutex
+	tickers map[string]bool
+}
+
+func newSubscriber() *subscriber {
+	return &subscriber{send: make(chan frame, subscriberBuffer), tickers: map[string]bool{}}
+}
+
+func (c *subscriber) subscribed(ticker string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tickers[allTickers] || c.tickers[ticker]
+}
+
+// subscribe добавляет тикеры (в верхнем регистре) к подпискам
+func (c *subscriber) subscribe(tickers []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range tickers {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			c.tickers[t] = true
+		}
+	}
+}
+
+// Publish реализует events.Publisher: присваивает событию ID, сохраняет его
+// в истории и отправляет подписчикам тикера
+func (h *Hub) Publish(e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	e.ID = h.boot + "-" + strconv.FormatUint(h.seq, 10)
+	data, err := json.Marshal(e)
+	if err != nil {
+		h.log.Error("Ошибка сериализации события", "type", e.Type, "err", err)
+		return
+	}
+	h.history = append(h.history, e)
+	if len(h.history) > hubHistory {
+		h.history = h.history[len(h.history)-hubHistory:]
+	}
+
+	for c := range h.clients {
+		if !c.subscribed(e.Ticker) {
+			continue
+		}
+		select {
+		case c.send <- frame{ID: e.ID, Type: e.Type, Data: data}:
+		default:
+			h.log.Warn("Подписчик не успевает читать события, отключаем")
+			h.removeLocked(c)
+		}
+	}
+}
+
+func (h *Hub) register(c *subscriber) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+// registerFrom регистрирует подписчика и атомарно возвращает события после
+// lastID, чтобы между докачкой и живым потоком не было пропусков и повторов.
+// complete равен false, если часть событий после lastID уже вытеснена из
+// истории или lastID относится к прошлому запуску.
+func (h *Hub) registerFrom(c *subscriber, lastID string) (missed []events.Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+	if lastID == "" {
+		return nil, true
+	}
+
+	after := uint64(0)
+	complete = false
+	if boot, seq, ok := strings.Cut(lastID, "-"); ok && boot == h.boot {
+		if n, err := strconv.ParseUint(seq, 10, 64); err == nil {
+			after = n
+			oldest := h.seq - uint64(len(h.history)) + 1
+			complete = n+1 >= oldest
+		}
+	}
+	for _, e := range h.history {
+		if eventSeq(e.ID) > after && c.subscribed(e.Ticker) {
+			missed = append(missed, e)
+		}
+	}
+	return missed, complete
+}
+
+// eventSeq возвращает номер события из его ID
+func eventSeq(id string) uint64 {
+	_, seq, _ := strings.Cut(id, "-")
+	n, _ := strconv.ParseUint(seq, 10, 64)
+	return n
+}
+
+func (h *Hub) unregister(c *subscriber) {
+	h.mu.Lock()
+	h.removeLocked(c)
+	h.mu.Unlock()
+}
+
+// removeLocked удаляет подписчика и закрывает его очередь. Вызывается под h.mu.
+func (h *Hub) removeLocked(c *subscriber) {
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// reply ставит сообщение подписчику в очередь, не блокируясь. Проверка под
+// h.mu гарантирует, что очередь еще не закрыта хабом.
+func (h *Hub) reply(c *subscriber, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- frame{Data: data}:
+	default:
+	}
+}
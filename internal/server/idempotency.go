@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// idempotencyHeader — ключ, под которым клиент повторяет запрос
+const idempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKey — максимальная длина ключа
+const maxIdempotencyKey = 255
+
+// idempotentRoutes — эндпоинты создания, принимающие Idempotency-Key (ключ —
+// метод и шаблон маршрута, как в routeRoles). POST /admin/api-keys сюда не
+// входит: ответ с ключом нельзя хранить в БД.
+var idempotentRoutes = map[string]bool{
+	"POST /admin/stocks":      true,
+	"POST /admin/predictions": true,
+	"POST /admin/webhooks":    true,
+}
+
+// IdempotencyStore хранит ответы на запросы с Idempotency-Key; доступен
+// только с PostgreSQL
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (storage.IdempotentRequest, bool, error)
+	SaveIdempotentResponse(ctx context.Context, scope, key string, status int, contentType string, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+}
+
+// WithIdempotency включает заголовок Idempotency-Key на эндпоинтах создания;
+// ответы хранятся ttl
+func WithIdempotency(st IdempotencyStore, ttl time.Duration) Option {
+	return func(s *Server) {
+		s.idempotency = st
+		s.idempotencyTTL = ttl
+	}
+}
+
+// idempotencyRecorder пропускает ответ клиенту и копирует его для сохранения
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware выполняет запрос с Idempotency-Key один раз: повтор
+// с тем же ключом получает сохраненный ответ с заголовком
+// Idempotent-Replayed: true. Ключ с другим телом запроса — 422, повтор, пока
+// первый запрос выполняется, — 409. Ответы 5xx не сохраняются, такой запрос
+// можно повторить с тем же ключом. Если БД недоступна, запрос выполняется
+// без ключа.
+func (s *Server) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" || !idempotentRoute(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeProblem(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", idempotencyHeader, maxIdempotencyKey))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "error reading request body: "+err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(auth.FromContext(r.Context()))
+		fingerprint := requestFingerprint(r, body)
+		stored, reserved, err := s.idempotency.ReserveIdempotencyKey(r.Context(), scope, key, fingerprint, s.idempotencyTTL)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при резервировании ключа идемпотентности", "key", key, "err", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch {
+		case !reserved && stored.Fingerprint != fingerprint:
+			writeProblem(w, http.StatusUnprocessableEntity, idempotencyHeader+" was already used for a different request")
+			return
+		case !reserved && stored.Status == 0:
+			w.Header().Set("Retry-After", "1")
+			writeProblem(w, http.StatusConflict, "a request with this "+idempotencyHeader+" is still in progress")
+			return
+		case !reserved:
+			s.log.InfoContext(r.Context(), "Повтор запроса с ключом идемпотентности", "method", r.Method, "path", r.URL.Path, "key", key)
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		// Ключ освобождается и при панике обработчика, ответ сохраняется и
+		// после отключения клиента
+		ctx := context.WithoutCancel(r.Context())
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := s.idempotency.ReleaseIdempotencyKey(ctx, scope, key); err != nil {
+				s.log.ErrorContext(r.Context(), "Ошибка при освобождении ключа идемпотентности", "key", key, "err", err)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			return
+		}
+		if err := s.idempotency.SaveIdempotentResponse(ctx, scope, key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при сохранении ответа для ключа идемпотентности", "key", key, "err", err)
+			return
+		}
+		saved = true
+	})
+}
+
+// idempotentRoute сообщает, принимает ли маршрут запроса Idempotency-Key
+func idempotentRoute(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	tpl, err := route.GetPathTemplate()
+	return err == nil && idempotentRoutes[r.Method+" "+tpl]
+}
+
+// idempotencyScope — пространство ключей клиента: одинаковые ключи разных
+// клиентов не пересекаются
+func idempotencyScope(p *auth.Principal) string {
+	switch {
+	case p == nil:
+		return "anonymous"
+	case p.KeyID != 0:
+		return "key:" + strconv.FormatInt(p.KeyID, 10)
+	case p.UserID != 0:
+		return "user:" + strconv.FormatInt(p.UserID, 10)
+	default:
+		return "name:" + p.Name
+	}
+}
+
+// requestFingerprint — SHA-256 метода, пути и тела запроса
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"frontend-backend/internal/storage"
+)
+
+// preferredLang выбирает язык названий по Accept-Language с учетом q;
+// если ни один поддерживаемый язык не подошел — DefaultLang
+func preferredLang(r *http.Request) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		// en-US → en
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{lang, q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.q <= 0 {
+			continue
+		}
+		for _, lang := range storage.SupportedLangs {
+			if c.lang == lang {
+				return lang
+			}
+		}
+	}
+	return storage.DefaultLang
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"frontend-backend/internal/marketdata"
+
+	"github.com/gorilla/mux"
+)
+
+// WithLicenses задает лицензии поставщиков на котировки по тикерам
+func WithLicenses(l *marketdata.Licenses) Option {
+	return func(s *Server) {
+		s.licenses = l
+	}
+}
+
+// attribution возвращает блок ссылки на поставщика котировок тикера или nil,
+// если лицензия его не требует. Блок дублируется в заголовке
+// X-Data-Attribution для ответов-массивов, где нет места под поле.
+func (s *Server) attribution(w http.ResponseWriter, ticker string) *marketdata.License {
+	lic := s.licenses.For(ticker)
+	if !lic.RequiresAttribution() {
+		return nil
+	}
+	// Значение заголовка — ASCII, поэтому текст кодируется как в URL
+	w.Header().Set("X-Data-Attribution", url.PathEscape(lic.Text()))
+	return &lic
+}
+
+// checkExport отвечает 403, если лицензия запрещает выгрузку котировок тикера
+func (s *Server) checkExport(w http.ResponseWriter, ticker string) bool {
+	lic := s.licenses.For(ticker)
+	if lic.AllowsExport() {
+		return true
+	}
+	s.log.Warn("Выгрузка котировок запрещена лицензией", "ticker", ticker, "source", lic.Source)
+	writeProblem(w, http.StatusForbidden, fmt.Sprintf("export of price data for %s is not permitted by the %s license", ticker, lic.Source))
+	return false
+}
+
+// getLicenseHandler возвращает лицензию на котировки тикера, чтобы фронтенд
+// показал ссылку на поставщика рядом с графиком
+func (s *Server) getLicenseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+	s.respond(w, r, s.licenses.For(ticker))
+}
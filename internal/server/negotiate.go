@@ -0,0 +1,82 @@
+package server
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// contentTypeMsgpack — бинарный формат ответа для больших выборок (история цен)
+const contentTypeMsgpack = "application/x-msgpack"
+
+// msgpackTypes — типы из Accept, при которых отдается MessagePack
+var msgpackTypes = map[string]bool{contentTypeMsgpack: true, "application/msgpack": true, "application/vnd.msgpack": true}
+
+// wantsMsgpack сообщает, что клиент предпочитает MessagePack: его q в Accept
+// строго больше, чем у JSON. Без Accept или при равенстве — JSON.
+func wantsMsgpack(r *http.Request) bool {
+	var jsonQ, packQ float64
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch {
+		case msgpackTypes[mediaType]:
+			packQ = max(packQ, q)
+		case mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return packQ > jsonQ
+}
+
+// respond кодирует ответ в формате, выбранном по Accept: JSON по умолчанию
+// или MessagePack с теми же именами полей, что и в JSON. Метки времени
+// отдаются в формате из ?ts= и ?tz=.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, v any) {
+	s.respondStatus(w, r, http.StatusOK, v)
+}
+
+// respondStatus — respond с кодом ответа, отличным от 200 (например, 201
+// при создании)
+func (s *Server) respondStatus(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+	if !wantsMsgpack(r) {
+		w.Header().Set("Content-Type", "application/json")
+		writeStatus(w, status)
+		encodeResponse(w, r, v, false)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeMsgpack)
+	writeStatus(w, status)
+	if err := encodeResponse(w, r, v, true); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка кодирования ответа в MessagePack", "err", err)
+	}
+}
+
+// writeStatus отправляет заголовки с кодом status; 200 net/http отправит сам
+// при первой записи тела
+func writeStatus(w http.ResponseWriter, status int) {
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	return enc.Encode(v)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page — общий конверт постраничных ответов /api/v1: одинаковые поля во
+// всех эндпоинтах, чтобы фронтенд обходился одним компонентом пагинации
+type Page struct {
+	Items      any    `json:"items"`
+	Total      *int64 `json:"total,omitempty"`       // всего элементов, если известно
+	Limit      int    `json:"limit"`                 // 0 — без ограничения
+	Offset     *int   `json:"offset,omitempty"`      // для постраничного обхода по смещению
+	Cursor     string `json:"cursor,omitempty"`      // позиция этой страницы
+	NextCursor string `json:"next_cursor,omitempty"` // передается в следующий запрос
+}
+
+// parseOffset разбирает необязательный ?offset=
+func parseOffset(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("offset")
+	if v == "" {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(v)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset: expected a non-negative integer")
+	}
+	return offset, nil
+}
+
+// pageLink — ссылка на соседнюю страницу: тот же запрос с другими параметрами
+type pageLink struct {
+	rel    string
+	params map[string]string
+}
+
+// setPageLinks пишет заголовок Link (RFC 8288, ранее RFC 5988) со ссылками
+// на страницы относительно URL запроса
+func setPageLinks(w http.ResponseWriter, r *http.Request, links ...pageLink) {
+	var values []string
+	for _, l := range links {
+		u := *r.URL
+		q := u.Query()
+		for name, value := range l.params {
+			q.Set(name, value)
+		}
+		u.RawQuery = q.Encode()
+		values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), l.rel))
+	}
+	if len(values) > 0 {
+		w.Header().Set("Link", strings.Join(values, ", "))
+	}
+}
+
+// setOffsetLinks пишет X-Total-Count и ссылки first/prev/next/last для обхода
+// по смещению. При limit 0 ответ содержит все элементы и ссылок нет.
+func setOffsetLinks(w http.ResponseWriter, r *http.Request, offset, limit, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if limit <= 0 {
+		return
+	}
+	at := func(rel string, offset int) pageLink {
+		return pageLink{rel, map[string]string{"offset": strconv.Itoa(offset), "limit": strconv.Itoa(limit)}}
+	}
+	links := []pageLink{at("first", 0)}
+	if offset > 0 {
+		links = append(links, at("prev", max(0, offset-limit)))
+	}
+	if offset+limit < total {
+		links = append(links, at("next", offset+limit))
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links = append(links, at("last", last))
+	setPageLinks(w, r, links...)
+}
+
+// pageFields применяет ?fields= к элементам конверта, а не к самому конверту
+func (fs fieldSet) pageFields() fieldSet {
+	if fs == nil {
+		return nil
+	}
+	return fieldSet{"items": fs, "total": nil, "limit": nil, "offset": nil, "cursor": nil, "next_cursor": nil}
+}
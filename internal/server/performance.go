@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// getPerformanceHandler возвращает последнюю цену и доходность за
+// стандартные окна (1d, 1w, 1m, 3m, ytd, 1y)
+func (s *Server) getPerformanceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	perf := storage.ComputePerformance(ticker, history)
+	perf.Warnings = warnings
+	s.respond(w, r, perf)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/breaker"
+	"frontend-backend/internal/requestid"
+	"frontend-backend/internal/storage"
+)
+
+// problem — тело ошибки в формате RFC 7807 (application/problem+json)
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Matches — акции с одинаковым тикером, если выбор неоднозначен
+	Matches []storage.StockMatch `json:"matches,omitempty"`
+	// RequestID — идентификатор запроса для обращения в поддержку
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeProblem отвечает ошибкой status с пояснением detail
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	writeProblemBody(w, problem{Status: status, Detail: detail})
+}
+
+func writeProblemBody(w http.ResponseWriter, p problem) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Disposition")
+	h.Set("Content-Type", "application/problem+json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.RequestID = h.Get(requestid.Header)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeError отвечает ошибкой хранилища или обработки: ErrNotFound — 404,
+// ErrValidation — 400, ErrConflict — 409, ErrUnavailable — 503 с
+// Retry-After, остальное — 500
+func writeError(w http.ResponseWriter, err error) {
+	p := problem{Status: errorStatus(err), Detail: err.Error()}
+	var ambiguous *storage.AmbiguousTickerError
+	if errors.As(err, &ambiguous) {
+		p.Matches = ambiguous.Matches
+	}
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(open.RetryAfter), 1)))
+	}
+	writeProblemBody(w, p)
+}
+
+// errorStatus выбирает код ответа по классу ошибки
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrPrecondition):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// notFoundHandler и methodNotAllowedHandler отвечают в том же формате на
+// неизвестные маршруты
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, http.StatusNotFound, "no route for "+r.URL.Path)
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, http.StatusMethodNotAllowed, r.Method+" is not allowed for "+r.URL.Path)
+}
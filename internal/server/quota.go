@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/storage"
+)
+
+// usagePath — эндпоинт потребления; он не расходует квоту, чтобы клиент мог
+// узнать о ее исчерпании
+const usagePath = "/account/usage"
+
+// UsageStore учитывает запросы по API-ключам; доступен только с PostgreSQL
+type UsageStore interface {
+	RecordAPIKeyUsage(ctx context.Context, keyID int64, at time.Time) (storage.APIKeyUsage, error)
+	GetAPIKeyUsage(ctx context.Context, keyID int64, at time.Time) (storage.APIKeyUsage, error)
+}
+
+// WithAPIKeyUsage включает учет запросов по ключам, выпущенным через API,
+// проверку их суточных и месячных квот и GET /account/usage
+func WithAPIKeyUsage(u UsageStore) Option {
+	return func(s *Server) {
+		s.usage = u
+	}
+}
+
+// quotaPeriod — потребление ключа за период; Limit и Remaining равны nil,
+// если квоты нет
+type quotaPeriod struct {
+	Limit     *int64    `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining *int64    `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// usageResponse — ответ GET /account/usage
+type usageResponse struct {
+	KeyID   int64       `json:"key_id"`
+	Name    string      `json:"name"`
+	Daily   quotaPeriod `json:"daily"`
+	Monthly quotaPeriod `json:"monthly"`
+}
+
+// quotaMiddleware учитывает запрос по API-ключу и отклоняет его, если квота
+// исчерпана: месячная — 402, суточная — 429. Ключи из конфигурации, токены и
+// анонимные запросы не учитываются. Если БД недоступна, запрос пропускается.
+func (s *Server) quotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := auth.FromContext(r.Context())
+		if p == nil || p.KeyID == 0 || r.Method == http.MethodOptions || r.URL.Path == usagePath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, ok := s.keys.Key(p.KeyID)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now().UTC()
+		used, err := s.usage.RecordAPIKeyUsage(r.Context(), key.ID, now)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при учете запроса по API-ключу", "id", key.ID, "err", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		day, month := quotaResets(now)
+		switch {
+		case key.MonthlyQuota > 0 && used.Month > key.MonthlyQuota:
+			s.log.WarnContext(r.Context(), "API-ключ исчерпал месячную квоту", "id", key.ID, "name", key.Name, "monthly_quota", key.MonthlyQuota)
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(month.Sub(now))))
+			writeProblem(w, http.StatusPaymentRequired, fmt.Sprintf("monthly quota of %d requests exhausted", key.MonthlyQuota))
+			return
+		case key.DailyQuota > 0 && used.Day > key.DailyQuota:
+			s.log.WarnContext(r.Context(), "API-ключ исчерпал суточную квоту", "id", key.ID, "name", key.Name, "daily_quota", key.DailyQuota)
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(day.Sub(now))))
+			writeProblem(w, http.StatusTooManyRequests, fmt.Sprintf("daily quota of %d requests exhausted", key.DailyQuota))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getUsageHandler возвращает потребление и квоты API-ключа запроса
+func (s *Server) getUsageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	p := auth.FromContext(r.Context())
+	if p == nil || p.KeyID == 0 {
+		writeProblem(w, http.StatusBadRequest, "usage is tracked only for API keys issued via /admin/api-keys")
+		return
+	}
+	key, ok := s.keys.Key(p.KeyID)
+	if !ok {
+		writeError(w, fmt.Errorf("%w: %d", storage.ErrAPIKeyNotFound, p.KeyID))
+		return
+	}
+	now := time.Now().UTC()
+	used, err := s.usage.GetAPIKeyUsage(r.Context(), key.ID, now)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении потребления API-ключа", "id", key.ID, "err", err)
+		writeError(w, err)
+		return
+	}
+	day, month := quotaResets(now)
+	s.respond(w, r, usageResponse{
+		KeyID:   key.ID,
+		Name:    key.Name,
+		Daily:   newQuotaPeriod(key.DailyQuota, used.Day, day),
+		Monthly: newQuotaPeriod(key.MonthlyQuota, used.Month, month),
+	})
+}
+
+func newQuotaPeriod(limit, used int64, resets time.Time) quotaPeriod {
+	q := quotaPeriod{Used: used, ResetsAt: resets}
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		q.Limit, q.Remaining = &limit, &remaining
+	}
+	return q
+}
+
+// quotaResets возвращает начало следующих суток и следующего месяца по UTC
+func quotaResets(now time.Time) (day, month time.Time) {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC), time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/ratelimit"
+)
+
+// apiKeyHeader — заголовок, по которому клиенты различаются при ограничении
+const apiKeyHeader = "X-API-Key"
+
+// rateLimitWarning — текст заголовка X-RateLimit-Warning в режиме soft
+const rateLimitWarning = "rate limit exceeded; requests will be rejected once enforcement is enabled"
+
+// WithRateLimit включает ограничение частоты запросов
+func WithRateLimit(l *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.rateLimit = l
+	}
+}
+
+// rateLimitMiddleware проверяет лимит клиента в группе маршрутов запроса.
+// Превышение в режиме soft только отмечается заголовком X-RateLimit-Warning
+// и считается в метриках; при жестком ограничении запрос отклоняется с кодом 429.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := ratelimit.Identity(r.Header.Get(apiKeyHeader), clientIP(r))
+		d := s.rateLimit.Allow(client, r.URL.Path)
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+		if !d.Exceeded {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if d.Enforced {
+			metrics.RateLimitViolations.WithLabelValues("enforced").Inc()
+			s.log.WarnContext(r.Context(), "Клиент превысил лимит запросов группы, запрос отклонен", "client", client, "group", d.Group)
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+			writeProblem(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		metrics.RateLimitViolations.WithLabelValues("soft").Inc()
+		w.Header().Set("X-RateLimit-Warning", rateLimitWarning)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientIP возвращает IP-адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
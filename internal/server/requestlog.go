@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"frontend-backend/internal/requestid"
+)
+
+// withRequestID берет идентификатор запроса из X-Request-ID или, если его
+// нет или он некорректен, генерирует новый; идентификатор попадает в
+// контекст и в заголовок ответа. Вызывается до маршрутизатора, чтобы
+// идентификатор был и у ответов на неизвестные маршруты.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	w.Header().Set(requestid.Header, id)
+	return r.WithContext(requestid.NewContext(r.Context(), id))
+}
+
+// requestLogMiddleware пишет по записи на каждый запрос: метод, путь, код,
+// размер ответа, длительность и IP клиента; идентификатор запроса
+// добавляет логгер из контекста. Ответы
+// 5xx пишутся с уровнем ERROR, остальные — INFO.
+func (s *Server) requestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.log.LogAttrs(r.Context(), level, "HTTP-запрос",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_ip", clientIP(r)),
+		)
+	})
+}
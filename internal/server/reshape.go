@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// encodeResponse кодирует ответ в JSON или MessagePack. Если запрос задает
+// ?fields=, ?ts= или ?tz=, ответ сначала кодируется в JSON, а затем
+// перекодируется: так отбор полей и формат времени одинаково работают для
+// любых типов ответа.
+func encodeResponse(w io.Writer, r *http.Request, v any, pack bool) error {
+	f := requestTimeFormat(r)
+	fields := requestFields(r)
+	if _, ok := v.(Page); ok {
+		fields = fields.pageFields()
+	}
+	if f.isDefault() && fields == nil {
+		if pack {
+			return encodeMsgpack(w, v)
+		}
+		return json.NewEncoder(w).Encode(v)
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if pack {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var generic any
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		return encodeMsgpack(w, f.convertValue("", fields.project(generic)))
+	}
+	var out bytes.Buffer
+	if err := rewriteJSON(&out, raw, f, fields); err != nil {
+		return err
+	}
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// rewriteJSON копирует JSON из src в dst, оставляя только поля из fields и
+// переводя метки времени в формат f. Порядок полей и числа сохраняются.
+func rewriteJSON(dst *bytes.Buffer, src []byte, f timeFormat, fields fieldSet) error {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	for {
+		err := copyValue(dst, dec, f, fields, "")
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dst.WriteByte('\n')
+	}
+}
+
+// copyValue копирует одно значение; key — поле, в котором оно лежит
+// (элементы массива наследуют поле массива)
+func copyValue(dst *bytes.Buffer, dec *json.Decoder, f timeFormat, fields fieldSet, key string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		dst.WriteRune(rune(t))
+		written := 0
+		for dec.More() {
+			sub, subKey := fields, key
+			if t == '{' {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				subKey = tok.(string)
+				var ok bool
+				if sub, ok = fields.lookup(subKey); !ok {
+					var skip json.RawMessage
+					if err := dec.Decode(&skip); err != nil {
+						return err
+					}
+					continue
+				}
+				if written > 0 {
+					dst.WriteByte(',')
+				}
+				writeJSONString(dst, subKey)
+				dst.WriteByte(':')
+			} else if written > 0 {
+				dst.WriteByte(',')
+			}
+			if err := copyValue(dst, dec, f, sub, subKey); err != nil {
+				return err
+			}
+			written++
+		}
+		end, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		dst.WriteRune(rune(end.(json.Delim)))
+	case string:
+		writeJSONString(dst, f.convert(key, t))
+	case json.Number:
+		dst.WriteString(t.String())
+	case bool:
+		dst.WriteString(strconv.FormatBool(t))
+	case nil:
+		dst.WriteString("null")
+	}
+	return nil
+}
+
+func writeJSONString(dst *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	dst.Write(b)
+}
+
+// convertValue переводит метки времени в значении, разобранном из JSON, для
+// кодирования в MessagePack; key — поле, в котором лежит значение. Числа
+// становятся int64, если они целые.
+func (f timeFormat) convertValue(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = f.convertValue(k, item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = f.convertValue(key, item)
+		}
+	case string:
+		return f.convert(key, v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	}
+	return v
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"frontend-backend/internal/storage"
+)
+
+// RevisionStore читает историю изменений прогнозов; доступен только с PostgreSQL
+type RevisionStore interface {
+	GetPredictionRevisions(ctx context.Context, id int64) ([]storage.PredictionRevision, error)
+}
+
+// WithRevisions включает эндпоинт истории прогноза /predictions/{id}/revisions
+func WithRevisions(rs RevisionStore) Option {
+	return func(s *Server) {
+		s.revisions = rs
+	}
+}
+
+// getPredictionRevisionsHandler возвращает версии прогноза от старых к новым;
+// последняя — текущая
+func (s *Server) getPredictionRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	revisions, err := s.revisions.GetPredictionRevisions(r.Context(), id)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории прогноза", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, revisions)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"frontend-backend/internal/analytics"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithBenchmark задает тикер индекса, относительно которого считается бета
+func WithBenchmark(ticker string) Option {
+	return func(s *Server) {
+		s.benchmark = ticker
+	}
+}
+
+// getRiskHandler возвращает метрики риска по тикеру за ?window= (по умолчанию api.defaults.risk.window)
+func (s *Server) getRiskHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	window, err := analytics.ParseWindow(s.param(r, "risk", "window"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	var since time.Time
+	if last := analytics.LastTime(history); !last.IsZero() {
+		since = window.Start(last)
+	}
+
+	benchmark := s.benchmark
+	var benchmarkHistory []storage.StockPriceHistory
+	if benchmark != "" && benchmark != ticker {
+		if benchmarkHistory, err = s.store.GetStockPriceHistory(r.Context(), benchmark); err != nil {
+			// Без истории индекса отдаем метрики без беты
+			s.log.WarnContext(r.Context(), "Нет истории цен индекса", "benchmark", benchmark, "err", err)
+			warnings = append(warnings, fmt.Sprintf("benchmark %s price history is not available, beta is omitted", benchmark))
+		}
+	}
+
+	risk := analytics.ComputeRisk(ticker, history, since, benchmark, benchmarkHistory)
+	risk.Warnings = warnings
+	s.respond(w, r, risk)
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// getPredictionRollupHandler возвращает число прогнозов по рекомендациям
+// за интервалы ?bucket=day|week|month (по умолчанию api.defaults.rollup.bucket).
+// Обслуживает /predictions/rollup и /predictions/activity.
+func (s *Server) getPredictionRollupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticker := mux.Vars(r)["ticker"]
+
+	bucket := s.param(r, "rollup", "bucket")
+	if !storage.ValidBucket(bucket) {
+		writeProblem(w, http.StatusBadRequest, "bucket must be day, week or month")
+		return
+	}
+
+	rollup, err := s.store.GetPredictionRollup(r.Context(), ticker, bucket)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при агрегации прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	s.log.DebugContext(r.Context(), "Возвращаем интервалы агрегации", "buckets", len(rollup), "ticker", ticker)
+	s.respond(w, r, rollup)
+}
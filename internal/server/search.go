@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/search"
+)
+
+// quickSearchLimits — число результатов каждого типа в выдаче палитры
+var quickSearchLimits = map[string]int{
+	search.TypeStock:      5,
+	search.TypeSource:     3,
+	search.TypeTag:        3,
+	search.TypePrediction: 5,
+}
+
+// quickSearchMaxLimit — верхняя граница параметра limit
+const quickSearchMaxLimit = 50
+
+// quickSearchHandler ищет по акциям, источникам, тегам и прогнозам
+// (?q=, необязательный ?limit=, по умолчанию api.defaults.quick_search.limit)
+func (s *Server) quickSearchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Vary", "Accept-Language")
+	q := r.URL.Query().Get("q")
+
+	limit, err := strconv.Atoi(s.param(r, "quick_search", "limit"))
+	if err != nil || limit <= 0 || limit > quickSearchMaxLimit {
+		writeProblem(w, http.StatusBadRequest, "limit must be between 1 and 50")
+		return
+	}
+
+	engine := search.NewEngine(quickSearchLimits,
+		search.Stocks{Store: s.store, Lang: preferredLang(r)},
+		search.Sources{Store: s.store},
+		search.Tags{Store: s.store},
+		search.Predictions{Store: s.store},
+	)
+	results, err := engine.Search(r.Context(), q, limit)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка поиска", "query", q, "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.DebugContext(r.Context(), "Результаты поиска", "results", len(results), "query", q)
+	s.respond(w, r, results)
+}
@@ -1,99 +1,500 @@
 package server
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"frontend-backend/internal/accuracy"
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/cdn"
+	"frontend-backend/internal/deadletter"
+	"frontend-backend/internal/extract"
+	"frontend-backend/internal/ingest"
+	"frontend-backend/internal/jobs"
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/ratelimit"
+	"frontend-backend/internal/shapes"
+	"frontend-backend/internal/sqlconsole"
 	"frontend-backend/internal/storage"
+	"frontend-backend/internal/version"
+	"frontend-backend/internal/webhook"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Server представляет HTTP-сервер
 type Server struct {
-	store  *storage.PostgresStorage
-	router *mux.Router
+	store            storage.Storage
+	router           *mux.Router
+	handler          http.Handler // router с метриками и журналом запросов
+	log              *slog.Logger
+	reprocessor      *extract.Reprocessor
+	normalizer       *extract.Normalizer
+	admin            AdminStore
+	demand           *marketdata.DemandTracker
+	deadLetters      *deadletter.Queue
+	sqlLog           *storage.SQLLogger
+	accessLog        *accessLogger
+	lenient          bool
+	benchmark        string
+	ingestLag        *ingest.LagTracker
+	rateLimit        *ratelimit.Limiter
+	jobs             *jobs.Runner
+	sqlConsole       *sqlconsole.Console
+	hub              *Hub
+	defaults         *Defaults
+	cdn              cdn.Purger
+	webhooks         *webhook.Dispatcher
+	changes          ChangeFeed
+	shapes           *shapes.Recorder
+	shapeReport      ShapeStore
+	licenses         *marketdata.Licenses
+	datasets         DatasetStore
+	datasetTTL       time.Duration // срок хранения снимков наборов данных
+	compress         bool
+	compressMin      int // минимальный размер ответа для сжатия, байт
+	cache            *CachePolicies
+	keys             *auth.Keyring
+	anonymous        auth.Role // роль запросов без ключа и токена; пустая — запрещены
+	static           fs.FS     // файлы фронтенда; nil — не раздаются
+	accounts         *auth.Accounts
+	registration     bool
+	oidc             *auth.OIDC
+	certs            *auth.ClientCerts
+	session          *SessionCookies // режим cookie; nil — токены только в Authorization
+	usage            UsageStore
+	stockWriter      storage.StockWriter
+	predictionWriter storage.PredictionWriter
+	idempotency      IdempotencyStore
+	audit            AuditStore
+	revisions        RevisionStore
+	readiness        []ReadinessCheck
+	cors             atomic.Pointer[corsPolicy]
+	idempotencyTTL   time.Duration
+	priceDataDir     string // каталог CSV-файлов истории цен для Last-Modified
+}
+
+// AdminStore — операции обслуживания данных, доступные только с PostgreSQL
+type AdminStore interface {
+	FindDuplicatePredictions(ctx context.Context) ([]storage.DuplicateGroup, error)
+	MergeDuplicatePredictions(ctx context.Context) (int64, error)
+}
+
+// Option настраивает необязательные возможности сервера
+type Option func(*Server)
+
+// WithLogger задает логгер сервера; по умолчанию slog.Default()
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.log = l
+	}
+}
+
+// WithPriceDataDir задает каталог CSV-файлов истории цен; по умолчанию
+// storage.DefaultPriceDataDir
+func WithPriceDataDir(dir string) Option {
+	return func(s *Server) {
+		s.priceDataDir = dir
+	}
+}
+
+// WithReprocessor включает админский эндпоинт повторного извлечения прогнозов
+func WithReprocessor(r *extract.Reprocessor) Option {
+	return func(s *Server) {
+		s.reprocessor = r
+	}
+}
+
+// WithRecommendations включает админские эндпоинты таблицы рекомендаций
+func WithRecommendations(n *extract.Normalizer) Option {
+	return func(s *Server) {
+		s.normalizer = n
+	}
+}
+
+// WithAdminStore включает админские эндпоинты обслуживания данных
+func WithAdminStore(a AdminStore) Option {
+	return func(s *Server) {
+		s.admin = a
+	}
+}
+
+// WithDemandTracker включает учет спроса на тикеры для планировщика котировок
+func WithDemandTracker(d *marketdata.DemandTracker) Option {
+	return func(s *Server) {
+		s.demand = d
+	}
+}
+
+// WithDeadLetters включает админские эндпоинты dead-letter очереди
+func WithDeadLetters(q *deadletter.Queue) Option {
+	return func(s *Server) {
+		s.deadLetters = q
+	}
+}
+
+// WithSQLLogger включает админское управление логированием SQL
+func WithSQLLogger(l *storage.SQLLogger) Option {
+	return func(s *Server) {
+		s.sqlLog = l
+	}
+}
+
+// WithIngestLag включает админский эндпоинт отставания загрузки по каналам
+func WithIngestLag(t *ingest.LagTracker) Option {
+	return func(s *Server) {
+		s.ingestLag = t
+	}
+}
+
+// WithJobs включает админский эндпоинт состояния фоновых задач
+func WithJobs(r *jobs.Runner) Option {
+	return func(s *Server) {
+		s.jobs = r
+	}
 }
 
 // NewServer создает новый экземпляр Server
-func NewServer(store *storage.PostgresStorage) *Server {
+func NewServer(store storage.Storage, opts ...Option) *Server {
 	s := &Server{
 		store:  store,
 		router: mux.NewRouter(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.log == nil {
+		s.log = slog.Default()
+	}
+	if s.priceDataDir == "" {
+		s.priceDataDir = storage.DefaultPriceDataDir
+	}
+	if s.hub == nil {
+		s.hub = NewHub(s.log)
+	}
+	if s.defaults == nil {
+		s.defaults, _ = NewDefaults(nil)
+	}
+	if s.licenses == nil {
+		s.licenses, _ = marketdata.NewLicenses(marketdata.License{})
+	}
+	if s.cache == nil {
+		s.cache, _ = NewCachePolicies("", nil)
+	}
+	if s.cors.Load() == nil {
+		s.SetCORSOrigins([]string{DefaultCORSOrigin})
+	}
+	s.router.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	s.router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
 	s.setupMiddleware()
 	s.routes()
+	s.checkCachePolicies()
 	return s
 }
 
-// setupMiddleware настраивает middleware для сервера
+// setupMiddleware настраивает middleware для сервера. Метрики и журнал
+// запросов оборачивают сам маршрутизатор, чтобы учитывались и ответы 404 и
+// 405 на неизвестные маршруты.
 func (s *Server) setupMiddleware() {
-	s.router.Use(corsMiddleware)
+	s.handler = s.metricsMiddleware(s.requestLogMiddleware(s.router))
+	s.router.Use(tracingMiddleware)
+	s.router.Use(versionMiddleware)
+	if s.accessLog != nil {
+		s.router.Use(s.accessLog.middleware)
+	}
+	s.router.Use(s.corsMiddleware)
+	if s.keys != nil || s.accounts != nil || s.oidc != nil || s.certs != nil {
+		s.router.Use(s.authMiddleware)
+	}
+	s.router.Use(actorMiddleware)
+	if s.compress {
+		s.router.Use(s.compressMiddleware)
+	}
+	s.router.Use(tickerMiddleware(s.log))
+	s.router.Use(timeFormatMiddleware)
+	s.router.Use(fieldsMiddleware)
+	s.router.Use(surrogateKeyMiddleware)
+	s.router.Use(s.cacheControlMiddleware)
+	if s.shapes != nil {
+		s.router.Use(s.shapeMiddleware)
+	}
+	if s.rateLimit != nil {
+		s.router.Use(s.rateLimitMiddleware)
+	}
+	// После ограничения частоты: отклоненный им запрос не расходует квоту
+	if s.usage != nil && s.keys != nil {
+		s.router.Use(s.quotaMiddleware)
+	}
+	if s.idempotency != nil {
+		s.router.Use(s.idempotencyMiddleware)
+	}
 }
 
 // routes инициализирует маршруты сервера
 func (s *Server) routes() {
+	// OpenMetrics отдается клиентам, которые его запрашивают в Accept
+	s.router.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))).Methods("GET")
+	s.router.HandleFunc("/version", s.getVersionHandler).Methods("GET")
+	s.router.HandleFunc("/healthz", s.getHealthzHandler).Methods("GET")
+	s.router.HandleFunc("/readyz", s.getReadyzHandler).Methods("GET")
+	// OPTIONS — чтобы preflight браузера прошел через corsMiddleware
+	s.router.HandleFunc("/graphql", s.graphqlHandler()).Methods("POST", "OPTIONS")
+	s.router.HandleFunc("/ws", s.wsHandler).Methods("GET")
+	s.router.HandleFunc("/events", s.eventsHandler).Methods("GET")
+	if s.changes != nil {
+		s.router.HandleFunc("/changes", s.getChangesHandler).Methods("GET")
+	}
 	s.router.HandleFunc("/stocks", s.getStocksHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/summary", s.getEODSummariesHandler).Methods("GET")
+	s.router.HandleFunc("/api/v1/quick-search", s.quickSearchHandler).Methods("GET")
+	if s.datasets != nil {
+		s.router.HandleFunc("/api/v1/datasets/predictions", s.getPredictionDatasetHandler).Methods("GET")
+	}
+	s.router.HandleFunc("/analytics/correlation", s.getCorrelationHandler).Methods("GET")
+	s.router.HandleFunc("/sources/leaderboard", s.getSourcesLeaderboardHandler).Methods("GET")
 	s.router.HandleFunc("/predictions/{ticker}", s.getPredictionsByTickerHandler).Methods("GET")
+	s.router.HandleFunc("/api/v1/predictions/{ticker}", s.getPredictionsPageHandler).Methods("GET")
+	s.router.HandleFunc("/predictions/{ticker}/accuracy", s.getPredictionAccuracyHandler).Methods("GET")
+	if s.revisions != nil {
+		s.router.HandleFunc("/predictions/{id:[0-9]+}/revisions", s.getPredictionRevisionsHandler).Methods("GET")
+	}
+	s.router.HandleFunc("/stocks/{ticker}/consensus", s.getConsensusHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/targets/bands", s.getTargetBandsHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/predictions/rollup", s.getPredictionRollupHandler).Methods("GET")
+	// activity — имя, под которым ряд использует график «внимания аналитиков»
+	s.router.HandleFunc("/stocks/{ticker}/predictions/activity", s.getPredictionRollupHandler).Methods("GET")
 	s.router.HandleFunc("/stocks/{ticker}/history", s.getStockHistoryHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/license", s.getLicenseHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/chart", s.getChartHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/risk", s.getRiskHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/performance", s.getPerformanceHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/summary", s.getStockSummaryHandler).Methods("GET")
+	s.router.HandleFunc("/stocks/{ticker}/history/gaps", s.getPriceGapsHandler).Methods("GET")
+
+	if s.accounts != nil {
+		if s.registration {
+			s.router.HandleFunc("/auth/register", s.postRegisterHandler).Methods("POST")
+		}
+		s.router.HandleFunc("/auth/login", s.postLoginHandler).Methods("POST")
+		s.router.HandleFunc("/auth/refresh", s.postRefreshHandler).Methods("POST")
+		s.router.HandleFunc("/auth/logout", s.postLogoutHandler).Methods("POST")
+		s.router.HandleFunc("/auth/me", s.getMeHandler).Methods("GET")
+		s.router.HandleFunc("/admin/users", s.getUsersHandler).Methods("GET")
+		s.router.HandleFunc("/admin/users/{id:[0-9]+}/role", s.putUserRoleHandler).Methods("PUT")
+	}
+	if s.keys != nil {
+		s.router.HandleFunc("/admin/api-keys", s.getAPIKeysHandler).Methods("GET")
+		s.router.HandleFunc("/admin/api-keys", s.postAPIKeyHandler).Methods("POST")
+		s.router.HandleFunc("/admin/api-keys/{id:[0-9]+}", s.deleteAPIKeyHandler).Methods("DELETE")
+		s.router.HandleFunc("/admin/api-keys/{id:[0-9]+}/role", s.putAPIKeyRoleHandler).Methods("PUT")
+		if s.usage != nil {
+			s.router.HandleFunc("/admin/api-keys/{id:[0-9]+}/quota", s.putAPIKeyQuotaHandler).Methods("PUT")
+			s.router.HandleFunc(usagePath, s.getUsageHandler).Methods("GET")
+		}
+	}
+	if s.stockWriter != nil {
+		s.router.HandleFunc("/admin/stocks", s.listStocksHandler).Methods("GET")
+		s.router.HandleFunc("/admin/stocks", s.postStockHandler).Methods("POST")
+		s.router.HandleFunc("/admin/stocks/upsert", s.upsertStocksHandler).Methods("POST")
+		s.router.HandleFunc("/admin/stocks/{id:[0-9]+}", s.getStockHandler).Methods("GET")
+		s.router.HandleFunc("/admin/stocks/{id:[0-9]+}", s.putStockHandler).Methods("PUT")
+		s.router.HandleFunc("/admin/stocks/{id:[0-9]+}", s.deleteStockHandler).Methods("DELETE")
+		s.router.HandleFunc("/admin/stocks/{id:[0-9]+}/restore", s.restoreStockHandler).Methods("POST")
+	}
+	if s.predictionWriter != nil {
+		s.router.HandleFunc("/admin/predictions", s.listPredictionsHandler).Methods("GET")
+		s.router.HandleFunc("/admin/predictions", s.postPredictionHandler).Methods("POST")
+		s.router.HandleFunc("/admin/predictions/{id:[0-9]+}", s.getPredictionHandler).Methods("GET")
+		s.router.HandleFunc("/admin/predictions/{id:[0-9]+}", s.putPredictionHandler).Methods("PUT")
+		s.router.HandleFunc("/admin/predictions/{id:[0-9]+}", s.patchPredictionHandler).Methods("PATCH")
+		s.router.HandleFunc("/admin/predictions/{id:[0-9]+}", s.deletePredictionHandler).Methods("DELETE")
+		s.router.HandleFunc("/admin/predictions/{id:[0-9]+}/restore", s.restorePredictionHandler).Methods("POST")
+	}
+	if s.audit != nil {
+		s.router.HandleFunc("/admin/audit", s.getAuditLogHandler).Methods("GET")
+	}
+	if s.reprocessor != nil {
+		s.router.HandleFunc("/admin/messages/reprocess", s.reprocessMessagesHandler).Methods("POST")
+	}
+	if s.normalizer != nil {
+		s.router.HandleFunc("/admin/recommendations/unknown", s.getUnknownRecommendationsHandler).Methods("GET")
+		s.router.HandleFunc("/admin/recommendations/reprocess", s.normalizeRecommendationsHandler).Methods("POST")
+	}
+	if s.deadLetters != nil {
+		s.router.HandleFunc("/admin/dead-letters", s.getDeadLettersHandler).Methods("GET")
+		s.router.HandleFunc("/admin/dead-letters/{id:[0-9]+}/retry", s.retryDeadLetterHandler).Methods("POST")
+		s.router.HandleFunc("/admin/dead-letters/{id:[0-9]+}", s.discardDeadLetterHandler).Methods("DELETE")
+	}
+	s.router.HandleFunc("/admin/defaults", s.getDefaultsHandler).Methods("GET")
+	if s.cdn != nil {
+		s.router.HandleFunc("/admin/cdn/purge", s.postCDNPurgeHandler).Methods("POST")
+	}
+	if s.shapeReport != nil {
+		s.router.HandleFunc("/admin/request-shapes", s.getRequestShapesHandler).Methods("GET")
+	}
+	if s.webhooks != nil {
+		s.router.HandleFunc("/admin/webhooks", s.getWebhooksHandler).Methods("GET")
+		s.router.HandleFunc("/admin/webhooks", s.postWebhookHandler).Methods("POST")
+		s.router.HandleFunc("/admin/webhooks/{id:[0-9]+}", s.deleteWebhookHandler).Methods("DELETE")
+	}
+	if s.jobs != nil {
+		s.router.HandleFunc("/admin/jobs", s.getJobsHandler).Methods("GET")
+	}
+	if s.ingestLag != nil {
+		s.router.HandleFunc("/admin/ingest/lag", s.getIngestLagHandler).Methods("GET")
+	}
+	if s.rateLimit != nil {
+		s.router.HandleFunc("/admin/rate-limit/violations", s.getRateLimitViolationsHandler).Methods("GET")
+		s.router.HandleFunc("/admin/rate-limit/violations", s.resetRateLimitViolationsHandler).Methods("DELETE")
+	}
+	if s.sqlConsole != nil {
+		s.router.HandleFunc("/admin/sql", s.postSQLConsoleHandler).Methods("POST")
+	}
+	if s.sqlLog != nil {
+		s.router.HandleFunc("/admin/sql-logging", s.getSQLLoggingHandler).Methods("GET")
+		s.router.HandleFunc("/admin/sql-logging", s.putSQLLoggingHandler).Methods("PUT")
+	}
+	if s.admin != nil {
+		s.router.HandleFunc("/admin/predictions/duplicates", s.getDuplicatePredictionsHandler).Methods("GET")
+		s.router.HandleFunc("/admin/predictions/duplicates/merge", s.mergeDuplicatePredictionsHandler).Methods("POST")
+	}
+	s.staticRoute()
+}
+
+// recordDemand учитывает запрос данных по тикеру
+func (s *Server) recordDemand(ticker string) {
+	if s.demand != nil {
+		s.demand.Record(ticker)
+	}
 }
 
 // ServeHTTP реализует интерфейс http.Handler
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, withRequestID(w, r))
 }
 
 // getStocksHandler обрабатывает запрос на получение списка акций
 func (s *Server) getStocksHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("GET /stocks - получение списка акций")
 	w.Header().Set("Content-Type", "application/json")
 
-	stocks, err := s.store.GetStocks()
+	w.Header().Add("Vary", "Accept-Language")
+
+	stocks, err := s.store.GetStocks(r.Context())
 	if err != nil {
-		log.Printf("Ошибка при получении акций: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.log.ErrorContext(r.Context(), "Ошибка при получении акций", "err", err)
+		writeError(w, err)
 		return
 	}
 
-	log.Printf("Возвращаем %d акций", len(stocks))
-	json.NewEncoder(w).Encode(stocks)
+	s.log.DebugContext(r.Context(), "Возвращаем список акций", "stocks", len(stocks))
+	s.respondConditional(w, r, storage.LocalizeStocks(stocks, preferredLang(r)), time.Time{})
 }
 
 // getPredictionsByTickerHandler обрабатывает запрос на получение прогнозов по тикеру
 func (s *Server) getPredictionsByTickerHandler(w http.ResponseWriter, r *http.Request) {
+	s.listPredictions(w, r, false)
+}
+
+// getPredictionsPageHandler отдает те же прогнозы в конверте Page
+func (s *Server) getPredictionsPageHandler(w http.ResponseWriter, r *http.Request) {
+	s.listPredictions(w, r, true)
+}
+
+// listPredictions отдает страницу прогнозов по тикеру (?sort=, ?limit=,
+// ?offset=): массивом или, если envelope, в конверте Page. Ссылки на соседние
+// страницы — в заголовке Link.
+func (s *Server) listPredictions(w http.ResponseWriter, r *http.Request, envelope bool) {
 	w.Header().Set("Content-Type", "application/json")
 	params := mux.Vars(r)
 	ticker := params["ticker"]
 
-	log.Printf("GET /predictions/%s - получение прогнозов для тикера: '%s'", ticker, ticker)
+	order := s.param(r, "predictions", "sort")
+	if err := defaultValidators["sort"](order); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := strconv.Atoi(s.param(r, "predictions", "limit"))
+	if err != nil || limit < 0 {
+		writeProblem(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	offset, err := parseOffset(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := exportFormat(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	predictions, err := s.store.GetPredictionsByTicker(ticker)
+	predictions, err := s.store.GetPredictionsByTicker(r.Context(), ticker, r.URL.Query().Get("exchange"))
 	if err != nil {
-		log.Printf("Ошибка при получении прогнозов для тикера '%s': %v", ticker, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
 		return
 	}
+	s.recordDemand(ticker)
+
+	modified := lastPredicted(predictions)
+	// Срез может принадлежать кешу, сортируем копию
+	predictions = append(make([]storage.Prediction, 0, len(predictions)), predictions...)
+	sortPredictions(predictions, order)
+	total := len(predictions)
+	predictions = predictions[min(offset, total):]
+	if limit > 0 && limit < len(predictions) {
+		predictions = predictions[:limit]
+	}
+	setOffsetLinks(w, r, offset, limit, total)
 
-	log.Printf("Найдено %d прогнозов для тикера '%s'", len(predictions), ticker)
-	json.NewEncoder(w).Encode(predictions)
+	s.log.DebugContext(r.Context(), "Найдены прогнозы", "predictions", len(predictions), "ticker", ticker)
+	metrics.PredictionsServed.WithLabelValues(ticker, "http").Add(float64(len(predictions)))
+	if envelope {
+		total64 := int64(total)
+		s.respondConditional(w, r, Page{Items: predictions, Total: &total64, Limit: limit, Offset: &offset}, modified)
+		return
+	}
+	if format != formatJSON {
+		if err := writeTable(w, r, format, predictionsTable(ticker, predictions)); err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка выгрузки прогнозов", "ticker", ticker, "format", format, "err", err)
+		}
+		return
+	}
+	s.respondConditional(w, r, predictions, modified)
 }
 
-// corsMiddleware добавляет CORS заголовки
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Разрешаем запросы с localhost:5173 (Vite dev server)
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// Обрабатываем preflight запросы
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// lastPredicted возвращает время самого нового прогноза — Last-Modified
+// списка прогнозов
+func lastPredicted(predictions []storage.Prediction) time.Time {
+	var last time.Time
+	for _, p := range predictions {
+		if t, ok := accuracy.PredictedTime(p); ok && t.After(last) {
+			last = t
 		}
+	}
+	return last
+}
+
+// getVersionHandler возвращает сведения о сборке
+func (s *Server) getVersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, version.Get())
+}
 
+// versionMiddleware добавляет версию сборки в заголовок X-App-Version
+func versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-App-Version", version.Version)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -104,15 +505,67 @@ func (s *Server) getStockHistoryHandler(w http.ResponseWriter, r *http.Request)
 	params := mux.Vars(r)
 	ticker := params["ticker"]
 
-	log.Printf("GET /stocks/%s/history - получение истории цен для тикера: '%s'", ticker, ticker)
+	rangeParam := s.param(r, "history", "range")
+	if err := defaultValidators["range"](rangeParam); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fill := s.param(r, "history", "fill")
+	if err := defaultValidators["fill"](fill); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := exportFormat(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if format != formatJSON && !s.checkExport(w, ticker) {
+		return
+	}
 
-	history, err := s.store.GetStockPriceHistory(ticker)
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
 	if err != nil {
-		log.Printf("Ошибка при получении истории цен для тикера '%s': %v", ticker, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
 		return
 	}
+	s.recordDemand(ticker)
+
+	setWarningHeaders(w, warnings)
+	history, _ = historyRange(history, rangeParam)
+
+	// ?adjusted=true — корректировка цен на сплиты и дивиденды
+	if r.URL.Query().Get("adjusted") == "true" {
+		actions, err := s.store.GetCorporateActions(r.Context(), ticker)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка при получении корпоративных действий", "ticker", ticker, "err", err)
+			writeError(w, err)
+			return
+		}
+		history = storage.AdjustPriceHistory(history, actions)
+	}
 
-	log.Printf("Найдено %d записей истории цен для тикера '%s'", len(history), ticker)
-	json.NewEncoder(w).Encode(history)
+	if fill == storage.FillForward || fill == storage.FillLinear {
+		history = storage.FillPriceGaps(history, fill)
+	}
+
+	s.log.DebugContext(r.Context(), "Найдена история цен", "points", len(history), "ticker", ticker)
+	metrics.PriceHistoryRowsServed.WithLabelValues(ticker, "http").Add(float64(len(history)))
+	attribution := s.attribution(w, ticker)
+	if format != formatJSON {
+		t := historyTable(ticker, storage.PriceSlots(history, fill))
+		t.attribution = attribution
+		if err := writeTable(w, r, format, t); err != nil {
+			s.log.ErrorContext(r.Context(), "Ошибка выгрузки истории цен", "ticker", ticker, "format", format, "err", err)
+		}
+		return
+	}
+	// Last-Modified — время изменения CSV-файла истории
+	modified := storage.PriceHistoryModTime(s.priceDataDir, ticker)
+	if fill == storage.FillNull {
+		s.respondConditional(w, r, storage.PriceSlots(history, fill), modified)
+		return
+	}
+	s.respondConditional(w, r, history, modified)
 }
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/auth"
+)
+
+// Cookie режима сессий: токены недоступны скриптам страницы, токен CSRF —
+// доступен, чтобы фронтенд повторил его в заголовке (double-submit cookie)
+const (
+	accessCookie  = "fb_access"
+	refreshCookie = "fb_refresh"
+	csrfCookie    = "fb_csrf"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// errCSRF — запрос с cookie сессии без совпадающего токена CSRF
+var errCSRF = errors.New("CSRF token missing or invalid: repeat the fb_csrf cookie in the X-CSRF-Token header")
+
+// SessionCookies — режим входа через cookie для браузера
+type SessionCookies struct {
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// WithSessionCookies включает режим cookie: /auth/login и /auth/refresh
+// кладут токены в HttpOnly cookie, а изменяющие запросы с такой cookie
+// проверяются на CSRF. Требует WithAccounts.
+func WithSessionCookies(c SessionCookies) Option {
+	return func(s *Server) {
+		s.session = &c
+	}
+}
+
+// ParseSameSite разбирает значение атрибута SameSite: lax, strict или none
+func ParseSameSite(v string) (http.SameSite, error) {
+	switch strings.ToLower(v) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("unknown SameSite mode %q: expected lax, strict or none", v)
+}
+
+// sessionResponse — ответ входа в режиме cookie: токены не попадают в тело
+type sessionResponse struct {
+	CSRFToken string `json:"csrf_token"`
+	ExpiresIn int    `json:"expires_in"` // срок жизни access-токена, секунд
+}
+
+// setSessionCookies кладет токены в cookie и выдает новый токен CSRF
+func (s *Server) setSessionCookies(w http.ResponseWriter, tokens auth.Tokens) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("error generating CSRF token: %w", err)
+	}
+	csrf := base64.RawURLEncoding.EncodeToString(raw)
+	refreshAge := int(s.accounts.RefreshTTL().Seconds())
+
+	http.SetCookie(w, s.sessionCookie(accessCookie, tokens.AccessToken, "/", tokens.ExpiresIn, true))
+	http.SetCookie(w, s.sessionCookie(refreshCookie, tokens.RefreshToken, "/auth", refreshAge, true))
+	http.SetCookie(w, s.sessionCookie(csrfCookie, csrf, "/", refreshAge, false))
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessionResponse{CSRFToken: csrf, ExpiresIn: tokens.ExpiresIn})
+	return nil
+}
+
+// clearSessionCookies удаляет cookie сессии при выходе
+func (s *Server) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, s.sessionCookie(accessCookie, "", "/", -1, true))
+	http.SetCookie(w, s.sessionCookie(refreshCookie, "", "/auth", -1, true))
+	http.SetCookie(w, s.sessionCookie(csrfCookie, "", "/", -1, false))
+}
+
+func (s *Server) sessionCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   s.session.Secure,
+		SameSite: s.session.SameSite,
+	}
+	if maxAge > 0 {
+		c.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return c
+}
+
+// sessionPrincipal возвращает пользователя по cookie сессии; nil без
+// ошибки — cookie нет. Изменяющий запрос должен повторить токен CSRF.
+func (s *Server) sessionPrincipal(r *http.Request) (*auth.Principal, error) {
+	c, err := r.Cookie(accessCookie)
+	if err != nil || c.Value == "" {
+		return nil, nil
+	}
+	p, err := s.accounts.Verify(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCSRF(r); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// checkCSRF сравнивает заголовок X-CSRF-Token с cookie fb_csrf. Чужой сайт
+// может заставить браузер отправить cookie, но не может прочитать ее
+// значение и выставить заголовок. Безопасные методы не проверяются.
+func checkCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	c, err := r.Cookie(csrfCookie)
+	if err != nil || c.Value == "" {
+		return errCSRF
+	}
+	header := r.Header.Get(csrfHeader)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(c.Value)) != 1 {
+		return errCSRF
+	}
+	return nil
+}
+
+// refreshFromCookie возвращает refresh-токен из cookie, если его нет в теле
+// запроса; такой запрос тоже проверяется на CSRF
+func (s *Server) refreshFromCookie(r *http.Request, token string) (string, error) {
+	if token != "" || s.session == nil {
+		return token, nil
+	}
+	c, err := r.Cookie(refreshCookie)
+	if err != nil {
+		return "", nil
+	}
+	if err := checkCSRF(r); err != nil {
+		return "", err
+	}
+	return c.Value, nil
+}
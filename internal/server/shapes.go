@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/ratelimit"
+	"frontend-backend/internal/shapes"
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/version"
+
+	"github.com/gorilla/mux"
+)
+
+// ShapeStore — отчет об использовании параметров, доступен только с PostgreSQL
+type ShapeStore interface {
+	GetParamUsage(ctx context.Context, since time.Time) ([]storage.ParamUsage, error)
+}
+
+// WithRequestShapes включает запись форм запросов и админский отчет по ним
+func WithRequestShapes(rec *shapes.Recorder, report ShapeStore) Option {
+	return func(s *Server) {
+		s.shapes = rec
+		s.shapeReport = report
+	}
+}
+
+// paramName — допустимое имя параметра; остальные записываются как "?",
+// чтобы в таблицу не попали данные, ошибочно переданные в имени
+var paramName = regexp.MustCompile(`^[A-Za-z0-9_.\-\[\]]{1,64}$`)
+
+// maxShapeParams — сколько имен параметров записывается для одного запроса
+const maxShapeParams = 20
+
+// shapeMiddleware записывает форму запроса после ответа: шаблон маршрута,
+// имена (не значения) параметров, версию схемы, клиента и код ответа
+func (s *Server) shapeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil || tpl == "/metrics" || tpl == "/healthz" || tpl == "/readyz" || strings.HasPrefix(tpl, "/admin") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.shapes.Record(storage.RequestShape{
+			Route:      tpl,
+			Method:     r.Method,
+			Params:     shapeParams(r),
+			Schema:     version.Schema,
+			Client:     ratelimit.Identity(r.Header.Get(apiKeyHeader), clientIP(r)),
+			Status:     status,
+			RecordedAt: time.Now(),
+		})
+	})
+}
+
+// shapeParams возвращает отсортированные имена параметров запроса
+func shapeParams(r *http.Request) []string {
+	query := r.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		if !paramName.MatchString(name) {
+			name = "?"
+		}
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	params = compactStrings(params)
+	if len(params) > maxShapeParams {
+		params = params[:maxShapeParams]
+	}
+	return params
+}
+
+// compactStrings убирает повторы из отсортированного списка
+func compactStrings(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// getRequestShapesHandler возвращает использование эндпоинтов и параметров
+// за последние ?days= дней (по умолчанию 30)
+func (s *Server) getRequestShapesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeProblem(w, http.StatusBadRequest, "invalid days: expected a positive integer")
+			return
+		}
+		days = n
+	}
+
+	report, err := s.shapeReport.GetParamUsage(r.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении использования параметров", "err", err)
+		writeError(w, err)
+		return
+	}
+	s.respond(w, r, report)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"frontend-backend/internal/auth"
+	"frontend-backend/internal/sqlconsole"
+)
+
+// WithSQLConsole включает админскую SQL-консоль; доступ — у клиентов с ролью
+// admin, как у остальных /admin/*
+func WithSQLConsole(c *sqlconsole.Console) Option {
+	return func(s *Server) {
+		s.sqlConsole = c
+	}
+}
+
+// postSQLConsoleHandler выполняет запрос только на чтение и возвращает таблицу
+func (s *Server) postSQLConsoleHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Роль проверил authMiddleware; анонимный admin (auth.anonymous_admin)
+	// консоль не получает: в журнале нужен автор запроса
+	p := auth.FromContext(r.Context())
+	if p == nil {
+		s.log.WarnContext(r.Context(), "SQL-консоль: отказ в доступе без учетных данных", "client_ip", clientIP(r))
+		writeProblem(w, http.StatusUnauthorized, "authentication required: the SQL console needs an admin API key or access token")
+		return
+	}
+
+	var req struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	res, err := s.sqlConsole.Run(r.Context(), p.Name, req.Query)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка SQL-консоли", "err", err)
+		status := http.StatusInternalServerError
+		var qerr *sqlconsole.QueryError
+		if errors.As(err, &qerr) {
+			status = http.StatusBadRequest
+		}
+		writeProblem(w, status, err.Error())
+		return
+	}
+	s.respond(w, r, res)
+}
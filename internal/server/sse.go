@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sseHeartbeat — период комментариев-пингов, чтобы прокси не закрывали
+// простаивающее соединение
+const sseHeartbeat = 15 * time.Second
+
+// eventsHandler отдает поток событий в формате Server-Sent Events для
+// клиентов, которые не могут использовать WebSocket. ?tickers=SBER,GAZP
+// ограничивает поток, без него — все тикеры. После переподключения
+// браузер передает Last-Event-ID, и пропущенные события досылаются.
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	c := newSubscriber()
+	if v := r.URL.Query().Get("tickers"); v != "" {
+		c.subscribe(strings.Split(v, ","))
+	} else {
+		c.subscribe([]string{allTickers})
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		// Для полифилов EventSource, которые не умеют ставить заголовок
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	missed, complete := s.hub.registerFrom(c, lastID)
+	defer s.hub.unregister(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		// Часть событий потеряна: клиенту нужно перечитать состояние
+		s.log.InfoContext(r.Context(), "SSE: события недоступны, отправляем reset", "last_id", lastID)
+		fmt.Fprintf(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range missed {
+		data, _ := json.Marshal(e)
+		writeSSE(w, frame{ID: e.ID, Type: e.Type, Data: data})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case f, ok := <-c.send:
+			if !ok {
+				return
+			}
+			writeSSE(w, f)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE пишет одно событие: id, event и data (JSON в одну строку)
+func writeSSE(w http.ResponseWriter, f frame) {
+	if f.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", f.ID)
+	}
+	if f.Type != "" {
+		fmt.Fprintf(w, "event: %s\n", f.Type)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", f.Data)
+}
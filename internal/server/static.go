@@ -0,0 +1,37 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WithStaticFiles раздает файлы фронтенда из fsys по путям, не занятым API;
+// index.html отдается на /
+func WithStaticFiles(fsys fs.FS) Option {
+	return func(s *Server) {
+		s.static = fsys
+	}
+}
+
+// staticRoute регистрирует раздачу фронтенда последним маршрутом, чтобы он
+// не перекрывал API
+func (s *Server) staticRoute() {
+	if s.static == nil {
+		return
+	}
+	files := http.FileServerFS(s.static)
+	s.router.PathPrefix("/").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Неизвестные пути получают тот же problem+json, что и без фронтенда
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if _, err := fs.Stat(s.static, name); err != nil {
+			notFoundHandler(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})).Methods("GET", "HEAD")
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// maxSummaryPredictions — верхняя граница ?limit= для сводки по акции
+const maxSummaryPredictions = 50
+
+// stockSummary — все данные страницы акции одним ответом
+type stockSummary struct {
+	Stock       storage.Stock        `json:"Stock"`
+	Performance storage.Performance  `json:"Performance"` // последняя цена и доходность
+	Predictions []storage.Prediction `json:"Predictions"` // последние прогнозы, от новых к старым
+	Consensus   storage.Consensus    `json:"Consensus"`
+	Attribution *marketdata.License  `json:"Attribution,omitempty"`
+	Warnings    []string             `json:"Warnings,omitempty"`
+}
+
+// getStockSummaryHandler возвращает карточку акции, последнюю цену,
+// доходность, последние ?limit= прогнозов (по умолчанию
+// api.defaults.summary.limit) и консенсус, чтобы страница акции строилась
+// одним запросом
+func (s *Server) getStockSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Vary", "Accept-Language")
+	ticker := mux.Vars(r)["ticker"]
+	exchange := r.URL.Query().Get("exchange")
+
+	limit, err := strconv.Atoi(s.param(r, "summary", "limit"))
+	if err != nil || limit < 0 || limit > maxSummaryPredictions {
+		writeProblem(w, http.StatusBadRequest, "invalid limit: expected 0.."+strconv.Itoa(maxSummaryPredictions))
+		return
+	}
+
+	predictions, err := s.store.GetPredictionsByTicker(r.Context(), ticker, exchange)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении прогнозов", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	s.recordDemand(ticker)
+
+	stock, err := s.findStock(r.Context(), ticker, exchange, predictions)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении акции", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	stock.Name = stock.LocalizedName(preferredLang(r))
+
+	consensus, err := s.store.GetConsensus(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при расчете консенсуса", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	history, warnings, err := s.priceHistory(r.Context(), ticker)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении истории цен", "ticker", ticker, "err", err)
+		writeError(w, err)
+		return
+	}
+	consensus.SetLastPrice(history)
+
+	// Срез может принадлежать кешу, сортируем копию
+	predictions = append(make([]storage.Prediction, 0, len(predictions)), predictions...)
+	sortPredictions(predictions, "desc")
+	if limit < len(predictions) {
+		predictions = predictions[:limit]
+	}
+
+	summary := stockSummary{
+		Stock:       stock,
+		Performance: storage.ComputePerformance(ticker, history),
+		Predictions: predictions,
+		Consensus:   consensus,
+		Attribution: s.attribution(w, ticker),
+		Warnings:    warnings,
+	}
+	s.log.DebugContext(r.Context(), "Возвращаем сводку по тикеру", "ticker", ticker, "predictions", len(predictions), "active_predictions", consensus.ActivePredictions)
+	s.respondConditional(w, r, summary, time.Time{})
+}
+
+// findStock находит запись акции: ту, к которой относятся прогнозы, иначе
+// по бирже или первую с этим тикером
+func (s *Server) findStock(ctx context.Context, ticker, exchange string, predictions []storage.Prediction) (storage.Stock, error) {
+	stocks, err := s.store.GetStocks(ctx)
+	if err != nil {
+		return storage.Stock{}, err
+	}
+	var matches []storage.Stock
+	for _, st := range stocks {
+		if st.Ticker == ticker && (exchange == "" || strings.EqualFold(st.Exchange, exchange)) {
+			matches = append(matches, st)
+		}
+	}
+	if len(matches) == 0 {
+		return storage.Stock{}, fmt.Errorf("%w for ticker %s", storage.ErrStockNotFound, ticker)
+	}
+	if len(predictions) > 0 {
+		for _, st := range matches {
+			if st.ID == predictions[0].StockID {
+				return st, nil
+			}
+		}
+	}
+	return matches[0], nil
+}
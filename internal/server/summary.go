@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+)
+
+// getEODSummariesHandler возвращает итоги торгового дня по всем акциям.
+// Параметр date (YYYY-MM-DD) необязателен, по умолчанию — последний день.
+func (s *Server) getEODSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	date, err := parseDateParam(r, "date")
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summaries, err := s.store.GetEODSummaries(r.Context(), date)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при получении итогов дня", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.DebugContext(r.Context(), "Возвращаем итоги дня", "summaries", len(summaries))
+	s.respond(w, r, summaries)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"frontend-backend/internal/cdn"
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// WithCDN включает админский эндпоинт сброса кеша CDN
+func WithCDN(p cdn.Purger) Option {
+	return func(s *Server) {
+		s.cdn = p
+	}
+}
+
+// surrogateExcluded — пути, ответы которых не кешируются в CDN
+var surrogateExcluded = []string{"/admin", "/metrics", "/version", "/healthz", "/readyz", "/graphql", "/ws", "/events", "/changes", "/api/v1/datasets"}
+
+// surrogateKeyMiddleware помечает ответы публичных GET-эндпоинтов ключами
+// эндпоинта и тикера: Surrogate-Key для Fastly, Cache-Tag для Cloudflare.
+// По этим ключам cdn.Invalidator сбрасывает кеш при изменении данных.
+func surrogateKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if keys := surrogateKeys(r); len(keys) > 0 {
+			w.Header().Set("Surrogate-Key", strings.Join(keys, " "))
+			w.Header().Set("Cache-Tag", strings.Join(keys, ","))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// surrogateKeys возвращает ключи ответа по шаблону маршрута:
+// /stocks/{ticker}/history → endpoint:stocks-history и ticker:SBER
+func surrogateKeys(r *http.Request) []string {
+	endpoint, ok := routeEndpoint(r)
+	if !ok {
+		return nil
+	}
+	keys := []string{cdn.EndpointKey(endpoint)}
+	if ticker := mux.Vars(r)["ticker"]; ticker != "" {
+		keys = append(keys, cdn.TickerKey(ticker))
+	}
+	return keys
+}
+
+// routeEndpoint возвращает имя кешируемого GET-эндпоинта по шаблону маршрута
+// (/stocks/{ticker}/history → stocks-history); ok равен false для остальных
+func routeEndpoint(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet {
+		return "", false
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+	return templateEndpoint(tpl)
+}
+
+// templateEndpoint строит имя эндпоинта из шаблона пути без параметров и /api/v1
+func templateEndpoint(tpl string) (string, bool) {
+	for _, prefix := range surrogateExcluded {
+		if tpl == prefix || strings.HasPrefix(tpl, prefix+"/") {
+			return "", false
+		}
+	}
+	var parts []string
+	for _, seg := range strings.Split(strings.TrimPrefix(tpl, "/api/v1"), "/") {
+		if seg != "" && !strings.HasPrefix(seg, "{") {
+			parts = append(parts, seg)
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// purgeRequest — тело POST /admin/cdn/purge
+type purgeRequest struct {
+	Tickers   []string `json:"tickers"`
+	Endpoints []string `json:"endpoints"`
+	Keys      []string `json:"keys"`
+}
+
+// postCDNPurgeHandler сбрасывает кеш CDN по тикерам, эндпоинтам или ключам
+func (s *Server) postCDNPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req purgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	keys := append([]string{}, req.Keys...)
+	for _, t := range req.Tickers {
+		keys = append(keys, cdn.TickerKey(strings.TrimSpace(t)))
+	}
+	for _, e := range req.Endpoints {
+		keys = append(keys, cdn.EndpointKey(strings.TrimSpace(e)))
+	}
+	if len(keys) == 0 {
+		writeProblem(w, http.StatusBadRequest, "at least one of tickers, endpoints or keys is required")
+		return
+	}
+
+	// Сброс записывается в журнал до обращения к CDN: без записи он не
+	// выполняется
+	if err := s.auditEvent(r, storage.AuditPurge, "cdn", nil, map[string][]string{"keys": keys}); err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка записи сброса CDN в журнал", "err", err)
+		writeError(w, err)
+		return
+	}
+	if err := s.cdn.Purge(r.Context(), keys); err != nil {
+		writeProblem(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	s.respond(w, r, map[string][]string{"purged": keys})
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"frontend-backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// tickerMiddleware проверяет {ticker} маршрута до обращения к хранилищу:
+// некорректный тикер — 400, корректный приводится к верхнему регистру
+func tickerMiddleware(logger *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return tickerHandler(logger, next)
+	}
+}
+
+func tickerHandler(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		raw, ok := vars["ticker"]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ticker, err := storage.NormalizeTicker(raw)
+		if err != nil {
+			logger.WarnContext(r.Context(), "Отклонен запрос с некорректным тикером", "path", r.URL.Path)
+			writeError(w, err)
+			return
+		}
+		if ticker != raw {
+			vars["ticker"] = ticker
+			r = mux.SetURLVars(r, vars)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Форматы меток времени в ответах (?ts=)
+const (
+	tsISO  = "iso"
+	tsUnix = "unix"
+)
+
+// timestampFields — поля ответов с метками времени. ?ts= и ?tz= меняют
+// только их, чтобы похожий на время текст (обоснование прогноза, тело
+// сообщения) возвращался как есть.
+var timestampFields = map[string]bool{
+	"CreatedAt": true, "UpdatedAt": true, "DeletedAt": true, "PredictedAt": true,
+	"SentAt": true, "EvaluatedAt": true, "ExpiresAt": true, "ReplacedAt": true,
+	"RecordedAt": true, "Timestamp": true, "Time": true, "Date": true,
+	"created_at": true, "updated_at": true, "deleted_at": true, "predicted_at": true,
+	"applied_at": true, "revoked_at": true, "resets_at": true, "saved_at": true,
+	"started_at": true, "last_message_at": true, "last_processed_at": true,
+	"first_seen": true, "last_seen": true, "last_run": true, "next_run": true,
+	"last_success": true, "build_time": true, "timestamp": true, "time": true,
+	"date": true, "first": true, "last": true,
+}
+
+// timestampRe — строка, которую ответ считает меткой времени RFC 3339.
+// Даты без времени (2025-01-02) не затрагиваются.
+var timestampRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+\-]\d{2}:\d{2})$`)
+
+// timeFormat — запрошенное представление меток времени: RFC 3339 в часовом
+// поясе loc или Unix timestamp строкой
+type timeFormat struct {
+	unix bool
+	loc  *time.Location
+}
+
+// defaultTimeFormat — RFC 3339 в UTC, как метки хранятся
+var defaultTimeFormat = timeFormat{loc: time.UTC}
+
+type timeFormatKey struct{}
+
+// isDefault сообщает, что ответ можно отдать без перекодирования
+func (f timeFormat) isDefault() bool {
+	return !f.unix && f.loc == time.UTC
+}
+
+// parseTimeFormat разбирает ?ts=iso|unix и ?tz=<зона IANA>
+func parseTimeFormat(r *http.Request) (timeFormat, error) {
+	f := defaultTimeFormat
+	switch ts := r.URL.Query().Get("ts"); ts {
+	case "", tsISO:
+	case tsUnix:
+		f.unix = true
+	default:
+		return f, fmt.Errorf("invalid ts %q: expected iso or unix", ts)
+	}
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return f, fmt.Errorf("invalid tz %q: expected an IANA time zone such as Europe/Moscow", tz)
+		}
+		f.loc = loc
+	}
+	return f, nil
+}
+
+// timeFormatMiddleware проверяет ts и tz до обработчика и передает формат
+// в respond через контекст запроса
+func timeFormatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseTimeFormat(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !f.isDefault() {
+			r = r.WithContext(context.WithValue(r.Context(), timeFormatKey{}, f))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestTimeFormat возвращает формат меток времени запроса
+func requestTimeFormat(r *http.Request) timeFormat {
+	if f, ok := r.Context().Value(timeFormatKey{}).(timeFormat); ok {
+		return f
+	}
+	return defaultTimeFormat
+}
+
+// format представляет момент времени в запрошенном формате
+func (f timeFormat) format(t time.Time) string {
+	if f.unix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.In(f.loc).Format(time.RFC3339Nano)
+}
+
+// cell переводит ячейку выгрузки: время — в часовой пояс или Unix timestamp
+func (f timeFormat) cell(v any) any {
+	t, ok := v.(time.Time)
+	if !ok {
+		return v
+	}
+	if f.unix {
+		return t.Unix()
+	}
+	return t.In(f.loc)
+}
+
+// convert заменяет метку времени в поле key; строки других полей и строки,
+// не похожие на метку, возвращаются как есть
+func (f timeFormat) convert(key, s string) string {
+	if !timestampFields[key] || !timestampRe.MatchString(s) {
+		return s
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return f.format(t)
+}
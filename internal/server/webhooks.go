@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"frontend-backend/internal/storage"
+	"frontend-backend/internal/webhook"
+
+	"github.com/gorilla/mux"
+)
+
+// WithWebhooks включает админские эндпоинты подписок на вебхуки
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(s *Server) {
+		s.webhooks = d
+	}
+}
+
+// webhookRequest — тело POST /admin/webhooks
+type webhookRequest struct {
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Tickers []string `json:"tickers"`
+}
+
+// getWebhooksHandler возвращает подписки на новые прогнозы
+func (s *Server) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.respond(w, r, s.webhooks.List())
+}
+
+// postWebhookHandler регистрирует подписку
+func (s *Server) postWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	sub, err := s.webhooks.Add(r.Context(), storage.WebhookSubscription{URL: req.URL, Secret: req.Secret, Tickers: req.Tickers})
+	if errors.Is(err, webhook.ErrInvalidSubscription) {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при регистрации вебхука", "err", err)
+		writeError(w, err)
+		return
+	}
+
+	s.log.InfoContext(r.Context(), "Зарегистрирован вебхук", "id", sub.ID, "url", sub.URL, "tickers", sub.Tickers)
+	s.respondStatus(w, r, http.StatusCreated, sub)
+}
+
+// deleteWebhookHandler удаляет подписку, зарегистрированную через API
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := s.webhooks.Remove(r.Context(), id); errors.Is(err, storage.ErrWebhookNotFound) {
+		writeProblem(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		s.log.ErrorContext(r.Context(), "Ошибка при удалении вебхука", "id", id, "err", err)
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
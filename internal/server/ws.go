@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessage — предельный размер сообщения от клиента
+	wsMaxMessage = 4096
+)
+
+// wsCheckOrigin пропускает клиентов без Origin (не браузеры) и фронтенд
+// с явно указанного в CORS адреса: браузер отправляет cookie в WebSocket с
+// любого сайта, поэтому «*» здесь не действует
+func (s *Server) wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || s.cors.Load().listed(origin)
+}
+
+// wsCommand — сообщение клиента: {"action": "subscribe", "tickers": ["SBER"]}
+type wsCommand struct {
+	Action  string   `json:"action"`
+	Tickers []string `json:"tickers"`
+}
+
+// wsReply — ответ на команду клиента
+type wsReply struct {
+	Type    string   `json:"type"` // subscribed или error
+	Tickers []string `json:"tickers,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// apply выполняет команду и возвращает текущий список подписок
+func (c *subscriber) apply(cmd wsCommand) wsReply {
+	switch cmd.Action {
+	case "subscribe":
+		c.subscribe(cmd.Tickers)
+	case "unsubscribe":
+		c.mu.Lock()
+		for _, t := range cmd.Tickers {
+			delete(c.tickers, strings.ToUpper(strings.TrimSpace(t)))
+		}
+		c.mu.Unlock()
+	default:
+		return wsReply{Type: "error", Error: fmt.Sprintf("unknown action %q: expected subscribe or unsubscribe", cmd.Action)}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reply := wsReply{Type: "subscribed", Tickers: []string{}}
+	for t := range c.tickers {
+		reply.Tickers = append(reply.Tickers, t)
+	}
+	sort.Strings(reply.Tickers)
+	return reply
+}
+
+// wsHandler открывает WebSocket-соединение. Начальные подписки можно
+// передать в ?tickers=SBER,GAZP; дальше — командами subscribe/unsubscribe.
+func (s *Server) wsHandler(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: s.wsCheckOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader уже ответил клиенту ошибкой
+		s.log.ErrorContext(r.Context(), "Ошибка открытия WebSocket", "err", err)
+		return
+	}
+
+	c := newSubscriber()
+	s.hub.register(c)
+	if v := r.URL.Query().Get("tickers"); v != "" {
+		s.wsReply(c, c.apply(wsCommand{Action: "subscribe", Tickers: strings.Split(v, ",")}))
+	}
+	go c.writeLoop(conn)
+	s.readLoop(conn, c)
+	s.hub.unregister(c)
+}
+
+func (s *Server) wsReply(c *subscriber, r wsReply) {
+	data, _ := json.Marshal(r)
+	s.hub.reply(c, data)
+}
+
+// readLoop читает команды клиента до закрытия соединения
+func (s *Server) readLoop(conn *websocket.Conn, c *subscriber) {
+	conn.SetReadLimit(wsMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd wsCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			s.wsReply(c, wsReply{Type: "error", Error: "invalid command: " + err.Error()})
+			continue
+		}
+		s.wsReply(c, c.apply(cmd))
+	}
+}
+
+// writeLoop отправляет события и ping; завершается, когда хаб закрыл очередь
+// или запись не удалась
+func (c *subscriber) writeLoop(conn *websocket.Conn) {
+	ping := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ping.Stop()
+		conn.Close()
+	}()
+	for {
+		select {
+		case f, ok := <-c.send:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, f.Data); err != nil {
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
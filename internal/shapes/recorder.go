@@ -0,0 +1,91 @@
+// Package shapes собирает формы запросов к API (маршрут, имена параметров,
+// версия схемы, обезличенный клиент) в аналитическую таблицу, чтобы перед
+// удалением параметра или поля было видно, кто им еще пользуется.
+package shapes
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Store сохраняет формы запросов пачками
+type Store interface {
+	InsertRequestShapes(ctx context.Context, shapes []storage.RequestShape) error
+}
+
+// Options задает выборку и пакетную запись
+type Options struct {
+	SampleRate    float64       // доля записываемых запросов, 0..1
+	BatchSize     int           // запись сразу при наборе пачки
+	FlushInterval time.Duration // и не реже этого интервала
+	Logger        *slog.Logger  // получает ошибки записи; nil — slog.Default()
+}
+
+// Recorder копит формы запросов и пишет их в хранилище в фоне. Record не
+// блокируется: если очередь заполнена, форма теряется.
+type Recorder struct {
+	store Store
+	opts  Options
+	queue chan storage.RequestShape
+}
+
+// NewRecorder создает новый экземпляр Recorder
+func NewRecorder(store Store, opts Options) *Recorder {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 10 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Recorder{store: store, opts: opts, queue: make(chan storage.RequestShape, opts.BatchSize*10)}
+}
+
+// Record ставит форму в очередь с вероятностью SampleRate
+func (r *Recorder) Record(s storage.RequestShape) {
+	if r.opts.SampleRate < 1 && rand.Float64() >= r.opts.SampleRate {
+		return
+	}
+	select {
+	case r.queue <- s:
+	default:
+	}
+}
+
+// Run записывает накопленные формы до отмены ctx; остаток пишется при остановке
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]storage.RequestShape, 0, r.opts.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.store.InsertRequestShapes(ctx, batch); err != nil {
+			r.opts.Logger.Error("Ошибка записи форм запросов", "batch", len(batch), "err", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			flush(shutdownCtx)
+			cancel()
+			return
+		case s := <-r.queue:
+			batch = append(batch, s)
+			if len(batch) >= r.opts.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
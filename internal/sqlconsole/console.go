@@ -0,0 +1,138 @@
+package sqlconsole
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// Auditor сохраняет журнал выполненных запросов
+type Auditor interface {
+	AddSQLAudit(ctx context.Context, e storage.SQLAuditEntry) error
+}
+
+// Result — результат запроса в табличном виде
+type Result struct {
+	Columns    []string `json:"columns"`
+	Rows       [][]any  `json:"rows"`
+	Truncated  bool     `json:"truncated"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Console выполняет произвольные запросы только на чтение. Защита
+// многослойная: подключение от роли БД без прав на запись, транзакция
+// READ ONLY, statement_timeout и одно выражение на запрос (расширенный
+// протокол). Каждый запрос, в том числе неудачный, записывается в журнал.
+type Console struct {
+	db      *sql.DB
+	audit   Auditor
+	timeout time.Duration
+	maxRows int
+	log     *slog.Logger
+}
+
+// New создает консоль поверх подключения от read-only роли; nil logger —
+// slog.Default()
+func New(db *sql.DB, audit Auditor, timeout time.Duration, maxRows int, logger *slog.Logger) *Console {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Console{db: db, audit: audit, timeout: timeout, maxRows: maxRows, log: logger}
+}
+
+// QueryError — ошибка самого запроса (синтаксис, права, таймаут),
+// в отличие от ошибок инфраструктуры
+type QueryError struct {
+	Err error
+}
+
+func (e *QueryError) Error() string { return e.Err.Error() }
+func (e *QueryError) Unwrap() error { return e.Err }
+
+// Run выполняет запрос от имени actor. Если запись в журнал не удалась,
+// результат не возвращается.
+func (c *Console) Run(ctx context.Context, actor, query string) (Result, error) {
+	start := time.Now()
+	res, err := c.run(ctx, query)
+	res.DurationMS = time.Since(start).Milliseconds()
+
+	entry := storage.SQLAuditEntry{Actor: actor, Query: query, Rows: len(res.Rows), Duration: time.Since(start)}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.log.InfoContext(ctx, "SQL-консоль: выполнен запрос", "actor", actor, "rows", entry.Rows, "duration", entry.Duration.Round(time.Millisecond), "query", query)
+	// Журнал пишется и после отмены запроса клиентом
+	auditCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if auditErr := c.audit.AddSQLAudit(auditCtx, entry); auditErr != nil {
+		return Result{}, fmt.Errorf("audit sql console query: %w", auditErr)
+	}
+
+	if err != nil {
+		return Result{}, &QueryError{Err: err}
+	}
+	return res, nil
+}
+
+func (c *Console) run(ctx context.Context, query string) (Result, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \n\t")
+	if query == "" {
+		return Result{}, errors.New("query is empty")
+	}
+
+	tx, err := c.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", c.timeout.Milliseconds())); err != nil {
+		return Result{}, err
+	}
+
+	// Подготовленное выражение идет по расширенному протоколу PostgreSQL,
+	// который принимает ровно одно выражение: «SELECT 1; DELETE ...»
+	// отклонит сервер, а ; внутри строковых литералов не мешает
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return Result{}, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer rows.Close()
+
+	res := Result{Rows: [][]any{}}
+	if res.Columns, err = rows.Columns(); err != nil {
+		return Result{}, err
+	}
+	for rows.Next() {
+		if len(res.Rows) >= c.maxRows {
+			res.Truncated = true
+			break
+		}
+		values := make([]any, len(res.Columns))
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return Result{}, err
+		}
+		for i, v := range values {
+			// text, numeric и т.п. драйвер отдает байтами
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		res.Rows = append(res.Rows, values)
+	}
+	return res, rows.Err()
+}
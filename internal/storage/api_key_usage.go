@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// APIKeyUsage — число запросов по ключу за текущие сутки и месяц (UTC)
+type APIKeyUsage struct {
+	Day   int64
+	Month int64
+}
+
+// RecordAPIKeyUsage учитывает запрос по ключу и возвращает потребление с его
+// учетом — одним запросом к БД
+func (s *PostgresStorage) RecordAPIKeyUsage(ctx context.Context, keyID int64, at time.Time) (APIKeyUsage, error) {
+	var u APIKeyUsage
+	err := s.db.QueryRowContext(ctx, `
+		WITH today AS (
+			INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, $2::date, 1)
+			ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+			RETURNING requests
+		)
+		SELECT today.requests, today.requests + COALESCE((
+			SELECT SUM(requests) FROM api_key_usage
+			WHERE key_id = $1 AND day >= date_trunc('month', $2::date) AND day < $2::date
+		), 0)
+		FROM today`, keyID, at.UTC().Format(time.DateOnly)).Scan(&u.Day, &u.Month)
+	if err != nil {
+		return u, fmt.Errorf("error recording usage of api key %d: %w", keyID, err)
+	}
+	return u, nil
+}
+
+// GetAPIKeyUsage возвращает потребление ключа за сутки и месяц, в которые попадает at
+func (s *PostgresStorage) GetAPIKeyUsage(ctx context.Context, keyID int64, at time.Time) (APIKeyUsage, error) {
+	var u APIKeyUsage
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(requests) FILTER (WHERE day = $2::date), 0), COALESCE(SUM(requests), 0)
+		FROM api_key_usage
+		WHERE key_id = $1 AND day >= date_trunc('month', $2::date) AND day <= $2::date`,
+		keyID, at.UTC().Format(time.DateOnly)).Scan(&u.Day, &u.Month)
+	if err != nil {
+		return u, fmt.Errorf("error querying usage of api key %d: %w", keyID, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrAPIKeyNotFound возвращается, если активного ключа с указанным id нет
+var ErrAPIKeyNotFound = NewNotFoundError("api key not found")
+
+// APIKey — ключ доступа к API. Hash — hex(SHA-256) ключа, сам ключ не
+// хранится; Prefix — его начало, чтобы ключ можно было узнать в списке.
+// Source — config или api; ключи из конфигурации имеют нулевой ID.
+// DailyQuota и MonthlyQuota — лимиты запросов за сутки и месяц (UTC), 0 — без
+// ограничения.
+type APIKey struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Hash         string     `json:"-"`
+	Role         string     `json:"role"`
+	Source       string     `json:"source"`
+	DailyQuota   int64      `json:"daily_quota"`
+	MonthlyQuota int64      `json:"monthly_quota"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ListAPIKeys возвращает ключи, выпущенные через API, включая отозванные
+func (s *PostgresStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, name, prefix, key_hash, role, daily_quota, monthly_quota, created_at, revoked_at FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error querying api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		var created time.Time
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &k.Role, &k.DailyQuota, &k.MonthlyQuota, &created, &k.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		created = created.UTC()
+		k.Source = "api"
+		k.CreatedAt = &created
+		if k.RevokedAt != nil {
+			revoked := k.RevokedAt.UTC()
+			k.RevokedAt = &revoked
+		}
+		keys = append(keys, k)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over api key rows: %w", err)
+	}
+	return keys, nil
+}
+
+// AddAPIKey сохраняет ключ и заполняет его ID и время создания
+func (s *PostgresStorage) AddAPIKey(ctx context.Context, k *APIKey) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting api key transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var created time.Time
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO api_keys (name, prefix, key_hash, role, daily_quota, monthly_quota)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		k.Name, k.Prefix, k.Hash, k.Role, k.DailyQuota, k.MonthlyQuota).Scan(&k.ID, &created)
+	if err != nil {
+		return fmt.Errorf("error inserting api key: %w", err)
+	}
+	if err := recordAudit(ctx, tx, AuditCreate, "api_keys", k.ID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing api key: %w", err)
+	}
+	created = created.UTC()
+	k.Source = "api"
+	k.CreatedAt = &created
+	return nil
+}
+
+// RevokeAPIKey отзывает ключ; запись остается в таблице для истории
+func (s *PostgresStorage) RevokeAPIKey(ctx context.Context, id int64) error {
+	n, err := s.execAudited(ctx, AuditRevoke, "api_keys", id,
+		"UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("error revoking api key %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrAPIKeyNotFound, id)
+	}
+	return nil
+}
+
+// SetAPIKeyRole меняет роль действующего ключа
+func (s *PostgresStorage) SetAPIKeyRole(ctx context.Context, id int64, role string) error {
+	n, err := s.execAudited(ctx, AuditUpdate, "api_keys", id,
+		"UPDATE api_keys SET role = $2 WHERE id = $1 AND revoked_at IS NULL", id, role)
+	if err != nil {
+		return fmt.Errorf("error updating role of api key %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrAPIKeyNotFound, id)
+	}
+	return nil
+}
+
+// SetAPIKeyQuota меняет квоты действующего ключа
+func (s *PostgresStorage) SetAPIKeyQuota(ctx context.Context, id, daily, monthly int64) error {
+	n, err := s.execAudited(ctx, AuditUpdate, "api_keys", id,
+		"UPDATE api_keys SET daily_quota = $2, monthly_quota = $3 WHERE id = $1 AND revoked_at IS NULL", id, daily, monthly)
+	if err != nil {
+		return fmt.Errorf("error updating quota of api key %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrAPIKeyNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Действия журнала изменений
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditRevoke  = "revoke"
+	AuditPurge   = "purge"
+)
+
+// auditRedacted — столбцы, которые не попадают в снимки журнала
+const auditRedacted = ` - 'key_hash' - 'password_hash' - 'secret'`
+
+// Actor — автор изменения: клиент запроса и его адрес
+type Actor struct {
+	Name string
+	IP   string
+}
+
+// systemActor — автор изменений вне HTTP-запросов (фоновые задачи, CLI)
+var systemActor = Actor{Name: "system"}
+
+type actorKey struct{}
+
+// WithActor возвращает контекст, изменения в котором записываются в журнал
+// от имени a
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// actorFromContext возвращает автора изменения или systemActor
+func actorFromContext(ctx context.Context) Actor {
+	if a, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return a
+	}
+	return systemActor
+}
+
+// AuditEntry — запись журнала изменений. Entity — таблица, Before и After —
+// ее строка до и после изменения (null при создании и удалении строки).
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Actor     string          `json:"actor"`
+	IP        string          `json:"ip,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+// AuditFilter отбирает записи журнала; пустые поля не ограничивают выборку.
+// Записи идут от новых к старым, BeforeID — курсор следующей страницы.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID int64
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
+
+// ListAuditLog возвращает записи журнала изменений по фильтру
+func (s *PostgresStorage) ListAuditLog(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.Entity != "" {
+		add("entity = ?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		add("entity_id = ?", f.EntityID)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("created_at < ?", f.Until)
+	}
+	if f.BeforeID != 0 {
+		add("id < ?", f.BeforeID)
+	}
+	query := `SELECT id, created_at, actor, ip, action, entity, entity_id, before, after FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Actor, &e.IP, &e.Action, &e.Entity, &e.EntityID, &before, &after); err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %w", err)
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		e.Before, e.After = rawJSON(before), rawJSON(after)
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over audit rows: %w", err)
+	}
+	return entries, nil
+}
+
+// rawJSON превращает NULL из БД в JSON null
+func rawJSON(b []byte) json.RawMessage {
+	if b == nil {
+		return json.RawMessage("null")
+	}
+	return b
+}
+
+// snapshotRow блокирует строку table с id до конца транзакции и возвращает
+// ее в JSON или nil, если строки нет
+func snapshotRow(ctx context.Context, tx *loggedTx, table string, id int64) ([]byte, error) {
+	var row []byte
+	err := tx.QueryRowContext(ctx, `SELECT to_jsonb(t)`+auditRedacted+` FROM `+table+` t WHERE t.id = $1 FOR UPDATE`, id).Scan(&row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s %d for audit: %w", table, id, err)
+	}
+	return row, nil
+}
+
+// recordAudit записывает изменение строки table с id в журнал в транзакции
+// изменения: before — снимок до изменения, снимок после делается здесь
+func recordAudit(ctx context.Context, tx *loggedTx, action, table string, id int64, before []byte) error {
+	after, err := snapshotRow(ctx, tx, table, id)
+	if err != nil {
+		return err
+	}
+	actor := actorFromContext(ctx)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (actor, ip, action, entity, entity_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, actor.Name, actor.IP, action, table, id, before, after)
+	if err != nil {
+		return fmt.Errorf("error recording audit entry for %s %d: %w", table, id, err)
+	}
+	return nil
+}
+
+// execAudited выполняет запрос, меняющий строку table с id, и записывает
+// изменение в журнал в одной транзакции. Возвращает число измененных строк;
+// если их нет, журнал не пишется.
+func (s *PostgresStorage) execAudited(ctx context.Context, action, table string, id int64, query string, args ...any) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshotRow(ctx, tx, table, id)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return 0, nil
+	}
+	if err := recordAudit(ctx, tx, action, table, id, before); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return n, nil
+}
+
+// updateAudited меняет строки table с id из selectIDs запросом update (id
+// строки — $1, updateArgs — со $2) и записывает каждое изменение в журнал в
+// одной транзакции. Возвращает число измененных строк.
+func (s *PostgresStorage) updateAudited(ctx context.Context, table, selectIDs string, selectArgs []any, update string, updateArgs ...any) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, selectIDs+" FOR UPDATE", selectArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("error selecting %s rows: %w", table, err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning %s id: %w", table, err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating over %s ids: %w", table, err)
+	}
+
+	var total int64
+	for _, id := range ids {
+		before, err := snapshotRow(ctx, tx, table, id)
+		if err != nil {
+			return 0, err
+		}
+		res, err := tx.ExecContext(ctx, update, append([]any{id}, updateArgs...)...)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		if err := recordAudit(ctx, tx, AuditUpdate, table, id, before); err != nil {
+			return 0, err
+		}
+		total++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return total, nil
+}
+
+// RecordAuditEvent записывает в журнал изменение, которое не сводится к
+// строке таблицы (настройки, внешние сервисы): entity — его объект,
+// entity_id — 0, before и after сохраняются в JSON
+func (s *PostgresStorage) RecordAuditEvent(ctx context.Context, action, entity string, before, after any) error {
+	b, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	a, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+	actor := actorFromContext(ctx)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (actor, ip, action, entity, entity_id, before, after)
+		VALUES ($1, $2, $3, $4, 0, $5, $6)
+	`, actor.Name, actor.IP, action, entity, b, a)
+	if err != nil {
+		return fmt.Errorf("error recording audit entry for %s: %w", entity, err)
+	}
+	return nil
+}
+
+// auditJSON кодирует состояние для журнала; nil остается NULL
+func auditJSON(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit state: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TargetBand — перцентили целевых цен прогнозов, активных в интервале
+type TargetBand struct {
+	Bucket string  `json:"Bucket"` // начало интервала, YYYY-MM-DD
+	Count  int     `json:"Count"`
+	P10    float64 `json:"P10"`
+	P50    float64 `json:"P50"`
+	P90    float64 `json:"P90"`
+}
+
+// GetTargetBands считает p10/p50/p90 целевых цен по интервалам (day, week,
+// month). Прогноз учитывается во всех интервалах, которые пересекаются с
+// периодом от даты прогноза до истечения его горизонта.
+func (s *PostgresStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+
+	periods, days := horizonArrays()
+	rows, err := s.db.QueryContext(ctx, `
+		WITH targets AS (
+			SELECT p.target_price, p.predicted_at,
+			       p.predicted_at + make_interval(days => COALESCE(h.days, $5)) AS expires_at
+			FROM predictions p
+			LEFT JOIN unnest($3::text[], $4::int[]) AS h(period, days) ON h.period = p.period
+			WHERE p.stock_id = $1 AND p.target_price IS NOT NULL AND p.deleted_at IS NULL
+		),
+		buckets AS (
+			SELECT b, b + ('1 ' || $2)::interval AS b_end
+			FROM generate_series(
+				date_trunc($2, (SELECT MIN(predicted_at) FROM targets)),
+				date_trunc($2, now()),
+				('1 ' || $2)::interval
+			) AS b
+		)
+		SELECT to_char(b.b, 'YYYY-MM-DD'), COUNT(*),
+		       percentile_cont(0.1) WITHIN GROUP (ORDER BY t.target_price),
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY t.target_price),
+		       percentile_cont(0.9) WITHIN GROUP (ORDER BY t.target_price)
+		FROM buckets b
+		JOIN targets t ON t.predicted_at < b.b_end AND t.expires_at >= b.b
+		GROUP BY b.b
+		ORDER BY b.b
+	`, stockID, bucket, pq.Array(periods), pq.Array(days), DefaultHorizonDays)
+	if err != nil {
+		return nil, fmt.Errorf("error querying target bands for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	bands := []TargetBand{}
+	for rows.Next() {
+		var b TargetBand
+		if err := rows.Scan(&b.Bucket, &b.Count, &b.P10, &b.P50, &b.P90); err != nil {
+			return nil, fmt.Errorf("error scanning target band: %w", err)
+		}
+		bands = append(bands, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over target band rows: %w", err)
+	}
+
+	return bands, nil
+}
+
+// ComputeTargetBands считает те же полосы, что и GetTargetBands, по уже
+// загруженным прогнозам; последний интервал — тот, в который попадает now
+func ComputeTargetBands(predictions []Prediction, bucket string, now time.Time) []TargetBand {
+	type target struct {
+		price         float64
+		from, expires time.Time
+	}
+	var targets []target
+	var first time.Time
+	for _, p := range predictions {
+		from, ok := ParseTimestamp(p.PredictedAt)
+		if !ok || p.TargetPrice == nil {
+			continue
+		}
+		targets = append(targets, target{*p.TargetPrice, from, from.AddDate(0, 0, HorizonDays(p.Period))})
+		if first.IsZero() || from.Before(first) {
+			first = from
+		}
+	}
+
+	bands := []TargetBand{}
+	if len(targets) == 0 {
+		return bands
+	}
+	last := BucketStart(now, bucket)
+	for b := BucketStart(first, bucket); !b.After(last); b = nextBucket(b, bucket) {
+		end := nextBucket(b, bucket)
+		var prices []float64
+		for _, t := range targets {
+			if t.from.Before(end) && !t.expires.Before(b) {
+				prices = append(prices, t.price)
+			}
+		}
+		if len(prices) == 0 {
+			continue
+		}
+		sort.Float64s(prices)
+		bands = append(bands, TargetBand{
+			Bucket: b.Format("2006-01-02"),
+			Count:  len(prices),
+			P10:    percentileCont(prices, 0.1),
+			P50:    percentileCont(prices, 0.5),
+			P90:    percentileCont(prices, 0.9),
+		})
+	}
+	return bands
+}
+
+// nextBucket возвращает начало следующего интервала
+func nextBucket(b time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return b.AddDate(0, 0, 7)
+	case BucketMonth:
+		return b.AddDate(0, 1, 0)
+	default:
+		return b.AddDate(0, 0, 1)
+	}
+}
+
+// percentileCont — перцентиль с линейной интерполяцией, как percentile_cont
+// в PostgreSQL; sorted отсортирован и не пуст
+func percentileCont(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(pos-float64(lo))
+}
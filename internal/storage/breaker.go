@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"frontend-backend/internal/breaker"
+)
+
+// BreakerStorage пропускает вызовы другого Storage через выключатель: когда
+// БД деградирует и ошибок становится много, запросы сразу получают
+// ErrUnavailable вместо ожидания таймаута. Стоит под кешем, чтобы кеш
+// продолжал отдавать сохраненные значения. Админские изменения акций и
+// прогнозов проходят через тот же выключатель (StockWriter и
+// PredictionWriter); остальные админские вызовы, загрузка сообщений и фоновые
+// задачи обращаются к БД напрямую: у них свои повторы, а отказ API им не
+// поможет.
+type BreakerStorage struct {
+	next Storage
+	g    guard
+}
+
+// NewBreakerStorage оборачивает next выключателем b. Ненулевой timeout
+// ограничивает каждый вызов; истекший таймаут считается неудачей.
+func NewBreakerStorage(next Storage, b *breaker.Breaker, timeout time.Duration) *BreakerStorage {
+	return &BreakerStorage{next: next, g: guard{b: b, timeout: timeout}}
+}
+
+// guard — выключатель и таймаут одного вызова
+type guard struct {
+	b       *breaker.Breaker
+	timeout time.Duration
+}
+
+// guarded выполняет call, если выключатель замкнут. Ошибки классов
+// хранилища (не найдено, неверный запрос и т.п.) и отмена запроса клиентом
+// не говорят о сбое БД и неудачей не считаются; истекший таймаут —
+// считается, даже если драйвер вернул другую ошибку.
+func guarded[T any](ctx context.Context, g guard, call func(ctx context.Context) (T, error)) (T, error) {
+	done, err := g.b.Allow()
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	callCtx := ctx
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+	v, err := call(callCtx)
+	failed := err != nil && !isClassError(err)
+	switch {
+	case errors.Is(callCtx.Err(), context.DeadlineExceeded):
+		failed = err != nil
+	case errors.Is(err, context.Canceled), errors.Is(ctx.Err(), context.Canceled):
+		failed = false
+	}
+	done(failed)
+	return v, err
+}
+
+// guardedErr — guarded для вызовов без результата
+func guardedErr(ctx context.Context, g guard, call func(ctx context.Context) error) error {
+	_, err := guarded(ctx, g, func(ctx context.Context) (struct{}, error) { return struct{}{}, call(ctx) })
+	return err
+}
+
+func isClassError(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrConflict) || errors.Is(err, ErrPrecondition)
+}
+
+// Методы ниже делегируют вызов next через выключатель
+
+func (s *BreakerStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]Stock, error) { return s.next.GetStocks(ctx) })
+}
+
+func (s *BreakerStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]Prediction, error) {
+		return s.next.GetPredictionsByTicker(ctx, ticker, exchange)
+	})
+}
+
+func (s *BreakerStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]StockPriceHistory, error) {
+		return s.next.GetStockPriceHistory(ctx, ticker)
+	})
+}
+
+func (s *BreakerStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]CorporateAction, error) { return s.next.GetCorporateActions(ctx, ticker) })
+}
+
+func (s *BreakerStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]EODSummary, error) { return s.next.GetEODSummaries(ctx, date) })
+}
+
+func (s *BreakerStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]PredictionRollup, error) {
+		return s.next.GetPredictionRollup(ctx, ticker, bucket)
+	})
+}
+
+func (s *BreakerStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) (Consensus, error) { return s.next.GetConsensus(ctx, ticker) })
+}
+
+func (s *BreakerStorage) GetSources(ctx context.Context) ([]Source, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]Source, error) { return s.next.GetSources(ctx) })
+}
+
+func (s *BreakerStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]string, error) { return s.next.GetPredictionTypes(ctx) })
+}
+
+func (s *BreakerStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]PredictionMatch, error) { return s.next.SearchPredictions(ctx, q, limit) })
+}
+
+func (s *BreakerStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	return guarded(ctx, s.g, func(ctx context.Context) ([]TargetBand, error) { return s.next.GetTargetBands(ctx, ticker, bucket) })
+}
+
+// StockWriter пропускает изменения акций через тот же выключатель
+func (s *BreakerStorage) StockWriter(w StockWriter) StockWriter {
+	return &breakerStockWriter{next: w, g: s.g}
+}
+
+type breakerStockWriter struct {
+	next StockWriter
+	g    guard
+}
+
+func (w *breakerStockWriter) ListStocks(ctx context.Context, includeDeleted bool) ([]Stock, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) ([]Stock, error) { return w.next.ListStocks(ctx, includeDeleted) })
+}
+
+func (w *breakerStockWriter) GetStock(ctx context.Context, id int64) (Stock, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Stock, error) { return w.next.GetStock(ctx, id) })
+}
+
+func (w *breakerStockWriter) CreateStock(ctx context.Context, st *Stock) error {
+	return guardedErr(ctx, w.g, func(ctx context.Context) error { return w.next.CreateStock(ctx, st) })
+}
+
+func (w *breakerStockWriter) UpsertStock(ctx context.Context, st *Stock) (string, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (string, error) { return w.next.UpsertStock(ctx, st) })
+}
+
+func (w *breakerStockWriter) UpdateStock(ctx context.Context, st *Stock, version int64) error {
+	return guardedErr(ctx, w.g, func(ctx context.Context) error { return w.next.UpdateStock(ctx, st, version) })
+}
+
+func (w *breakerStockWriter) DeleteStock(ctx context.Context, id int64) error {
+	return guardedErr(ctx, w.g, func(ctx context.Context) error { return w.next.DeleteStock(ctx, id) })
+}
+
+func (w *breakerStockWriter) RestoreStock(ctx context.Context, id int64) (Stock, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Stock, error) { return w.next.RestoreStock(ctx, id) })
+}
+
+// PredictionWriter пропускает изменения прогнозов через тот же выключатель
+func (s *BreakerStorage) PredictionWriter(w PredictionWriter) PredictionWriter {
+	return &breakerPredictionWriter{next: w, g: s.g}
+}
+
+type breakerPredictionWriter struct {
+	next PredictionWriter
+	g    guard
+}
+
+func (w *breakerPredictionWriter) GetPrediction(ctx context.Context, id int64) (Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Prediction, error) { return w.next.GetPrediction(ctx, id) })
+}
+
+func (w *breakerPredictionWriter) CreatePrediction(ctx context.Context, in PredictionInput) (Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Prediction, error) { return w.next.CreatePrediction(ctx, in) })
+}
+
+func (w *breakerPredictionWriter) UpdatePrediction(ctx context.Context, id int64, in PredictionInput, version int64) (Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Prediction, error) { return w.next.UpdatePrediction(ctx, id, in, version) })
+}
+
+func (w *breakerPredictionWriter) PatchPrediction(ctx context.Context, id int64, patch PredictionPatch, version int64) (Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Prediction, error) { return w.next.PatchPrediction(ctx, id, patch, version) })
+}
+
+func (w *breakerPredictionWriter) DeletePrediction(ctx context.Context, id int64) error {
+	return guardedErr(ctx, w.g, func(ctx context.Context) error { return w.next.DeletePrediction(ctx, id) })
+}
+
+func (w *breakerPredictionWriter) ListPredictions(ctx context.Context, ticker, exchange string, includeDeleted bool) ([]Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) ([]Prediction, error) {
+		return w.next.ListPredictions(ctx, ticker, exchange, includeDeleted)
+	})
+}
+
+func (w *breakerPredictionWriter) RestorePrediction(ctx context.Context, id int64) (Prediction, error) {
+	return guarded(ctx, w.g, func(ctx context.Context) (Prediction, error) { return w.next.RestorePrediction(ctx, id) })
+}
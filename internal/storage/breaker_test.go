@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"frontend-backend/internal/breaker"
+)
+
+// newTestGuard возвращает выключатель, который размыкается после первой
+// неудачи
+func newTestGuard(t *testing.T, timeout time.Duration) guard {
+	t.Helper()
+	b := breaker.New(t.Name(), breaker.Options{
+		Window:       time.Hour,
+		MinRequests:  1,
+		FailureRatio: 1,
+		OpenTimeout:  time.Hour,
+	})
+	return guard{b: b, timeout: timeout}
+}
+
+func TestGuardedCountsFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		failure bool
+	}{
+		{"success", nil, false},
+		{"database error", errors.New("connection reset"), true},
+		{"not found", fmt.Errorf("%w: SBER", ErrStockNotFound), false},
+		{"validation", NewValidationError("bad ticker"), false},
+		{"client canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGuard(t, 0)
+			_, err := guarded(context.Background(), g, func(context.Context) (int, error) { return 1, tt.err })
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("guarded() error = %v, want %v", err, tt.err)
+			}
+			if got := g.b.State() == breaker.Open; got != tt.failure {
+				t.Errorf("breaker open = %v, want %v", got, tt.failure)
+			}
+		})
+	}
+}
+
+func TestGuardedTimeout(t *testing.T) {
+	g := newTestGuard(t, 10*time.Millisecond)
+	err := guardedErr(context.Background(), g, func(ctx context.Context) error {
+		<-ctx.Done()
+		// Драйвер может вернуть свою ошибку вместо ctx.Err()
+		return errors.New("pq: canceling statement due to user request")
+	})
+	if err == nil {
+		t.Fatal("guardedErr() = nil, want error")
+	}
+	if got := g.b.State(); got != breaker.Open {
+		t.Fatalf("state after timeout = %v, want open", got)
+	}
+
+	_, err = guarded(context.Background(), g, func(context.Context) (int, error) {
+		t.Fatal("call made through open breaker")
+		return 0, nil
+	})
+	var open *breaker.OpenError
+	if !errors.Is(err, ErrUnavailable) || !errors.As(err, &open) {
+		t.Fatalf("guarded() on open breaker = %v, want ErrUnavailable wrapping *OpenError", err)
+	}
+}
+
+func TestGuardedClientCancel(t *testing.T) {
+	g := newTestGuard(t, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = guardedErr(ctx, g, func(ctx context.Context) error {
+		return errors.New("pq: canceling statement due to user request")
+	})
+	if got := g.b.State(); got != breaker.Closed {
+		t.Fatalf("state after client cancel = %v, want closed", got)
+	}
+}
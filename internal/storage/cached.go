@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"frontend-backend/internal/cache"
+)
+
+// CachedStorage кеширует ответы другого Storage (config: cache.enabled)
+type CachedStorage struct {
+	next        Storage
+	stocks      *cache.Cache[[]Stock]
+	predictions *cache.Cache[[]Prediction]
+	history     *cache.Cache[[]StockPriceHistory]
+	actions     *cache.Cache[[]CorporateAction]
+	eod         *cache.Cache[[]EODSummary]
+	rollup      *cache.Cache[[]PredictionRollup]
+	consensus   *cache.Cache[Consensus]
+	sources     *cache.Cache[[]Source]
+	types       *cache.Cache[[]string]
+	bands       *cache.Cache[[]TargetBand]
+}
+
+// NewCachedStorage оборачивает next кешем с заданными параметрами
+func NewCachedStorage(next Storage, opts cache.Options) *CachedStorage {
+	return &CachedStorage{
+		next:        next,
+		stocks:      cache.New[[]Stock]("stocks", opts),
+		predictions: cache.New[[]Prediction]("predictions", opts),
+		history:     cache.New[[]StockPriceHistory]("history", opts),
+		actions:     cache.New[[]CorporateAction]("actions", opts),
+		eod:         cache.New[[]EODSummary]("eod", opts),
+		rollup:      cache.New[[]PredictionRollup]("rollup", opts),
+		consensus:   cache.New[Consensus]("consensus", opts),
+		sources:     cache.New[[]Source]("sources", opts),
+		types:       cache.New[[]string]("types", opts),
+		bands:       cache.New[[]TargetBand]("bands", opts),
+	}
+}
+
+// loadContext отвязывает загрузку в кеш от отмены запроса: ее результат
+// ждут и другие запросы, а при stale-while-revalidate она идет в фоне уже
+// после ответа. Значения контекста (трассировка) сохраняются.
+func loadContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// GetStocks возвращает список акций из кеша
+func (s *CachedStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	return s.stocks.Get("stocks", func() ([]Stock, error) {
+		return s.next.GetStocks(loadContext(ctx))
+	})
+}
+
+// GetPredictionsByTicker возвращает прогнозы по тикеру из кеша
+func (s *CachedStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	key := ticker
+	if exchange != "" {
+		key += "@" + exchange
+	}
+	return s.predictions.Get(key, func() ([]Prediction, error) {
+		return s.next.GetPredictionsByTicker(loadContext(ctx), ticker, exchange)
+	})
+}
+
+// GetStockPriceHistory возвращает историю цен по тикеру из кеша
+func (s *CachedStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	return s.history.Get(ticker, func() ([]StockPriceHistory, error) {
+		return s.next.GetStockPriceHistory(loadContext(ctx), ticker)
+	})
+}
+
+// GetCorporateActions возвращает корпоративные действия по тикеру из кеша
+func (s *CachedStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	return s.actions.Get(ticker, func() ([]CorporateAction, error) {
+		return s.next.GetCorporateActions(loadContext(ctx), ticker)
+	})
+}
+
+// GetEODSummaries возвращает итоги дня из кеша
+func (s *CachedStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	return s.eod.Get(date.Format("2006-01-02"), func() ([]EODSummary, error) {
+		return s.next.GetEODSummaries(loadContext(ctx), date)
+	})
+}
+
+// GetPredictionRollup возвращает агрегаты прогнозов по тикеру из кеша
+func (s *CachedStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	return s.rollup.Get(ticker+":"+bucket, func() ([]PredictionRollup, error) {
+		return s.next.GetPredictionRollup(loadContext(ctx), ticker, bucket)
+	})
+}
+
+// GetConsensus возвращает консенсус по тикеру из кеша
+func (s *CachedStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	return s.consensus.Get(ticker, func() (Consensus, error) {
+		return s.next.GetConsensus(loadContext(ctx), ticker)
+	})
+}
+
+// GetSources возвращает список источников из кеша
+func (s *CachedStorage) GetSources(ctx context.Context) ([]Source, error) {
+	return s.sources.Get("sources", func() ([]Source, error) {
+		return s.next.GetSources(loadContext(ctx))
+	})
+}
+
+// GetPredictionTypes возвращает типы прогнозов из кеша
+func (s *CachedStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	return s.types.Get("types", func() ([]string, error) {
+		return s.next.GetPredictionTypes(loadContext(ctx))
+	})
+}
+
+// SearchPredictions не кешируется: запросы почти не повторяются
+func (s *CachedStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	return s.next.SearchPredictions(ctx, q, limit)
+}
+
+// GetTargetBands возвращает полосы целевых цен из кеша
+func (s *CachedStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	return s.bands.Get(ticker+":"+bucket, func() ([]TargetBand, error) {
+		return s.next.GetTargetBands(loadContext(ctx), ticker, bucket)
+	})
+}
+
+// CacheSnapshot — содержимое кешей CachedStorage для сохранения на диск
+type CacheSnapshot struct {
+	Stocks      map[string]cache.Item[[]Stock]             `json:"stocks"`
+	Predictions map[string]cache.Item[[]Prediction]        `json:"predictions"`
+	History     map[string]cache.Item[[]StockPriceHistory] `json:"history"`
+	Actions     map[string]cache.Item[[]CorporateAction]   `json:"actions"`
+	EOD         map[string]cache.Item[[]EODSummary]        `json:"eod"`
+	Rollup      map[string]cache.Item[[]PredictionRollup]  `json:"rollup"`
+	Consensus   map[string]cache.Item[Consensus]           `json:"consensus"`
+	Bands       map[string]cache.Item[[]TargetBand]        `json:"bands"`
+}
+
+// Snapshot возвращает текущее содержимое кешей
+func (s *CachedStorage) Snapshot() CacheSnapshot {
+	return CacheSnapshot{
+		Stocks:      s.stocks.Snapshot(),
+		Predictions: s.predictions.Snapshot(),
+		History:     s.history.Snapshot(),
+		Actions:     s.actions.Snapshot(),
+		EOD:         s.eod.Snapshot(),
+		Rollup:      s.rollup.Snapshot(),
+		Consensus:   s.consensus.Snapshot(),
+		Bands:       s.bands.Snapshot(),
+	}
+}
+
+// SetTTL меняет сроки жизни во всех кешах (см. cache.Cache.SetTTL)
+func (s *CachedStorage) SetTTL(ttl, staleTTL time.Duration, jitter float64) {
+	s.stocks.SetTTL(ttl, staleTTL, jitter)
+	s.predictions.SetTTL(ttl, staleTTL, jitter)
+	s.history.SetTTL(ttl, staleTTL, jitter)
+	s.actions.SetTTL(ttl, staleTTL, jitter)
+	s.eod.SetTTL(ttl, staleTTL, jitter)
+	s.rollup.SetTTL(ttl, staleTTL, jitter)
+	s.consensus.SetTTL(ttl, staleTTL, jitter)
+	s.sources.SetTTL(ttl, staleTTL, jitter)
+	s.types.SetTTL(ttl, staleTTL, jitter)
+	s.bands.SetTTL(ttl, staleTTL, jitter)
+}
+
+// Restore заполняет кеши из снимка
+func (s *CachedStorage) Restore(snap CacheSnapshot) {
+	s.stocks.Restore(snap.Stocks)
+	s.predictions.Restore(snap.Predictions)
+	s.history.Restore(snap.History)
+	s.actions.Restore(snap.Actions)
+	s.eod.Restore(snap.EOD)
+	s.rollup.Restore(snap.Rollup)
+	s.consensus.Restore(snap.Consensus)
+	s.bands.Restore(snap.Bands)
+}
+
+// Keys возвращает число ключей в снимке
+func (snap CacheSnapshot) Keys() int {
+	return len(snap.Stocks) + len(snap.Predictions) + len(snap.History) + len(snap.Actions) +
+		len(snap.EOD) + len(snap.Rollup) + len(snap.Consensus) + len(snap.Bands)
+}
+
+// StockWriter оборачивает w так, что после каждого изменения справочника
+// список акций перечитывается из БД. Кеши по тикеру истекают по TTL.
+func (s *CachedStorage) StockWriter(w StockWriter) StockWriter {
+	return &cachedStockWriter{StockWriter: w, s: s}
+}
+
+type cachedStockWriter struct {
+	StockWriter
+	s *CachedStorage
+}
+
+// invalidate сбрасывает справочник; удаление и восстановление акции
+// прячут или возвращают и ее прогнозы, поэтому сбрасываются и их кеши
+func (w *cachedStockWriter) invalidate() {
+	w.s.stocks.Invalidate("stocks")
+	w.s.predictions.InvalidateAll()
+	w.s.rollup.InvalidateAll()
+	w.s.consensus.InvalidateAll()
+	w.s.bands.InvalidateAll()
+	w.s.types.InvalidateAll()
+}
+
+func (w *cachedStockWriter) CreateStock(ctx context.Context, st *Stock) error {
+	defer w.s.stocks.Invalidate("stocks")
+	return w.StockWriter.CreateStock(ctx, st)
+}
+
+func (w *cachedStockWriter) UpsertStock(ctx context.Context, st *Stock) (string, error) {
+	defer w.invalidate()
+	return w.StockWriter.UpsertStock(ctx, st)
+}
+
+func (w *cachedStockWriter) UpdateStock(ctx context.Context, st *Stock, version int64) error {
+	defer w.invalidate()
+	return w.StockWriter.UpdateStock(ctx, st, version)
+}
+
+func (w *cachedStockWriter) DeleteStock(ctx context.Context, id int64) error {
+	defer w.invalidate()
+	return w.StockWriter.DeleteStock(ctx, id)
+}
+
+func (w *cachedStockWriter) RestoreStock(ctx context.Context, id int64) (Stock, error) {
+	defer w.invalidate()
+	return w.StockWriter.RestoreStock(ctx, id)
+}
+
+// PredictionWriter оборачивает w так, что после каждого изменения прогнозов
+// сбрасываются кеши, которые от них зависят
+func (s *CachedStorage) PredictionWriter(w PredictionWriter) PredictionWriter {
+	return &cachedPredictionWriter{PredictionWriter: w, s: s}
+}
+
+type cachedPredictionWriter struct {
+	PredictionWriter
+	s *CachedStorage
+}
+
+func (w *cachedPredictionWriter) invalidate() {
+	w.s.predictions.InvalidateAll()
+	w.s.rollup.InvalidateAll()
+	w.s.consensus.InvalidateAll()
+	w.s.bands.InvalidateAll()
+	w.s.types.InvalidateAll()
+}
+
+func (w *cachedPredictionWriter) CreatePrediction(ctx context.Context, in PredictionInput) (Prediction, error) {
+	defer w.invalidate()
+	return w.PredictionWriter.CreatePrediction(ctx, in)
+}
+
+func (w *cachedPredictionWriter) UpdatePrediction(ctx context.Context, id int64, in PredictionInput, version int64) (Prediction, error) {
+	defer w.invalidate()
+	return w.PredictionWriter.UpdatePrediction(ctx, id, in, version)
+}
+
+func (w *cachedPredictionWriter) PatchPrediction(ctx context.Context, id int64, patch PredictionPatch, version int64) (Prediction, error) {
+	defer w.invalidate()
+	return w.PredictionWriter.PatchPrediction(ctx, id, patch, version)
+}
+
+func (w *cachedPredictionWriter) DeletePrediction(ctx context.Context, id int64) error {
+	defer w.invalidate()
+	return w.PredictionWriter.DeletePrediction(ctx, id)
+}
+
+func (w *cachedPredictionWriter) RestorePrediction(ctx context.Context, id int64) (Prediction, error) {
+	defer w.invalidate()
+	return w.PredictionWriter.RestorePrediction(ctx, id)
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor возвращается для курсора /changes, который не удалось разобрать
+var ErrInvalidCursor = NewValidationError("invalid change cursor")
+
+// ChangeCursor — позиция в ленте изменений: последняя выданная акция и
+// последний выданный прогноз в порядке (updated_at, id)
+type ChangeCursor struct {
+	StockTime      time.Time `json:"st"`
+	StockID        int64     `json:"sid"`
+	PredictionTime time.Time `json:"pt"`
+	PredictionID   int64     `json:"pid"`
+}
+
+// CursorSince возвращает курсор, с которого выдаются изменения после t
+func CursorSince(t time.Time) ChangeCursor {
+	return ChangeCursor{StockTime: t, PredictionTime: t}
+}
+
+// Encode кодирует курсор в непрозрачную строку для клиента
+func (c ChangeCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает строку, полученную из Encode
+func DecodeCursor(s string) (ChangeCursor, error) {
+	var c ChangeCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("%w: %q", ErrInvalidCursor, s)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: %q", ErrInvalidCursor, s)
+	}
+	return c, nil
+}
+
+// ChangedStock — акция из ленты изменений
+type ChangedStock struct {
+	Stock
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChangedPrediction — прогноз из ленты изменений. MessageID здесь —
+// telegram_id сообщения в канале Channel.
+type ChangedPrediction struct {
+	Prediction
+	Ticker    string    `json:"Ticker"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
+
+// Changes — страница ленты изменений
+type Changes struct {
+	Stocks      []ChangedStock      `json:"Stocks"`
+	Predictions []ChangedPrediction `json:"Predictions"`
+	Cursor      string              `json:"Cursor"`  // передается в следующий ?since=
+	HasMore     bool                `json:"HasMore"` // страница неполная, стоит запросить сразу
+}
+
+// GetChanges возвращает акции и прогнозы, созданные или измененные после
+// курсора, не больше limit каждого вида. Удаленные записи тоже попадают в
+// ленту — с заполненным deleted_at, чтобы клиенты убрали их у себя.
+func (s *PostgresStorage) GetChanges(ctx context.Context, after ChangeCursor, limit int) (Changes, error) {
+	out := Changes{Stocks: []ChangedStock{}, Predictions: []ChangedPrediction{}}
+	next := after
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.ticker, s.name,
+		       COALESCE(json_object_agg(n.lang, n.name) FILTER (WHERE n.lang IS NOT NULL), '{}'),
+		       s.updated_at, s.deleted_at
+		FROM stocks s
+		LEFT JOIN stock_names n ON n.stock_id = s.id
+		WHERE (s.updated_at, s.id) > ($1, $2)
+		GROUP BY s.id
+		ORDER BY s.updated_at, s.id
+		LIMIT $3
+	`, after.StockTime, after.StockID, limit)
+	if err != nil {
+		return out, fmt.Errorf("error querying changed stocks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ChangedStock
+		var names []byte
+		if err := rows.Scan(&c.ID, &c.Ticker, &c.Name, &names, &c.UpdatedAt, &c.DeletedAt); err != nil {
+			return out, fmt.Errorf("error scanning changed stock: %w", err)
+		}
+		if err := json.Unmarshal(names, &c.Names); err != nil {
+			return out, fmt.Errorf("error decoding names of stock %s: %w", c.Ticker, err)
+		}
+		out.Stocks = append(out.Stocks, c)
+		next.StockTime, next.StockID = c.UpdatedAt, c.ID
+	}
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("error iterating over changed stock rows: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT
+			p.id, p.channel, p.message_id, p.stock_id, st.ticker, p.prediction_type,
+			p.target_price, p.target_change_percent, p.period,
+			p.recommendation, p.direction, p.justification_text,
+			m.text, p.predicted_at, src.id, COALESCE(src.name, src.channel),
+			p.outcome, p.realized_return, p.updated_at, p.deleted_at
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		LEFT JOIN sources src ON src.id = m.source_id
+		WHERE (p.updated_at, p.id) > ($1, $2)
+		ORDER BY p.updated_at, p.id
+		LIMIT $3
+	`, after.PredictionTime, after.PredictionID, limit)
+	if err != nil {
+		return out, fmt.Errorf("error querying changed predictions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ChangedPrediction
+		var text sql.NullString
+		var predictedAt time.Time
+		var deletedAt sql.NullTime
+		err := rows.Scan(
+			&c.ID, &c.Channel, &c.MessageID, &c.StockID, &c.Ticker, &c.PredictionType,
+			&c.TargetPrice, &c.TargetChangePercent, &c.Period,
+			&c.Recommendation, &c.Direction, &c.JustificationText,
+			&text, &predictedAt, &c.SourceID, &c.Source,
+			&c.Outcome, &c.RealizedReturn, &c.UpdatedAt, &deletedAt,
+		)
+		if err != nil {
+			return out, fmt.Errorf("error scanning changed prediction: %w", err)
+		}
+		if text.Valid {
+			c.Message = &text.String
+		}
+		c.PredictedAt = predictedAt.UTC().Format(time.RFC3339)
+		if deletedAt.Valid {
+			c.DeletedAt = FormatTimestamp(deletedAt.Time)
+		}
+		out.Predictions = append(out.Predictions, c)
+		next.PredictionTime, next.PredictionID = c.UpdatedAt, c.ID
+	}
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("error iterating over changed prediction rows: %w", err)
+	}
+
+	out.Cursor = next.Encode()
+	out.HasMore = len(out.Stocks) == limit || len(out.Predictions) == limit
+	return out, nil
+}
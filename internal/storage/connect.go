@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"frontend-backend/internal/metrics"
+)
+
+// pingTimeout — сколько ждать ответа БД в одной проверке соединения
+const pingTimeout = 5 * time.Second
+
+// RetryOptions задает повторы подключения к БД: пауза растет вдвое от
+// InitialBackoff до MaxBackoff и случайно сокращается до половины, чтобы
+// несколько экземпляров не стучались в БД одновременно
+type RetryOptions struct {
+	// Attempts — число попыток; 0 или 1 — без повторов
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Logger         *slog.Logger // nil — slog.Default()
+}
+
+func (o RetryOptions) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
+
+// backoff возвращает паузу перед попыткой attempt+1 (attempt с нуля)
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	d := o.InitialBackoff
+	for i := 0; i < attempt && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// Open подключается к PostgreSQL по dsn и проверяет соединение, повторяя
+// проверку по opts: при старте в docker-compose БД может быть еще не готова
+func Open(ctx context.Context, dsn string, opts RetryOptions) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	attempts := max(opts.Attempts, 1)
+	for attempt := 0; ; attempt++ {
+		err = ping(ctx, db)
+		if err == nil {
+			metrics.DBUp.Set(1)
+			return db, nil
+		}
+		if attempt+1 >= attempts || ctx.Err() != nil {
+			break
+		}
+		delay := opts.backoff(attempt)
+		opts.logger().Warn("База данных недоступна, повтор подключения", "attempt", attempt+1, "attempts", attempts, "delay", delay, "err", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+	db.Close()
+	return nil, fmt.Errorf("connect to database after %d attempt(s): %w", attempts, err)
+}
+
+// KeepAlive проверяет соединение с БД каждые interval до отмены ctx. Пока
+// БД недоступна, проверки повторяются с паузой по opts (без ограничения
+// числа попыток): так пул восстанавливает соединения до прихода запросов,
+// а потеря и восстановление видны в логе и метрике db_up.
+func KeepAlive(ctx context.Context, db *sql.DB, interval time.Duration, opts RetryOptions) {
+	failures := 0
+	for {
+		delay := interval
+		if failures > 0 {
+			delay = min(interval, opts.backoff(failures-1))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		err := ping(ctx, db)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			if failures == 0 {
+				opts.logger().Error("Соединение с базой данных потеряно", "err", err)
+			}
+			failures++
+			metrics.DBUp.Set(0)
+		case failures > 0:
+			opts.logger().Info("Соединение с базой данных восстановлено", "failed_checks", failures)
+			failures = 0
+			metrics.DBUp.Set(1)
+		}
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
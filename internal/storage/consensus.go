@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PeriodHorizons — горизонт прогноза в днях по значению Period
+var PeriodHorizons = map[string]int{
+	"Краткосрочный": 30,
+	"Среднесрочный": 90,
+	"Долгосрочный":  365,
+}
+
+// DefaultHorizonDays — горизонт для прогнозов без периода или с неизвестным периодом
+const DefaultHorizonDays = 90
+
+// HorizonDays возвращает горизонт прогноза в днях по его периоду
+func HorizonDays(period *string) int {
+	if period != nil {
+		if days, ok := PeriodHorizons[*period]; ok {
+			return days
+		}
+	}
+	return DefaultHorizonDays
+}
+
+// Consensus — сводка активных прогнозов по акции (горизонт еще не истек)
+type Consensus struct {
+	StockID           int64          `json:"StockID"`
+	Ticker            string         `json:"Ticker"`
+	ActivePredictions int            `json:"ActivePredictions"`
+	AvgTargetPrice    *float64       `json:"AvgTargetPrice"`
+	MedianTargetPrice *float64       `json:"MedianTargetPrice"`
+	Distribution      map[string]int `json:"Distribution"` // число прогнозов по рекомендациям
+	LastPrice         *float64       `json:"LastPrice"`
+	ImpliedUpside     *float64       `json:"ImpliedUpside"` // медиана цели к последней цене, %
+	Warnings          []string       `json:"Warnings,omitempty"`
+}
+
+// horizonArrays раскладывает PeriodHorizons в параллельные массивы для SQL
+func horizonArrays() (periods []string, days []int64) {
+	for period, d := range PeriodHorizons {
+		periods = append(periods, period)
+		days = append(days, int64(d))
+	}
+	return periods, days
+}
+
+// GetConsensus агрегирует активные прогнозы по тикеру в SQL. Последняя цена
+// и потенциал роста не заполняются: история цен хранится вне БД.
+func (s *PostgresStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	c := Consensus{Ticker: ticker, Distribution: map[string]int{}}
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return Consensus{}, err
+	}
+	c.StockID = stockID
+
+	periods, days := horizonArrays()
+	rows, err := s.db.QueryContext(ctx, `
+		WITH active AS (
+			SELECT p.target_price, COALESCE(p.recommendation, $5) AS recommendation
+			FROM predictions p
+			LEFT JOIN unnest($2::text[], $3::int[]) AS h(period, days) ON h.period = p.period
+			WHERE p.stock_id = $1 AND p.deleted_at IS NULL
+			  AND p.predicted_at + make_interval(days => COALESCE(h.days, $4)) >= now()
+		)
+		SELECT recommendation, COUNT(*),
+		       (SELECT AVG(target_price) FROM active),
+		       (SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY target_price) FROM active)
+		FROM active
+		GROUP BY recommendation
+	`, c.StockID, pq.Array(periods), pq.Array(days), DefaultHorizonDays, unknownRecommendation)
+	if err != nil {
+		return Consensus{}, fmt.Errorf("error querying consensus for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recommendation string
+		var n int
+		var avg, median sql.NullFloat64
+		if err := rows.Scan(&recommendation, &n, &avg, &median); err != nil {
+			return Consensus{}, fmt.Errorf("error scanning consensus: %w", err)
+		}
+		c.Distribution[recommendation] = n
+		c.ActivePredictions += n
+		if avg.Valid {
+			c.AvgTargetPrice = &avg.Float64
+		}
+		if median.Valid {
+			c.MedianTargetPrice = &median.Float64
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return Consensus{}, fmt.Errorf("error iterating over consensus rows: %w", err)
+	}
+
+	return c, nil
+}
+
+// ComputeConsensus считает ту же сводку, что и GetConsensus, по уже
+// загруженным прогнозам относительно момента now
+func ComputeConsensus(stockID int64, ticker string, predictions []Prediction, now time.Time) Consensus {
+	c := Consensus{StockID: stockID, Ticker: ticker, Distribution: map[string]int{}}
+	var targets []float64
+	for _, p := range predictions {
+		at, ok := ParseTimestamp(p.PredictedAt)
+		if !ok {
+			continue
+		}
+		if at.AddDate(0, 0, HorizonDays(p.Period)).Before(now) {
+			continue
+		}
+		recommendation := unknownRecommendation
+		if p.Recommendation != nil {
+			recommendation = *p.Recommendation
+		}
+		c.Distribution[recommendation]++
+		c.ActivePredictions++
+		if p.TargetPrice != nil {
+			targets = append(targets, *p.TargetPrice)
+		}
+	}
+
+	if len(targets) > 0 {
+		sort.Float64s(targets)
+		var sum float64
+		for _, t := range targets {
+			sum += t
+		}
+		avg := sum / float64(len(targets))
+		median := targets[len(targets)/2]
+		if len(targets)%2 == 0 {
+			median = (targets[len(targets)/2-1] + median) / 2
+		}
+		c.AvgTargetPrice, c.MedianTargetPrice = &avg, &median
+	}
+	return c
+}
+
+// SetLastPrice заполняет последнюю цену и потенциал роста по истории цен
+func (c *Consensus) SetLastPrice(history []StockPriceHistory) {
+	if len(history) == 0 {
+		return
+	}
+	last := history[len(history)-1].Price
+	c.LastPrice = &last
+	if c.MedianTargetPrice != nil && last > 0 {
+		upside := (*c.MedianTargetPrice/last - 1) * 100
+		c.ImpliedUpside = &upside
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Типы корпоративных действий
+const (
+	ActionSplit    = "split"
+	ActionDividend = "dividend"
+)
+
+// CorporateAction представляет сплит или дивиденд из таблицы corporate_actions
+type CorporateAction struct {
+	StockID int64     `json:"StockID"`
+	Date    time.Time `json:"Date"`
+	Type    string    `json:"Type"`
+	Ratio   *float64  `json:"Ratio,omitempty"`
+	Amount  *float64  `json:"Amount,omitempty"`
+}
+
+// GetCorporateActions извлекает корпоративные действия по тикеру
+func (s *PostgresStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ca.stock_id, ca.action_date, ca.type, ca.ratio, ca.amount
+		FROM corporate_actions ca
+		JOIN stocks st ON st.id = ca.stock_id
+		WHERE st.ticker = $1 AND st.deleted_at IS NULL
+		ORDER BY ca.action_date
+	`, ticker)
+	if err != nil {
+		return nil, fmt.Errorf("error querying corporate actions for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	actions := []CorporateAction{}
+	for rows.Next() {
+		var a CorporateAction
+		if err := rows.Scan(&a.StockID, &a.Date, &a.Type, &a.Ratio, &a.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning corporate action: %w", err)
+		}
+		actions = append(actions, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over corporate action rows: %w", err)
+	}
+
+	return actions, nil
+}
+
+// AdjustPriceHistory возвращает копию истории (от старых к новым), в которой
+// цены до каждого корпоративного действия пересчитаны обратной корректировкой:
+// на сплит цена делится на ratio (объем умножается), на дивиденд цена
+// умножается на (1 - amount / цена закрытия накануне отсечки).
+func AdjustPriceHistory(history []StockPriceHistory, actions []CorporateAction) []StockPriceHistory {
+	adjusted := make([]StockPriceHistory, len(history))
+	copy(adjusted, history)
+	if len(actions) == 0 || len(history) == 0 {
+		return adjusted
+	}
+
+	times := make([]time.Time, len(history))
+	for i, h := range history {
+		times[i], _ = time.Parse(time.RFC3339, h.Timestamp)
+	}
+
+	for _, a := range actions {
+		// Индекс первой записи на дату действия или позже
+		cut := sort.Search(len(times), func(i int) bool { return !times[i].Before(a.Date) })
+		if cut == 0 {
+			continue
+		}
+
+		priceFactor, volumeFactor := 1.0, 1.0
+		switch a.Type {
+		case ActionSplit:
+			if a.Ratio == nil || *a.Ratio <= 0 {
+				continue
+			}
+			priceFactor, volumeFactor = 1 / *a.Ratio, *a.Ratio
+		case ActionDividend:
+			prevClose := history[cut-1].Price
+			if a.Amount == nil || prevClose <= 0 || *a.Amount >= prevClose {
+				continue
+			}
+			priceFactor = 1 - *a.Amount/prevClose
+		default:
+			continue
+		}
+
+		for i := 0; i < cut; i++ {
+			adjusted[i].Price *= priceFactor
+			adjusted[i].Volume = int64(float64(adjusted[i].Volume) * volumeFactor)
+		}
+	}
+	return adjusted
+}
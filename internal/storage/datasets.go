@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ErrSnapshotNotFound возвращается для неизвестного или удаленного по сроку снимка
+var ErrSnapshotNotFound = NewNotFoundError("dataset snapshot not found")
+
+// DatasetPredictions — имя набора данных прогнозов в dataset_snapshots
+const DatasetPredictions = "predictions"
+
+// DatasetSnapshot — зафиксированный набор строк, который можно выгружать
+// постранично сколько угодно раз с одним и тем же результатом
+type DatasetSnapshot struct {
+	ID        int64     `json:"ID"`
+	Dataset   string    `json:"Dataset"`
+	Rows      int64     `json:"Rows"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	ExpiresAt time.Time `json:"ExpiresAt"`
+}
+
+// DatasetPrediction — прогноз с проставленным исходом в снимке набора данных
+type DatasetPrediction struct {
+	Seq                 int64     `json:"Seq"` // позиция в снимке, с 1
+	Channel             string    `json:"Channel"`
+	MessageID           int64     `json:"MessageID"`
+	StockID             int64     `json:"StockID"`
+	Ticker              string    `json:"Ticker"`
+	Exchange            string    `json:"Exchange"`
+	PredictionType      *string   `json:"PredictionType"`
+	TargetPrice         *float64  `json:"TargetPrice"`
+	TargetChangePercent *float64  `json:"TargetChangePercent"`
+	Period              *string   `json:"Period"`
+	Recommendation      *string   `json:"Recommendation"`
+	Direction           *string   `json:"Direction"`
+	JustificationText   *string   `json:"JustificationText"`
+	Message             *string   `json:"Message"`
+	PredictedAt         time.Time `json:"PredictedAt"`
+	SourceID            *int64    `json:"SourceID"`
+	Source              *string   `json:"Source"`
+	Outcome             string    `json:"Outcome"`
+	RealizedReturn      *float64  `json:"RealizedReturn"`
+	EvaluatedAt         time.Time `json:"EvaluatedAt"`
+}
+
+// CreatePredictionSnapshot копирует все прогнозы с исходом в новый снимок.
+// Снимки старше retention удаляются в той же транзакции.
+func (s *PostgresStorage) CreatePredictionSnapshot(ctx context.Context, retention time.Duration) (DatasetSnapshot, error) {
+	snap := DatasetSnapshot{Dataset: DatasetPredictions}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return snap, fmt.Errorf("error starting snapshot transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dataset_snapshots WHERE created_at < now() - $1 * interval '1 second'`, retention.Seconds()); err != nil {
+		return snap, fmt.Errorf("error deleting expired snapshots: %w", err)
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO dataset_snapshots (dataset) VALUES ($1) RETURNING id, created_at
+	`, DatasetPredictions).Scan(&snap.ID, &snap.CreatedAt)
+	if err != nil {
+		return snap, fmt.Errorf("error creating snapshot: %w", err)
+	}
+
+	// Порядок строк фиксируется номером seq, поэтому страницы не зависят от
+	// последующих изменений прогнозов
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO dataset_prediction_rows (
+		    snapshot_id, seq, channel, message_id, stock_id, ticker, exchange,
+		    prediction_type, target_price, target_change_percent, period,
+		    recommendation, direction, justification_text, message, predicted_at,
+		    source_id, source, outcome, realized_return, evaluated_at)
+		SELECT $1, row_number() OVER (ORDER BY p.predicted_at, p.message_id, p.channel, p.stock_id),
+		       p.channel, p.message_id, p.stock_id, st.ticker, st.exchange,
+		       p.prediction_type, p.target_price, p.target_change_percent, p.period,
+		       p.recommendation, p.direction, p.justification_text, m.text, p.predicted_at,
+		       src.id, COALESCE(src.name, src.channel), p.outcome, p.realized_return, COALESCE(p.evaluated_at, now())
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		LEFT JOIN sources src ON src.id = m.source_id
+		WHERE p.outcome IS NOT NULL AND p.deleted_at IS NULL AND st.deleted_at IS NULL
+	`, snap.ID)
+	if err != nil {
+		return snap, fmt.Errorf("error copying predictions into snapshot %d: %w", snap.ID, err)
+	}
+	snap.Rows, _ = res.RowsAffected()
+	if _, err := tx.ExecContext(ctx, `UPDATE dataset_snapshots SET row_count = $2 WHERE id = $1`, snap.ID, snap.Rows); err != nil {
+		return snap, fmt.Errorf("error saving snapshot %d size: %w", snap.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return snap, fmt.Errorf("error committing snapshot %d: %w", snap.ID, err)
+	}
+	snap.ExpiresAt = snap.CreatedAt.Add(retention)
+	return snap, nil
+}
+
+// GetPredictionSnapshot возвращает снимок и не больше limit строк после позиции after
+func (s *PostgresStorage) GetPredictionSnapshot(ctx context.Context, id, after int64, limit int, retention time.Duration) (DatasetSnapshot, []DatasetPrediction, error) {
+	snap := DatasetSnapshot{ID: id}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT dataset, row_count, created_at FROM dataset_snapshots
+		WHERE id = $1 AND dataset = $2 AND created_at >= now() - $3 * interval '1 second'
+	`, id, DatasetPredictions, retention.Seconds()).Scan(&snap.Dataset, &snap.Rows, &snap.CreatedAt)
+	if err == sql.ErrNoRows {
+		return snap, nil, fmt.Errorf("%w: %d", ErrSnapshotNotFound, id)
+	} else if err != nil {
+		return snap, nil, fmt.Errorf("error getting snapshot %d: %w", id, err)
+	}
+	snap.ExpiresAt = snap.CreatedAt.Add(retention)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT seq, channel, message_id, stock_id, ticker, exchange,
+		       prediction_type, target_price, target_change_percent, period,
+		       recommendation, direction, justification_text, message, predicted_at,
+		       source_id, source, outcome, realized_return, evaluated_at
+		FROM dataset_prediction_rows
+		WHERE snapshot_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`, id, after, limit)
+	if err != nil {
+		return snap, nil, fmt.Errorf("error querying snapshot %d rows: %w", id, err)
+	}
+	defer rows.Close()
+
+	preds := []DatasetPrediction{}
+	for rows.Next() {
+		var p DatasetPrediction
+		err := rows.Scan(
+			&p.Seq, &p.Channel, &p.MessageID, &p.StockID, &p.Ticker, &p.Exchange,
+			&p.PredictionType, &p.TargetPrice, &p.TargetChangePercent, &p.Period,
+			&p.Recommendation, &p.Direction, &p.JustificationText, &p.Message, &p.PredictedAt,
+			&p.SourceID, &p.Source, &p.Outcome, &p.RealizedReturn, &p.EvaluatedAt,
+		)
+		if err != nil {
+			return snap, nil, fmt.Errorf("error scanning snapshot %d row: %w", id, err)
+		}
+		p.PredictedAt, p.EvaluatedAt = p.PredictedAt.UTC(), p.EvaluatedAt.UTC()
+		preds = append(preds, p)
+	}
+	if err = rows.Err(); err != nil {
+		return snap, nil, fmt.Errorf("error iterating over snapshot %d rows: %w", id, err)
+	}
+	return snap, preds, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ErrDeadLetterNotFound возвращается, если элемента с указанным id нет
+var ErrDeadLetterNotFound = NewNotFoundError("dead letter not found")
+
+// DeadLetter — элемент конвейера, который не удалось обработать
+type DeadLetter struct {
+	ID        int64     `json:"ID"`
+	Source    string    `json:"Source"`
+	Payload   string    `json:"Payload"`
+	Error     string    `json:"Error"`
+	Attempts  int       `json:"Attempts"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
+
+// AddDeadLetter сохраняет необработанный элемент вместе с ошибкой
+func (s *PostgresStorage) AddDeadLetter(ctx context.Context, source string, payload []byte, reason string) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO dead_letters (source, payload, error) VALUES ($1, $2, $3)",
+		source, string(payload), reason)
+	if err != nil {
+		return fmt.Errorf("error inserting dead letter from %s: %w", source, err)
+	}
+	return nil
+}
+
+// ListDeadLetters возвращает элементы, от новых к старым. Пустой source — все источники.
+func (s *PostgresStorage) ListDeadLetters(ctx context.Context, source string, limit int) ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, source, payload, error, attempts, created_at, updated_at
+		FROM dead_letters
+		WHERE $1 = '' OR source = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, source, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		var d DeadLetter
+		if err := rows.Scan(&d.ID, &d.Source, &d.Payload, &d.Error, &d.Attempts, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning dead letter: %w", err)
+		}
+		letters = append(letters, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dead letter rows: %w", err)
+	}
+
+	return letters, nil
+}
+
+// GetDeadLetter возвращает элемент по id
+func (s *PostgresStorage) GetDeadLetter(ctx context.Context, id int64) (DeadLetter, error) {
+	var d DeadLetter
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, source, payload, error, attempts, created_at, updated_at
+		FROM dead_letters WHERE id = $1
+	`, id).Scan(&d.ID, &d.Source, &d.Payload, &d.Error, &d.Attempts, &d.CreatedAt, &d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return d, fmt.Errorf("%w: %d", ErrDeadLetterNotFound, id)
+	} else if err != nil {
+		return d, fmt.Errorf("error getting dead letter %d: %w", id, err)
+	}
+	return d, nil
+}
+
+// RecordDeadLetterAttempt увеличивает счетчик попыток, сохраняет новую ошибку
+// и записывает изменение в журнал
+func (s *PostgresStorage) RecordDeadLetterAttempt(ctx context.Context, id int64, reason string) error {
+	_, err := s.execAudited(ctx, AuditUpdate, "dead_letters", id,
+		"UPDATE dead_letters SET attempts = attempts + 1, error = $2, updated_at = now() WHERE id = $1",
+		id, reason)
+	if err != nil {
+		return fmt.Errorf("error updating dead letter %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteDeadLetter удаляет элемент и записывает его в журнал
+func (s *PostgresStorage) DeleteDeadLetter(ctx context.Context, id int64) error {
+	n, err := s.execAudited(ctx, AuditDelete, "dead_letters", id, "DELETE FROM dead_letters WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting dead letter %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrDeadLetterNotFound, id)
+	}
+	return nil
+}
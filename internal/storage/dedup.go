@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+var urlRe = regexp.MustCompile(`https?://\S+|t\.me/\S+|@\w+`)
+
+// NormalizeText приводит текст сообщения к виду, одинаковому для кросс-постов:
+// нижний регистр, без ссылок и упоминаний каналов, только буквы и цифры
+func NormalizeText(text string) string {
+	text = urlRe.ReplaceAllString(strings.ToLower(text), " ")
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// DedupHash вычисляет ключ дедупликации прогноза
+func DedupHash(text string, stockID int64, targetPrice *float64) string {
+	target := "-"
+	if targetPrice != nil {
+		target = strconv.FormatFloat(*targetPrice, 'f', 2, 64)
+	}
+	sum := sha256.Sum256([]byte(NormalizeText(text) + "|" + strconv.FormatInt(stockID, 10) + "|" + target))
+	return hex.EncodeToString(sum[:])
+}
+
+// messageText возвращает текст сообщения канала; ok=false, если сообщение еще
+// не загружено
+func (s *PostgresStorage) messageText(ctx context.Context, channel string, telegramID int64) (text string, ok bool, err error) {
+	var t sql.NullString
+	err = s.db.QueryRowContext(ctx, "SELECT text FROM messages WHERE channel = $1 AND telegram_id = $2", channel, telegramID).Scan(&t)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("error getting text of message %d: %w", telegramID, err)
+	}
+	return t.String, t.Valid && t.String != "", nil
+}
+
+// PredictionRef идентифицирует прогноз тройкой (channel, message_id, stock_id)
+type PredictionRef struct {
+	Channel   string `json:"Channel"`
+	MessageID int64  `json:"MessageID"`
+	StockID   int64  `json:"StockID"`
+}
+
+// DuplicateGroup — группа прогнозов с одинаковым ключом дедупликации.
+// Keep — самый ранний прогноз группы, Duplicates — остальные.
+type DuplicateGroup struct {
+	Hash        string          `json:"Hash"`
+	Ticker      string          `json:"Ticker"`
+	TargetPrice *float64        `json:"TargetPrice"`
+	Keep        PredictionRef   `json:"Keep"`
+	Duplicates  []PredictionRef `json:"Duplicates"`
+}
+
+// ErrDuplicatePrediction возвращается, если прогноз совпадает по ключу
+// дедупликации с уже сохраненным, например с прогнозом из кросс-поста
+var ErrDuplicatePrediction = NewConflictError("prediction duplicates an existing one")
+
+// dedupHashKey — уникальный индекс ключа дедупликации (миграция 000028)
+const dedupHashKey = "predictions_dedup_hash_key"
+
+// DedupBackfillStats — итог BackfillDedupHashes
+type DedupBackfillStats struct {
+	Hashed     int // прогнозов получили ключ
+	Duplicates int // ключ уже занят: дубликаты, их удаляет MergeDuplicatePredictions
+}
+
+// dedupCandidate — действующий прогноз для поиска дубликатов
+type dedupCandidate struct {
+	id          int64
+	ref         PredictionRef
+	ticker      string
+	target      *float64
+	predictedAt sql.NullTime
+	hash        string
+}
+
+// before сообщает, что прогноз c сохранен раньше o (без даты — позже всех)
+func (c dedupCandidate) before(o dedupCandidate) bool {
+	if c.predictedAt.Valid != o.predictedAt.Valid {
+		return c.predictedAt.Valid
+	}
+	if !c.predictedAt.Time.Equal(o.predictedAt.Time) {
+		return c.predictedAt.Time.Before(o.predictedAt.Time)
+	}
+	if c.ref.MessageID != o.ref.MessageID {
+		return c.ref.MessageID < o.ref.MessageID
+	}
+	return c.ref.Channel < o.ref.Channel
+}
+
+// unhashedPredictions возвращает действующие прогнозы без ключа
+// дедупликации — сохраненные до его появления или дубликаты — с ключом,
+// вычисленным по тексту сообщения. Прогнозы без текста пропускаются.
+func (s *PostgresStorage) unhashedPredictions(ctx context.Context) ([]dedupCandidate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.channel, p.message_id, p.stock_id, st.ticker, p.target_price, p.predicted_at, m.text
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		WHERE p.dedup_hash IS NULL AND p.deleted_at IS NULL AND m.text IS NOT NULL AND m.text <> ''
+		ORDER BY p.predicted_at, p.message_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying predictions without dedup hash: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []dedupCandidate
+	for rows.Next() {
+		var c dedupCandidate
+		var text string
+		if err := rows.Scan(&c.id, &c.ref.Channel, &c.ref.MessageID, &c.ref.StockID, &c.ticker, &c.target, &c.predictedAt, &text); err != nil {
+			return nil, fmt.Errorf("error scanning prediction: %w", err)
+		}
+		c.hash = DedupHash(text, c.ref.StockID, c.target)
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction rows: %w", err)
+	}
+	return candidates, nil
+}
+
+// BackfillDedupHashes вычисляет ключи для действующих прогнозов, сохраненных
+// до появления дедупликации. Прогноз, ключ которого уже занят, остается без
+// ключа: это дубликат, его показывает FindDuplicatePredictions.
+func (s *PostgresStorage) BackfillDedupHashes(ctx context.Context) (DedupBackfillStats, error) {
+	var stats DedupBackfillStats
+	candidates, err := s.unhashedPredictions(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, c := range candidates {
+		_, err := s.db.ExecContext(ctx, "UPDATE predictions SET dedup_hash = $1 WHERE id = $2 AND dedup_hash IS NULL", c.hash, c.id)
+		if pgConstraint(err) == dedupHashKey {
+			stats.Duplicates++
+			continue
+		}
+		if err != nil {
+			return stats, fmt.Errorf("error updating dedup hash for message %d: %w", c.ref.MessageID, err)
+		}
+		stats.Hashed++
+	}
+	return stats, nil
+}
+
+// FindDuplicatePredictions возвращает группы дубликатов среди сохраненных
+// прогнозов. Уникальный индекс не дает двум прогнозам с ключом совпасть,
+// поэтому в каждой группе есть прогнозы без ключа; их ключ вычисляется на
+// лету, в БД ничего не записывается.
+func (s *PostgresStorage) FindDuplicatePredictions(ctx context.Context) ([]DuplicateGroup, error) {
+	candidates, err := s.unhashedPredictions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := []DuplicateGroup{}
+	if len(candidates) == 0 {
+		return groups, nil
+	}
+
+	byHash := make(map[string][]dedupCandidate)
+	hashes := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if _, ok := byHash[c.hash]; !ok {
+			hashes = append(hashes, c.hash)
+		}
+		byHash[c.hash] = append(byHash[c.hash], c)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.channel, p.message_id, p.stock_id, st.ticker, p.target_price, p.predicted_at, p.dedup_hash
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		WHERE p.deleted_at IS NULL AND p.dedup_hash = ANY($1)
+	`, pq.Array(hashes))
+	if err != nil {
+		return nil, fmt.Errorf("error querying duplicate predictions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c dedupCandidate
+		if err := rows.Scan(&c.id, &c.ref.Channel, &c.ref.MessageID, &c.ref.StockID, &c.ticker, &c.target, &c.predictedAt, &c.hash); err != nil {
+			return nil, fmt.Errorf("error scanning duplicate prediction: %w", err)
+		}
+		byHash[c.hash] = append(byHash[c.hash], c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over duplicate rows: %w", err)
+	}
+
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		group := byHash[hash]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].before(group[j]) })
+		g := DuplicateGroup{Hash: hash, Ticker: group[0].ticker, TargetPrice: group[0].target, Keep: group[0].ref}
+		for _, c := range group[1:] {
+			g.Duplicates = append(g.Duplicates, c.ref)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// MergeDuplicatePredictions мягко удаляет дубликаты, оставляя в каждой группе
+// самый ранний прогноз, и отдает ключ группы ему. У удаленных дубликатов ключ
+// сбрасывается, поэтому восстановленный дубликат снова попадет в группу.
+// Возвращает количество удаленных строк.
+func (s *PostgresStorage) MergeDuplicatePredictions(ctx context.Context) (int64, error) {
+	groups, err := s.FindDuplicatePredictions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	for _, g := range groups {
+		for _, d := range g.Duplicates {
+			var id int64
+			err := tx.QueryRowContext(ctx, "SELECT id FROM predictions WHERE channel = $1 AND message_id = $2 AND stock_id = $3 AND deleted_at IS NULL", d.Channel, d.MessageID, d.StockID).Scan(&id)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return 0, fmt.Errorf("error querying duplicate prediction for message %d: %w", d.MessageID, err)
+			}
+			before, err := snapshotRow(ctx, tx, "predictions", id)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := tx.ExecContext(ctx, "UPDATE predictions SET deleted_at = now(), dedup_hash = NULL WHERE id = $1", id); err != nil {
+				return 0, fmt.Errorf("error deleting duplicate prediction for message %d: %w", d.MessageID, err)
+			}
+			if err := recordAudit(ctx, tx, AuditDelete, "predictions", id, before); err != nil {
+				return 0, err
+			}
+			removed++
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE predictions SET dedup_hash = $1 WHERE channel = $2 AND message_id = $3 AND stock_id = $4 AND deleted_at IS NULL AND dedup_hash IS NULL",
+			g.Hash, g.Keep.Channel, g.Keep.MessageID, g.Keep.StockID)
+		if err != nil {
+			return 0, fmt.Errorf("error updating dedup hash for message %d: %w", g.Keep.MessageID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return removed, nil
+}
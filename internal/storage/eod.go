@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// eodAverageDays — число предыдущих торговых дней для среднего объема
+const eodAverageDays = 20
+
+// EODSummary — итог торгового дня по акции
+type EODSummary struct {
+	StockID        int64    `json:"StockID"`
+	Ticker         string   `json:"Ticker"`
+	Date           string   `json:"Date"` // YYYY-MM-DD
+	Close          float64  `json:"Close"`
+	ChangePercent  *float64 `json:"ChangePercent"`
+	Volume         int64    `json:"Volume"`
+	AvgVolume      *float64 `json:"AvgVolume"`   // средний объем за 20 предыдущих дней
+	VolumeRatio    *float64 `json:"VolumeRatio"` // объем дня к среднему
+	NewPredictions int      `json:"NewPredictions"`
+}
+
+// ComputeEODSummary считает итог дня по истории (от старых к новым).
+// Если date нулевая, берется последний день истории. ok=false, если дня нет в истории.
+func ComputeEODSummary(history []StockPriceHistory, date time.Time) (summary EODSummary, ok bool) {
+	idx := len(history) - 1
+	if !date.IsZero() {
+		day := date.Format("2006-01-02")
+		idx = -1
+		for i, h := range history {
+			if len(h.Timestamp) >= 10 && h.Timestamp[:10] == day {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 {
+		return EODSummary{}, false
+	}
+
+	cur := history[idx]
+	summary = EODSummary{
+		StockID: cur.StockID,
+		Date:    cur.Timestamp[:10],
+		Close:   cur.Price,
+		Volume:  cur.Volume,
+	}
+	if idx > 0 && history[idx-1].Price > 0 {
+		change := (cur.Price/history[idx-1].Price - 1) * 100
+		summary.ChangePercent = &change
+	}
+	if start := max(0, idx-eodAverageDays); idx > start {
+		var total int64
+		for _, h := range history[start:idx] {
+			total += h.Volume
+		}
+		avg := float64(total) / float64(idx-start)
+		summary.AvgVolume = &avg
+		if avg > 0 {
+			ratio := float64(cur.Volume) / avg
+			summary.VolumeRatio = &ratio
+		}
+	}
+	return summary, true
+}
+
+// CountPredictionsOn возвращает число прогнозов по акциям, сделанных в указанный день
+func (s *PostgresStorage) CountPredictionsOn(ctx context.Context, date time.Time) (map[int64]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT stock_id, COUNT(*)
+		FROM predictions
+		WHERE predicted_at >= $1 AND predicted_at < $1 + INTERVAL '1 day' AND deleted_at IS NULL
+		GROUP BY stock_id
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("error counting predictions on %s: %w", date.Format("2006-01-02"), err)
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var stockID int64
+		var n int
+		if err := rows.Scan(&stockID, &n); err != nil {
+			return nil, fmt.Errorf("error scanning prediction count: %w", err)
+		}
+		counts[stockID] = n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// UpsertEODSummary сохраняет итог дня, перезаписывая ранее вычисленный
+func (s *PostgresStorage) UpsertEODSummary(ctx context.Context, e EODSummary) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO eod_summaries (
+			stock_id, date, close, change_percent, volume,
+			avg_volume, volume_ratio, new_predictions
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (stock_id, date) DO UPDATE SET
+			close = EXCLUDED.close,
+			change_percent = EXCLUDED.change_percent,
+			volume = EXCLUDED.volume,
+			avg_volume = EXCLUDED.avg_volume,
+			volume_ratio = EXCLUDED.volume_ratio,
+			new_predictions = EXCLUDED.new_predictions,
+			computed_at = now()
+	`, e.StockID, e.Date, e.Close, e.ChangePercent, e.Volume, e.AvgVolume, e.VolumeRatio, e.NewPredictions)
+	if err != nil {
+		return fmt.Errorf("error saving EOD summary for stock %d on %s: %w", e.StockID, e.Date, err)
+	}
+	return nil
+}
+
+// GetEODSummaries извлекает итоги дня по всем акциям. Если date нулевая,
+// берется последний день, за который есть итоги.
+func (s *PostgresStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.stock_id, st.ticker, to_char(e.date, 'YYYY-MM-DD'), e.close, e.change_percent,
+		       e.volume, e.avg_volume, e.volume_ratio, e.new_predictions
+		FROM eod_summaries e
+		JOIN stocks st ON st.id = e.stock_id
+		WHERE e.date = COALESCE($1::date, (SELECT MAX(date) FROM eod_summaries)) AND st.deleted_at IS NULL
+		ORDER BY st.ticker
+	`, nullTime(date))
+	if err != nil {
+		return nil, fmt.Errorf("error querying EOD summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []EODSummary{}
+	for rows.Next() {
+		var e EODSummary
+		err := rows.Scan(&e.StockID, &e.Ticker, &e.Date, &e.Close, &e.ChangePercent,
+			&e.Volume, &e.AvgVolume, &e.VolumeRatio, &e.NewPredictions)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning EOD summary: %w", err)
+		}
+		summaries = append(summaries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over EOD summary rows: %w", err)
+	}
+
+	return summaries, nil
+}
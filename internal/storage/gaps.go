@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"time"
+)
+
+// Способы заполнения пропусков в истории цен
+const (
+	FillSkip    = "skip"   // пропуски остаются как есть
+	FillForward = "ffill"  // повтор предыдущей цены
+	FillLinear  = "linear" // линейная интерполяция
+	FillNull    = "null"   // точка без цены (Price: null)
+)
+
+// ValidFill сообщает, что значение ?fill= поддерживается
+func ValidFill(v string) bool {
+	return v == FillSkip || v == FillForward || v == FillLinear || v == FillNull
+}
+
+// PriceGap — подряд идущие торговые дни без котировок
+type PriceGap struct {
+	From        string `json:"From"` // первый пропущенный день, YYYY-MM-DD
+	To          string `json:"To"`   // последний пропущенный день, YYYY-MM-DD
+	MissingDays int    `json:"MissingDays"`
+}
+
+// isTradingDay считает торговыми днями будни; праздники биржи не учитываются
+func isTradingDay(t time.Time) bool {
+	wd := t.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
+
+// missingTradingDays возвращает торговые дни строго между a и b
+func missingTradingDays(a, b time.Time) []time.Time {
+	var days []time.Time
+	for d := a.AddDate(0, 0, 1); d.Before(b); d = d.AddDate(0, 0, 1) {
+		if isTradingDay(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// historyDays разбирает даты записей истории (от старых к новым), отбрасывая время
+func historyDays(history []StockPriceHistory) []time.Time {
+	days := make([]time.Time, len(history))
+	for i, h := range history {
+		t, _ := time.Parse(time.RFC3339, h.Timestamp)
+		days[i] = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return days
+}
+
+// DetectPriceGaps находит пропущенные торговые дни между соседними записями
+// истории (от старых к новым)
+func DetectPriceGaps(history []StockPriceHistory) []PriceGap {
+	gaps := []PriceGap{}
+	days := historyDays(history)
+	for i := 1; i < len(days); i++ {
+		missing := missingTradingDays(days[i-1], days[i])
+		if len(missing) == 0 {
+			continue
+		}
+		gaps = append(gaps, PriceGap{
+			From:        missing[0].Format("2006-01-02"),
+			To:          missing[len(missing)-1].Format("2006-01-02"),
+			MissingDays: len(missing),
+		})
+	}
+	return gaps
+}
+
+// FillPriceGaps возвращает копию истории, в которую добавлены пропущенные
+// торговые дни с флагом Filled и нулевым объемом. FillForward повторяет
+// предыдущую цену, FillLinear интерполирует между соседними записями.
+func FillPriceGaps(history []StockPriceHistory, method string) []StockPriceHistory {
+	days := historyDays(history)
+	filled := make([]StockPriceHistory, 0, len(history))
+	for i, h := range history {
+		if i > 0 {
+			prev := history[i-1]
+			missing := missingTradingDays(days[i-1], days[i])
+			span := days[i].Sub(days[i-1]).Hours()
+			for _, d := range missing {
+				price := prev.Price
+				if method == FillLinear {
+					frac := d.Sub(days[i-1]).Hours() / span
+					price = prev.Price + (h.Price-prev.Price)*frac
+				}
+				filled = append(filled, StockPriceHistory{
+					StockID:   h.StockID,
+					Timestamp: d.Format(time.RFC3339),
+					Price:     price,
+					Filled:    true,
+				})
+			}
+		}
+		filled = append(filled, h)
+	}
+	return filled
+}
+
+// PriceSlot — точка сетки торговых дней. Price равна nil для пропущенного
+// дня при FillNull, чтобы графические библиотеки рисовали разрыв.
+type PriceSlot struct {
+	StockID   int64    `json:"StockID"`
+	Timestamp string   `json:"Timestamp"`
+	Price     *float64 `json:"Price"`
+	Volume    int64    `json:"Volume,omitempty"`
+	Filled    bool     `json:"Filled,omitempty"`
+}
+
+// PriceSlots переводит историю в точки сетки. С FillNull пропущенные
+// торговые дни добавляются с пустой ценой и флагом Filled.
+func PriceSlots(history []StockPriceHistory, method string) []PriceSlot {
+	days := historyDays(history)
+	slots := make([]PriceSlot, 0, len(history))
+	for i, h := range history {
+		if i > 0 && method == FillNull {
+			for _, d := range missingTradingDays(days[i-1], days[i]) {
+				slots = append(slots, PriceSlot{StockID: h.StockID, Timestamp: d.Format(time.RFC3339), Filled: true})
+			}
+		}
+		price := h.Price
+		slots = append(slots, PriceSlot{StockID: h.StockID, Timestamp: h.Timestamp, Price: &price, Volume: h.Volume, Filled: h.Filled})
+	}
+	return slots
+}
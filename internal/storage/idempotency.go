@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotentRequest — запрос, выполненный с заголовком Idempotency-Key.
+// Status равен 0, пока первый запрос с ключом еще выполняется.
+type IdempotentRequest struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// ReserveIdempotencyKey занимает ключ клиента scope под запрос с отпечатком
+// fingerprint. Если ключ уже занят, возвращает сохраненный запрос и
+// reserved = false. Ключи старше ttl удаляются при каждом вызове.
+func (s *PostgresStorage) ReserveIdempotencyKey(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (req IdempotentRequest, reserved bool, err error) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < now() - $1 * interval '1 second'`, ttl.Seconds()); err != nil {
+		return req, false, fmt.Errorf("error deleting expired idempotency keys: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (scope, key, fingerprint) VALUES ($1, $2, $3)
+		ON CONFLICT (scope, key) DO NOTHING
+		RETURNING created_at
+	`, scope, key, fingerprint).Scan(&req.CreatedAt)
+	if err == nil {
+		req.Fingerprint = fingerprint
+		return req, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return req, false, fmt.Errorf("error reserving idempotency key %q: %w", key, err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status, content_type, COALESCE(body, ''), created_at
+		FROM idempotency_keys WHERE scope = $1 AND key = $2
+	`, scope, key).Scan(&req.Fingerprint, &req.Status, &req.ContentType, &req.Body, &req.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ключ освободили между INSERT и SELECT — для клиента запрос еще
+		// выполняется, повтор займет ключ заново
+		req.Fingerprint = fingerprint
+		return req, false, nil
+	}
+	if err != nil {
+		return req, false, fmt.Errorf("error querying idempotency key %q: %w", key, err)
+	}
+	return req, false, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос, занявший ключ
+func (s *PostgresStorage) SaveIdempotentResponse(ctx context.Context, scope, key string, status int, contentType string, body []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = $3, content_type = $4, body = $5
+		WHERE scope = $1 AND key = $2
+	`, scope, key, status, contentType, body)
+	if err != nil {
+		return fmt.Errorf("error saving response for idempotency key %q: %w", key, err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы запрос можно было повторить
+func (s *PostgresStorage) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key); err != nil {
+		return fmt.Errorf("error releasing idempotency key %q: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"frontend-backend/internal/metrics"
+)
+
+// InstrumentedStorage записывает в Prometheus размер результатов другого
+// Storage: число строк и объем в JSON. Размер в байтах считается повторной
+// сериализацией, поэтому обертка стоит ненулевого CPU на больших ответах.
+type InstrumentedStorage struct {
+	next Storage
+}
+
+// NewInstrumentedStorage оборачивает next метриками размера результатов
+func NewInstrumentedStorage(next Storage) *InstrumentedStorage {
+	return &InstrumentedStorage{next: next}
+}
+
+// observe записывает метрики для успешного результата из rows элементов
+func observe[T any](method string, rows int, v T, err error) (T, error) {
+	if err != nil {
+		return v, err
+	}
+	metrics.StorageResultRows.WithLabelValues(method).Observe(float64(rows))
+	if data, err := json.Marshal(v); err == nil {
+		metrics.StorageResultBytes.WithLabelValues(method).Observe(float64(len(data)))
+	}
+	return v, nil
+}
+
+// observeSlice записывает метрики для результата-среза
+func observeSlice[T any](method string, v []T, err error) ([]T, error) {
+	return observe(method, len(v), v, err)
+}
+
+// Методы ниже делегируют вызов next и записывают размер результата
+
+func (s *InstrumentedStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	v, err := s.next.GetStocks(ctx)
+	return observeSlice("GetStocks", v, err)
+}
+
+func (s *InstrumentedStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	v, err := s.next.GetPredictionsByTicker(ctx, ticker, exchange)
+	return observeSlice("GetPredictionsByTicker", v, err)
+}
+
+func (s *InstrumentedStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	v, err := s.next.GetStockPriceHistory(ctx, ticker)
+	return observeSlice("GetStockPriceHistory", v, err)
+}
+
+func (s *InstrumentedStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	v, err := s.next.GetCorporateActions(ctx, ticker)
+	return observeSlice("GetCorporateActions", v, err)
+}
+
+func (s *InstrumentedStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	v, err := s.next.GetEODSummaries(ctx, date)
+	return observeSlice("GetEODSummaries", v, err)
+}
+
+func (s *InstrumentedStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	v, err := s.next.GetPredictionRollup(ctx, ticker, bucket)
+	return observeSlice("GetPredictionRollup", v, err)
+}
+
+func (s *InstrumentedStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	v, err := s.next.GetConsensus(ctx, ticker)
+	return observe("GetConsensus", 1, v, err)
+}
+
+func (s *InstrumentedStorage) GetSources(ctx context.Context) ([]Source, error) {
+	v, err := s.next.GetSources(ctx)
+	return observeSlice("GetSources", v, err)
+}
+
+func (s *InstrumentedStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	v, err := s.next.GetPredictionTypes(ctx)
+	return observeSlice("GetPredictionTypes", v, err)
+}
+
+func (s *InstrumentedStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	v, err := s.next.SearchPredictions(ctx, q, limit)
+	return observeSlice("SearchPredictions", v, err)
+}
+
+func (s *InstrumentedStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	v, err := s.next.GetTargetBands(ctx, ticker, bucket)
+	return observeSlice("GetTargetBands", v, err)
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StockMatch — одна из акций с одинаковым тикером на разных биржах
+type StockMatch struct {
+	ID       int64  `json:"id"`
+	Ticker   string `json:"ticker"`
+	Exchange string `json:"exchange"`
+	Primary  bool   `json:"primary"`
+	Active   bool   `json:"active"`
+}
+
+// AmbiguousTickerError возвращается, если правила выбора не дали одну акцию.
+// Относится к классу ErrValidation: клиент должен уточнить ?exchange=.
+type AmbiguousTickerError struct {
+	Ticker  string
+	Matches []StockMatch
+}
+
+func (e *AmbiguousTickerError) Error() string {
+	exchanges := make([]string, len(e.Matches))
+	for i, m := range e.Matches {
+		exchanges[i] = m.Exchange
+	}
+	return fmt.Sprintf("ticker %s is ambiguous: listed on %s; specify exchange", e.Ticker, strings.Join(exchanges, ", "))
+}
+
+func (e *AmbiguousTickerError) Unwrap() error { return ErrValidation }
+
+// resolveStock находит id акции по тикеру. Если тикер торгуется на нескольких
+// биржах, выбирается активная запись, затем запись основной биржи; при
+// равенстве возвращается AmbiguousTickerError. Непустой exchange ограничивает
+// поиск одной биржей.
+func (s *PostgresStorage) resolveStock(ctx context.Context, ticker, exchange string) (int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, ticker, exchange, is_primary, active FROM stocks
+		WHERE ticker = $1 AND ($2 = '' OR upper(exchange) = upper($2)) AND deleted_at IS NULL
+		ORDER BY active DESC, is_primary DESC, id
+	`, ticker, exchange)
+	if err != nil {
+		return 0, fmt.Errorf("error getting stock ID for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	var matches []StockMatch
+	for rows.Next() {
+		var m StockMatch
+		if err := rows.Scan(&m.ID, &m.Ticker, &m.Exchange, &m.Primary, &m.Active); err != nil {
+			return 0, fmt.Errorf("error scanning stock for ticker %s: %w", ticker, err)
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating over stock rows for ticker %s: %w", ticker, err)
+	}
+
+	switch {
+	case len(matches) == 0 && exchange != "":
+		return 0, fmt.Errorf("%w for ticker %s on exchange %s", ErrStockNotFound, ticker, exchange)
+	case len(matches) == 0:
+		return 0, fmt.Errorf("%w for ticker %s", ErrStockNotFound, ticker)
+	case len(matches) == 1:
+		return matches[0].ID, nil
+	}
+	// Строки отсортированы: первая выигрывает, только если она строго лучше второй
+	best, next := matches[0], matches[1]
+	if best.Active != next.Active || best.Primary != next.Primary {
+		return best.ID, nil
+	}
+	return 0, &AmbiguousTickerError{Ticker: ticker, Matches: matches}
+}
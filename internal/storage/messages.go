@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Message представляет сообщение из Telegram-канала (таблица messages)
+type Message struct {
+	TelegramID int64     `json:"TelegramID"`
+	Channel    string    `json:"Channel"`
+	Text       string    `json:"Text"`
+	SentAt     time.Time `json:"SentAt"`
+}
+
+// SaveMessage сохраняет сообщение и при необходимости заводит источник
+// (таблица sources) для его канала. Повторная запись с тем же каналом и
+// telegram_id игнорируется; inserted сообщает, была ли вставлена новая строка.
+func (s *PostgresStorage) SaveMessage(ctx context.Context, m Message) (inserted bool, err error) {
+	res, err := s.db.ExecContext(ctx, `
+		WITH src AS (
+			INSERT INTO sources (channel)
+			SELECT $2::text WHERE $2::text <> ''
+			ON CONFLICT (channel) DO UPDATE SET channel = EXCLUDED.channel
+			RETURNING id
+		)
+		INSERT INTO messages (telegram_id, channel, source_id, text, sent_at)
+		VALUES ($1, $2, (SELECT id FROM src), $3, $4)
+		ON CONFLICT (channel, telegram_id) DO NOTHING
+	`, m.TelegramID, m.Channel, m.Text, m.SentAt)
+	if err != nil {
+		return false, fmt.Errorf("error inserting message %d from %q: %w", m.TelegramID, m.Channel, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting affected rows for message %d: %w", m.TelegramID, err)
+	}
+	return n > 0, nil
+}
+
+// ListMessages возвращает сообщения, отправленные в интервале [from, to).
+// Нулевая граница означает отсутствие ограничения.
+func (s *PostgresStorage) ListMessages(ctx context.Context, from, to time.Time) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT telegram_id, channel, COALESCE(text, ''), sent_at
+		FROM messages
+		WHERE ($1::timestamptz IS NULL OR sent_at >= $1)
+		  AND ($2::timestamptz IS NULL OR sent_at < $2)
+		ORDER BY sent_at
+	`, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("error querying messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.TelegramID, &m.Channel, &m.Text, &m.SentAt); err != nil {
+			return nil, fmt.Errorf("error scanning message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over message rows: %w", err)
+	}
+
+	return messages, nil
+}
+
+// nullTime превращает нулевое время в NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationLockKey — ключ advisory-блокировки: две команды migrate не
+// применяют миграции одновременно
+const migrationLockKey = 7_420_137
+
+// Migration — пара файлов NNNNNN_name.up.sql / .down.sql
+type Migration struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // nil — не применена
+}
+
+// migrations возвращает встроенные миграции по возрастанию версии
+func migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	out := make([]Migration, 0, len(files))
+	for _, f := range files {
+		base := strings.TrimSuffix(strings.TrimPrefix(f, "migrations/"), ".up.sql")
+		version, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration file %s has no NNNNNN_ prefix", f)
+		}
+		out = append(out, Migration{Version: version, Name: name})
+	}
+	return out, nil
+}
+
+func (m Migration) file(direction string) string {
+	return "migrations/" + m.Version + "_" + m.Name + "." + direction + ".sql"
+}
+
+// Migrator применяет и откатывает встроенные миграции, отмечая примененные
+// в таблице schema_migrations
+type Migrator struct {
+	db *sql.DB
+}
+
+// NewMigrator создает Migrator для БД db
+func NewMigrator(db *sql.DB) *Migrator {
+	return &Migrator{db: db}
+}
+
+// Status возвращает все миграции с временем применения
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return status(ctx, conn)
+}
+
+// Version возвращает версию последней примененной миграции или "", если
+// миграции не применялись
+func (m *Migrator) Version(ctx context.Context) (string, error) {
+	all, err := m.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].AppliedAt != nil {
+			return all[i].Version, nil
+		}
+	}
+	return "", nil
+}
+
+// Up применяет steps непримененных миграций по порядку (0 — все) и
+// возвращает примененные
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	return m.run(ctx, func(all []Migration) []Migration {
+		var pending []Migration
+		for _, mg := range all {
+			if mg.AppliedAt == nil {
+				pending = append(pending, mg)
+			}
+		}
+		return limit(pending, steps)
+	}, "up")
+}
+
+// Down откатывает steps последних примененных миграций (0 — все) и
+// возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return m.run(ctx, func(all []Migration) []Migration {
+		var applied []Migration
+		for i := len(all) - 1; i >= 0; i-- {
+			if all[i].AppliedAt != nil {
+				applied = append(applied, all[i])
+			}
+		}
+		return limit(applied, steps)
+	}, "down")
+}
+
+// Baseline отмечает миграции до version включительно примененными, не
+// выполняя их: для БД, которую раньше обновляли вручную через psql.
+// Возвращает число отмеченных.
+func (m *Migrator) Baseline(ctx context.Context, version string) (int, error) {
+	all, err := migrations()
+	if err != nil {
+		return 0, err
+	}
+	if !slices.ContainsFunc(all, func(mg Migration) bool { return mg.Version == version }) {
+		return 0, NewNotFoundError(fmt.Sprintf("migration %s not found", version))
+	}
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	res, err := conn.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version)
+		SELECT unnest($1::text[]) AS version
+		ON CONFLICT (version) DO NOTHING`, versionsUpTo(all, version))
+	if err != nil {
+		return 0, fmt.Errorf("error recording baseline: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func versionsUpTo(all []Migration, version string) string {
+	var vs []string
+	for _, mg := range all {
+		if mg.Version <= version {
+			vs = append(vs, mg.Version)
+		}
+	}
+	return "{" + strings.Join(vs, ",") + "}"
+}
+
+// run выбирает миграции через pick и выполняет их в направлении direction,
+// каждую в своей транзакции вместе с отметкой в schema_migrations
+func (m *Migrator) run(ctx context.Context, pick func([]Migration) []Migration, direction string) ([]Migration, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	all, err := status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mg := range pick(all) {
+		body, err := migrationsFS.ReadFile(mg.file(direction))
+		if err != nil {
+			return done, fmt.Errorf("migration %s_%s has no %s file: %w", mg.Version, mg.Name, direction, err)
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return done, err
+		}
+		mark := `INSERT INTO schema_migrations (version) VALUES ($1)`
+		if direction == "down" {
+			mark = `DELETE FROM schema_migrations WHERE version = $1`
+		}
+		if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+			tx.Rollback()
+			return done, fmt.Errorf("migration %s_%s %s: %w", mg.Version, mg.Name, direction, err)
+		}
+		if _, err := tx.ExecContext(ctx, mark, mg.Version); err != nil {
+			tx.Rollback()
+			return done, fmt.Errorf("error recording migration %s: %w", mg.Version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return done, fmt.Errorf("migration %s_%s %s: %w", mg.Version, mg.Name, direction, err)
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// lock берет соединение с advisory-блокировкой и создает schema_migrations
+func (m *Migrator) lock(ctx context.Context) (*sql.Conn, func(), error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("error locking migrations: %w", err)
+	}
+	unlock := func() {
+		conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+		conn.Close()
+	}
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+		unlock()
+		return nil, nil, fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+	return conn, unlock, nil
+}
+
+// status дополняет встроенные миграции временем применения из БД
+func status(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	all, err := migrations()
+	if err != nil {
+		return nil, err
+	}
+	// До первого migrate up таблицы нет: все миграции не применены
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error checking schema_migrations: %w", err)
+	}
+	if !exists {
+		return all, nil
+	}
+	applied := map[string]time.Time{}
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, fmt.Errorf("error scanning schema_migrations: %w", err)
+		}
+		applied[v] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations: %w", err)
+	}
+	for i := range all {
+		if at, ok := applied[all[i].Version]; ok {
+			all[i].AppliedAt = &at
+		}
+	}
+	return all, nil
+}
+
+func limit(ms []Migration, steps int) []Migration {
+	if steps > 0 && steps < len(ms) {
+		return ms[:steps]
+	}
+	return ms
+}
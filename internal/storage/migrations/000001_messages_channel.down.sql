@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS messages_telegram_id_key;
+ALTER TABLE messages DROP COLUMN IF EXISTS ingested_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS channel;
@@ -0,0 +1,5 @@
+-- Канал-источник сообщения и время загрузки.
+-- JOIN прогнозов идет по messages.telegram_id, поэтому он должен быть уникальным.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS channel TEXT;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS ingested_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE UNIQUE INDEX IF NOT EXISTS messages_telegram_id_key ON messages (telegram_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS predictions_message_stock_key;
@@ -0,0 +1,3 @@
+-- Одно сообщение дает не более одного прогноза по каждой акции.
+-- Ключ используется для идемпотентной вставки событий из шины.
+CREATE UNIQUE INDEX IF NOT EXISTS predictions_message_stock_key ON predictions (message_id, stock_id);
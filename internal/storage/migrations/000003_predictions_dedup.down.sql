@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS predictions_dedup_hash_idx;
+ALTER TABLE predictions DROP COLUMN IF EXISTS dedup_hash;
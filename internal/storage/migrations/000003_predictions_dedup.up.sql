@@ -0,0 +1,4 @@
+-- Хеш нормализованного текста сообщения, акции и цели для поиска дубликатов
+-- прогнозов из кросс-постов
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS dedup_hash TEXT;
+CREATE INDEX IF NOT EXISTS predictions_dedup_hash_idx ON predictions (dedup_hash);
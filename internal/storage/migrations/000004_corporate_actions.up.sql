@@ -0,0 +1,14 @@
+-- Корпоративные действия для корректировки истории цен.
+-- split: ratio — сколько новых акций дается за одну старую (2 для сплита 1:2).
+-- dividend: amount — дивиденд на акцию, action_date — дата отсечки (ex-date).
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    id          BIGSERIAL PRIMARY KEY,
+    stock_id    BIGINT NOT NULL REFERENCES stocks (id),
+    action_date DATE NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('split', 'dividend')),
+    ratio       NUMERIC,
+    amount      NUMERIC,
+    CHECK (type <> 'split' OR ratio > 0),
+    CHECK (type <> 'dividend' OR amount > 0)
+);
+CREATE INDEX IF NOT EXISTS corporate_actions_stock_date_idx ON corporate_actions (stock_id, action_date);
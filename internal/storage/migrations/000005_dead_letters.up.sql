@@ -0,0 +1,11 @@
+-- Элементы конвейера, которые не удалось обработать
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id         BIGSERIAL PRIMARY KEY,
+    source     TEXT NOT NULL,
+    payload    TEXT NOT NULL,
+    error      TEXT NOT NULL,
+    attempts   INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS dead_letters_source_idx ON dead_letters (source, created_at);
@@ -0,0 +1,14 @@
+-- Итоги торгового дня по акциям для обзора рынка
+CREATE TABLE IF NOT EXISTS eod_summaries (
+    stock_id        BIGINT NOT NULL REFERENCES stocks (id),
+    date            DATE NOT NULL,
+    close           NUMERIC NOT NULL,
+    change_percent  NUMERIC,
+    volume          BIGINT NOT NULL,
+    avg_volume      NUMERIC,
+    volume_ratio    NUMERIC,
+    new_predictions INT NOT NULL DEFAULT 0,
+    computed_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (stock_id, date)
+);
+CREATE INDEX IF NOT EXISTS eod_summaries_date_idx ON eod_summaries (date);
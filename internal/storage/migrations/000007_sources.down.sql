@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS messages_source_id_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS source_id;
+DROP TABLE IF EXISTS sources;
@@ -0,0 +1,17 @@
+-- Источники прогнозов (Telegram-каналы), на которые ссылаются сообщения
+CREATE TABLE IF NOT EXISTS sources (
+    id         BIGSERIAL PRIMARY KEY,
+    channel    TEXT NOT NULL UNIQUE,
+    name       TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS source_id BIGINT REFERENCES sources (id);
+CREATE INDEX IF NOT EXISTS messages_source_id_idx ON messages (source_id);
+
+INSERT INTO sources (channel)
+SELECT DISTINCT channel FROM messages WHERE channel IS NOT NULL AND channel <> ''
+ON CONFLICT (channel) DO NOTHING;
+
+UPDATE messages m SET source_id = s.id
+FROM sources s
+WHERE m.source_id IS NULL AND s.channel = m.channel;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS predictions_unevaluated_idx;
+ALTER TABLE predictions DROP COLUMN IF EXISTS evaluated_at;
+ALTER TABLE predictions DROP COLUMN IF EXISTS realized_return;
+ALTER TABLE predictions DROP COLUMN IF EXISTS outcome;
@@ -0,0 +1,6 @@
+-- Исход прогноза после истечения горизонта: hit, partially-hit или miss.
+-- realized_return — доходность за горизонт в направлении прогноза, %.
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS outcome TEXT;
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS realized_return NUMERIC;
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS predictions_unevaluated_idx ON predictions (predicted_at) WHERE outcome IS NULL;
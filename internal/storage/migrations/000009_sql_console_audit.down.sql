@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sql_console_audit;
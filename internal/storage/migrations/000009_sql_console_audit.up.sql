@@ -0,0 +1,11 @@
+-- Журнал запросов админской SQL-консоли
+CREATE TABLE IF NOT EXISTS sql_console_audit (
+    id          BIGSERIAL PRIMARY KEY,
+    actor       TEXT NOT NULL,
+    query       TEXT NOT NULL,
+    row_count   INT NOT NULL DEFAULT 0,
+    duration_ms INT NOT NULL DEFAULT 0,
+    error       TEXT,
+    executed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS sql_console_audit_executed_idx ON sql_console_audit (executed_at);
@@ -0,0 +1,13 @@
+-- Локализованные названия акций (ru, en, ...)
+CREATE TABLE IF NOT EXISTS stock_names (
+    stock_id BIGINT NOT NULL REFERENCES stocks (id) ON DELETE CASCADE,
+    lang     TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    PRIMARY KEY (stock_id, lang)
+);
+CREATE INDEX IF NOT EXISTS stock_names_name_idx ON stock_names (lower(name));
+
+-- Текущие названия считаются русскими
+INSERT INTO stock_names (stock_id, lang, name)
+SELECT id, 'ru', name FROM stocks
+ON CONFLICT (stock_id, lang) DO NOTHING;
@@ -0,0 +1,8 @@
+-- Подписки внешних систем на новые прогнозы, зарегистрированные через админский API
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id         BIGSERIAL PRIMARY KEY,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL DEFAULT '',
+    tickers    TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
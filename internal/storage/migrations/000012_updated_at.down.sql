@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS stock_names_touch_stock ON stock_names;
+DROP TRIGGER IF EXISTS predictions_touch_updated_at ON predictions;
+DROP TRIGGER IF EXISTS stocks_touch_updated_at ON stocks;
+DROP FUNCTION IF EXISTS touch_stock_on_name_change();
+DROP FUNCTION IF EXISTS touch_updated_at();
+DROP INDEX IF EXISTS predictions_updated_idx;
+DROP INDEX IF EXISTS stocks_updated_idx;
+ALTER TABLE predictions DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE stocks DROP COLUMN IF EXISTS updated_at;
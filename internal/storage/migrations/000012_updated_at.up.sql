@@ -0,0 +1,32 @@
+-- Время последнего изменения акций и прогнозов для инкрементальной выгрузки (/changes)
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS stocks_updated_idx ON stocks (updated_at, id);
+CREATE INDEX IF NOT EXISTS predictions_updated_idx ON predictions (updated_at, message_id, stock_id);
+
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stocks_touch_updated_at ON stocks;
+CREATE TRIGGER stocks_touch_updated_at BEFORE UPDATE ON stocks
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+DROP TRIGGER IF EXISTS predictions_touch_updated_at ON predictions;
+CREATE TRIGGER predictions_touch_updated_at BEFORE UPDATE ON predictions
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+-- Локализованные названия входят в описание акции
+CREATE OR REPLACE FUNCTION touch_stock_on_name_change() RETURNS trigger AS $$
+BEGIN
+    UPDATE stocks SET updated_at = now() WHERE id = COALESCE(NEW.stock_id, OLD.stock_id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_names_touch_stock ON stock_names;
+CREATE TRIGGER stock_names_touch_stock AFTER INSERT OR UPDATE OR DELETE ON stock_names
+    FOR EACH ROW EXECUTE FUNCTION touch_stock_on_name_change();
@@ -0,0 +1,13 @@
+-- Выборка запросов к API без значений параметров: какие эндпоинты и
+-- параметры реально используют клиенты (для решений об удалении)
+CREATE TABLE IF NOT EXISTS request_shapes (
+    id             BIGSERIAL PRIMARY KEY,
+    route          TEXT NOT NULL,
+    method         TEXT NOT NULL,
+    params         TEXT[] NOT NULL DEFAULT '{}',
+    schema_version TEXT NOT NULL,
+    client         TEXT NOT NULL,
+    status         INT NOT NULL,
+    recorded_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS request_shapes_recorded_idx ON request_shapes (recorded_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS stocks_ticker_exchange_idx;
+ALTER TABLE stocks DROP COLUMN IF EXISTS active;
+ALTER TABLE stocks DROP COLUMN IF EXISTS is_primary;
+ALTER TABLE stocks DROP COLUMN IF EXISTS exchange;
@@ -0,0 +1,9 @@
+-- Один тикер может торговаться на нескольких биржах. Из дубликатов
+-- выбирается активная запись основной биржи (см. resolveStock).
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS exchange TEXT NOT NULL DEFAULT 'MOEX';
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT true;
+
+-- Уникальность тикера заменяется уникальностью пары (тикер, биржа)
+ALTER TABLE stocks DROP CONSTRAINT IF EXISTS stocks_ticker_key;
+CREATE UNIQUE INDEX IF NOT EXISTS stocks_ticker_exchange_idx ON stocks (ticker, upper(exchange));
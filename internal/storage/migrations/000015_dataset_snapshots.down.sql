@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dataset_prediction_rows;
+DROP TABLE IF EXISTS dataset_snapshots;
@@ -0,0 +1,33 @@
+-- Снимки наборов данных для воспроизводимой постраничной выгрузки
+CREATE TABLE IF NOT EXISTS dataset_snapshots (
+    id         BIGSERIAL PRIMARY KEY,
+    dataset    TEXT NOT NULL,
+    row_count  BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS dataset_snapshots_created_idx ON dataset_snapshots (created_at);
+
+-- Копии прогнозов с исходом на момент снимка; seq задает порядок страниц
+CREATE TABLE IF NOT EXISTS dataset_prediction_rows (
+    snapshot_id           BIGINT NOT NULL REFERENCES dataset_snapshots (id) ON DELETE CASCADE,
+    seq                   BIGINT NOT NULL,
+    message_id            BIGINT NOT NULL,
+    stock_id              BIGINT NOT NULL,
+    ticker                TEXT NOT NULL,
+    exchange              TEXT NOT NULL,
+    prediction_type       TEXT,
+    target_price          DOUBLE PRECISION,
+    target_change_percent DOUBLE PRECISION,
+    period                TEXT,
+    recommendation        TEXT,
+    direction             TEXT,
+    justification_text    TEXT,
+    message               TEXT,
+    predicted_at          TIMESTAMPTZ NOT NULL,
+    source_id             BIGINT,
+    source                TEXT,
+    outcome               TEXT NOT NULL,
+    realized_return       DOUBLE PRECISION,
+    evaluated_at          TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (snapshot_id, seq)
+);
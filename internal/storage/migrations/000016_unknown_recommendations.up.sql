@@ -0,0 +1,9 @@
+-- Фразы рекомендаций, которые не распознала таблица правил, — на проверку
+CREATE TABLE IF NOT EXISTS unknown_recommendations (
+    phrase      TEXT PRIMARY KEY,
+    source      TEXT NOT NULL,
+    occurrences BIGINT NOT NULL DEFAULT 1,
+    message_id  BIGINT,
+    first_seen  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT false;
+UPDATE api_keys SET admin = true WHERE role = 'admin';
+ALTER TABLE api_keys DROP COLUMN IF EXISTS role;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_key_usage;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS monthly_quota;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS daily_quota;
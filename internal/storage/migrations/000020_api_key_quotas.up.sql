@@ -0,0 +1,11 @@
+-- Квоты API-ключей: 0 — без ограничения
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_quota BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS monthly_quota BIGINT NOT NULL DEFAULT 0;
+
+-- Число запросов по ключу за сутки (UTC); месячное потребление — сумма за месяц
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id   BIGINT NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    day      DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
@@ -0,0 +1,4 @@
+DROP SEQUENCE IF EXISTS manual_message_seq;
+
+DROP INDEX IF EXISTS predictions_id_idx;
+ALTER TABLE predictions DROP COLUMN IF EXISTS id;
@@ -0,0 +1,8 @@
+-- Суррогатный ключ прогноза для админского API; пара (message_id, stock_id)
+-- остается уникальной
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS id BIGSERIAL;
+CREATE UNIQUE INDEX IF NOT EXISTS predictions_id_idx ON predictions (id);
+
+-- Прогнозы, введенные вручную, ссылаются на сообщения с отрицательным
+-- telegram_id из этой последовательности
+CREATE SEQUENCE IF NOT EXISTS manual_message_seq;
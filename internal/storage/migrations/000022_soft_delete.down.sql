@@ -0,0 +1,3 @@
+-- Удаленные строки снова становятся видимыми
+ALTER TABLE predictions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE stocks DROP COLUMN IF EXISTS deleted_at;
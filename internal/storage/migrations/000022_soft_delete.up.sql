@@ -0,0 +1,5 @@
+-- Мягкое удаление: строка остается в таблице, запросы ее не видят.
+-- Уникальность тикера и пары (сообщение, акция) учитывает и удаленные
+-- строки: удаленную запись восстанавливают, а не создают заново.
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS predictions_bump_version ON predictions;
+DROP TRIGGER IF EXISTS stocks_bump_version ON stocks;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE predictions DROP COLUMN IF EXISTS version;
+ALTER TABLE stocks DROP COLUMN IF EXISTS version;
@@ -0,0 +1,20 @@
+-- Версии акций и прогнозов для оптимистичной блокировки (If-Match).
+-- Версия растет при каждом изменении строки, в том числе при изменении
+-- локализованного названия.
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stocks_bump_version ON stocks;
+CREATE TRIGGER stocks_bump_version BEFORE UPDATE ON stocks
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+DROP TRIGGER IF EXISTS predictions_bump_version ON predictions;
+CREATE TRIGGER predictions_bump_version BEFORE UPDATE ON predictions
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
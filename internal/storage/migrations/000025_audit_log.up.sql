@@ -0,0 +1,17 @@
+-- Журнал изменений, сделанных через админский API и регистрацию: кто, что и
+-- когда изменил. before и after — строка таблицы до и после изменения
+-- (to_jsonb) без хешей ключей, паролей и секретов.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor      TEXT NOT NULL,
+    ip         TEXT NOT NULL DEFAULT '',
+    action     TEXT NOT NULL,
+    entity     TEXT NOT NULL,
+    entity_id  BIGINT NOT NULL,
+    before     JSONB,
+    after      JSONB
+);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, id);
+CREATE INDEX IF NOT EXISTS audit_log_created_idx ON audit_log (created_at);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS predictions_keep_revision ON predictions;
+DROP FUNCTION IF EXISTS keep_prediction_revision();
+DROP TABLE IF EXISTS prediction_revisions;
@@ -0,0 +1,44 @@
+-- Прежние версии прогнозов: при изменении содержания прогноза старая строка
+-- копируется сюда. Исход, мягкое удаление и другие служебные поля ревизий не
+-- создают.
+CREATE TABLE IF NOT EXISTS prediction_revisions (
+    id                    BIGSERIAL PRIMARY KEY,
+    prediction_id         BIGINT NOT NULL,
+    version               BIGINT NOT NULL,
+    stock_id              BIGINT NOT NULL,
+    prediction_type       TEXT,
+    target_price          DOUBLE PRECISION,
+    target_change_percent DOUBLE PRECISION,
+    period                TEXT,
+    recommendation        TEXT,
+    direction             TEXT,
+    justification_text    TEXT,
+    predicted_at          TIMESTAMPTZ,
+    replaced_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS prediction_revisions_prediction_idx ON prediction_revisions (prediction_id, version);
+
+CREATE OR REPLACE FUNCTION keep_prediction_revision() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO prediction_revisions (
+        prediction_id, version, stock_id, prediction_type,
+        target_price, target_change_percent, period,
+        recommendation, direction, justification_text, predicted_at
+    ) VALUES (
+        OLD.id, OLD.version, OLD.stock_id, OLD.prediction_type,
+        OLD.target_price, OLD.target_change_percent, OLD.period,
+        OLD.recommendation, OLD.direction, OLD.justification_text, OLD.predicted_at
+    );
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS predictions_keep_revision ON predictions;
+CREATE TRIGGER predictions_keep_revision AFTER UPDATE ON predictions
+    FOR EACH ROW
+    WHEN ((OLD.stock_id, OLD.prediction_type, OLD.target_price, OLD.target_change_percent, OLD.period,
+           OLD.recommendation, OLD.direction, OLD.justification_text, OLD.predicted_at)
+          IS DISTINCT FROM
+          (NEW.stock_id, NEW.prediction_type, NEW.target_price, NEW.target_change_percent, NEW.period,
+           NEW.recommendation, NEW.direction, NEW.justification_text, NEW.predicted_at))
+    EXECUTE FUNCTION keep_prediction_revision();
@@ -0,0 +1,7 @@
+-- Откат не пройдет, если в разных каналах уже есть сообщения с одинаковым
+-- telegram_id
+DROP INDEX IF EXISTS messages_telegram_id_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS messages_telegram_id_key ON messages (telegram_id);
+DROP INDEX IF EXISTS messages_channel_telegram_id_key;
+ALTER TABLE messages ALTER COLUMN channel DROP NOT NULL;
+ALTER TABLE messages ALTER COLUMN channel DROP DEFAULT;
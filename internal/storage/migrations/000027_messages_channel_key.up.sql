@@ -0,0 +1,8 @@
+-- Telegram нумерует сообщения в каждом канале отдельно, поэтому telegram_id
+-- уникален только вместе с каналом
+UPDATE messages SET channel = '' WHERE channel IS NULL;
+ALTER TABLE messages ALTER COLUMN channel SET DEFAULT '';
+ALTER TABLE messages ALTER COLUMN channel SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS messages_channel_telegram_id_key ON messages (channel, telegram_id);
+DROP INDEX IF EXISTS messages_telegram_id_key;
+CREATE INDEX IF NOT EXISTS messages_telegram_id_idx ON messages (telegram_id);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS predictions_dedup_hash_idx ON predictions (dedup_hash);
+DROP INDEX IF EXISTS predictions_dedup_hash_key;
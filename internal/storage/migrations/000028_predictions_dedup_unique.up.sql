@@ -0,0 +1,16 @@
+-- Ключ дедупликации уникален: кросс-пост отсекается через ON CONFLICT, а не
+-- проверкой, которую обгоняет параллельная вставка. У существующих дубликатов
+-- ключ сбрасывается, кроме самого раннего действующего прогноза группы;
+-- они остаются в GET /admin/predictions/duplicates.
+UPDATE predictions p SET dedup_hash = NULL
+FROM (
+    SELECT id, row_number() OVER (
+        PARTITION BY dedup_hash
+        ORDER BY deleted_at IS NOT NULL, predicted_at, message_id
+    ) AS n
+    FROM predictions
+    WHERE dedup_hash IS NOT NULL
+) d
+WHERE p.id = d.id AND d.n > 1;
+CREATE UNIQUE INDEX IF NOT EXISTS predictions_dedup_hash_key ON predictions (dedup_hash);
+DROP INDEX IF EXISTS predictions_dedup_hash_idx;
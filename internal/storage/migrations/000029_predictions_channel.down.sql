@@ -0,0 +1,8 @@
+-- Откат не пройдет, если у сообщений разных каналов с одинаковым telegram_id
+-- есть прогнозы по одной акции
+CREATE UNIQUE INDEX IF NOT EXISTS predictions_message_stock_key ON predictions (message_id, stock_id);
+DROP INDEX IF EXISTS predictions_channel_message_stock_key;
+CREATE INDEX IF NOT EXISTS predictions_updated_idx ON predictions (updated_at, message_id, stock_id);
+DROP INDEX IF EXISTS predictions_updated_id_idx;
+ALTER TABLE dataset_prediction_rows DROP COLUMN IF EXISTS channel;
+ALTER TABLE predictions DROP COLUMN IF EXISTS channel;
@@ -0,0 +1,17 @@
+-- Прогноз ссылается на сообщение парой (channel, message_id): telegram_id
+-- уникален только внутри канала. Существующим прогнозам канал достается от
+-- самого раннего сообщения с тем же telegram_id.
+ALTER TABLE predictions ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT '';
+UPDATE predictions p SET channel = m.channel
+FROM (
+    SELECT DISTINCT ON (telegram_id) telegram_id, channel
+    FROM messages
+    ORDER BY telegram_id, ingested_at, channel
+) m
+WHERE m.telegram_id = p.message_id AND p.channel = '';
+CREATE UNIQUE INDEX IF NOT EXISTS predictions_channel_message_stock_key ON predictions (channel, message_id, stock_id);
+DROP INDEX IF EXISTS predictions_message_stock_key;
+-- Лента /changes теперь упорядочена по (updated_at, id)
+CREATE INDEX IF NOT EXISTS predictions_updated_id_idx ON predictions (updated_at, id);
+DROP INDEX IF EXISTS predictions_updated_idx;
+ALTER TABLE dataset_prediction_rows ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT '';
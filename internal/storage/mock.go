@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mockEpoch — последняя дата синтетической истории цен. Дата зафиксирована,
+// чтобы ответы не менялись от запуска к запуску (снапшоты во фронтенд-CI).
+var mockEpoch = time.Date(2025, time.September, 15, 0, 0, 0, 0, time.UTC)
+
+// mockHistoryDays — глубина синтетической истории цен в днях
+const mockHistoryDays = 365
+
+// mockExchange — биржа всех акций mock-режима
+const mockExchange = "MOEX"
+
+// mockStocks — справочник тикеров для mock-режима
+var mockStocks = []struct {
+	Ticker string
+	Name   string
+	NameEN string
+	Price  float64
+}{
+	{"SBER", "Сбербанк", "Sberbank", 300},
+	{"GAZP", "Газпром", "Gazprom", 130},
+	{"LKOH", "Лукойл", "Lukoil", 6500},
+	{"ROSN", "Роснефть", "Rosneft", 450},
+	{"NVTK", "Новатэк", "Novatek", 1100},
+	{"GMKN", "Норникель", "Nornickel", 120},
+	{"YNDX", "Яндекс", "Yandex", 4000},
+	{"MGNT", "Магнит", "Magnit", 4500},
+	{"MTSS", "МТС", "MTS", 220},
+	{"VTBR", "ВТБ", "VTB Bank", 75},
+	{"TATN", "Татнефть", "Tatneft", 650},
+	{"MOEX", "Московская Биржа", "Moscow Exchange", 190},
+}
+
+var (
+	mockPredictionTypes  = []string{"Продолжение тренда", "Разворот", "Пробой уровня", "Отскок"}
+	mockPeriods          = []string{"Краткосрочный", "Среднесрочный", "Долгосрочный"}
+	mockRecommendations  = []string{"Покупать", "Держать", "Продавать"}
+	mockDirections       = []string{"Лонг", "Шорт", "Неопределенный"}
+	mockJustifications   = []string{"Сильный рост объема торгов", "Коррекция после быстрого роста", "Сильная отчетность", "Выход из боковика"}
+	mockSources          = []string{"@moex_signals", "@invest_daily", "@trader_notes"}
+	mockPredictionsCount = 8
+)
+
+// MockStorage отдает детерминированные синтетические данные без БД и файлов.
+// Используется фронтендом в CI и Storybook (config: storage.driver=mock).
+type MockStorage struct {
+	seed   int64
+	prices fs.FS // CSV <TICKER>_D1.csv вместо синтетической истории; nil — не используются
+}
+
+// NewMockStorage создает новый экземпляр MockStorage
+func NewMockStorage(seed int64) *MockStorage {
+	return &MockStorage{seed: seed}
+}
+
+// NewMockStorageWithPrices создает MockStorage, который берет историю цен
+// из CSV-файлов prices, например встроенных в бинарник. Для тикеров без
+// файла история остается синтетической.
+func NewMockStorageWithPrices(seed int64, prices fs.FS) *MockStorage {
+	return &MockStorage{seed: seed, prices: prices}
+}
+
+// Epoch возвращает дату, на которую построены синтетические данные
+func (s *MockStorage) Epoch() time.Time {
+	return mockEpoch
+}
+
+// rng возвращает генератор, детерминированный для пары (seed, key)
+func (s *MockStorage) rng(key string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return rand.New(rand.NewSource(s.seed ^ int64(h.Sum64())))
+}
+
+// lookup ищет тикер в справочнике и возвращает его StockID
+func (s *MockStorage) lookup(ticker string) (int64, float64, error) {
+	for i, st := range mockStocks {
+		if st.Ticker == ticker {
+			return int64(i + 1), st.Price, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%w for ticker %s", ErrStockNotFound, ticker)
+}
+
+// GetStocks возвращает синтетический список акций
+func (s *MockStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	stocks := make([]Stock, 0, len(mockStocks))
+	for i, st := range mockStocks {
+		stocks = append(stocks, Stock{
+			ID: int64(i + 1), Ticker: st.Ticker, Name: st.Name, Exchange: mockExchange,
+			Names: map[string]string{LangRU: st.Name, LangEN: st.NameEN},
+		})
+	}
+	return stocks, nil
+}
+
+// GetPredictionsByTicker возвращает синтетические прогнозы для тикера.
+// Все mock-акции торгуются на mockExchange.
+func (s *MockStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	if exchange != "" && !strings.EqualFold(exchange, mockExchange) {
+		return nil, fmt.Errorf("%w for ticker %s on exchange %s", ErrStockNotFound, ticker, exchange)
+	}
+	stockID, basePrice, err := s.lookup(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	r := s.rng("predictions:" + ticker)
+	predictions := make([]Prediction, 0, mockPredictionsCount)
+	for i := 0; i < mockPredictionsCount; i++ {
+		change := math.Round((r.Float64()*40-15)*100) / 100
+		target := math.Round(basePrice*(1+change/100)*100) / 100
+		message := fmt.Sprintf("%s: цель %.2f₽ (%+.2f%%)", ticker, target, change)
+
+		// Прогнозы идут от новых к старым, как и в PostgresStorage
+		predictedAt := mockEpoch.AddDate(0, 0, -(i*mockHistoryDays)/mockPredictionsCount)
+
+		// Источник выбирается без rng, чтобы не менять остальные поля
+		sourceIdx := (i + int(stockID)) % len(mockSources)
+		sourceID := int64(sourceIdx + 1)
+
+		predictions = append(predictions, Prediction{
+			ID:                  int64(i + 1),
+			MessageID:           int64(i + 1),
+			StockID:             stockID,
+			PredictionType:      mockPick(r, mockPredictionTypes),
+			TargetPrice:         &target,
+			TargetChangePercent: &change,
+			Period:              mockPick(r, mockPeriods),
+			Recommendation:      mockPick(r, mockRecommendations),
+			Direction:           mockPick(r, mockDirections),
+			JustificationText:   mockPick(r, mockJustifications),
+			Message:             &message,
+			PredictedAt:         FormatTimestamp(predictedAt),
+			SourceID:            &sourceID,
+			Source:              &mockSources[sourceIdx],
+		})
+	}
+
+	return predictions, nil
+}
+
+// GetStockPriceHistory читает историю цен из CSV, если они заданы, иначе
+// генерирует ее случайным блужданием
+func (s *MockStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	stockID, price, err := s.lookup(ticker)
+	if err != nil {
+		return nil, err
+	}
+	if s.prices != nil {
+		data, err := fs.ReadFile(s.prices, ticker+"_D1.csv")
+		if err == nil {
+			return parsePriceHistory(bytes.NewReader(data), ticker, stockID)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error opening price history file for ticker %s: %w", ticker, err)
+		}
+	}
+
+	r := s.rng("history:" + ticker)
+	start := mockEpoch.AddDate(0, 0, -mockHistoryDays)
+	history := make([]StockPriceHistory, 0, mockHistoryDays+1)
+	for day := 0; day <= mockHistoryDays; day++ {
+		price *= 1 + r.NormFloat64()*0.015
+		history = append(history, StockPriceHistory{
+			StockID:   stockID,
+			Timestamp: start.AddDate(0, 0, day).Format(time.RFC3339),
+			Price:     math.Round(price*100) / 100,
+			Volume:    1_000_000 + r.Int63n(9_000_000),
+		})
+	}
+
+	return history, nil
+}
+
+// GetCorporateActions возвращает один синтетический дивиденд за полгода до mockEpoch
+func (s *MockStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	stockID, price, err := s.lookup(ticker)
+	if err != nil {
+		return nil, err
+	}
+	amount := math.Round(price*0.05*100) / 100
+	return []CorporateAction{{
+		StockID: stockID,
+		Date:    mockEpoch.AddDate(0, -6, 0),
+		Type:    ActionDividend,
+		Amount:  &amount,
+	}}, nil
+}
+
+// GetEODSummaries считает итоги дня по синтетической истории
+func (s *MockStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	summaries := []EODSummary{}
+	for _, st := range mockStocks {
+		history, err := s.GetStockPriceHistory(ctx, st.Ticker)
+		if err != nil {
+			return nil, err
+		}
+		e, ok := ComputeEODSummary(history, date)
+		if !ok {
+			continue
+		}
+		e.Ticker = st.Ticker
+
+		predictions, err := s.GetPredictionsByTicker(ctx, st.Ticker, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range predictions {
+			if at, ok := ParseTimestamp(p.PredictedAt); ok && at.Format("2006-01-02") == e.Date {
+				e.NewPredictions++
+			}
+		}
+		summaries = append(summaries, e)
+	}
+	return summaries, nil
+}
+
+// GetPredictionRollup агрегирует синтетические прогнозы по интервалам
+func (s *MockStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+	predictions, err := s.GetPredictionsByTicker(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	return RollupPredictions(predictions, bucket), nil
+}
+
+// GetConsensus считает консенсус синтетических прогнозов на момент mockEpoch
+func (s *MockStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	stockID, _, err := s.lookup(ticker)
+	if err != nil {
+		return Consensus{}, err
+	}
+	predictions, err := s.GetPredictionsByTicker(ctx, ticker, "")
+	if err != nil {
+		return Consensus{}, err
+	}
+	return ComputeConsensus(stockID, ticker, predictions, mockEpoch), nil
+}
+
+// GetSources возвращает синтетические источники
+func (s *MockStorage) GetSources(ctx context.Context) ([]Source, error) {
+	sources := make([]Source, 0, len(mockSources))
+	for i, channel := range mockSources {
+		sources = append(sources, Source{ID: int64(i + 1), Channel: channel})
+	}
+	return sources, nil
+}
+
+// GetPredictionTypes возвращает типы синтетических прогнозов
+func (s *MockStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	types := append([]string(nil), mockPredictionTypes...)
+	sort.Strings(types)
+	return types, nil
+}
+
+// SearchPredictions ищет синтетические прогнозы по тексту сообщения и обоснованию
+func (s *MockStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	q = strings.ToLower(q)
+	matches := []PredictionMatch{}
+	for _, st := range mockStocks {
+		predictions, err := s.GetPredictionsByTicker(ctx, st.Ticker, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range predictions {
+			if strings.Contains(strings.ToLower(*p.Message), q) || strings.Contains(strings.ToLower(*p.JustificationText), q) {
+				matches = append(matches, PredictionMatch{Ticker: st.Ticker, Prediction: p})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Prediction.PredictedAt > matches[j].Prediction.PredictedAt
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// GetTargetBands считает полосы целевых цен синтетических прогнозов до mockEpoch
+func (s *MockStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+	predictions, err := s.GetPredictionsByTicker(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	return ComputeTargetBands(predictions, bucket, mockEpoch), nil
+}
+
+// mockPick выбирает случайный элемент и возвращает указатель на копию
+func mockPick(r *rand.Rand, values []string) *string {
+	v := values[r.Intn(len(values))]
+	return &v
+}
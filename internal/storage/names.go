@@ -0,0 +1,44 @@
+package storage
+
+// Языки названий акций (таблица stock_names)
+const (
+	LangRU = "ru"
+	LangEN = "en"
+	// DefaultLang — язык stocks.name
+	DefaultLang = LangRU
+)
+
+// SupportedLangs — языки, которые можно выбрать через Accept-Language
+var SupportedLangs = []string{LangRU, LangEN}
+
+// LocalizedName возвращает название на языке lang, а если его нет —
+// основное название из stocks.name
+func (st Stock) LocalizedName(lang string) string {
+	if name := st.Names[lang]; name != "" {
+		return name
+	}
+	return st.Name
+}
+
+// AllNames возвращает основное и все локализованные названия для поиска
+func (st Stock) AllNames() []string {
+	names := make([]string, 0, len(st.Names)+1)
+	names = append(names, st.Name)
+	for _, name := range st.Names {
+		if name != st.Name {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// LocalizeStocks возвращает копию списка, где Name заменен названием на
+// языке lang. Исходный срез не меняется: он может принадлежать кешу.
+func LocalizeStocks(stocks []Stock, lang string) []Stock {
+	out := make([]Stock, len(stocks))
+	for i, st := range stocks {
+		st.Name = st.LocalizedName(lang)
+		out[i] = st
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UnevaluatedPrediction — прогноз с истекшим горизонтом без исхода
+type UnevaluatedPrediction struct {
+	Ticker     string
+	Prediction Prediction
+}
+
+// ListUnevaluatedPredictions возвращает прогнозы с целевой ценой, у которых
+// горизонт истек к моменту now, а исход еще не проставлен
+func (s *PostgresStorage) ListUnevaluatedPredictions(ctx context.Context, now time.Time) ([]UnevaluatedPrediction, error) {
+	periods, days := horizonArrays()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT st.ticker, p.channel, p.message_id, p.stock_id, p.prediction_type, p.target_price,
+		       p.target_change_percent, p.period, p.recommendation, p.direction,
+		       p.predicted_at, m.source_id
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		LEFT JOIN unnest($2::text[], $3::int[]) AS h(period, days) ON h.period = p.period
+		WHERE p.outcome IS NULL AND p.deleted_at IS NULL
+		  AND p.target_price IS NOT NULL
+		  AND p.predicted_at + make_interval(days => COALESCE(h.days, $4)) < $1
+		ORDER BY st.ticker, p.predicted_at
+	`, now, pq.Array(periods), pq.Array(days), DefaultHorizonDays)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unevaluated predictions: %w", err)
+	}
+	defer rows.Close()
+
+	predictions := []UnevaluatedPrediction{}
+	for rows.Next() {
+		var u UnevaluatedPrediction
+		var predictedAt time.Time
+		p := &u.Prediction
+		err := rows.Scan(&u.Ticker, &p.Channel, &p.MessageID, &p.StockID, &p.PredictionType, &p.TargetPrice,
+			&p.TargetChangePercent, &p.Period, &p.Recommendation, &p.Direction,
+			&predictedAt, &p.SourceID)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning unevaluated prediction: %w", err)
+		}
+		p.PredictedAt = FormatTimestamp(predictedAt)
+		predictions = append(predictions, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over unevaluated prediction rows: %w", err)
+	}
+
+	return predictions, nil
+}
+
+// SetPredictionOutcome сохраняет исход прогноза и доходность за горизонт
+func (s *PostgresStorage) SetPredictionOutcome(ctx context.Context, channel string, messageID, stockID int64, outcome string, realizedReturn float64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE predictions
+		SET outcome = $4, realized_return = $5, evaluated_at = now()
+		WHERE channel = $1 AND message_id = $2 AND stock_id = $3
+	`, channel, messageID, stockID, outcome, realizedReturn)
+	if err != nil {
+		return fmt.Errorf("error saving outcome for prediction %d/%d: %w", messageID, stockID, err)
+	}
+	return nil
+}
+
+// OutcomeUpdate — исход одного прогноза для пакетного сохранения
+type OutcomeUpdate struct {
+	Channel        string
+	MessageID      int64
+	StockID        int64
+	Outcome        string
+	RealizedReturn float64
+}
+
+// SetPredictionOutcomes сохраняет пачку исходов одним запросом
+func (s *PostgresStorage) SetPredictionOutcomes(ctx context.Context, updates []OutcomeUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	channels, outcomes := make([]string, len(updates)), make([]string, len(updates))
+	messageIDs, stockIDs := make([]int64, len(updates)), make([]int64, len(updates))
+	returns := make([]float64, len(updates))
+	for i, u := range updates {
+		channels[i], messageIDs[i], stockIDs[i] = u.Channel, u.MessageID, u.StockID
+		outcomes[i], returns[i] = u.Outcome, u.RealizedReturn
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE predictions p
+		SET outcome = u.outcome, realized_return = u.realized_return, evaluated_at = now()
+		FROM unnest($1::text[], $2::bigint[], $3::bigint[], $4::text[], $5::float8[])
+		     AS u(channel, message_id, stock_id, outcome, realized_return)
+		WHERE p.channel = u.channel AND p.message_id = u.message_id AND p.stock_id = u.stock_id
+	`, pq.Array(channels), pq.Array(messageIDs), pq.Array(stockIDs), pq.Array(outcomes), pq.Array(returns))
+	if err != nil {
+		return fmt.Errorf("error saving %d prediction outcomes: %w", len(updates), err)
+	}
+	return nil
+}
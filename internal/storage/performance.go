@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"time"
+)
+
+// Performance — последняя цена и доходность за стандартные окна, %
+type Performance struct {
+	Ticker    string              `json:"Ticker"`
+	LastPrice *float64            `json:"LastPrice"`
+	AsOf      string              `json:"AsOf,omitempty"` // дата последней цены, YYYY-MM-DD
+	Returns   map[string]*float64 `json:"Returns"`
+	Warnings  []string            `json:"Warnings,omitempty"`
+}
+
+// performanceWindows — окна доходности: ключ и дата начала относительно последней цены
+var performanceWindows = []struct {
+	key   string
+	start func(last time.Time) time.Time
+}{
+	{"1d", func(t time.Time) time.Time { return t.AddDate(0, 0, -1) }},
+	{"1w", func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+	{"1m", func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }},
+	{"3m", func(t time.Time) time.Time { return t.AddDate(0, -3, 0) }},
+	{"ytd", func(t time.Time) time.Time {
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 0, -1)
+	}},
+	{"1y", func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+}
+
+// ComputePerformance считает доходность по истории (от старых к новым).
+// История нужна целиком, без обрезки по году: иначе YTD и 1y теряют базу.
+// База окна — последняя цена закрытия на дату начала окна или раньше
+// (для YTD — закрытие последнего дня прошлого года). Если история короче
+// окна, доходность равна nil.
+func ComputePerformance(ticker string, history []StockPriceHistory) Performance {
+	p := Performance{Ticker: ticker, Returns: map[string]*float64{}}
+	for _, w := range performanceWindows {
+		p.Returns[w.key] = nil
+	}
+	if len(history) == 0 {
+		return p
+	}
+
+	times := make([]time.Time, len(history))
+	for i, h := range history {
+		times[i], _ = time.Parse(time.RFC3339, h.Timestamp)
+	}
+	last := history[len(history)-1]
+	lastTime := times[len(times)-1]
+	p.LastPrice = &last.Price
+	p.AsOf = lastTime.Format("2006-01-02")
+
+	for _, w := range performanceWindows {
+		start := w.start(lastTime)
+		base := -1
+		for i := len(times) - 1; i >= 0; i-- {
+			if !times[i].After(start) {
+				base = i
+				break
+			}
+		}
+		if base < 0 || history[base].Price <= 0 {
+			continue
+		}
+		ret := (last.Price/history[base].Price - 1) * 100
+		p.Returns[w.key] = &ret
+	}
+	return p
+}
@@ -1,27 +1,39 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"frontend-backend/internal/metrics"
 )
 
 // Stock представляет акцию из таблицы stocks
 type Stock struct {
-	ID     int64  `json:"id"`
-	Ticker string `json:"ticker"`
-	Name   string `json:"name"`
+	ID       int64             `json:"id"`
+	Ticker   string            `json:"ticker"`
+	Name     string            `json:"name"`
+	Exchange string            `json:"exchange,omitempty"`
+	Names    map[string]string `json:"names,omitempty"` // локализованные названия по языку (stock_names)
+	// DeletedAt — время мягкого удаления; удаленные акции видны только в админском API
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version растет при каждом изменении; только в админском API
+	Version int64 `json:"version,omitempty"`
 }
 
 // Prediction представляет прогноз, как описано для фронтенда
 type Prediction struct {
 	ID                  int64    `json:"ID"`
+	Channel             string   `json:"Channel"` // канал сообщения; вместе с MessageID идентифицирует его
 	MessageID           int64    `json:"MessageID"`
 	StockID             int64    `json:"StockID"`
 	PredictionType      *string  `json:"PredictionType"`
@@ -32,7 +44,14 @@ type Prediction struct {
 	Direction           *string  `json:"Direction"`
 	JustificationText   *string  `json:"JustificationText"`
 	Message             *string  `json:"Message"`     // Полный текст сообщения из таблицы messages
-	PredictedAt         string   `json:"PredictedAt"` // ISO-формат даты или Unix timestamp
+	PredictedAt         string   `json:"PredictedAt"` // RFC 3339 в UTC, см. FormatTimestamp
+	SourceID            *int64   `json:"SourceID"`    // Источник сообщения из таблицы sources
+	Source              *string  `json:"Source"`      // Имя источника или его канал
+	Outcome             *string  `json:"Outcome"`     // Исход после истечения горизонта
+	RealizedReturn      *float64 `json:"RealizedReturn"`
+	UpdatedAt           string   `json:"UpdatedAt,omitempty"` // время последнего изменения; только в админском API
+	DeletedAt           string   `json:"DeletedAt,omitempty"` // время мягкого удаления; только в админском API
+	Version             int64    `json:"Version,omitempty"`   // растет при каждом изменении; только в админском API
 }
 
 // StockPriceHistory представляет историческую цену акции
@@ -41,21 +60,44 @@ type StockPriceHistory struct {
 	Timestamp string  `json:"Timestamp"`
 	Price     float64 `json:"Price"`
 	Volume    int64   `json:"Volume,omitempty"`
+	Filled    bool    `json:"Filled,omitempty"` // точка восстановлена FillPriceGaps
 }
 
 // PostgresStorage реализует хранилище данных для PostgreSQL
 type PostgresStorage struct {
-	db *sql.DB
+	db     *loggedDB
+	sqlLog *SQLLogger
+	// dataDir — каталог CSV-файлов истории цен
+	dataDir string
+}
+
+// NewPostgresStorage создает новый экземпляр PostgresStorage. История цен
+// читается из dataDir (пустой — DefaultPriceDataDir); logger получает журнал
+// SQL-запросов, nil — slog.Default().
+func NewPostgresStorage(db *sql.DB, dataDir string, logger *slog.Logger) *PostgresStorage {
+	if dataDir == "" {
+		dataDir = DefaultPriceDataDir
+	}
+	sqlLog := NewSQLLogger(DefaultRedactedColumns, logger)
+	return &PostgresStorage{db: &loggedDB{DB: db, log: sqlLog}, sqlLog: sqlLog, dataDir: dataDir}
 }
 
-// NewPostgresStorage создает новый экземпляр PostgresStorage
-func NewPostgresStorage(db *sql.DB) *PostgresStorage {
-	return &PostgresStorage{db: db}
+// SQLLogger возвращает логгер SQL-запросов хранилища
+func (s *PostgresStorage) SQLLogger() *SQLLogger {
+	return s.sqlLog
 }
 
 // GetStocks извлекает список акций из базы данных
-func (s *PostgresStorage) GetStocks() ([]Stock, error) {
-	rows, err := s.db.Query("SELECT id, ticker, name FROM stocks")
+func (s *PostgresStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.ticker, s.name, s.exchange,
+		       COALESCE(json_object_agg(n.lang, n.name) FILTER (WHERE n.lang IS NOT NULL), '{}')
+		FROM stocks s
+		LEFT JOIN stock_names n ON n.stock_id = s.id
+		WHERE s.deleted_at IS NULL
+		GROUP BY s.id
+		ORDER BY s.id
+	`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying stocks: %w", err)
 	}
@@ -64,10 +106,14 @@ func (s *PostgresStorage) GetStocks() ([]Stock, error) {
 	stocks := []Stock{}
 	for rows.Next() {
 		var stock Stock
-		err := rows.Scan(&stock.ID, &stock.Ticker, &stock.Name)
+		var names []byte
+		err := rows.Scan(&stock.ID, &stock.Ticker, &stock.Name, &stock.Exchange, &names)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning stock: %w", err)
 		}
+		if err := json.Unmarshal(names, &stock.Names); err != nil {
+			return nil, fmt.Errorf("error decoding names of stock %s: %w", stock.Ticker, err)
+		}
 		stocks = append(stocks, stock)
 	}
 
@@ -78,33 +124,34 @@ func (s *PostgresStorage) GetStocks() ([]Stock, error) {
 	return stocks, nil
 }
 
-// GetPredictionsByTicker извлекает прогнозы для указанного тикера
-func (s *PostgresStorage) GetPredictionsByTicker(ticker string) ([]Prediction, error) {
-	var stockID int64
-	err := s.db.QueryRow("SELECT id FROM stocks WHERE ticker = $1", ticker).Scan(&stockID)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("stock not found for ticker %s", ticker)
-	} else if err != nil {
-		return nil, fmt.Errorf("error getting stock ID for ticker %s: %w", ticker, err)
+// GetPredictionsByTicker извлекает прогнозы для указанного тикера. Пустой
+// exchange выбирает акцию по правилам resolveStock.
+func (s *PostgresStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	stockID, err := s.resolveStock(ctx, ticker, exchange)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
 		SELECT
-			p.message_id, p.stock_id, p.prediction_type,
+			p.id, p.channel, p.message_id, p.stock_id, p.prediction_type,
 			p.target_price, p.target_change_percent, p.period,
 			p.recommendation, p.direction, p.justification_text,
-			m.text, m.sent_at
+			m.text, m.sent_at, src.id, COALESCE(src.name, src.channel),
+			p.outcome, p.realized_return
 		FROM
 			predictions p
 		JOIN
-			messages m ON p.message_id = m.telegram_id
+			messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		LEFT JOIN
+			sources src ON src.id = m.source_id
 		WHERE
-			p.stock_id = $1
+			p.stock_id = $1 AND p.deleted_at IS NULL
 		ORDER BY
 			p.predicted_at DESC
 	`
 
-	rows, err := s.db.Query(query, stockID)
+	rows, err := s.db.QueryContext(ctx, query, stockID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying predictions: %w", err)
 	}
@@ -119,10 +166,11 @@ func (s *PostgresStorage) GetPredictionsByTicker(ticker string) ([]Prediction, e
 
 		var temp int64
 		err := rows.Scan(
-			&temp, &p.StockID, &p.PredictionType,
+			&p.ID, &p.Channel, &temp, &p.StockID, &p.PredictionType,
 			&p.TargetPrice, &p.TargetChangePercent, &p.Period,
 			&p.Recommendation, &p.Direction, &p.JustificationText,
-			&messageText, &sentAt,
+			&messageText, &sentAt, &p.SourceID, &p.Source,
+			&p.Outcome, &p.RealizedReturn,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning prediction: %w", err)
@@ -131,7 +179,7 @@ func (s *PostgresStorage) GetPredictionsByTicker(ticker string) ([]Prediction, e
 		p.Message = &messageText.String
 		p.MessageID = counter
 		counter += 1
-		p.PredictedAt = strconv.FormatInt(sentAt.Unix(), 10) // Unix timestamp в строке
+		p.PredictedAt = FormatTimestamp(sentAt)
 		predictions = append(predictions, p)
 	}
 
@@ -143,43 +191,47 @@ func (s *PostgresStorage) GetPredictionsByTicker(ticker string) ([]Prediction, e
 }
 
 // GetStockPriceHistory читает историю цен из CSV файла
-func (s *PostgresStorage) GetStockPriceHistory(ticker string) ([]StockPriceHistory, error) {
-	// Получаем StockID для тикера
-	var stockID int64
-	err := s.db.QueryRow("SELECT id FROM stocks WHERE ticker = $1", ticker).Scan(&stockID)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("stock not found for ticker %s", ticker)
-	} else if err != nil {
-		return nil, fmt.Errorf("error getting stock ID for ticker %s: %w", ticker, err)
+func (s *PostgresStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	// Путь к CSV файлу; проверяется до обращения к БД
+	path, err := PriceHistoryPath(s.dataDir, ticker)
+	if err != nil {
+		return nil, err
 	}
 
-	// Путь к CSV файлу
-	filename := fmt.Sprintf("%s_D1.csv", ticker)
-	filepath := filepath.Join("data", filename)
+	// Получаем StockID для тикера
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
 
 	// Проверяем существование файла
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("price history file not found for ticker %s", ticker)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w for ticker %s", ErrNoPriceHistory, ticker)
 	}
 
 	// Открываем CSV файл
-	file, err := os.Open(filepath)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("error opening price history file for ticker %s: %w", ticker, err)
 	}
 	defer file.Close()
 
+	return parsePriceHistory(file, ticker, stockID)
+}
+
+// parsePriceHistory разбирает CSV с дневными свечами и возвращает цены
+// закрытия от старых к новым. Некорректные строки пропускаются.
+func parsePriceHistory(r io.Reader, ticker string, stockID int64) ([]StockPriceHistory, error) {
 	// Создаем CSV reader
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(r)
 	records, err := reader.ReadAll()
 	if err != nil {
+		metrics.CSVParseFailures.WithLabelValues("price_history", "read").Inc()
 		return nil, fmt.Errorf("error reading CSV file for ticker %s: %w", ticker, err)
 	}
 
 	// Парсим данные
 	var history []StockPriceHistory
-	// Временно: Загружаем данные только с начала текущего года
-	currentYear := time.Now().Year()
 	for i, record := range records {
 		// Пропускаем заголовок (если есть)
 		if i == 0 && strings.Contains(record[0], "Time") {
@@ -187,6 +239,7 @@ func (s *PostgresStorage) GetStockPriceHistory(ticker string) ([]StockPriceHisto
 		}
 
 		if len(record) < 8 {
+			metrics.CSVParseFailures.WithLabelValues("price_history", "short_row").Inc()
 			continue // Пропускаем некорректные строки
 		}
 
@@ -194,22 +247,21 @@ func (s *PostgresStorage) GetStockPriceHistory(ticker string) ([]StockPriceHisto
 		timeStr := record[0]
 		parsedTime, err := time.Parse("2006.01.02 15:04:05", timeStr)
 		if err != nil {
+			metrics.CSVParseFailures.WithLabelValues("price_history", "bad_time").Inc()
 			continue // Пропускаем строки с некорректной датой
 		}
-		// Пропускаем записи до начала текущего года
-		if parsedTime.Year() < currentYear {
-			continue
-		}
 
 		// Парсим цену закрытия (Close)
 		closePrice, err := strconv.ParseFloat(record[4], 64)
 		if err != nil {
+			metrics.CSVParseFailures.WithLabelValues("price_history", "bad_price").Inc()
 			continue // Пропускаем строки с некорректной ценой
 		}
 
 		// Парсим объем (RealVolume)
 		volume, err := strconv.ParseInt(record[7], 10, 64)
 		if err != nil {
+			metrics.CSVParseFailures.WithLabelValues("price_history", "bad_volume").Inc()
 			volume = 0 // Если не удалось распарсить объем, ставим 0
 		}
 
@@ -0,0 +1,543 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewPrediction — прогноз для вставки, акция задается тикером
+type NewPrediction struct {
+	Channel             string // канал сообщения: telegram_id уникален только в нем
+	MessageID           int64
+	Ticker              string
+	PredictionType      *string
+	TargetPrice         *float64
+	TargetChangePercent *float64
+	Period              *string
+	Recommendation      *string
+	Direction           *string
+	JustificationText   *string
+	PredictedAt         time.Time
+}
+
+// InsertPrediction идемпотентно вставляет прогноз: повторная вставка для той
+// же тройки (channel, message_id, stock_id) игнорируется, как и дубликат из
+// кросс-поста с тем же ключом дедупликации (уникальный индекс, поэтому
+// параллельные вставки не проходят обе). В обоих случаях inserted равен false.
+func (s *PostgresStorage) InsertPrediction(ctx context.Context, p NewPrediction) (inserted bool, err error) {
+	stockID, err := s.resolveStock(ctx, p.Ticker, "")
+	if err != nil {
+		return false, err
+	}
+
+	// Без текста сообщения ключ дедупликации не вычисляется: иначе разные
+	// прогнозы с одинаковой целью считались бы дубликатами
+	text, ok, err := s.messageText(ctx, p.Channel, p.MessageID)
+	if err != nil {
+		return false, err
+	}
+	var hash *string
+	if ok {
+		h := DedupHash(text, stockID, p.TargetPrice)
+		hash = &h
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO predictions (
+			channel, message_id, stock_id, prediction_type,
+			target_price, target_change_percent, period,
+			recommendation, direction, justification_text,
+			predicted_at, dedup_hash
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT DO NOTHING
+	`,
+		p.Channel, p.MessageID, stockID, p.PredictionType,
+		p.TargetPrice, p.TargetChangePercent, p.Period,
+		p.Recommendation, p.Direction, p.JustificationText,
+		p.PredictedAt, hash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error inserting prediction for message %d: %w", p.MessageID, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting affected rows for message %d: %w", p.MessageID, err)
+	}
+	return n > 0, nil
+}
+
+// ErrPredictionNotFound возвращается, если прогноза с указанным id нет
+var ErrPredictionNotFound = NewNotFoundError("prediction not found")
+
+// ErrPredictionExists возвращается, если у сообщения уже есть прогноз по этой
+// акции, в том числе удаленный — его восстанавливают через RestorePrediction
+var ErrPredictionExists = NewConflictError("prediction already exists")
+
+// ErrInvalidPrediction возвращается для прогноза с неверными полями
+var ErrInvalidPrediction = NewValidationError("invalid prediction")
+
+// PredictionInput — прогноз, который задает администратор. Акция задается
+// тикером и, если он торгуется на нескольких биржах, биржей. Сообщение
+// задается парой Channel и MessageID; без MessageID для прогноза создается
+// ручное сообщение с текстом Message.
+type PredictionInput struct {
+	Channel             string    `json:"channel"`
+	MessageID           *int64    `json:"message_id"`
+	Message             string    `json:"message"`
+	Ticker              string    `json:"ticker"`
+	Exchange            string    `json:"exchange"`
+	PredictionType      *string   `json:"prediction_type"`
+	TargetPrice         *float64  `json:"target_price"`
+	TargetChangePercent *float64  `json:"target_change_percent"`
+	Period              *string   `json:"period"`
+	Recommendation      *string   `json:"recommendation"`
+	Direction           *string   `json:"direction"`
+	JustificationText   *string   `json:"justification_text"`
+	PredictedAt         time.Time `json:"predicted_at"`
+}
+
+// PredictionWriter изменяет прогнозы; доступен только с PostgreSQL
+type PredictionWriter interface {
+	GetPrediction(ctx context.Context, id int64) (Prediction, error)
+	CreatePrediction(ctx context.Context, in PredictionInput) (Prediction, error)
+	UpdatePrediction(ctx context.Context, id int64, in PredictionInput, version int64) (Prediction, error)
+	PatchPrediction(ctx context.Context, id int64, patch PredictionPatch, version int64) (Prediction, error)
+	DeletePrediction(ctx context.Context, id int64) error
+	ListPredictions(ctx context.Context, ticker, exchange string, includeDeleted bool) ([]Prediction, error)
+	RestorePrediction(ctx context.Context, id int64) (Prediction, error)
+}
+
+// normalize приводит тикер к верхнему регистру и проверяет поля
+func (in *PredictionInput) normalize() error {
+	if strings.TrimSpace(in.Ticker) == "" {
+		return fmt.Errorf("%w: ticker is required", ErrInvalidPrediction)
+	}
+	ticker, err := NormalizeTicker(in.Ticker)
+	if err != nil {
+		return err
+	}
+	in.Ticker = ticker
+	if in.TargetPrice != nil && *in.TargetPrice <= 0 {
+		return fmt.Errorf("%w: target_price must be positive", ErrInvalidPrediction)
+	}
+	return nil
+}
+
+// adminPredictionQuery выбирает прогнозы для админского API — вместе с
+// удаленными; условие и порядок дописывает вызывающий
+const adminPredictionQuery = `
+	SELECT p.id, p.channel, p.message_id, p.stock_id, p.prediction_type,
+	       p.target_price, p.target_change_percent, p.period,
+	       p.recommendation, p.direction, p.justification_text,
+	       m.text, COALESCE(m.sent_at, p.predicted_at),
+	       src.id, COALESCE(src.name, src.channel), p.outcome, p.realized_return,
+	       p.updated_at, p.deleted_at, p.version
+	FROM predictions p
+	LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+	LEFT JOIN sources src ON src.id = m.source_id
+`
+
+// scanAdminPrediction читает строку adminPredictionQuery
+func scanAdminPrediction(row interface{ Scan(...any) error }) (Prediction, error) {
+	var p Prediction
+	var text sql.NullString
+	var sentAt, updatedAt time.Time
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&p.ID, &p.Channel, &p.MessageID, &p.StockID, &p.PredictionType,
+		&p.TargetPrice, &p.TargetChangePercent, &p.Period,
+		&p.Recommendation, &p.Direction, &p.JustificationText,
+		&text, &sentAt,
+		&p.SourceID, &p.Source, &p.Outcome, &p.RealizedReturn,
+		&updatedAt, &deletedAt, &p.Version,
+	)
+	if err != nil {
+		return p, err
+	}
+	if text.Valid {
+		p.Message = &text.String
+	}
+	// Как в GetPredictionsByTicker: время прогноза — время сообщения
+	p.PredictedAt = FormatTimestamp(sentAt)
+	p.UpdatedAt = FormatTimestamp(updatedAt)
+	if deletedAt.Valid {
+		p.DeletedAt = FormatTimestamp(deletedAt.Time)
+	}
+	return p, nil
+}
+
+// GetPrediction возвращает прогноз по id с текстом сообщения и источником,
+// в том числе удаленный
+func (s *PostgresStorage) GetPrediction(ctx context.Context, id int64) (Prediction, error) {
+	p, err := scanAdminPrediction(s.db.QueryRowContext(ctx, adminPredictionQuery+`WHERE p.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, fmt.Errorf("%w: %d", ErrPredictionNotFound, id)
+	}
+	if err != nil {
+		return p, fmt.Errorf("error querying prediction %d: %w", id, err)
+	}
+	return p, nil
+}
+
+// ListPredictions возвращает прогнозы по акции для админского API, новые
+// первыми; includeDeleted добавляет удаленные прогнозы
+func (s *PostgresStorage) ListPredictions(ctx context.Context, ticker, exchange string, includeDeleted bool) ([]Prediction, error) {
+	ticker, err := NormalizeTicker(ticker)
+	if err != nil {
+		return nil, err
+	}
+	stockID, err := s.resolveStock(ctx, ticker, exchange)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, adminPredictionQuery+`
+		WHERE p.stock_id = $1 AND ($2 OR p.deleted_at IS NULL)
+		ORDER BY COALESCE(m.sent_at, p.predicted_at) DESC, p.id DESC
+	`, stockID, includeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("error querying predictions for %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	predictions := []Prediction{}
+	for rows.Next() {
+		p, err := scanAdminPrediction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning prediction: %w", err)
+		}
+		predictions = append(predictions, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction rows: %w", err)
+	}
+	return predictions, nil
+}
+
+// CreatePrediction добавляет прогноз и, если MessageID не задан, ручное
+// сообщение для него — в одной транзакции
+func (s *PostgresStorage) CreatePrediction(ctx context.Context, in PredictionInput) (Prediction, error) {
+	if err := in.normalize(); err != nil {
+		return Prediction{}, err
+	}
+	if in.PredictedAt.IsZero() {
+		in.PredictedAt = time.Now()
+	}
+	stockID, err := s.resolveStock(ctx, in.Ticker, in.Exchange)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error starting prediction transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var messageID int64
+	channel, text := "", in.Message
+	if in.MessageID != nil {
+		channel, messageID = in.Channel, *in.MessageID
+		var stored sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT text FROM messages WHERE channel = $1 AND telegram_id = $2`, channel, messageID).Scan(&stored)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Prediction{}, fmt.Errorf("%w: message %d not found in channel %q", ErrInvalidPrediction, messageID, channel)
+		}
+		if err != nil {
+			return Prediction{}, fmt.Errorf("error querying message %d: %w", messageID, err)
+		}
+		text = stored.String
+	} else {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO messages (telegram_id, channel, text, sent_at)
+			VALUES (-nextval('manual_message_seq'), '', $1, $2)
+			RETURNING telegram_id
+		`, in.Message, in.PredictedAt).Scan(&messageID)
+		if err != nil {
+			return Prediction{}, fmt.Errorf("error inserting manual message: %w", err)
+		}
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO predictions (
+			channel, message_id, stock_id, prediction_type,
+			target_price, target_change_percent, period,
+			recommendation, direction, justification_text,
+			predicted_at, dedup_hash
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (channel, message_id, stock_id) DO NOTHING
+		RETURNING id
+	`,
+		channel, messageID, stockID, in.PredictionType,
+		in.TargetPrice, in.TargetChangePercent, in.Period,
+		in.Recommendation, in.Direction, in.JustificationText,
+		in.PredictedAt, dedupHash(text, stockID, in.TargetPrice),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Prediction{}, fmt.Errorf("%w: message %d, %s", ErrPredictionExists, messageID, in.Ticker)
+	}
+	if pgConstraint(err) == dedupHashKey {
+		return Prediction{}, fmt.Errorf("%w: message %d, %s", ErrDuplicatePrediction, messageID, in.Ticker)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error inserting prediction for message %d: %w", messageID, err)
+	}
+	if err := recordAudit(ctx, tx, AuditCreate, "predictions", id, nil); err != nil {
+		return Prediction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Prediction{}, fmt.Errorf("error committing prediction for message %d: %w", messageID, err)
+	}
+	return s.GetPrediction(ctx, id)
+}
+
+// UpdatePrediction заменяет поля прогноза, включая акцию. Сообщение прогноза
+// не меняется, кроме времени ручного сообщения; нулевое PredictedAt
+// оставляет прежнее время. Если version не 0, а прогноз уже изменили,
+// возвращает ErrVersionMismatch.
+func (s *PostgresStorage) UpdatePrediction(ctx context.Context, id int64, in PredictionInput, version int64) (Prediction, error) {
+	if err := in.normalize(); err != nil {
+		return Prediction{}, err
+	}
+	stockID, err := s.resolveStock(ctx, in.Ticker, in.Exchange)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error starting prediction transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var channel string
+	var messageID, current int64
+	var text sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.channel, p.message_id, p.version, m.text FROM predictions p
+		LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+		FOR UPDATE OF p
+	`, id).Scan(&channel, &messageID, &current, &text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Prediction{}, fmt.Errorf("%w: %d", ErrPredictionNotFound, id)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error locking prediction %d: %w", id, err)
+	}
+	if version != 0 && version != current {
+		return Prediction{}, fmt.Errorf("%w: prediction %d is at version %d", ErrVersionMismatch, id, current)
+	}
+	before, err := snapshotRow(ctx, tx, "predictions", id)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	var predictedAt *time.Time
+	if !in.PredictedAt.IsZero() {
+		predictedAt = &in.PredictedAt
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE predictions SET
+			stock_id = $2, prediction_type = $3,
+			target_price = $4, target_change_percent = $5, period = $6,
+			recommendation = $7, direction = $8, justification_text = $9,
+			predicted_at = COALESCE($10, predicted_at), dedup_hash = $11
+		WHERE id = $1
+	`,
+		id, stockID, in.PredictionType,
+		in.TargetPrice, in.TargetChangePercent, in.Period,
+		in.Recommendation, in.Direction, in.JustificationText,
+		predictedAt, dedupHash(text.String, stockID, in.TargetPrice),
+	)
+	if pgConstraint(err) == dedupHashKey {
+		return Prediction{}, fmt.Errorf("%w: %s", ErrDuplicatePrediction, in.Ticker)
+	}
+	if pgCode(err) == pgUniqueViolation {
+		return Prediction{}, fmt.Errorf("%w: %s for the same message", ErrPredictionExists, in.Ticker)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error updating prediction %d: %w", id, err)
+	}
+	// Время ручного прогноза показывается по его сообщению
+	if messageID < 0 && predictedAt != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE messages SET sent_at = $3 WHERE channel = $1 AND telegram_id = $2`, channel, messageID, *predictedAt); err != nil {
+			return Prediction{}, fmt.Errorf("error updating manual message %d: %w", messageID, err)
+		}
+	}
+	if err := recordAudit(ctx, tx, AuditUpdate, "predictions", id, before); err != nil {
+		return Prediction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Prediction{}, fmt.Errorf("error committing prediction %d: %w", id, err)
+	}
+	return s.GetPrediction(ctx, id)
+}
+
+// DeletePrediction мягко удаляет прогноз; его сообщение остается, чтобы
+// прогноз можно было восстановить
+func (s *PostgresStorage) DeletePrediction(ctx context.Context, id int64) error {
+	n, err := s.execAudited(ctx, AuditDelete, "predictions", id, `UPDATE predictions SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting prediction %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrPredictionNotFound, id)
+	}
+	return nil
+}
+
+// RestorePrediction восстанавливает удаленный прогноз; восстановление
+// действующего прогноза ничего не меняет
+func (s *PostgresStorage) RestorePrediction(ctx context.Context, id int64) (Prediction, error) {
+	if _, err := s.execAudited(ctx, AuditRestore, "predictions", id, `UPDATE predictions SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return Prediction{}, fmt.Errorf("error restoring prediction %d: %w", id, err)
+	}
+	return s.GetPrediction(ctx, id)
+}
+
+// dedupHash возвращает ключ дедупликации или nil, если текста нет
+func dedupHash(text string, stockID int64, targetPrice *float64) *string {
+	if text == "" {
+		return nil
+	}
+	h := DedupHash(text, stockID, targetPrice)
+	return &h
+}
+
+// patchableColumns — столбцы прогноза, которые меняет PatchPrediction; имена
+// совпадают с полями JSON в PredictionInput
+var patchableColumns = map[string]bool{
+	"prediction_type":       true,
+	"target_price":          true,
+	"target_change_percent": true,
+	"period":                true,
+	"recommendation":        true,
+	"direction":             true,
+	"justification_text":    true,
+	"predicted_at":          true,
+}
+
+// PredictionPatch — частичное изменение прогноза (JSON merge patch): Fields
+// содержит только переданные столбцы, nil очищает столбец. Непустой Ticker
+// переносит прогноз на другую акцию.
+type PredictionPatch struct {
+	Ticker   string
+	Exchange string
+	Fields   map[string]any
+}
+
+// PatchPrediction меняет только переданные поля прогноза; updated_at
+// обновляет триггер predictions_touch_updated_at. version проверяется, как в
+// UpdatePrediction.
+func (s *PostgresStorage) PatchPrediction(ctx context.Context, id int64, patch PredictionPatch, version int64) (Prediction, error) {
+	for col := range patch.Fields {
+		if !patchableColumns[col] {
+			return Prediction{}, fmt.Errorf("%w: field %s cannot be changed", ErrInvalidPrediction, col)
+		}
+	}
+	if v, ok := patch.Fields["target_price"].(*float64); ok && v != nil && *v <= 0 {
+		return Prediction{}, fmt.Errorf("%w: target_price must be positive", ErrInvalidPrediction)
+	}
+	if v, ok := patch.Fields["predicted_at"].(*time.Time); ok && v == nil {
+		return Prediction{}, fmt.Errorf("%w: predicted_at cannot be null", ErrInvalidPrediction)
+	}
+	var newStockID int64
+	if patch.Ticker != "" {
+		ticker, err := NormalizeTicker(patch.Ticker)
+		if err != nil {
+			return Prediction{}, err
+		}
+		if newStockID, err = s.resolveStock(ctx, ticker, patch.Exchange); err != nil {
+			return Prediction{}, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error starting prediction transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var channel string
+	var messageID, stockID, current int64
+	var targetPrice *float64
+	var text sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.channel, p.message_id, p.stock_id, p.target_price, p.version, m.text FROM predictions p
+		LEFT JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+		FOR UPDATE OF p
+	`, id).Scan(&channel, &messageID, &stockID, &targetPrice, &current, &text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Prediction{}, fmt.Errorf("%w: %d", ErrPredictionNotFound, id)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error locking prediction %d: %w", id, err)
+	}
+	if version != 0 && version != current {
+		return Prediction{}, fmt.Errorf("%w: prediction %d is at version %d", ErrVersionMismatch, id, current)
+	}
+	if len(patch.Fields) == 0 && newStockID == 0 {
+		return s.GetPrediction(ctx, id)
+	}
+	before, err := snapshotRow(ctx, tx, "predictions", id)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	// Столбцы перечисляются в постоянном порядке, чтобы текст запроса не
+	// зависел от порядка обхода map
+	cols := make([]string, 0, len(patch.Fields))
+	for col := range patch.Fields {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	set := make([]string, 0, len(cols)+2)
+	args := []any{id}
+	for _, col := range cols {
+		args = append(args, patch.Fields[col])
+		set = append(set, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+	if newStockID != 0 {
+		stockID = newStockID
+		args = append(args, stockID)
+		set = append(set, fmt.Sprintf("stock_id = $%d", len(args)))
+	}
+	if v, ok := patch.Fields["target_price"]; ok {
+		targetPrice, _ = v.(*float64)
+	}
+	args = append(args, dedupHash(text.String, stockID, targetPrice))
+	set = append(set, fmt.Sprintf("dedup_hash = $%d", len(args)))
+
+	_, err = tx.ExecContext(ctx, "UPDATE predictions SET "+strings.Join(set, ", ")+" WHERE id = $1", args...)
+	if pgConstraint(err) == dedupHashKey {
+		return Prediction{}, fmt.Errorf("%w: %s", ErrDuplicatePrediction, patch.Ticker)
+	}
+	if pgCode(err) == pgUniqueViolation {
+		return Prediction{}, fmt.Errorf("%w: %s for the same message", ErrPredictionExists, patch.Ticker)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("error patching prediction %d: %w", id, err)
+	}
+	if at, ok := patch.Fields["predicted_at"].(*time.Time); ok && messageID < 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE messages SET sent_at = $3 WHERE channel = $1 AND telegram_id = $2`, channel, messageID, *at); err != nil {
+			return Prediction{}, fmt.Errorf("error updating manual message %d: %w", messageID, err)
+		}
+	}
+	if err := recordAudit(ctx, tx, AuditUpdate, "predictions", id, before); err != nil {
+		return Prediction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Prediction{}, fmt.Errorf("error committing prediction %d: %w", id, err)
+	}
+	return s.GetPrediction(ctx, id)
+}
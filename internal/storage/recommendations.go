@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UnknownRecommendation — фраза рекомендации, которой нет в таблице правил
+type UnknownRecommendation struct {
+	Phrase      string    `json:"phrase"`
+	Source      string    `json:"source"`
+	Occurrences int64     `json:"occurrences"`
+	MessageID   *int64    `json:"message_id"` // последнее сообщение с этой фразой
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// RecommendationValue — значение recommendation в predictions и число прогнозов с ним
+type RecommendationValue struct {
+	Value string
+	Count int64
+}
+
+// PredictionText — прогноз без рекомендации и текст, из которого он извлечен
+type PredictionText struct {
+	Channel   string
+	MessageID int64
+	StockID   int64
+	Text      string
+}
+
+// RecordUnknownRecommendation добавляет фразу на проверку или увеличивает ее счетчик.
+// messageID равен 0, если фраза пришла не из сообщения.
+func (s *PostgresStorage) RecordUnknownRecommendation(ctx context.Context, phrase, source string, messageID int64) error {
+	var msg *int64
+	if messageID != 0 {
+		msg = &messageID
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO unknown_recommendations (phrase, source, message_id) VALUES ($1, $2, $3)
+		ON CONFLICT (phrase) DO UPDATE SET
+		    occurrences = unknown_recommendations.occurrences + 1,
+		    message_id = COALESCE(EXCLUDED.message_id, unknown_recommendations.message_id),
+		    last_seen = now()
+	`, phrase, source, msg)
+	if err != nil {
+		return fmt.Errorf("error recording unknown recommendation %q: %w", phrase, err)
+	}
+	return nil
+}
+
+// ListUnknownRecommendations возвращает фразы на проверку, самые частые первыми
+func (s *PostgresStorage) ListUnknownRecommendations(ctx context.Context) ([]UnknownRecommendation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT phrase, source, occurrences, message_id, first_seen, last_seen
+		FROM unknown_recommendations
+		ORDER BY occurrences DESC, phrase
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unknown recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	phrases := []UnknownRecommendation{}
+	for rows.Next() {
+		var u UnknownRecommendation
+		if err := rows.Scan(&u.Phrase, &u.Source, &u.Occurrences, &u.MessageID, &u.FirstSeen, &u.LastSeen); err != nil {
+			return nil, fmt.Errorf("error scanning unknown recommendation: %w", err)
+		}
+		u.FirstSeen, u.LastSeen = u.FirstSeen.UTC(), u.LastSeen.UTC()
+		phrases = append(phrases, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over unknown recommendations: %w", err)
+	}
+	return phrases, nil
+}
+
+// DeleteUnknownRecommendations снимает с проверки фразы, для которых появились правила
+func (s *PostgresStorage) DeleteUnknownRecommendations(ctx context.Context, phrases []string) error {
+	if len(phrases) == 0 {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM unknown_recommendations WHERE phrase = ANY($1)`, pq.Array(phrases)); err != nil {
+		return fmt.Errorf("error deleting unknown recommendations: %w", err)
+	}
+	return nil
+}
+
+// ListRecommendationValues возвращает различные непустые значения рекомендаций в прогнозах
+func (s *PostgresStorage) ListRecommendationValues(ctx context.Context) ([]RecommendationValue, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recommendation, count(*) FROM predictions
+		WHERE recommendation IS NOT NULL
+		GROUP BY recommendation
+		ORDER BY recommendation
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying recommendation values: %w", err)
+	}
+	defer rows.Close()
+
+	var values []RecommendationValue
+	for rows.Next() {
+		var v RecommendationValue
+		if err := rows.Scan(&v.Value, &v.Count); err != nil {
+			return nil, fmt.Errorf("error scanning recommendation value: %w", err)
+		}
+		values = append(values, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over recommendation values: %w", err)
+	}
+	return values, nil
+}
+
+// RenameRecommendation заменяет значение рекомендации во всех прогнозах и
+// записывает изменение каждого в журнал. Возвращает количество измененных
+// строк.
+func (s *PostgresStorage) RenameRecommendation(ctx context.Context, from, to string) (int64, error) {
+	n, err := s.updateAudited(ctx, "predictions",
+		`SELECT id FROM predictions WHERE recommendation = $1 ORDER BY id`, []any{from},
+		`UPDATE predictions SET recommendation = $2 WHERE id = $1`, to)
+	if err != nil {
+		return 0, fmt.Errorf("error renaming recommendation %q: %w", from, err)
+	}
+	return n, nil
+}
+
+// ListPredictionsWithoutRecommendation возвращает прогнозы без рекомендации,
+// у которых сохранен текст обоснования
+func (s *PostgresStorage) ListPredictionsWithoutRecommendation(ctx context.Context) ([]PredictionText, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT channel, message_id, stock_id, justification_text FROM predictions
+		WHERE recommendation IS NULL AND justification_text IS NOT NULL
+		ORDER BY message_id, channel, stock_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying predictions without recommendation: %w", err)
+	}
+	defer rows.Close()
+
+	var preds []PredictionText
+	for rows.Next() {
+		var p PredictionText
+		if err := rows.Scan(&p.Channel, &p.MessageID, &p.StockID, &p.Text); err != nil {
+			return nil, fmt.Errorf("error scanning prediction text: %w", err)
+		}
+		preds = append(preds, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction texts: %w", err)
+	}
+	return preds, nil
+}
+
+// SetPredictionRecommendation проставляет рекомендацию прогнозу, у которого
+// ее нет, и записывает изменение в журнал
+func (s *PostgresStorage) SetPredictionRecommendation(ctx context.Context, channel string, messageID, stockID int64, recommendation string) (bool, error) {
+	n, err := s.updateAudited(ctx, "predictions", `
+		SELECT id FROM predictions
+		WHERE channel = $1 AND message_id = $2 AND stock_id = $3 AND recommendation IS NULL
+	`, []any{channel, messageID, stockID},
+		`UPDATE predictions SET recommendation = $2 WHERE id = $1 AND recommendation IS NULL`, recommendation)
+	if err != nil {
+		return false, fmt.Errorf("error setting recommendation for message %d: %w", messageID, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RequestShape — форма запроса к API: шаблон маршрута и имена параметров без
+// значений. Client — обезличенный идентификатор (ratelimit.Identity).
+type RequestShape struct {
+	Route      string
+	Method     string
+	Params     []string
+	Schema     string
+	Client     string
+	Status     int
+	RecordedAt time.Time
+}
+
+// ParamUsage — использование параметра эндпоинта за период. Пустой Param —
+// запросы без параметров.
+type ParamUsage struct {
+	Route    string    `json:"route"`
+	Method   string    `json:"method"`
+	Param    string    `json:"param"`
+	Requests int64     `json:"requests"`
+	Clients  int64     `json:"clients"`
+	Schemas  []string  `json:"schemas"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// InsertRequestShapes сохраняет пачку форм запросов одним запросом
+func (s *PostgresStorage) InsertRequestShapes(ctx context.Context, shapes []RequestShape) error {
+	if len(shapes) == 0 {
+		return nil
+	}
+	n := len(shapes)
+	routes, methods, params := make([]string, n), make([]string, n), make([]string, n)
+	schemas, clients := make([]string, n), make([]string, n)
+	statuses, times := make([]int64, n), make([]string, n)
+	for i, sh := range shapes {
+		routes[i], methods[i], params[i] = sh.Route, sh.Method, strings.Join(sh.Params, ",")
+		schemas[i], clients[i] = sh.Schema, sh.Client
+		statuses[i], times[i] = int64(sh.Status), sh.RecordedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO request_shapes (route, method, params, schema_version, client, status, recorded_at)
+		SELECT route, method, COALESCE(string_to_array(NULLIF(params, ''), ','), '{}'),
+		       schema, client, status, recorded_at::timestamptz
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::int[], $7::text[])
+		     AS t(route, method, params, schema, client, status, recorded_at)
+	`, pq.Array(routes), pq.Array(methods), pq.Array(params), pq.Array(schemas),
+		pq.Array(clients), pq.Array(statuses), pq.Array(times))
+	if err != nil {
+		return fmt.Errorf("error inserting %d request shapes: %w", n, err)
+	}
+	return nil
+}
+
+// GetParamUsage возвращает использование эндпоинтов и их параметров с since
+func (s *PostgresStorage) GetParamUsage(ctx context.Context, since time.Time) ([]ParamUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.route, r.method, COALESCE(p.param, ''), count(*), count(DISTINCT r.client),
+		       array_agg(DISTINCT r.schema_version ORDER BY r.schema_version), max(r.recorded_at)
+		FROM request_shapes r
+		LEFT JOIN LATERAL unnest(r.params) AS p(param) ON true
+		WHERE r.recorded_at >= $1
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying param usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []ParamUsage{}
+	for rows.Next() {
+		var u ParamUsage
+		if err := rows.Scan(&u.Route, &u.Method, &u.Param, &u.Requests, &u.Clients, pq.Array(&u.Schemas), &u.LastSeen); err != nil {
+			return nil, fmt.Errorf("error scanning param usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over param usage rows: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PredictionRevision — версия прогноза. Прежние версии сохраняет триггер
+// при изменении содержания прогноза; последняя версия — текущая, у нее нет
+// ReplacedAt.
+type PredictionRevision struct {
+	Version             int64    `json:"Version"`
+	Ticker              string   `json:"Ticker"`
+	PredictionType      *string  `json:"PredictionType"`
+	TargetPrice         *float64 `json:"TargetPrice"`
+	TargetChangePercent *float64 `json:"TargetChangePercent"`
+	Period              *string  `json:"Period"`
+	Recommendation      *string  `json:"Recommendation"`
+	Direction           *string  `json:"Direction"`
+	JustificationText   *string  `json:"JustificationText"`
+	PredictedAt         string   `json:"PredictedAt,omitempty"`
+	ReplacedAt          *string  `json:"ReplacedAt"` // когда версию сменила следующая
+}
+
+// GetPredictionRevisions возвращает версии прогноза от старых к новым,
+// включая текущую. Удаленный прогноз считается отсутствующим.
+func (s *PostgresStorage) GetPredictionRevisions(ctx context.Context, id int64) ([]PredictionRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.version, st.ticker, r.prediction_type,
+		       r.target_price, r.target_change_percent, r.period,
+		       r.recommendation, r.direction, r.justification_text,
+		       r.predicted_at, r.replaced_at
+		FROM prediction_revisions r
+		JOIN stocks st ON st.id = r.stock_id
+		JOIN predictions p ON p.id = r.prediction_id AND p.deleted_at IS NULL
+		WHERE r.prediction_id = $1
+		UNION ALL
+		SELECT p.version, st.ticker, p.prediction_type,
+		       p.target_price, p.target_change_percent, p.period,
+		       p.recommendation, p.direction, p.justification_text,
+		       p.predicted_at, NULL
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+		ORDER BY 1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying revisions of prediction %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	var revisions []PredictionRevision
+	for rows.Next() {
+		var rev PredictionRevision
+		var predictedAt, replacedAt sql.NullTime
+		if err := rows.Scan(
+			&rev.Version, &rev.Ticker, &rev.PredictionType,
+			&rev.TargetPrice, &rev.TargetChangePercent, &rev.Period,
+			&rev.Recommendation, &rev.Direction, &rev.JustificationText,
+			&predictedAt, &replacedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning revision of prediction %d: %w", id, err)
+		}
+		if predictedAt.Valid {
+			rev.PredictedAt = FormatTimestamp(predictedAt.Time)
+		}
+		if replacedAt.Valid {
+			ts := FormatTimestamp(replacedAt.Time)
+			rev.ReplacedAt = &ts
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revisions of prediction %d: %w", id, err)
+	}
+	// Текущая версия есть у любого неудаленного прогноза
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrPredictionNotFound, id)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Размеры интервалов для агрегации прогнозов
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// unknownRecommendation — ключ для прогнозов без рекомендации
+const unknownRecommendation = "unknown"
+
+// ValidBucket проверяет, что интервал агрегации поддерживается
+func ValidBucket(bucket string) bool {
+	return bucket == BucketDay || bucket == BucketWeek || bucket == BucketMonth
+}
+
+// PredictionRollup — число прогнозов по рекомендациям за один интервал
+type PredictionRollup struct {
+	Bucket string         `json:"Bucket"` // начало интервала, YYYY-MM-DD
+	Total  int            `json:"Total"`
+	Counts map[string]int `json:"Counts"`
+}
+
+// GetPredictionRollup считает прогнозы по тикеру, сгруппированные по интервалам
+// (day, week, month) и рекомендациям, от старых интервалов к новым
+func (s *PostgresStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT to_char(date_trunc($2, predicted_at), 'YYYY-MM-DD'),
+		       COALESCE(recommendation, $3), COUNT(*)
+		FROM predictions
+		WHERE stock_id = $1 AND deleted_at IS NULL
+		GROUP BY 1, 2
+		ORDER BY 1
+	`, stockID, bucket, unknownRecommendation)
+	if err != nil {
+		return nil, fmt.Errorf("error querying prediction rollup for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	rollup := []PredictionRollup{}
+	for rows.Next() {
+		var day, recommendation string
+		var n int
+		if err := rows.Scan(&day, &recommendation, &n); err != nil {
+			return nil, fmt.Errorf("error scanning prediction rollup: %w", err)
+		}
+		if len(rollup) == 0 || rollup[len(rollup)-1].Bucket != day {
+			rollup = append(rollup, PredictionRollup{Bucket: day, Counts: map[string]int{}})
+		}
+		r := &rollup[len(rollup)-1]
+		r.Counts[recommendation] += n
+		r.Total += n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction rollup rows: %w", err)
+	}
+
+	return rollup, nil
+}
+
+// BucketStart возвращает начало интервала, как date_trunc в PostgreSQL
+// (неделя начинается с понедельника)
+func BucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// RollupPredictions агрегирует уже загруженные прогнозы так же, как
+// GetPredictionRollup
+func RollupPredictions(predictions []Prediction, bucket string) []PredictionRollup {
+	byBucket := map[string]*PredictionRollup{}
+	for _, p := range predictions {
+		at, ok := ParseTimestamp(p.PredictedAt)
+		if !ok {
+			continue
+		}
+		key := BucketStart(at, bucket).Format("2006-01-02")
+		r, ok := byBucket[key]
+		if !ok {
+			r = &PredictionRollup{Bucket: key, Counts: map[string]int{}}
+			byBucket[key] = r
+		}
+		recommendation := unknownRecommendation
+		if p.Recommendation != nil {
+			recommendation = *p.Recommendation
+		}
+		r.Counts[recommendation]++
+		r.Total++
+	}
+
+	rollup := make([]PredictionRollup, 0, len(byBucket))
+	for _, r := range byBucket {
+		rollup = append(rollup, *r)
+	}
+	sort.Slice(rollup, func(i, j int) bool { return rollup[i].Bucket < rollup[j].Bucket })
+	return rollup
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var (
+	schemaCreateTableRe = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([a-z_][a-z0-9_]*)`)
+	schemaAddColumnRe   = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+([a-z_][a-z0-9_]*)\s+ADD\s+COLUMN\s+(?:IF\s+NOT\s+EXISTS\s+)?([a-z_][a-z0-9_]*)`)
+	schemaDropColumnRe  = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+([a-z_][a-z0-9_]*)\s+DROP\s+COLUMN\s+(?:IF\s+EXISTS\s+)?([a-z_][a-z0-9_]*)`)
+)
+
+// expectedSchema собирает по up-миграциям таблицы и добавленные столбцы,
+// которые должны быть в БД после последней миграции. Возвращает их и имя
+// последней миграции.
+func expectedSchema() (map[string]map[string]bool, string, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Strings(files)
+
+	tables := map[string]map[string]bool{}
+	table := func(name string) map[string]bool {
+		name = strings.ToLower(name)
+		if tables[name] == nil {
+			tables[name] = map[string]bool{}
+		}
+		return tables[name]
+	}
+	for _, f := range files {
+		body, err := migrationsFS.ReadFile(f)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, m := range schemaCreateTableRe.FindAllStringSubmatch(string(body), -1) {
+			table(m[1])
+		}
+		for _, m := range schemaAddColumnRe.FindAllStringSubmatch(string(body), -1) {
+			table(m[1])[strings.ToLower(m[2])] = true
+		}
+		for _, m := range schemaDropColumnRe.FindAllStringSubmatch(string(body), -1) {
+			delete(table(m[1]), strings.ToLower(m[2]))
+		}
+	}
+	latest := ""
+	if len(files) > 0 {
+		latest = strings.TrimSuffix(strings.TrimPrefix(files[len(files)-1], "migrations/"), ".up.sql")
+	}
+	return tables, latest, nil
+}
+
+// CheckSchema проверяет, что миграции применены: в БД есть все таблицы и
+// столбцы, которые создают up-миграции
+func (s *PostgresStorage) CheckSchema(ctx context.Context) error {
+	want, latest, err := expectedSchema()
+	if err != nil {
+		return fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("error querying schema: %w", err)
+	}
+	defer rows.Close()
+	have := map[string]map[string]bool{}
+	for rows.Next() {
+		var t, c string
+		if err := rows.Scan(&t, &c); err != nil {
+			return fmt.Errorf("error scanning schema: %w", err)
+		}
+		if have[t] == nil {
+			have[t] = map[string]bool{}
+		}
+		have[t][c] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating schema: %w", err)
+	}
+
+	var missing []string
+	for t, cols := range want {
+		if have[t] == nil {
+			missing = append(missing, t)
+			continue
+		}
+		for c := range cols {
+			if !have[t][c] {
+				missing = append(missing, t+"."+c)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("schema is behind migration %s: missing %s", latest, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CheckPriceDataDir проверяет, что каталог CSV-файлов истории цен path
+// существует и читается
+func CheckPriceDataDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("price data directory: %w", err)
+	}
+	defer dir.Close()
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("price data directory %s is not readable: %w", path, err)
+	}
+	return nil
+}
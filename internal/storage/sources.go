@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source — источник прогнозов (Telegram-канал) из таблицы sources
+type Source struct {
+	ID      int64   `json:"ID"`
+	Channel string  `json:"Channel"`
+	Name    *string `json:"Name"`
+}
+
+// PredictionMatch — прогноз, найденный по тексту, с тикером акции
+type PredictionMatch struct {
+	Ticker     string     `json:"Ticker"`
+	Prediction Prediction `json:"Prediction"`
+}
+
+// GetSources извлекает список источников
+func (s *PostgresStorage) GetSources(ctx context.Context) ([]Source, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, channel, name FROM sources ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error querying sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []Source{}
+	for rows.Next() {
+		var src Source
+		if err := rows.Scan(&src.ID, &src.Channel, &src.Name); err != nil {
+			return nil, fmt.Errorf("error scanning source: %w", err)
+		}
+		sources = append(sources, src)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over source rows: %w", err)
+	}
+
+	return sources, nil
+}
+
+// GetPredictionTypes возвращает различающиеся типы прогнозов
+func (s *PostgresStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT prediction_type FROM predictions
+		WHERE prediction_type IS NOT NULL AND deleted_at IS NULL ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying prediction types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []string{}
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("error scanning prediction type: %w", err)
+		}
+		types = append(types, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction type rows: %w", err)
+	}
+
+	return types, nil
+}
+
+// SearchPredictions ищет последние прогнозы, в тексте сообщения или
+// обосновании которых встречается q (без учета регистра)
+func (s *PostgresStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT st.ticker, p.channel, p.message_id, p.stock_id, p.prediction_type, p.target_price,
+		       p.recommendation, p.direction, m.text, p.predicted_at
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		WHERE (m.text ILIKE '%' || $1 || '%' OR p.justification_text ILIKE '%' || $1 || '%')
+		  AND p.deleted_at IS NULL AND st.deleted_at IS NULL
+		ORDER BY p.predicted_at DESC
+		LIMIT $2
+	`, escapeLike(q), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching predictions: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []PredictionMatch{}
+	for rows.Next() {
+		var m PredictionMatch
+		var predictedAt time.Time
+		p := &m.Prediction
+		err := rows.Scan(&m.Ticker, &p.Channel, &p.MessageID, &p.StockID, &p.PredictionType, &p.TargetPrice,
+			&p.Recommendation, &p.Direction, &p.Message, &predictedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning prediction match: %w", err)
+		}
+		p.PredictedAt = FormatTimestamp(predictedAt)
+		matches = append(matches, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction match rows: %w", err)
+	}
+
+	return matches, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы q искался буквально
+func escapeLike(q string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SQLAuditEntry — запись журнала админской SQL-консоли
+type SQLAuditEntry struct {
+	Actor    string
+	Query    string
+	Rows     int
+	Duration time.Duration
+	Error    string // пустая — запрос выполнен успешно
+}
+
+// AddSQLAudit сохраняет запись журнала SQL-консоли
+func (s *PostgresStorage) AddSQLAudit(ctx context.Context, e SQLAuditEntry) error {
+	var errText *string
+	if e.Error != "" {
+		errText = &e.Error
+	}
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO sql_console_audit (actor, query, row_count, duration_ms, error) VALUES ($1, $2, $3, $4, $5)",
+		e.Actor, e.Query, e.Rows, e.Duration.Milliseconds(), errText)
+	if err != nil {
+		return fmt.Errorf("error inserting sql console audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,572 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // драйвер database/sql "sqlite" без cgo
+)
+
+// sqliteSchema — схема SQLiteStorage: подмножество таблиц PostgreSQL, нужное
+// для чтения. Время хранится строкой RFC 3339 в UTC, поэтому строки
+// сортируются и сравниваются как время.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS stocks (
+    id       INTEGER PRIMARY KEY,
+    ticker   TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    exchange TEXT NOT NULL DEFAULT '',
+    UNIQUE (ticker, exchange)
+);
+CREATE TABLE IF NOT EXISTS stock_names (
+    stock_id INTEGER NOT NULL REFERENCES stocks (id),
+    lang     TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    PRIMARY KEY (stock_id, lang)
+);
+CREATE TABLE IF NOT EXISTS sources (
+    id      INTEGER PRIMARY KEY,
+    channel TEXT NOT NULL UNIQUE,
+    name    TEXT
+);
+CREATE TABLE IF NOT EXISTS messages (
+    channel     TEXT NOT NULL,
+    telegram_id INTEGER NOT NULL,
+    source_id   INTEGER REFERENCES sources (id),
+    text        TEXT,
+    sent_at     TEXT NOT NULL,
+    PRIMARY KEY (channel, telegram_id)
+);
+CREATE TABLE IF NOT EXISTS predictions (
+    id                    INTEGER PRIMARY KEY,
+    channel               TEXT NOT NULL DEFAULT '',
+    message_id            INTEGER NOT NULL,
+    stock_id              INTEGER NOT NULL REFERENCES stocks (id),
+    prediction_type       TEXT,
+    target_price          REAL,
+    target_change_percent REAL,
+    period                TEXT,
+    recommendation        TEXT,
+    direction             TEXT,
+    justification_text    TEXT,
+    predicted_at          TEXT NOT NULL,
+    outcome               TEXT,
+    realized_return       REAL,
+    UNIQUE (channel, message_id, stock_id)
+);
+CREATE INDEX IF NOT EXISTS predictions_stock_idx ON predictions (stock_id, predicted_at);
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    stock_id INTEGER NOT NULL REFERENCES stocks (id),
+    date     TEXT NOT NULL,
+    type     TEXT NOT NULL,
+    ratio    REAL,
+    amount   REAL
+);
+`
+
+// SQLiteStorage — хранилище в SQLite без сервера БД и cgo для демо-режима.
+// Поддерживает только чтение (Storage); данные загружаются через Seed.
+// История цен, как и в PostgresStorage, читается из CSV-файлов.
+type SQLiteStorage struct {
+	db     *sql.DB
+	prices fs.FS
+	asOf   time.Time
+}
+
+// SQLiteOptions задает источник данных SQLiteStorage
+type SQLiteOptions struct {
+	// Path — файл базы; пустой — база в памяти, которая пропадает при
+	// закрытии
+	Path string
+	// Prices — CSV <TICKER>_D1.csv с историей цен, например встроенные в
+	// бинарник
+	Prices fs.FS
+	// AsOf — момент, на который считаются консенсус и полосы целей; нулевой —
+	// текущее время
+	AsOf time.Time
+}
+
+// OpenSQLiteStorage открывает базу и создает в ней таблицы
+func OpenSQLiteStorage(ctx context.Context, opts SQLiteOptions) (*SQLiteStorage, error) {
+	dsn := "file:" + opts.Path
+	if opts.Path == "" {
+		dsn = "file::memory:"
+	}
+	db, err := sql.Open("sqlite", dsn+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("error opening SQLite database: %w", err)
+	}
+	// База в памяти живет, пока открыто соединение, и у каждого соединения
+	// своя; одно соединение к тому же убирает блокировки записи
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating SQLite schema: %w", err)
+	}
+	return &SQLiteStorage{db: db, prices: opts.Prices, asOf: opts.AsOf}, nil
+}
+
+// Close закрывает базу
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// now возвращает момент, на который считаются сводки
+func (s *SQLiteStorage) now() time.Time {
+	if s.asOf.IsZero() {
+		return time.Now()
+	}
+	return s.asOf
+}
+
+// Empty сообщает, что в базе еще нет акций
+func (s *SQLiteStorage) Empty(ctx context.Context) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, "SELECT count(*) FROM stocks").Scan(&n); err != nil {
+		return false, fmt.Errorf("error counting stocks: %w", err)
+	}
+	return n == 0, nil
+}
+
+// Seed копирует в базу акции, источники, прогнозы и корпоративные действия
+// из src в одной транзакции. Каждый прогноз получает свое сообщение в
+// канале источника.
+func (s *SQLiteStorage) Seed(ctx context.Context, src Storage) error {
+	stocks, err := src.GetStocks(ctx)
+	if err != nil {
+		return err
+	}
+	sources, err := src.GetSources(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting seed transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	channels := make(map[int64]string, len(sources))
+	for _, src := range sources {
+		channels[src.ID] = src.Channel
+		_, err := tx.ExecContext(ctx, "INSERT INTO sources (id, channel, name) VALUES (?, ?, ?)", src.ID, src.Channel, src.Name)
+		if err != nil {
+			return fmt.Errorf("error inserting source %s: %w", src.Channel, err)
+		}
+	}
+
+	var messageID int64
+	for _, st := range stocks {
+		_, err := tx.ExecContext(ctx, "INSERT INTO stocks (id, ticker, name, exchange) VALUES (?, ?, ?, ?)", st.ID, st.Ticker, st.Name, st.Exchange)
+		if err != nil {
+			return fmt.Errorf("error inserting stock %s: %w", st.Ticker, err)
+		}
+		for lang, name := range st.Names {
+			_, err := tx.ExecContext(ctx, "INSERT INTO stock_names (stock_id, lang, name) VALUES (?, ?, ?)", st.ID, lang, name)
+			if err != nil {
+				return fmt.Errorf("error inserting %s name of stock %s: %w", lang, st.Ticker, err)
+			}
+		}
+
+		predictions, err := src.GetPredictionsByTicker(ctx, st.Ticker, st.Exchange)
+		if err != nil {
+			return err
+		}
+		for _, p := range predictions {
+			at, ok := ParseTimestamp(p.PredictedAt)
+			if !ok {
+				return fmt.Errorf("prediction %d of %s: bad predicted_at %q", p.ID, st.Ticker, p.PredictedAt)
+			}
+			messageID++
+			channel := ""
+			if p.SourceID != nil {
+				channel = channels[*p.SourceID]
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO messages (channel, telegram_id, source_id, text, sent_at) VALUES (?, ?, ?, ?, ?)",
+				channel, messageID, p.SourceID, p.Message, FormatTimestamp(at))
+			if err != nil {
+				return fmt.Errorf("error inserting message of %s prediction: %w", st.Ticker, err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO predictions (
+					channel, message_id, stock_id, prediction_type,
+					target_price, target_change_percent, period,
+					recommendation, direction, justification_text,
+					predicted_at, outcome, realized_return
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`,
+				channel, messageID, st.ID, p.PredictionType,
+				p.TargetPrice, p.TargetChangePercent, p.Period,
+				p.Recommendation, p.Direction, p.JustificationText,
+				FormatTimestamp(at), p.Outcome, p.RealizedReturn,
+			)
+			if err != nil {
+				return fmt.Errorf("error inserting %s prediction: %w", st.Ticker, err)
+			}
+		}
+
+		actions, err := src.GetCorporateActions(ctx, st.Ticker)
+		if err != nil {
+			return err
+		}
+		for _, a := range actions {
+			_, err := tx.ExecContext(ctx, "INSERT INTO corporate_actions (stock_id, date, type, ratio, amount) VALUES (?, ?, ?, ?, ?)",
+				st.ID, FormatTimestamp(a.Date), a.Type, a.Ratio, a.Amount)
+			if err != nil {
+				return fmt.Errorf("error inserting corporate action of %s: %w", st.Ticker, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing seed: %w", err)
+	}
+	return nil
+}
+
+// resolveStock ищет акцию по тикеру и, если задана, бирже
+func (s *SQLiteStorage) resolveStock(ctx context.Context, ticker, exchange string) (int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, ticker, exchange FROM stocks
+		WHERE ticker = ? AND (? = '' OR upper(exchange) = upper(?))
+		ORDER BY id
+	`, ticker, exchange, exchange)
+	if err != nil {
+		return 0, fmt.Errorf("error getting stock ID for ticker %s: %w", ticker, err)
+	}
+	defer rows.Close()
+
+	var matches []StockMatch
+	for rows.Next() {
+		m := StockMatch{Active: true}
+		if err := rows.Scan(&m.ID, &m.Ticker, &m.Exchange); err != nil {
+			return 0, fmt.Errorf("error scanning stock for ticker %s: %w", ticker, err)
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating over stock rows for ticker %s: %w", ticker, err)
+	}
+
+	switch {
+	case len(matches) == 0 && exchange != "":
+		return 0, fmt.Errorf("%w for ticker %s on exchange %s", ErrStockNotFound, ticker, exchange)
+	case len(matches) == 0:
+		return 0, fmt.Errorf("%w for ticker %s", ErrStockNotFound, ticker)
+	case len(matches) == 1:
+		return matches[0].ID, nil
+	}
+	return 0, &AmbiguousTickerError{Ticker: ticker, Matches: matches}
+}
+
+// GetStocks возвращает акции с локализованными названиями
+func (s *SQLiteStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.ticker, s.name, s.exchange, n.lang, n.name
+		FROM stocks s
+		LEFT JOIN stock_names n ON n.stock_id = s.id
+		ORDER BY s.id, n.lang
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stocks: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := []Stock{}
+	for rows.Next() {
+		var st Stock
+		var lang, name sql.NullString
+		if err := rows.Scan(&st.ID, &st.Ticker, &st.Name, &st.Exchange, &lang, &name); err != nil {
+			return nil, fmt.Errorf("error scanning stock: %w", err)
+		}
+		if n := len(stocks); n == 0 || stocks[n-1].ID != st.ID {
+			st.Names = map[string]string{}
+			stocks = append(stocks, st)
+		}
+		if lang.Valid {
+			stocks[len(stocks)-1].Names[lang.String] = name.String
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stock rows: %w", err)
+	}
+	return stocks, nil
+}
+
+// predictions возвращает прогнозы акции от новых к старым. Как и в
+// PostgresStorage, MessageID в публичном API — порядковый номер прогноза.
+func (s *SQLiteStorage) predictions(ctx context.Context, stockID int64) ([]Prediction, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.channel, p.stock_id, p.prediction_type,
+		       p.target_price, p.target_change_percent, p.period,
+		       p.recommendation, p.direction, p.justification_text,
+		       m.text, m.sent_at, src.id, COALESCE(src.name, src.channel),
+		       p.outcome, p.realized_return
+		FROM predictions p
+		JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		LEFT JOIN sources src ON src.id = m.source_id
+		WHERE p.stock_id = ?
+		ORDER BY p.predicted_at DESC, p.id
+	`, stockID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying predictions: %w", err)
+	}
+	defer rows.Close()
+
+	predictions := []Prediction{}
+	for rows.Next() {
+		var p Prediction
+		var text sql.NullString
+		var sentAt string
+		err := rows.Scan(
+			&p.ID, &p.Channel, &p.StockID, &p.PredictionType,
+			&p.TargetPrice, &p.TargetChangePercent, &p.Period,
+			&p.Recommendation, &p.Direction, &p.JustificationText,
+			&text, &sentAt, &p.SourceID, &p.Source,
+			&p.Outcome, &p.RealizedReturn,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning prediction: %w", err)
+		}
+		p.Message = &text.String
+		p.MessageID = int64(len(predictions) + 1)
+		p.PredictedAt = sentAt
+		predictions = append(predictions, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction rows: %w", err)
+	}
+	return predictions, nil
+}
+
+// GetPredictionsByTicker возвращает прогнозы по тикеру, новые первыми
+func (s *SQLiteStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	stockID, err := s.resolveStock(ctx, ticker, exchange)
+	if err != nil {
+		return nil, err
+	}
+	return s.predictions(ctx, stockID)
+}
+
+// GetStockPriceHistory читает историю цен из CSV-файла тикера
+func (s *SQLiteStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	if s.prices == nil {
+		return nil, fmt.Errorf("%w for ticker %s", ErrNoPriceHistory, ticker)
+	}
+	data, err := fs.ReadFile(s.prices, ticker+"_D1.csv")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w for ticker %s", ErrNoPriceHistory, ticker)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening price history file for ticker %s: %w", ticker, err)
+	}
+	return parsePriceHistory(bytes.NewReader(data), ticker, stockID)
+}
+
+// GetCorporateActions возвращает корпоративные действия по тикеру по дате
+func (s *SQLiteStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT stock_id, date, type, ratio, amount FROM corporate_actions
+		WHERE stock_id = ? ORDER BY date
+	`, stockID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying corporate actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []CorporateAction{}
+	for rows.Next() {
+		var a CorporateAction
+		var date string
+		if err := rows.Scan(&a.StockID, &date, &a.Type, &a.Ratio, &a.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning corporate action: %w", err)
+		}
+		if a.Date, err = time.Parse(time.RFC3339, date); err != nil {
+			return nil, fmt.Errorf("error parsing corporate action date %q: %w", date, err)
+		}
+		actions = append(actions, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over corporate action rows: %w", err)
+	}
+	return actions, nil
+}
+
+// GetEODSummaries считает итоги дня по истории цен из CSV; акции без
+// истории пропускаются
+func (s *SQLiteStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	stocks, err := s.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []EODSummary{}
+	for _, st := range stocks {
+		history, err := s.GetStockPriceHistory(ctx, st.Ticker)
+		if errors.Is(err, ErrNoPriceHistory) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		e, ok := ComputeEODSummary(history, date)
+		if !ok {
+			continue
+		}
+		e.Ticker = st.Ticker
+		err = s.db.QueryRowContext(ctx, `
+			SELECT count(*) FROM predictions WHERE stock_id = ? AND substr(predicted_at, 1, 10) = ?
+		`, st.ID, e.Date).Scan(&e.NewPredictions)
+		if err != nil {
+			return nil, fmt.Errorf("error counting new predictions of %s: %w", st.Ticker, err)
+		}
+		summaries = append(summaries, e)
+	}
+	return summaries, nil
+}
+
+// GetPredictionRollup агрегирует прогнозы по интервалам
+func (s *SQLiteStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+	predictions, err := s.GetPredictionsByTicker(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	return RollupPredictions(predictions, bucket), nil
+}
+
+// GetConsensus считает консенсус прогнозов, активных на момент AsOf
+func (s *SQLiteStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	stockID, err := s.resolveStock(ctx, ticker, "")
+	if err != nil {
+		return Consensus{}, err
+	}
+	predictions, err := s.predictions(ctx, stockID)
+	if err != nil {
+		return Consensus{}, err
+	}
+	return ComputeConsensus(stockID, ticker, predictions, s.now()), nil
+}
+
+// GetSources возвращает источники
+func (s *SQLiteStorage) GetSources(ctx context.Context) ([]Source, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, channel, name FROM sources ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error querying sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []Source{}
+	for rows.Next() {
+		var src Source
+		if err := rows.Scan(&src.ID, &src.Channel, &src.Name); err != nil {
+			return nil, fmt.Errorf("error scanning source: %w", err)
+		}
+		sources = append(sources, src)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over source rows: %w", err)
+	}
+	return sources, nil
+}
+
+// GetPredictionTypes возвращает различающиеся типы прогнозов
+func (s *SQLiteStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT prediction_type FROM predictions
+		WHERE prediction_type IS NOT NULL ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying prediction types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []string{}
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("error scanning prediction type: %w", err)
+		}
+		types = append(types, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction type rows: %w", err)
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// SearchPredictions ищет последние прогнозы, в тексте сообщения или
+// обосновании которых встречается q. lower() в SQLite не знает кириллицы,
+// поэтому регистр сравнивается на стороне Go.
+func (s *SQLiteStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT st.ticker, p.channel, p.message_id, p.stock_id, p.prediction_type, p.target_price,
+		       p.recommendation, p.direction, p.justification_text, m.text, p.predicted_at
+		FROM predictions p
+		JOIN stocks st ON st.id = p.stock_id
+		JOIN messages m ON m.channel = p.channel AND m.telegram_id = p.message_id
+		ORDER BY p.predicted_at DESC, p.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error searching predictions: %w", err)
+	}
+	defer rows.Close()
+
+	q = strings.ToLower(q)
+	matches := []PredictionMatch{}
+	for rows.Next() && len(matches) < limit {
+		var m PredictionMatch
+		p := &m.Prediction
+		err := rows.Scan(&m.Ticker, &p.Channel, &p.MessageID, &p.StockID, &p.PredictionType, &p.TargetPrice,
+			&p.Recommendation, &p.Direction, &p.JustificationText, &p.Message, &p.PredictedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning prediction match: %w", err)
+		}
+		if !containsFold(p.Message, q) && !containsFold(p.JustificationText, q) {
+			continue
+		}
+		p.JustificationText = nil // как в PostgresStorage: в результатах поиска обоснования нет
+		matches = append(matches, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over prediction match rows: %w", err)
+	}
+	return matches, nil
+}
+
+// containsFold сообщает, что s содержит lowerQ без учета регистра
+func containsFold(s *string, lowerQ string) bool {
+	return s != nil && strings.Contains(strings.ToLower(*s), lowerQ)
+}
+
+// GetTargetBands считает полосы целевых цен на момент AsOf
+func (s *SQLiteStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
+	}
+	predictions, err := s.GetPredictionsByTicker(ctx, ticker, "")
+	if err != nil {
+		return nil, err
+	}
+	return ComputeTargetBands(predictions, bucket, s.now()), nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestSQLiteMatchesMock проверяет, что SQLiteStorage, заполненный из
+// MockStorage, отвечает так же, как он
+func TestSQLiteMatchesMock(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockStorage(42)
+	store, err := OpenSQLiteStorage(ctx, SQLiteOptions{AsOf: mock.Epoch()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Seed(ctx, mock); err != nil {
+		t.Fatal(err)
+	}
+
+	same := func(t *testing.T, name string, got, want any, gotErr, wantErr error) {
+		t.Helper()
+		if gotErr != nil || wantErr != nil {
+			t.Fatalf("%s: sqlite error = %v, mock error = %v", name, gotErr, wantErr)
+		}
+		// JSON — то, что видит клиент; заодно разыменовывает указатели в выводе
+		g, _ := json.Marshal(got)
+		w, _ := json.Marshal(want)
+		if string(g) != string(w) {
+			t.Errorf("%s:\nsqlite = %s\nmock   = %s", name, g, w)
+		}
+	}
+
+	gotStocks, err1 := store.GetStocks(ctx)
+	wantStocks, err2 := mock.GetStocks(ctx)
+	same(t, "GetStocks", gotStocks, wantStocks, err1, err2)
+	gotSources, err1 := store.GetSources(ctx)
+	wantSources, err2 := mock.GetSources(ctx)
+	same(t, "GetSources", gotSources, wantSources, err1, err2)
+	gotTypes, err1 := store.GetPredictionTypes(ctx)
+	wantTypes, err2 := mock.GetPredictionTypes(ctx)
+	same(t, "GetPredictionTypes", gotTypes, wantTypes, err1, err2)
+
+	for _, st := range wantStocks {
+		t.Run(st.Ticker, func(t *testing.T) {
+			got, err1 := store.GetPredictionsByTicker(ctx, st.Ticker, "")
+			want, err2 := mock.GetPredictionsByTicker(ctx, st.Ticker, "")
+			// ID в SQLite сквозной, а канал берется из источника; у mock их нет
+			for i := range got {
+				if got[i].Channel == "" {
+					t.Errorf("prediction %d has no channel", got[i].ID)
+				}
+				got[i].ID, got[i].Channel = 0, ""
+			}
+			for i := range want {
+				want[i].ID = 0
+			}
+			same(t, "GetPredictionsByTicker", got, want, err1, err2)
+			gotActions, err1 := store.GetCorporateActions(ctx, st.Ticker)
+			wantActions, err2 := mock.GetCorporateActions(ctx, st.Ticker)
+			same(t, "GetCorporateActions", gotActions, wantActions, err1, err2)
+			gotConsensus, err1 := store.GetConsensus(ctx, st.Ticker)
+			wantConsensus, err2 := mock.GetConsensus(ctx, st.Ticker)
+			same(t, "GetConsensus", gotConsensus, wantConsensus, err1, err2)
+			gotBands, err1 := store.GetTargetBands(ctx, st.Ticker, "month")
+			wantBands, err2 := mock.GetTargetBands(ctx, st.Ticker, "month")
+			same(t, "GetTargetBands", gotBands, wantBands, err1, err2)
+			gotRollup, err1 := store.GetPredictionRollup(ctx, st.Ticker, "week")
+			wantRollup, err2 := mock.GetPredictionRollup(ctx, st.Ticker, "week")
+			same(t, "GetPredictionRollup", gotRollup, wantRollup, err1, err2)
+		})
+	}
+
+	if _, err := store.GetPredictionsByTicker(ctx, "NOPE", ""); !errors.Is(err, ErrStockNotFound) {
+		t.Errorf("GetPredictionsByTicker(NOPE) error = %v, want not found", err)
+	}
+	if _, err := store.GetStockPriceHistory(ctx, wantStocks[0].Ticker); !errors.Is(err, ErrNoPriceHistory) {
+		t.Errorf("GetStockPriceHistory without prices error = %v, want ErrNoPriceHistory", err)
+	}
+}
@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/tracing"
+)
+
+// DefaultRedactedColumns — столбцы, значения которых не попадают в лог
+var DefaultRedactedColumns = []string{"password", "password_hash", "token", "refresh_token", "secret", "api_key", "key_hash"}
+
+var (
+	// col = $N, col <> $N и т.п.
+	sqlComparisonRe = regexp.MustCompile(`(?i)([a-z_][a-z0-9_]*)\s*(?:=|<>|!=|>=|<=|>|<|\bLIKE\b|\bILIKE\b)\s*\$(\d+)`)
+	// INSERT INTO t (a, b) VALUES ($1, $2)
+	sqlInsertRe = regexp.MustCompile(`(?is)INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*(?:VALUES\s*\(([^)]*)\)|SELECT\s+(.*?)(?:\s+WHERE|$))`)
+	sqlSpaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// SQLLogger пишет в лог SQL-запросы хранилища с параметрами. Включается
+// и выключается на лету (админский API); значения параметров, связанных со
+// столбцами из списка редактирования, заменяются на ***. Запросы дольше
+// порога медленных пишутся всегда, даже при выключенном логировании.
+type SQLLogger struct {
+	enabled atomic.Bool
+	slow    atomic.Int64 // порог медленного запроса, нс; 0 — не следить
+	logger  *slog.Logger
+
+	mu     sync.RWMutex
+	redact map[string]bool
+}
+
+// NewSQLLogger создает выключенный логгер, пишущий в logger;
+// nil — slog.Default()
+func NewSQLLogger(redact []string, logger *slog.Logger) *SQLLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	l := &SQLLogger{logger: logger}
+	l.SetRedacted(redact)
+	return l
+}
+
+// SetEnabled включает или выключает логирование
+func (l *SQLLogger) SetEnabled(enabled bool) {
+	l.enabled.Store(enabled)
+}
+
+// Enabled сообщает, включено ли логирование
+func (l *SQLLogger) Enabled() bool {
+	return l.enabled.Load()
+}
+
+// SetSlowThreshold задает порог медленного запроса; 0 — не следить
+func (l *SQLLogger) SetSlowThreshold(d time.Duration) {
+	l.slow.Store(int64(d))
+}
+
+// SlowThreshold возвращает порог медленного запроса
+func (l *SQLLogger) SlowThreshold() time.Duration {
+	return time.Duration(l.slow.Load())
+}
+
+// SetRedacted заменяет список редактируемых столбцов
+func (l *SQLLogger) SetRedacted(columns []string) {
+	m := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		m[strings.ToLower(c)] = true
+	}
+	l.mu.Lock()
+	l.redact = m
+	l.mu.Unlock()
+}
+
+// Redacted возвращает текущий список редактируемых столбцов
+func (l *SQLLogger) Redacted() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]string, 0, len(l.redact))
+	for c := range l.redact {
+		out = append(out, c)
+	}
+	return out
+}
+
+// log пишет запрос, если логирование включено или запрос медленный
+func (l *SQLLogger) log(ctx context.Context, name, query string, args []any, elapsed time.Duration, err error) {
+	slow := l.SlowThreshold()
+	isSlow := slow > 0 && elapsed >= slow
+	if !l.Enabled() && !isSlow {
+		return
+	}
+	level, msg := slog.LevelInfo, "SQL-запрос"
+	if isSlow {
+		level, msg = slog.LevelWarn, "Медленный SQL-запрос"
+	}
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	l.logger.Log(ctx, level, msg,
+		"name", name,
+		"query", sqlSpaceRe.ReplaceAllString(strings.TrimSpace(query), " "),
+		"args", l.formatArgs(query, args),
+		"elapsed", elapsed.Round(time.Microsecond),
+		"status", status)
+}
+
+// formatArgs форматирует параметры, скрывая значения редактируемых столбцов
+func (l *SQLLogger) formatArgs(query string, args []any) string {
+	hidden := l.redactedParams(query)
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if hidden[i+1] {
+			parts[i] = fmt.Sprintf("$%d=***", i+1)
+			continue
+		}
+		parts[i] = fmt.Sprintf("$%d=%v", i+1, formatArg(a))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// redactedParams возвращает номера параметров, связанных с редактируемыми столбцами
+func (l *SQLLogger) redactedParams(query string) map[int]bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	hidden := map[int]bool{}
+	for _, m := range sqlComparisonRe.FindAllStringSubmatch(query, -1) {
+		if l.redact[strings.ToLower(m[1])] {
+			n, _ := strconv.Atoi(m[2])
+			hidden[n] = true
+		}
+	}
+	if m := sqlInsertRe.FindStringSubmatch(query); m != nil {
+		columns := strings.Split(m[1], ",")
+		values := m[2]
+		if values == "" {
+			values = m[3]
+		}
+		for i, v := range strings.Split(values, ",") {
+			v = strings.TrimSpace(v)
+			if i >= len(columns) || !strings.HasPrefix(v, "$") {
+				continue
+			}
+			if l.redact[strings.ToLower(strings.TrimSpace(columns[i]))] {
+				n, _ := strconv.Atoi(strings.TrimPrefix(strings.SplitN(v, "::", 2)[0], "$"))
+				hidden[n] = true
+			}
+		}
+	}
+	return hidden
+}
+
+func formatArg(a any) any {
+	switch v := a.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case *string:
+		if v == nil {
+			return "NULL"
+		}
+		return strconv.Quote(*v)
+	case *float64:
+		if v == nil {
+			return "NULL"
+		}
+		return *v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// startQuerySpan открывает клиентский спан SQL-запроса. Текст запроса
+// записывается без значений параметров.
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	text := strings.TrimSpace(sqlSpaceRe.ReplaceAllString(query, " "))
+	op, _, _ := strings.Cut(text, " ")
+	return tracing.Start(ctx, "db "+strings.ToUpper(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBQueryText(text)))
+}
+
+// loggedDB оборачивает *sql.DB: пишет выполняемые запросы в SQLLogger,
+// считает их длительность в метриках по имени запроса и открывает для них
+// спаны трассировки. Транзакции из BeginTx делают то же самое.
+type loggedDB struct {
+	*sql.DB
+	log *SQLLogger
+}
+
+// loggedTx оборачивает *sql.Tx транзакции loggedDB
+type loggedTx struct {
+	*sql.Tx
+	db *loggedDB
+}
+
+// done учитывает выполненный запрос в метриках и логе
+func (d *loggedDB) done(ctx context.Context, query string, args []any, elapsed time.Duration, err error) {
+	name := queryName()
+	metrics.DBQueryDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+	d.log.log(ctx, name, query, args, elapsed, err)
+}
+
+// queryName возвращает имя запроса — метод хранилища, который его выполнил,
+// например GetPredictionsByTicker. Запросы из вложенных функций метода
+// получают имя метода.
+func queryName() string {
+	var pcs [16]uintptr
+	// Пропускаем runtime.Callers, queryName и done; методы loggedDB и
+	// loggedTx отсеиваются ниже
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if name, ok := strings.CutPrefix(f.Function, storagePackage); ok &&
+			!strings.HasPrefix(name, "(*loggedDB)") && !strings.HasPrefix(name, "(*loggedTx)") {
+			if i := strings.LastIndex(name, ")."); i >= 0 {
+				name = name[i+2:]
+			}
+			if i := strings.Index(name, "."); i >= 0 {
+				name = name[:i] // .func1 и т.п. у замыканий
+			}
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// storagePackage — префикс имен функций пакета в стеке вызовов
+var storagePackage = reflect.TypeOf(loggedDB{}).PkgPath() + "."
+
+// queryer — общие методы *sql.DB и *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (d *loggedDB) query(ctx context.Context, q queryer, query string, args []any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args...)
+	d.done(ctx, query, args, time.Since(start), err)
+	// Спан покрывает выполнение запроса, но не чтение строк
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (d *loggedDB) queryRow(ctx context.Context, q queryer, query string, args []any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	start := time.Now()
+	row := q.QueryRowContext(ctx, query, args...)
+	d.done(ctx, query, args, time.Since(start), row.Err())
+	tracing.End(span, row.Err())
+	return row
+}
+
+func (d *loggedDB) exec(ctx context.Context, q queryer, query string, args []any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	start := time.Now()
+	res, err := q.ExecContext(ctx, query, args...)
+	d.done(ctx, query, args, time.Since(start), err)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (d *loggedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return d.query(context.Background(), d.DB, query, args)
+}
+
+func (d *loggedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return d.query(ctx, d.DB, query, args)
+}
+
+func (d *loggedDB) QueryRow(query string, args ...any) *sql.Row {
+	return d.queryRow(context.Background(), d.DB, query, args)
+}
+
+func (d *loggedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return d.queryRow(ctx, d.DB, query, args)
+}
+
+func (d *loggedDB) Exec(query string, args ...any) (sql.Result, error) {
+	return d.exec(context.Background(), d.DB, query, args)
+}
+
+func (d *loggedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return d.exec(ctx, d.DB, query, args)
+}
+
+// BeginTx начинает транзакцию, запросы которой логируются так же, как
+// запросы вне транзакции
+func (d *loggedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*loggedTx, error) {
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedTx{Tx: tx, db: d}, nil
+}
+
+func (t *loggedTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.db.query(context.Background(), t.Tx, query, args)
+}
+
+func (t *loggedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.db.query(ctx, t.Tx, query, args)
+}
+
+func (t *loggedTx) QueryRow(query string, args ...any) *sql.Row {
+	return t.db.queryRow(context.Background(), t.Tx, query, args)
+}
+
+func (t *loggedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return t.db.queryRow(ctx, t.Tx, query, args)
+}
+
+func (t *loggedTx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.db.exec(context.Background(), t.Tx, query, args)
+}
+
+func (t *loggedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.db.exec(ctx, t.Tx, query, args)
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRedactedParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{
+			name:  "comparison",
+			query: `SELECT id FROM users WHERE email = $1 AND password_hash = $2`,
+			want:  []int{2},
+		},
+		{
+			name:  "comparison operators and case",
+			query: `SELECT 1 FROM api_keys WHERE KEY_HASH<>$3 OR token ILIKE $1 OR secret>=$2`,
+			want:  []int{1, 2, 3},
+		},
+		{
+			name:  "qualified column",
+			query: `SELECT u.id FROM users u WHERE u.password_hash = $1`,
+			want:  []int{1},
+		},
+		{
+			name:  "insert values",
+			query: `INSERT INTO users (email, password_hash, role) VALUES ($1, $2, $3)`,
+			want:  []int{2},
+		},
+		{
+			name: "insert values across lines with casts",
+			query: `
+				INSERT INTO webhooks (url, secret)
+				VALUES ($1, $2::text)
+				RETURNING id`,
+			want: []int{2},
+		},
+		{
+			name:  "insert select",
+			query: `INSERT INTO api_keys (name, key_hash) SELECT $1, $2 WHERE NOT EXISTS (SELECT 1)`,
+			want:  []int{2},
+		},
+		{
+			name:  "insert literal shifts nothing",
+			query: `INSERT INTO users (role, password_hash) VALUES ('viewer', $1)`,
+			want:  []int{1},
+		},
+		{
+			name:  "update set",
+			query: `UPDATE users SET password_hash = $2 WHERE id = $1`,
+			want:  []int{2},
+		},
+		{
+			name:  "no redacted columns",
+			query: `SELECT * FROM stocks WHERE ticker = $1 AND exchange = $2`,
+			want:  []int{},
+		},
+		{
+			name:  "column name prefix is not enough",
+			query: `SELECT * FROM t WHERE tokens = $1 AND secret_name = $2`,
+			want:  []int{},
+		},
+	}
+	l := NewSQLLogger(DefaultRedactedColumns, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Sorted(maps.Keys(l.redactedParams(tt.query)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("redactedParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatArgsRedacts(t *testing.T) {
+	l := NewSQLLogger([]string{"Password_Hash"}, nil)
+	got := l.formatArgs(`UPDATE users SET password_hash = $2 WHERE email = $1`, []any{"a@b.c", "hash"})
+	if want := `[$1="a@b.c" $2=***]`; got != want {
+		t.Errorf("formatArgs() = %s, want %s", got, want)
+	}
+
+	l.SetRedacted(nil)
+	got = l.formatArgs(`UPDATE users SET password_hash = $2 WHERE email = $1`, []any{"a@b.c", nil})
+	if want := `[$1="a@b.c" $2=NULL]`; got != want {
+		t.Errorf("formatArgs() without redaction = %s, want %s", got, want)
+	}
+}
+
+// stubDriver — драйвер без БД: запросы ничего не делают и ничего не возвращают
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return stubStmt{}, nil }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return stubTx{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubStmt struct{}
+
+func (stubStmt) Close() error                               { return nil }
+func (stubStmt) NumInput() int                              { return -1 }
+func (stubStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (stubStmt) Query([]driver.Value) (driver.Rows, error)  { return stubRows{}, nil }
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string         { return []string{"id"} }
+func (stubRows) Close() error              { return nil }
+func (stubRows) Next([]driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("sqllog-stub", stubDriver{})
+}
+
+func TestLoggedTx(t *testing.T) {
+	raw, err := sql.Open("sqllog-stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	var buf bytes.Buffer
+	l := NewSQLLogger(DefaultRedactedColumns, slog.New(slog.NewTextHandler(&buf, nil)))
+	l.SetEnabled(true)
+	db := &loggedDB{DB: raw, log: l}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, "hash", 7); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM users WHERE email = $1`, "a@b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`name=TestLoggedTx query="UPDATE users SET password_hash = $1 WHERE id = $2" args="[$1=*** $2=7]"`,
+		`name=TestLoggedTx query="SELECT id FROM users WHERE email = $1" args="[$1=\"a@b.c\"]"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log does not contain %s:\n%s", want, out)
+		}
+	}
+}
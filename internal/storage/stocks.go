@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrStockExists возвращается, если акция с таким тикером на бирже уже есть,
+// в том числе удаленная — ее восстанавливают через RestoreStock
+var ErrStockExists = NewConflictError("stock already exists")
+
+// ErrInvalidStock возвращается для акции без названия; неверный тикер
+// отклоняет NormalizeTicker
+var ErrInvalidStock = NewValidationError("invalid stock")
+
+// defaultExchange — биржа акции, если она не указана (как в миграции 000014)
+const defaultExchange = "MOEX"
+
+// pgUniqueViolation — код ошибки PostgreSQL при нарушении уникальности
+const pgUniqueViolation = "23505"
+
+// StockWriter изменяет справочник акций; доступен только с PostgreSQL
+type StockWriter interface {
+	ListStocks(ctx context.Context, includeDeleted bool) ([]Stock, error)
+	GetStock(ctx context.Context, id int64) (Stock, error)
+	CreateStock(ctx context.Context, st *Stock) error
+	UpsertStock(ctx context.Context, st *Stock) (string, error)
+	UpdateStock(ctx context.Context, st *Stock, version int64) error
+	DeleteStock(ctx context.Context, id int64) error
+	RestoreStock(ctx context.Context, id int64) (Stock, error)
+}
+
+// normalizeStock приводит тикер и биржу к верхнему регистру и проверяет поля
+func normalizeStock(st *Stock) error {
+	ticker, err := NormalizeTicker(st.Ticker)
+	if err != nil {
+		return err
+	}
+	st.Ticker = ticker
+	st.Name = strings.TrimSpace(st.Name)
+	st.Exchange = strings.ToUpper(strings.TrimSpace(st.Exchange))
+	if st.Exchange == "" {
+		st.Exchange = defaultExchange
+	}
+	if st.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidStock)
+	}
+	for lang, name := range st.Names {
+		if strings.TrimSpace(lang) == "" || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: localized names must have a language and a name", ErrInvalidStock)
+		}
+	}
+	return nil
+}
+
+// CreateStock добавляет акцию с локализованными названиями и заполняет ее ID
+// и версию
+func (s *PostgresStorage) CreateStock(ctx context.Context, st *Stock) error {
+	if err := normalizeStock(st); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting stock transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stocks (ticker, name, exchange) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, st.Ticker, st.Name, st.Exchange).Scan(&st.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s on %s", ErrStockExists, st.Ticker, st.Exchange)
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting stock %s: %w", st.Ticker, err)
+	}
+	if err := replaceStockNames(ctx, tx, st); err != nil {
+		return err
+	}
+	if err := scanStockVersion(ctx, tx, st); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, AuditCreate, "stocks", st.ID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing stock %s: %w", st.Ticker, err)
+	}
+	return nil
+}
+
+// Результаты UpsertStock
+const (
+	StockCreated   = "created"
+	StockUpdated   = "updated"
+	StockUnchanged = "unchanged"
+)
+
+// UpsertStock добавляет акцию или, если тикер на этой бирже уже есть,
+// обновляет ее название и — если st.Names не nil — локализованные названия.
+// Повтор с теми же данными ничего не меняет, версия не растет. Удаленная акция
+// обновляется, но остается удаленной. Заполняет ID, версию и DeletedAt и
+// возвращает StockCreated, StockUpdated или StockUnchanged.
+func (s *PostgresStorage) UpsertStock(ctx context.Context, st *Stock) (string, error) {
+	if err := normalizeStock(st); err != nil {
+		return "", err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error starting stock transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before []byte
+	var existing int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM stocks WHERE ticker = $1 AND upper(exchange) = $2`, st.Ticker, st.Exchange).Scan(&existing)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("error querying stock %s: %w", st.Ticker, err)
+	}
+	if existing != 0 {
+		if before, err = snapshotRow(ctx, tx, "stocks", existing); err != nil {
+			return "", err
+		}
+	}
+
+	// Без изменения названия ON CONFLICT не обновляет строку и не возвращает ее
+	result := StockUpdated
+	var inserted bool
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stocks (ticker, name, exchange) VALUES ($1, $2, $3)
+		ON CONFLICT (ticker, upper(exchange)) DO UPDATE SET name = EXCLUDED.name
+		WHERE stocks.name IS DISTINCT FROM EXCLUDED.name
+		RETURNING id, xmax = 0
+	`, st.Ticker, st.Name, st.Exchange).Scan(&st.ID, &inserted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result = StockUnchanged
+		if err := tx.QueryRowContext(ctx, `SELECT id FROM stocks WHERE ticker = $1 AND upper(exchange) = $2`, st.Ticker, st.Exchange).Scan(&st.ID); err != nil {
+			return "", fmt.Errorf("error querying stock %s: %w", st.Ticker, err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("error upserting stock %s: %w", st.Ticker, err)
+	case inserted:
+		result = StockCreated
+	}
+
+	if st.Names != nil {
+		current, err := stockNames(ctx, tx, st.ID)
+		if err != nil {
+			return "", err
+		}
+		if !maps.Equal(current, normalizedNames(st.Names)) {
+			if err := replaceStockNames(ctx, tx, st); err != nil {
+				return "", err
+			}
+			if result == StockUnchanged {
+				result = StockUpdated
+			}
+		}
+	}
+	switch result {
+	case StockCreated:
+		err = recordAudit(ctx, tx, AuditCreate, "stocks", st.ID, nil)
+	case StockUpdated:
+		err = recordAudit(ctx, tx, AuditUpdate, "stocks", st.ID, before)
+	}
+	if err != nil {
+		return "", err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT version, deleted_at FROM stocks WHERE id = $1`, st.ID).Scan(&st.Version, &st.DeletedAt)
+	if err != nil {
+		return "", fmt.Errorf("error querying version of stock %d: %w", st.ID, err)
+	}
+	if st.DeletedAt != nil {
+		deleted := st.DeletedAt.UTC()
+		st.DeletedAt = &deleted
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("error committing stock %s: %w", st.Ticker, err)
+	}
+	return result, nil
+}
+
+// UpdateStock меняет тикер, название и биржу акции и заполняет новую версию.
+// Локализованные названия заменяются, только если st.Names не nil. Если
+// version не 0, а акцию уже изменили, возвращает ErrVersionMismatch.
+// Удаленную акцию сначала восстанавливают.
+func (s *PostgresStorage) UpdateStock(ctx context.Context, st *Stock, version int64) error {
+	if err := normalizeStock(st); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting stock transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current int64
+	err = tx.QueryRowContext(ctx, `SELECT version FROM stocks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, st.ID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: id %d", ErrStockNotFound, st.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("error locking stock %d: %w", st.ID, err)
+	}
+	if version != 0 && version != current {
+		return fmt.Errorf("%w: stock %d is at version %d", ErrVersionMismatch, st.ID, current)
+	}
+	before, err := snapshotRow(ctx, tx, "stocks", st.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE stocks SET ticker = $2, name = $3, exchange = $4 WHERE id = $1`,
+		st.ID, st.Ticker, st.Name, st.Exchange)
+	if pgCode(err) == pgUniqueViolation {
+		return fmt.Errorf("%w: %s on %s", ErrStockExists, st.Ticker, st.Exchange)
+	}
+	if err != nil {
+		return fmt.Errorf("error updating stock %d: %w", st.ID, err)
+	}
+	if st.Names != nil {
+		if err := replaceStockNames(ctx, tx, st); err != nil {
+			return err
+		}
+	}
+	if err := scanStockVersion(ctx, tx, st); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, AuditUpdate, "stocks", st.ID, before); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing stock %d: %w", st.ID, err)
+	}
+	return nil
+}
+
+// DeleteStock мягко удаляет акцию: она и ее прогнозы пропадают из API, но
+// остаются в БД вместе с историей
+func (s *PostgresStorage) DeleteStock(ctx context.Context, id int64) error {
+	n, err := s.execAudited(ctx, AuditDelete, "stocks", id, `UPDATE stocks SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting stock %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: id %d", ErrStockNotFound, id)
+	}
+	return nil
+}
+
+// RestoreStock восстанавливает удаленную акцию; восстановление действующей
+// акции ничего не меняет
+func (s *PostgresStorage) RestoreStock(ctx context.Context, id int64) (Stock, error) {
+	if _, err := s.execAudited(ctx, AuditRestore, "stocks", id, `UPDATE stocks SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return Stock{}, fmt.Errorf("error restoring stock %d: %w", id, err)
+	}
+	return s.GetStock(ctx, id)
+}
+
+// GetStock возвращает акцию по id, в том числе удаленную
+func (s *PostgresStorage) GetStock(ctx context.Context, id int64) (Stock, error) {
+	stocks, err := s.listStocks(ctx, "s.id = $1", id)
+	if err != nil {
+		return Stock{}, err
+	}
+	if len(stocks) == 0 {
+		return Stock{}, fmt.Errorf("%w: id %d", ErrStockNotFound, id)
+	}
+	return stocks[0], nil
+}
+
+// ListStocks возвращает справочник акций для админского API; includeDeleted
+// добавляет удаленные акции с заполненным DeletedAt
+func (s *PostgresStorage) ListStocks(ctx context.Context, includeDeleted bool) ([]Stock, error) {
+	if includeDeleted {
+		return s.listStocks(ctx, "true")
+	}
+	return s.listStocks(ctx, "s.deleted_at IS NULL")
+}
+
+// listStocks выбирает акции с локализованными названиями по условию where
+func (s *PostgresStorage) listStocks(ctx context.Context, where string, args ...any) ([]Stock, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.ticker, s.name, s.exchange, s.deleted_at, s.version,
+		       COALESCE(json_object_agg(n.lang, n.name) FILTER (WHERE n.lang IS NOT NULL), '{}')
+		FROM stocks s
+		LEFT JOIN stock_names n ON n.stock_id = s.id
+		WHERE `+where+`
+		GROUP BY s.id
+		ORDER BY s.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stocks: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := []Stock{}
+	for rows.Next() {
+		var st Stock
+		var names []byte
+		if err := rows.Scan(&st.ID, &st.Ticker, &st.Name, &st.Exchange, &st.DeletedAt, &st.Version, &names); err != nil {
+			return nil, fmt.Errorf("error scanning stock: %w", err)
+		}
+		if err := json.Unmarshal(names, &st.Names); err != nil {
+			return nil, fmt.Errorf("error decoding names of stock %s: %w", st.Ticker, err)
+		}
+		if st.DeletedAt != nil {
+			deleted := st.DeletedAt.UTC()
+			st.DeletedAt = &deleted
+		}
+		stocks = append(stocks, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stock rows: %w", err)
+	}
+	return stocks, nil
+}
+
+// scanStockVersion читает версию акции после изменений в транзакции tx:
+// изменение каждого названия тоже увеличивает версию
+func scanStockVersion(ctx context.Context, tx *loggedTx, st *Stock) error {
+	if err := tx.QueryRowContext(ctx, `SELECT version FROM stocks WHERE id = $1`, st.ID).Scan(&st.Version); err != nil {
+		return fmt.Errorf("error querying version of stock %d: %w", st.ID, err)
+	}
+	return nil
+}
+
+// stockNames возвращает локализованные названия акции
+func stockNames(ctx context.Context, tx *loggedTx, id int64) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT lang, name FROM stock_names WHERE stock_id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying names of stock %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var lang, name string
+		if err := rows.Scan(&lang, &name); err != nil {
+			return nil, fmt.Errorf("error scanning name of stock %d: %w", id, err)
+		}
+		names[lang] = name
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over names of stock %d: %w", id, err)
+	}
+	return names, nil
+}
+
+// normalizedNames приводит названия к виду, в котором их сохраняет replaceStockNames
+func normalizedNames(names map[string]string) map[string]string {
+	out := make(map[string]string, len(names))
+	for lang, name := range names {
+		out[strings.ToLower(strings.TrimSpace(lang))] = strings.TrimSpace(name)
+	}
+	return out
+}
+
+// replaceStockNames заменяет локализованные названия акции на st.Names
+func replaceStockNames(ctx context.Context, tx *loggedTx, st *Stock) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM stock_names WHERE stock_id = $1`, st.ID); err != nil {
+		return fmt.Errorf("error deleting names of stock %d: %w", st.ID, err)
+	}
+	for lang, name := range normalizedNames(st.Names) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO stock_names (stock_id, lang, name) VALUES ($1, $2, $3)`,
+			st.ID, lang, name)
+		if err != nil {
+			return fmt.Errorf("error inserting %s name of stock %d: %w", lang, st.ID, err)
+		}
+	}
+	return nil
+}
+
+// pgCode возвращает код ошибки PostgreSQL или пустую строку
+func pgCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// pgConstraint возвращает имя нарушенного ограничения PostgreSQL или пустую
+// строку
+func pgConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+	return ""
+}
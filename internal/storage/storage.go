@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Классы ошибок хранилища: по ним API выбирает код ответа (404, 400, 409,
+// 412 и 503). Конкретные ошибки создаются NewNotFoundError, NewValidationError,
+// NewConflictError и NewPreconditionError и сравниваются с классом через
+// errors.Is; ErrUnavailable возвращает BreakerStorage.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrPrecondition = errors.New("precondition failed")
+	ErrUnavailable  = errors.New("storage unavailable")
+)
+
+// classError — ошибка со своим текстом, принадлежащая одному из классов
+type classError struct {
+	msg   string
+	class error
+}
+
+func (e *classError) Error() string { return e.msg }
+func (e *classError) Unwrap() error { return e.class }
+
+// NewNotFoundError создает ошибку класса ErrNotFound
+func NewNotFoundError(msg string) error {
+	return &classError{msg: msg, class: ErrNotFound}
+}
+
+// NewValidationError создает ошибку класса ErrValidation
+func NewValidationError(msg string) error {
+	return &classError{msg: msg, class: ErrValidation}
+}
+
+// NewConflictError создает ошибку класса ErrConflict
+func NewConflictError(msg string) error {
+	return &classError{msg: msg, class: ErrConflict}
+}
+
+// NewPreconditionError создает ошибку класса ErrPrecondition
+func NewPreconditionError(msg string) error {
+	return &classError{msg: msg, class: ErrPrecondition}
+}
+
+// IsPermanent сообщает, что ошибка вызвана самими данными и повтор той же
+// операции ее не исправит: ошибки классов ErrNotFound, ErrValidation,
+// ErrConflict и ErrPrecondition и ошибки PostgreSQL классов 22 (некорректные
+// данные) и 23 (нарушение ограничения). Остальное — сбои соединения,
+// ErrUnavailable, отмена контекста — считается временным.
+func IsPermanent(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrValidation),
+		errors.Is(err, ErrConflict), errors.Is(err, ErrPrecondition):
+		return true
+	}
+	code := pgCode(err)
+	return strings.HasPrefix(code, "22") || strings.HasPrefix(code, "23")
+}
+
+// ErrVersionMismatch возвращается, если запись изменили после того, как
+// клиент прочитал ее версию
+var ErrVersionMismatch = NewPreconditionError("version mismatch")
+
+// ErrStockNotFound возвращается, если акции с указанным тикером нет
+var ErrStockNotFound = NewNotFoundError("stock not found")
+
+// ErrNoPriceHistory возвращается, если для акции нет истории цен
+var ErrNoPriceHistory = NewNotFoundError("price history not found")
+
+// Storage описывает источник данных, который использует HTTP-сервер
+type Storage interface {
+	GetStocks(ctx context.Context) ([]Stock, error)
+	GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error)
+	GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error)
+	GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error)
+	GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error)
+	GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error)
+	GetConsensus(ctx context.Context, ticker string) (Consensus, error)
+	GetSources(ctx context.Context) ([]Source, error)
+	GetPredictionTypes(ctx context.Context) ([]string, error)
+	SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error)
+	GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error)
+}
+
+// Поддерживаемые драйверы хранилища (config: storage.driver)
+const (
+	DriverPostgres = "postgres"
+	DriverMock     = "mock"
+)
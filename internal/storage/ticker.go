@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxTickerLen — предел длины тикера
+const MaxTickerLen = 12
+
+// tickerPattern — буквы и цифры, допускаются одиночные точки и дефисы внутри
+// (BRK.B, RU-ABC); ни «..», ни разделителей пути тикер содержать не может
+var tickerPattern = regexp.MustCompile(`^[A-Z0-9]+([.\-][A-Z0-9]+)*$`)
+
+// NormalizeTicker приводит тикер к верхнему регистру и проверяет его.
+// Ошибка относится к классу ErrValidation.
+func NormalizeTicker(ticker string) (string, error) {
+	t := strings.ToUpper(strings.TrimSpace(ticker))
+	switch {
+	case t == "":
+		return "", NewValidationError("ticker is empty")
+	case len(t) > MaxTickerLen:
+		return "", NewValidationError(fmt.Sprintf("ticker %.20q is too long: at most %d characters", ticker, MaxTickerLen))
+	case !tickerPattern.MatchString(t):
+		return "", NewValidationError(fmt.Sprintf("invalid ticker %q: expected letters, digits and single inner dots or dashes", ticker))
+	}
+	return t, nil
+}
+
+// DefaultPriceDataDir — каталог CSV-файлов истории цен по умолчанию
+// (marketdata.data_dir)
+const DefaultPriceDataDir = "data"
+
+// PriceHistoryModTime возвращает время изменения файла истории цен тикера
+// в dir или нулевое время, если файла нет
+func PriceHistoryModTime(dir, ticker string) time.Time {
+	path, err := PriceHistoryPath(dir, ticker)
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// PriceHistoryPath возвращает путь к CSV-файлу истории цен тикера в dir
+// (<dir>/<TICKER>_D1.csv). Тикер проверяется, чтобы имя файла не вышло за dir.
+func PriceHistoryPath(dir, ticker string) (string, error) {
+	t, err := NormalizeTicker(ticker)
+	if err != nil {
+		return "", err
+	}
+	name := t + "_D1.csv"
+	if filepath.Base(name) != name {
+		return "", NewValidationError(fmt.Sprintf("invalid ticker %q", ticker))
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"strconv"
+	"time"
+)
+
+// FormatTimestamp — единый формат меток времени в ответах: RFC 3339 в UTC
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseTimestamp разбирает метку времени в RFC 3339 или Unix timestamp
+// (так PredictedAt хранился раньше, например в снимках состояния)
+func ParseTimestamp(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), true
+	}
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(ts, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
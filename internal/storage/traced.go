@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"frontend-backend/internal/tracing"
+)
+
+// TracedStorage открывает спан трассировки на каждый вызов другого Storage.
+// Стоит снаружи кеша: по длительности спана видно, попал ли запрос в кеш.
+type TracedStorage struct {
+	next Storage
+}
+
+// NewTracedStorage оборачивает next спанами трассировки
+func NewTracedStorage(next Storage) *TracedStorage {
+	return &TracedStorage{next: next}
+}
+
+// traced вызывает call в спане storage.<method>
+func traced[T any](ctx context.Context, method string, call func(context.Context) (T, error), attrs ...attribute.KeyValue) (T, error) {
+	ctx, span := tracing.Start(ctx, "storage."+method, trace.WithAttributes(attrs...))
+	v, err := call(ctx)
+	tracing.End(span, err)
+	return v, err
+}
+
+// tickerAttr — тикер запроса в атрибутах спана
+func tickerAttr(ticker string) attribute.KeyValue {
+	return attribute.String("ticker", ticker)
+}
+
+// Методы ниже делегируют вызов next внутри спана
+
+func (s *TracedStorage) GetStocks(ctx context.Context) ([]Stock, error) {
+	return traced(ctx, "GetStocks", s.next.GetStocks)
+}
+
+func (s *TracedStorage) GetPredictionsByTicker(ctx context.Context, ticker, exchange string) ([]Prediction, error) {
+	return traced(ctx, "GetPredictionsByTicker", func(ctx context.Context) ([]Prediction, error) {
+		return s.next.GetPredictionsByTicker(ctx, ticker, exchange)
+	}, tickerAttr(ticker), attribute.String("exchange", exchange))
+}
+
+func (s *TracedStorage) GetStockPriceHistory(ctx context.Context, ticker string) ([]StockPriceHistory, error) {
+	return traced(ctx, "GetStockPriceHistory", func(ctx context.Context) ([]StockPriceHistory, error) {
+		return s.next.GetStockPriceHistory(ctx, ticker)
+	}, tickerAttr(ticker))
+}
+
+func (s *TracedStorage) GetCorporateActions(ctx context.Context, ticker string) ([]CorporateAction, error) {
+	return traced(ctx, "GetCorporateActions", func(ctx context.Context) ([]CorporateAction, error) {
+		return s.next.GetCorporateActions(ctx, ticker)
+	}, tickerAttr(ticker))
+}
+
+func (s *TracedStorage) GetEODSummaries(ctx context.Context, date time.Time) ([]EODSummary, error) {
+	return traced(ctx, "GetEODSummaries", func(ctx context.Context) ([]EODSummary, error) {
+		return s.next.GetEODSummaries(ctx, date)
+	}, attribute.String("date", date.Format("2006-01-02")))
+}
+
+func (s *TracedStorage) GetPredictionRollup(ctx context.Context, ticker, bucket string) ([]PredictionRollup, error) {
+	return traced(ctx, "GetPredictionRollup", func(ctx context.Context) ([]PredictionRollup, error) {
+		return s.next.GetPredictionRollup(ctx, ticker, bucket)
+	}, tickerAttr(ticker), attribute.String("bucket", bucket))
+}
+
+func (s *TracedStorage) GetConsensus(ctx context.Context, ticker string) (Consensus, error) {
+	return traced(ctx, "GetConsensus", func(ctx context.Context) (Consensus, error) {
+		return s.next.GetConsensus(ctx, ticker)
+	}, tickerAttr(ticker))
+}
+
+func (s *TracedStorage) GetSources(ctx context.Context) ([]Source, error) {
+	return traced(ctx, "GetSources", s.next.GetSources)
+}
+
+func (s *TracedStorage) GetPredictionTypes(ctx context.Context) ([]string, error) {
+	return traced(ctx, "GetPredictionTypes", s.next.GetPredictionTypes)
+}
+
+func (s *TracedStorage) SearchPredictions(ctx context.Context, q string, limit int) ([]PredictionMatch, error) {
+	return traced(ctx, "SearchPredictions", func(ctx context.Context) ([]PredictionMatch, error) {
+		return s.next.SearchPredictions(ctx, q, limit)
+	}, attribute.Int("limit", limit))
+}
+
+func (s *TracedStorage) GetTargetBands(ctx context.Context, ticker, bucket string) ([]TargetBand, error) {
+	return traced(ctx, "GetTargetBands", func(ctx context.Context) ([]TargetBand, error) {
+		return s.next.GetTargetBands(ctx, ticker, bucket)
+	}, tickerAttr(ticker), attribute.String("bucket", bucket))
+}
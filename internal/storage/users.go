@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUserNotFound возвращается, если пользователя нет
+var ErrUserNotFound = NewNotFoundError("user not found")
+
+// ErrUserExists возвращается при регистрации занятого email
+var ErrUserExists = NewConflictError("user with this email already exists")
+
+// ErrRefreshTokenInvalid возвращается для неизвестного, отозванного или
+// истекшего refresh-токена
+var ErrRefreshTokenInvalid = NewNotFoundError("refresh token is invalid or expired")
+
+// User — учетная запись пользователя. PasswordHash — bcrypt-хеш пароля;
+// Role — viewer, editor или admin.
+type User struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateUser сохраняет пользователя и заполняет его ID и время создания
+func (s *PostgresStorage) CreateUser(ctx context.Context, u *User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting user transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (email, password_hash) VALUES ($1, $2)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING id, role, created_at
+	`, u.Email, u.PasswordHash).Scan(&u.ID, &u.Role, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrUserExists, u.Email)
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting user: %w", err)
+	}
+	if err := recordAudit(ctx, tx, AuditCreate, "users", u.ID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing user: %w", err)
+	}
+	u.CreatedAt = u.CreatedAt.UTC()
+	return nil
+}
+
+// GetUserByEmail возвращает пользователя по email
+func (s *PostgresStorage) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	return s.getUser(ctx, "email = $1", email)
+}
+
+// GetUser возвращает пользователя по ID
+func (s *PostgresStorage) GetUser(ctx context.Context, id int64) (User, error) {
+	return s.getUser(ctx, "id = $1", id)
+}
+
+func (s *PostgresStorage) getUser(ctx context.Context, where string, arg any) (User, error) {
+	var u User
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, email, password_hash, role, created_at FROM users WHERE "+where, arg).
+		Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrUserNotFound
+	}
+	if err != nil {
+		return u, fmt.Errorf("error querying user: %w", err)
+	}
+	u.CreatedAt = u.CreatedAt.UTC()
+	return u, nil
+}
+
+// ListUsers возвращает пользователей по порядку регистрации
+func (s *PostgresStorage) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, email, role, created_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Email, &u.Role, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		u.CreatedAt = u.CreatedAt.UTC()
+		users = append(users, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+	return users, nil
+}
+
+// SetUserRole меняет роль пользователя
+func (s *PostgresStorage) SetUserRole(ctx context.Context, id int64, role string) error {
+	n, err := s.execAudited(ctx, AuditUpdate, "users", id, "UPDATE users SET role = $2 WHERE id = $1", id, role)
+	if err != nil {
+		return fmt.Errorf("error updating role of user %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrUserNotFound, id)
+	}
+	return nil
+}
+
+// AddRefreshToken сохраняет хеш refresh-токена пользователя
+func (s *PostgresStorage) AddRefreshToken(ctx context.Context, userID int64, hash string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+		userID, hash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("error inserting refresh token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken отзывает действующий refresh-токен и возвращает его
+// пользователя. Токен можно использовать один раз: повторный вызов вернет
+// ErrRefreshTokenInvalid.
+func (s *PostgresStorage) ConsumeRefreshToken(ctx context.Context, hash string) (int64, error) {
+	var userID int64
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()
+		RETURNING user_id
+	`, hash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error consuming refresh token: %w", err)
+	}
+	return userID, nil
+}
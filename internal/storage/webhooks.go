@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrWebhookNotFound возвращается, если подписки с указанным id нет
+var ErrWebhookNotFound = NewNotFoundError("webhook subscription not found")
+
+// WebhookSubscription — URL, который получает новые прогнозы по тикерам.
+// Тикер "*" означает все тикеры. Source — config или api; подписки из
+// конфигурации не хранятся в БД и имеют нулевой ID.
+type WebhookSubscription struct {
+	ID        int64      `json:"id"`
+	URL       string     `json:"url"`
+	Secret    string     `json:"-"`
+	Tickers   []string   `json:"tickers"`
+	Source    string     `json:"source"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// ListWebhookSubscriptions возвращает подписки, зарегистрированные через API
+func (s *PostgresStorage) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, url, secret, tickers, created_at FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []WebhookSubscription{}
+	for rows.Next() {
+		var w WebhookSubscription
+		var created time.Time
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.Tickers), &created); err != nil {
+			return nil, fmt.Errorf("error scanning webhook subscription: %w", err)
+		}
+		w.Source = "api"
+		w.CreatedAt = &created
+		subs = append(subs, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over webhook subscription rows: %w", err)
+	}
+	return subs, nil
+}
+
+// AddWebhookSubscription сохраняет подписку и заполняет ее ID и время создания
+func (s *PostgresStorage) AddWebhookSubscription(ctx context.Context, w *WebhookSubscription) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting webhook subscription transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var created time.Time
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO webhook_subscriptions (url, secret, tickers) VALUES ($1, $2, $3) RETURNING id, created_at",
+		w.URL, w.Secret, pq.Array(w.Tickers)).Scan(&w.ID, &created)
+	if err != nil {
+		return fmt.Errorf("error inserting webhook subscription: %w", err)
+	}
+	if err := recordAudit(ctx, tx, AuditCreate, "webhook_subscriptions", w.ID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing webhook subscription: %w", err)
+	}
+	w.Source = "api"
+	w.CreatedAt = &created
+	return nil
+}
+
+// DeleteWebhookSubscription удаляет подписку
+func (s *PostgresStorage) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	n, err := s.execAudited(ctx, AuditDelete, "webhook_subscriptions", id, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook subscription %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+	}
+	return nil
+}
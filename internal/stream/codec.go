@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec сериализует конверты потока. Кодек выбирается при подключении
+// клиента и не меняется до конца соединения.
+type Codec interface {
+	// Name — имя кодека в ?encoding= и в Sec-WebSocket-Protocol
+	Name() string
+	ContentType() string
+	// Binary сообщает, что результат нельзя отправлять текстовым фреймом/SSE
+	Binary() bool
+	Encode(e Envelope) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+)
+
+// RegisterCodec добавляет кодек в реестр, заменяя кодек с тем же именем
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// LookupCodec возвращает кодек по имени
+func LookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[strings.ToLower(name)]
+	return c, ok
+}
+
+// Negotiate выбирает кодек для соединения: сначала ?encoding=, затем
+// Sec-WebSocket-Protocol, по умолчанию JSON. Если allowBinary=false
+// (например, для SSE), бинарные кодеки не выбираются.
+func Negotiate(r *http.Request, allowBinary bool) Codec {
+	candidates := []string{r.URL.Query().Get("encoding")}
+	for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(h, ",") {
+			candidates = append(candidates, strings.TrimSpace(p))
+		}
+	}
+
+	for _, name := range candidates {
+		if c, ok := LookupCodec(name); ok && (allowBinary || !c.Binary()) {
+			return c
+		}
+	}
+	return JSON
+}
+
+// JSON — кодек по умолчанию
+var JSON Codec = jsonCodec{}
+
+// MessagePack — компактный бинарный кодек для высокочастотных потоков
+var MessagePack Codec = msgpackCodec{}
+
+func init() {
+	RegisterCodec(JSON)
+	RegisterCodec(MessagePack)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string        { return "json" }
+func (jsonCodec) ContentType() string { return "application/json" }
+func (jsonCodec) Binary() bool        { return false }
+
+func (jsonCodec) Encode(e Envelope) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string        { return "msgpack" }
+func (msgpackCodec) ContentType() string { return "application/x-msgpack" }
+func (msgpackCodec) Binary() bool        { return true }
+
+// Encode использует json-теги полезной нагрузки, чтобы имена полей
+// совпадали с JSON-представлением
+func (msgpackCodec) Encode(e Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
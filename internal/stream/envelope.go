@@ -0,0 +1,17 @@
+package stream
+
+// Типы сообщений потока
+const (
+	TypeStock      = "stock"
+	TypePrediction = "prediction"
+	TypePrice      = "price"
+)
+
+// Envelope — конверт сообщения WebSocket/SSE-потока.
+// Seq монотонно растет в пределах хаба и позволяет клиенту заметить пропуски.
+type Envelope struct {
+	Type    string `json:"type" msgpack:"type"`
+	Ticker  string `json:"ticker,omitempty" msgpack:"ticker,omitempty"`
+	Seq     uint64 `json:"seq" msgpack:"seq"`
+	Payload any    `json:"payload" msgpack:"payload"`
+}
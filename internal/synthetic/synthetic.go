@@ -0,0 +1,203 @@
+// Package synthetic генерирует правдоподобные случайные акции, историю цен и
+// прогнозы для нагрузочного тестирования и разработки фронтенда без реальных
+// данных. При одинаковых параметрах и seed результат повторяется.
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"frontend-backend/internal/fixtures"
+	"frontend-backend/internal/marketdata"
+	"frontend-backend/internal/storage"
+)
+
+// Exchange — биржа синтетических акций; по ней их отличают от реальных
+const Exchange = "SYNTH"
+
+// MessageIDBase — начало диапазона telegram_id синтетических сообщений;
+// реальные идентификаторы Telegram до него не доходят
+const MessageIDBase int64 = 1 << 50
+
+// Пределы параметров. Диапазоны telegram_id разных seed не пересекаются.
+const (
+	maxStocks      = 10_000
+	maxPredictions = 10_000_000
+	maxSeed        = 1 << 20
+	minDays        = 7 // хотя бы одна неделя, чтобы в истории были торговые дни
+)
+
+var (
+	namePrefixes = []string{"Север", "Урал", "Сибирь", "Волга", "Балтик", "Дальвосток", "Кама", "Нева", "Алтай", "Енисей"}
+	nameSuffixes = []string{"нефть", "газ", "сталь", "банк", "телеком", "хим", "энерго", "строй", "транс", "агро", "фарм", "ритейл"}
+	enPrefixes   = []string{"Sever", "Ural", "Sibir", "Volga", "Baltic", "Dalvostok", "Kama", "Neva", "Altai", "Yenisei"}
+	enSuffixes   = []string{"neft", "gaz", "steel", "bank", "telecom", "chem", "energo", "stroy", "trans", "agro", "pharm", "retail"}
+
+	channels        = []string{"@synthetic_signals", "@synthetic_invest", "@synthetic_trader"}
+	predictionTypes = []string{"Продолжение тренда", "Разворот", "Пробой уровня", "Отскок"}
+	periods         = []string{"Краткосрочный", "Среднесрочный", "Долгосрочный"}
+	justifications  = []string{"Сильный рост объема торгов", "Коррекция после быстрого роста", "Сильная отчетность", "Выход из боковика", "Слабая отчетность", "Пробой поддержки"}
+)
+
+// Options — параметры генерации
+type Options struct {
+	Stocks      int
+	Days        int // глубина истории цен в календарных днях
+	Predictions int
+	Seed        int64
+	End         time.Time // последний день истории; по умолчанию сегодня
+	// Exclude — тикеры, которые нельзя занимать: реальные акции и файлы
+	// истории цен
+	Exclude map[string]bool
+}
+
+// Dataset — сгенерированные данные: записи для БД и история цен по тикерам
+type Dataset struct {
+	Fixtures *fixtures.File
+	Prices   map[string][]marketdata.Candle
+}
+
+// Validate проверяет параметры генерации
+func (o Options) Validate() error {
+	switch {
+	case o.Stocks < 1:
+		return fmt.Errorf("stocks must be positive")
+	case o.Stocks > maxStocks:
+		return fmt.Errorf("at most %d stocks can be generated", maxStocks)
+	case o.Days < minDays:
+		return fmt.Errorf("days must be at least %d", minDays)
+	case o.Predictions < 0 || o.Predictions > maxPredictions:
+		return fmt.Errorf("predictions must be between 0 and %d", maxPredictions)
+	case o.Seed < 0 || o.Seed >= maxSeed:
+		return fmt.Errorf("seed must be between 0 and %d", maxSeed-1)
+	}
+	return nil
+}
+
+// Generate создает o.Stocks акций биржи Exchange с историей цен за o.Days дней и
+// o.Predictions прогнозов, по одному на сообщение. Цены — геометрическое
+// случайное блуждание только по будним дням; цель прогноза отсчитывается от
+// цены закрытия в день прогноза, направление и рекомендация согласованы с
+// ожидаемым изменением.
+func Generate(o Options) (*Dataset, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(o.Seed))
+	if o.End.IsZero() {
+		o.End = time.Now().UTC()
+	}
+	end := time.Date(o.End.Year(), o.End.Month(), o.End.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -o.Days)
+
+	ds := &Dataset{Fixtures: &fixtures.File{}, Prices: make(map[string][]marketdata.Candle, o.Stocks)}
+	tickers := make([]string, 0, o.Stocks)
+	used := make(map[string]bool, o.Stocks)
+	for len(tickers) < o.Stocks {
+		p, s := r.Intn(len(namePrefixes)), r.Intn(len(nameSuffixes))
+		ticker := randomTicker(r)
+		if used[ticker] || o.Exclude[ticker] {
+			continue
+		}
+		used[ticker] = true
+		tickers = append(tickers, ticker)
+		ds.Fixtures.Stocks = append(ds.Fixtures.Stocks, fixtures.Stock{
+			Ticker:   ticker,
+			Name:     namePrefixes[p] + nameSuffixes[s],
+			Exchange: Exchange,
+			Names:    map[string]string{storage.LangEN: enPrefixes[p] + enSuffixes[s]},
+		})
+		ds.Prices[ticker] = priceWalk(r, start, end)
+	}
+
+	base := MessageIDBase + o.Seed*maxPredictions
+	for i := 0; i < o.Predictions; i++ {
+		ticker := tickers[r.Intn(len(tickers))]
+		candles := ds.Prices[ticker]
+		c := candles[r.Intn(len(candles))]
+		sentAt := c.Time.Add(time.Duration(10*60+r.Intn(8*60)) * time.Minute)
+
+		change := math.Round((r.NormFloat64()*12+3)*100) / 100
+		target := math.Round(c.Close*(1+change/100)*100) / 100
+		period := pick(r, periods)
+		recommendation, direction := "Держать", "Неопределенный"
+		switch {
+		case change >= 5:
+			recommendation, direction = "Покупать", "Лонг"
+		case change <= -5:
+			recommendation, direction = "Продавать", "Шорт"
+		}
+		id, channel := base+int64(i), channels[r.Intn(len(channels))]
+		ds.Fixtures.Messages = append(ds.Fixtures.Messages, fixtures.Message{
+			TelegramID: id,
+			Channel:    channel,
+			Text:       fmt.Sprintf("%s: цель %.2f₽ (%+.2f%%), горизонт — %s. %s", ticker, target, change, strings.ToLower(*period), recommendation),
+			SentAt:     sentAt,
+		})
+		ds.Fixtures.Predictions = append(ds.Fixtures.Predictions, fixtures.Prediction{
+			Channel:             channel,
+			MessageID:           id,
+			Ticker:              ticker,
+			PredictionType:      pick(r, predictionTypes),
+			TargetPrice:         &target,
+			TargetChangePercent: &change,
+			Period:              period,
+			Recommendation:      &recommendation,
+			Direction:           &direction,
+			JustificationText:   pick(r, justifications),
+			PredictedAt:         sentAt,
+		})
+	}
+	return ds, nil
+}
+
+// randomTicker возвращает тикер из четырех латинских букв
+func randomTicker(r *rand.Rand) string {
+	b := make([]byte, 4)
+	for i := range b {
+		b[i] = byte('A' + r.Intn(26))
+	}
+	return string(b)
+}
+
+// priceWalk строит дневные свечи с start по end без выходных. Начальная цена
+// распределена логарифмически равномерно от 10 до 5000, волатильность — от 1
+// до 3% в день.
+func priceWalk(r *rand.Rand, start, end time.Time) []marketdata.Candle {
+	price := math.Exp(math.Log(10) + r.Float64()*(math.Log(5000)-math.Log(10)))
+	vol := 0.01 + r.Float64()*0.02
+	drift := (r.Float64() - 0.5) * vol / 10
+	baseVolume := 100_000 + r.Int63n(10_000_000)
+
+	var candles []marketdata.Candle
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			continue
+		}
+		open := price * (1 + r.NormFloat64()*vol/4)
+		price *= math.Exp(drift + r.NormFloat64()*vol)
+		high := math.Max(open, price) * (1 + math.Abs(r.NormFloat64())*vol/2)
+		low := math.Min(open, price) * (1 - math.Abs(r.NormFloat64())*vol/2)
+		candles = append(candles, marketdata.Candle{
+			Time:   day,
+			Open:   round(open),
+			High:   round(high),
+			Low:    round(low),
+			Close:  round(price),
+			Volume: baseVolume/2 + r.Int63n(baseVolume),
+		})
+	}
+	return candles
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func pick(r *rand.Rand, values []string) *string {
+	v := values[r.Intn(len(values))]
+	return &v
+}
@@ -0,0 +1,129 @@
+// Package tracing настраивает трассировку OpenTelemetry: экспорт спанов по
+// OTLP/HTTP и распространение контекста в заголовках W3C traceparent.
+// Пока Setup не вызван, спаны не записываются и почти ничего не стоят.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName — имя инструментирующей библиотеки в спанах
+const instrumentationName = "frontend-backend"
+
+// Options задает экспорт спанов. Пустые поля берутся из стандартных
+// переменных окружения OTEL_EXPORTER_OTLP_* и OTEL_SERVICE_NAME.
+type Options struct {
+	// Endpoint — адрес коллектора host:port
+	Endpoint string
+	// Insecure отключает TLS при отправке в коллектор
+	Insecure bool
+	// Headers добавляются к запросам экспорта (например, ключ доступа)
+	Headers map[string]string
+	// SampleRatio — доля записываемых трасс (0..1) для запросов без
+	// родительского спана; решение родителя соблюдается всегда
+	SampleRatio float64
+	// ServiceName — service.name в ресурсе
+	ServiceName string
+}
+
+// Setup включает экспорт спанов и возвращает функцию, которая отправляет
+// оставшиеся спаны при остановке
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	var exporterOpts []otlptracehttp.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	if len(opts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{}
+	if opts.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(opts.ServiceName))
+	}
+	// WithFromEnv идет последним: OTEL_SERVICE_NAME сильнее конфига
+	res, err := resource.New(ctx, resource.WithAttributes(attrs...), resource.WithFromEnv(), resource.WithHost())
+	if err != nil {
+		return nil, fmt.Errorf("create otel resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start открывает спан от текущего провайдера
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End завершает спан, отмечая в нем ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport оборачивает base (nil — http.DefaultTransport) клиентскими
+// спанами и передает контекст трассы в заголовках исходящих запросов
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			// Путь не записывается: в нем бывают секреты (токен Bot API)
+			semconv.ServerAddress(req.URL.Hostname()),
+		))
+	// RoundTrip не должен менять исходный запрос
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
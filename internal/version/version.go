@@ -0,0 +1,35 @@
+// Package version хранит сведения о сборке, которые подставляются через ldflags:
+//
+//	go build -ldflags "-X frontend-backend/internal/version.Version=v1.2.3 \
+//	  -X frontend-backend/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X frontend-backend/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package version
+
+// Значения по умолчанию используются при go run без ldflags
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Schema — версия схемы ответов API; увеличивается при несовместимых изменениях
+// полей, чтобы аналитика показывала, какие клиенты еще видят старую схему
+const Schema = "1"
+
+// Info — сведения о сборке для /version
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	Schema    string `json:"schema"`
+}
+
+// Get возвращает сведения о текущей сборке
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime, Schema: Schema}
+}
+
+// String возвращает сведения о сборке одной строкой для логов
+func (i Info) String() string {
+	return i.Version + " (commit " + i.Commit + ", built " + i.BuildTime + ")"
+}
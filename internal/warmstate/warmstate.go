@@ -0,0 +1,66 @@
+// Package warmstate сохраняет «прогретое» состояние (спрос на тикеры и
+// содержимое кешей) на диск при остановке и восстанавливает его при старте,
+// чтобы после деплоя кеши не заполнялись заново запросами к БД.
+package warmstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"frontend-backend/internal/storage"
+)
+
+// State — снимок прогретого состояния
+type State struct {
+	SavedAt time.Time              `json:"saved_at"`
+	Demand  map[string]float64     `json:"demand"`
+	Cache   *storage.CacheSnapshot `json:"cache,omitempty"`
+}
+
+// Save атомарно записывает снимок в path (через временный файл и rename)
+func Save(path string, st State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("error encoding warm state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating warm state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing warm state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing warm state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving warm state to %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load читает снимок из path. ok=false, если файла нет или снимок старше
+// maxAge (0 — без ограничения): устаревшие данные хуже пустого кеша.
+func Load(path string, maxAge time.Duration) (st State, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return State{}, false, nil
+	} else if err != nil {
+		return State{}, false, fmt.Errorf("error reading warm state from %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &st); err != nil {
+		return State{}, false, fmt.Errorf("error decoding warm state from %s: %w", path, err)
+	}
+	if maxAge > 0 && time.Since(st.SavedAt) > maxAge {
+		return State{}, false, nil
+	}
+	return st, true, nil
+}
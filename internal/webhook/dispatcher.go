@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"frontend-backend/internal/events"
+	"frontend-backend/internal/metrics"
+	"frontend-backend/internal/storage"
+)
+
+// ErrInvalidSubscription возвращается для подписки с некорректным URL или без тикеров
+var ErrInvalidSubscription = storage.NewValidationError("invalid webhook subscription")
+
+// dispatcherQueue — сколько доставок может ждать свободного воркера
+const dispatcherQueue = 1024
+
+// SubscriptionStore хранит подписки, зарегистрированные через админский API
+type SubscriptionStore interface {
+	ListWebhookSubscriptions(ctx context.Context) ([]storage.WebhookSubscription, error)
+	AddWebhookSubscription(ctx context.Context, w *storage.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+}
+
+// subscription — подписка и отправитель для ее URL
+type subscription struct {
+	storage.WebhookSubscription
+	sender *Sender
+}
+
+// delivery — событие, которое нужно доставить подписчику
+type delivery struct {
+	sub   subscription
+	event events.Event
+}
+
+// Dispatcher рассылает новые прогнозы подписчикам по тикерам. Publish только
+// ставит доставки в очередь; отправку с повторами и экспоненциальной паузой
+// выполняют воркеры Run.
+type Dispatcher struct {
+	store   SubscriptionStore
+	timeout time.Duration
+	retries int
+	queue   chan delivery
+	log     *slog.Logger
+
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+// NewDispatcher создает новый экземпляр Dispatcher с подписками из
+// конфигурации; store может быть nil, тогда подписки через API недоступны,
+// nil logger — slog.Default()
+func NewDispatcher(store SubscriptionStore, static []storage.WebhookSubscription, timeout time.Duration, retries int, logger *slog.Logger) (*Dispatcher, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	d := &Dispatcher{store: store, timeout: timeout, retries: retries, queue: make(chan delivery, dispatcherQueue), log: logger}
+	for _, w := range static {
+		if err := normalize(&w); err != nil {
+			return nil, err
+		}
+		w.ID = 0
+		w.Source = "config"
+		d.subs = append(d.subs, d.subscription(w))
+	}
+	return d, nil
+}
+
+// Load загружает подписки из хранилища
+func (d *Dispatcher) Load(ctx context.Context) error {
+	if d.store == nil {
+		return nil
+	}
+	stored, err := d.store.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range stored {
+		d.subs = append(d.subs, d.subscription(w))
+	}
+	d.log.Info("Подписок на вебхуки новых прогнозов", "subscriptions", len(d.subs))
+	return nil
+}
+
+func (d *Dispatcher) subscription(w storage.WebhookSubscription) subscription {
+	return subscription{WebhookSubscription: w, sender: NewSender(w.URL, w.Secret, d.timeout, d.retries, d.log)}
+}
+
+// normalize проверяет URL и приводит тикеры к верхнему регистру
+func normalize(w *storage.WebhookSubscription) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url %q is not an absolute http(s) URL", ErrInvalidSubscription, w.URL)
+	}
+	var tickers []string
+	for _, t := range w.Tickers {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			tickers = append(tickers, t)
+		}
+	}
+	if len(tickers) == 0 {
+		return fmt.Errorf("%w: at least one ticker (or \"*\") is required for %s", ErrInvalidSubscription, w.URL)
+	}
+	w.Tickers = tickers
+	return nil
+}
+
+// List возвращает все подписки: сначала из конфигурации, затем из API
+func (d *Dispatcher) List() []storage.WebhookSubscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]storage.WebhookSubscription, len(d.subs))
+	for i, s := range d.subs {
+		out[i] = s.WebhookSubscription
+	}
+	return out
+}
+
+// Add сохраняет подписку и сразу начинает доставку по ней
+func (d *Dispatcher) Add(ctx context.Context, w storage.WebhookSubscription) (storage.WebhookSubscription, error) {
+	if d.store == nil {
+		return w, fmt.Errorf("webhook subscriptions store is not configured")
+	}
+	if err := normalize(&w); err != nil {
+		return w, err
+	}
+	if err := d.store.AddWebhookSubscription(ctx, &w); err != nil {
+		return w, err
+	}
+	d.mu.Lock()
+	d.subs = append(d.subs, d.subscription(w))
+	d.mu.Unlock()
+	return w, nil
+}
+
+// Remove удаляет подписку, зарегистрированную через API
+func (d *Dispatcher) Remove(ctx context.Context, id int64) error {
+	if d.store == nil {
+		return fmt.Errorf("%w: %d", storage.ErrWebhookNotFound, id)
+	}
+	if err := d.store.DeleteWebhookSubscription(ctx, id); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, s := range d.subs {
+		if s.ID == id {
+			d.subs = append(d.subs[:i], d.subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Publish реализует events.Publisher: ставит prediction.created в очередь
+// доставки каждому подписчику тикера
+func (d *Dispatcher) Publish(e events.Event) {
+	if e.Type != events.TypePredictionCreated {
+		return
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, s := range d.subs {
+		if !s.watches(e.Ticker) {
+			continue
+		}
+		select {
+		case d.queue <- delivery{sub: s, event: e}:
+		default:
+			metrics.WebhookDeliveries.WithLabelValues(e.Type, "dropped").Inc()
+			d.log.Warn("Очередь вебхуков переполнена, событие пропущено", "type", e.Type, "ticker", e.Ticker, "url", s.URL)
+		}
+	}
+}
+
+func (s subscription) watches(ticker string) bool {
+	for _, t := range s.Tickers {
+		if t == "*" || t == ticker {
+			return true
+		}
+	}
+	return false
+}
+
+// Run запускает workers воркеров доставки и ждет отмены ctx
+func (d *Dispatcher) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case dl := <-d.queue:
+					d.deliver(ctx, dl)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, dl delivery) {
+	if err := dl.sub.sender.Send(ctx, dl.event.Type, dl.event); err != nil {
+		metrics.WebhookDeliveries.WithLabelValues(dl.event.Type, "failure").Inc()
+		d.log.Warn("Вебхук не доставлен", "type", dl.event.Type, "url", dl.sub.URL, "err", err)
+		return
+	}
+	metrics.WebhookDeliveries.WithLabelValues(dl.event.Type, "success").Inc()
+}
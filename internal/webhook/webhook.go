@@ -0,0 +1,108 @@
+// Package webhook доставляет события внешним потребителям HTTP POST-запросом
+// с JSON-телом и HMAC-подписью.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"frontend-backend/internal/requestid"
+	"frontend-backend/internal/tracing"
+)
+
+// SignatureHeader содержит hex(HMAC-SHA256(secret, тело запроса))
+const SignatureHeader = "X-Signature-SHA256"
+
+// EventHeader содержит тип события
+const EventHeader = "X-Event-Type"
+
+// Sender отправляет события на один URL с повторами при ошибках
+type Sender struct {
+	url     string
+	secret  []byte
+	retries int
+	backoff time.Duration
+	client  *http.Client
+	log     *slog.Logger
+}
+
+// NewSender создает отправителя. retries — число повторов после первой
+// неудачной попытки, пауза между ними удваивается начиная с секунды.
+// nil logger — slog.Default().
+func NewSender(url, secret string, timeout time.Duration, retries int, logger *slog.Logger) *Sender {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Sender{
+		log:     logger,
+		url:     url,
+		secret:  []byte(secret),
+		retries: retries,
+		backoff: time.Second,
+		client:  &http.Client{Timeout: timeout, Transport: tracing.Transport(requestid.Transport(nil))},
+	}
+}
+
+// Send отправляет событие eventType с телом payload. Ответы 2xx считаются
+// доставкой; 4xx, кроме 429, не повторяются.
+func (s *Sender) Send(ctx context.Context, eventType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding %s webhook: %w", eventType, err)
+	}
+
+	delay := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, eventType, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.retries {
+			return err
+		}
+		s.log.Error("Ошибка доставки вебхука, повтор", "event_type", eventType, "err", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post выполняет одну попытку; retry сообщает, имеет ли смысл повторять
+func (s *Sender) post(ctx context.Context, eventType string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("error calling webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}